@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pschuurmans/bijbel-api/internal/account"
+	"github.com/pschuurmans/bijbel-api/internal/annotation"
+)
+
+// maxBody bounds the JSON bodies of the account and annotation routes.
+const maxBody = 64 << 10
+
+// userStores holds the stores of user data, which share the accounts
+// database.
+type userStores struct {
+	accounts    *account.Store
+	annotations *annotation.Store
+}
+
+// newUserStores migrates the tables of user data in db.
+func newUserStores(db *sql.DB, sessionTTL time.Duration) (*userStores, error) {
+	accounts, err := account.New(db, sessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := annotation.New(db, canon{})
+	if err != nil {
+		return nil, err
+	}
+	return &userStores{accounts, annotations}, nil
+}
+
+type userKey struct{}
+
+type userValue struct {
+	user  account.User
+	token string
+	// apiKey is set when the request was authenticated with an API key
+	// rather than a session token.
+	apiKey bool
+}
+
+// authenticate attaches the user of the bearer token in the Authorization
+// header to the request context. Requests without a token pass through
+// anonymously; an invalid or expired token is refused, so that clients
+// notice instead of silently losing their data. It is only mounted on the
+// routes of user data, so that public routes ignore the header and don't
+// touch the accounts database.
+func authenticate(accounts *account.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				unauthorized(w, "Authorization must be a Bearer token")
+				return
+			}
+			u, err := accounts.Authenticate(r.Context(), token)
+			if errors.Is(err, account.ErrUnauthenticated) {
+				unauthorized(w, "Invalid or expired token")
+				return
+			} else if err != nil {
+				internalError(w, r, err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), userKey{}, userValue{u, token, account.IsAPIKey(token)})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateAnnotated authenticates the requests for a chapter with
+// ?annotations=true, and lets the others pass untouched.
+func authenticateAnnotated(accounts *account.Store) func(http.Handler) http.Handler {
+	auth := authenticate(accounts)
+	return func(next http.Handler) http.Handler {
+		authenticated := auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("annotations") == "true" {
+				authenticated.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireUser refuses requests without a user.
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := currentUser(r); !ok {
+			unauthorized(w, "Sign in required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireSession refuses requests without a session token, so that an API
+// key handed to a script can't change the password or create more keys.
+func requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := r.Context().Value(userKey{}).(userValue)
+		if !ok {
+			unauthorized(w, "Sign in required")
+			return
+		}
+		if v.apiKey {
+			http.Error(w, "Not allowed with an API key", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// currentUser returns the user attached by authenticate.
+func currentUser(r *http.Request) (account.User, bool) {
+	v, ok := r.Context().Value(userKey{}).(userValue)
+	return v.user, ok
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="bijbel-api"`)
+	http.Error(w, msg, http.StatusUnauthorized)
+}
+
+// accountRoutes mounts the routes to register, sign in and manage the
+// current user.
+func accountRoutes(r chi.Router, accounts *account.Store) {
+	r.Post("/accounts", RegisterHandler(accounts))
+	r.Post("/sessions", LoginHandler(accounts))
+	r.Group(func(r chi.Router) {
+		r.Use(authenticate(accounts), requireUser)
+		r.Get("/me", GetMeHandler)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(authenticate(accounts), requireSession)
+		r.Delete("/sessions/current", LogoutHandler(accounts))
+		r.Put("/me/password", ChangePasswordHandler(accounts))
+		r.Get("/me/api-keys", GetAPIKeysHandler(accounts))
+		r.Post("/me/api-keys", CreateAPIKeyHandler(accounts))
+		r.Delete("/me/api-keys/{keyId}", DeleteAPIKeyHandler(accounts))
+	})
+}
+
+// RegisterHandler creates a user from {"email", "name", "password"}.
+func RegisterHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email    string `json:"email"`
+			Name     string `json:"name"`
+			Password string `json:"password"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		u, err := accounts.Register(r.Context(), req.Email, req.Name, req.Password)
+		if err != nil {
+			accountError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, u)
+	}
+}
+
+// LoginHandler starts a session from {"email", "password"}. The token of
+// the response goes in the Authorization header as "Bearer <token>".
+func LoginHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		session, err := accounts.Login(r.Context(), req.Email, req.Password)
+		if err != nil {
+			accountError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, session)
+	}
+}
+
+// LogoutHandler ends the session of the request.
+func LogoutHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v := r.Context().Value(userKey{}).(userValue)
+		if err := accounts.Logout(r.Context(), v.token); err != nil {
+			accountError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetMeHandler(w http.ResponseWriter, r *http.Request) {
+	u, _ := currentUser(r)
+	writeJSON(w, http.StatusOK, u)
+}
+
+// ChangePasswordHandler replaces the password from {"current",
+// "password"}. All sessions end, including that of the request.
+func ChangePasswordHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Current  string `json:"current"`
+			Password string `json:"password"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		u, _ := currentUser(r)
+		if err := accounts.ChangePassword(r.Context(), u.Id, req.Current, req.Password); err != nil {
+			accountError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetAPIKeysHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, _ := currentUser(r)
+		keys, err := accounts.APIKeys(r.Context(), u.Id)
+		if err != nil {
+			accountError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, keys)
+	}
+}
+
+// CreateAPIKeyHandler creates an API key from {"name"}. The key is only
+// returned here.
+func CreateAPIKeyHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		u, _ := currentUser(r)
+		key, err := accounts.CreateAPIKey(r.Context(), u.Id, req.Name)
+		if err != nil {
+			accountError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, key)
+	}
+}
+
+func DeleteAPIKeyHandler(accounts *account.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "keyId"), 10, 64)
+		if err != nil {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		u, _ := currentUser(r)
+		if err := accounts.DeleteAPIKey(r.Context(), u.Id, id); err != nil {
+			accountError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// accountError maps the errors of the account package to a status.
+func accountError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *account.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, account.ErrEmailTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, account.ErrInvalidCredentials):
+		unauthorized(w, err.Error())
+	case errors.Is(err, account.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, account.ErrTooManyAttempts):
+		w.Header().Set("Retry-After", strconv.Itoa(int(account.FailedLoginWindow.Seconds())))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, account.ErrBusy):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		internalError(w, r, err)
+	}
+}
+
+// decodeBody reads a JSON request body into v, answering 400 if it can't.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decodeLimited(w, r, v, maxBody)
+}
+
+// decodeLimited is decodeBody for bodies of at most limit bytes.
+func decodeLimited(w http.ResponseWriter, r *http.Request, v any, limit int64) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
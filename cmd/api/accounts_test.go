@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/account"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+)
+
+// newTestUsers returns user stores in a temporary database.
+func newTestUsers(t *testing.T) *userStores {
+	t.Helper()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "users.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	users, err := newUserStores(db, time.Hour)
+	require.NoError(t, err)
+	return users
+}
+
+func TestAccounts(t *testing.T) {
+	router := newRouter(config.Default(), newTestRegistry(t), newTestUsers(t), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/accounts", "", `{"email":"maria@example.org","name":"Maria","password":"geheim123"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	require.Equal(t, http.StatusConflict, do(http.MethodPost, "/accounts", "", `{"email":"maria@example.org","password":"geheim123"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/accounts", "", `{"email":"jozef@example.org","password":"kort"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/accounts", "", `{"mail":"jozef@example.org"}`).Code)
+
+	rr = do(http.MethodPost, "/sessions", "", `{"email":"maria@example.org","password":"fout1234"}`)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = do(http.MethodPost, "/sessions", "", `{"email":"maria@example.org","password":"geheim123"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var session account.Session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+	require.NotEmpty(t, session.Token)
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", "", "").Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", "bjs_onbekend", "").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/books", "bjs_onbekend", "").Code, "public routes ignore the token")
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/books/genesis/chapter/1", "bjs_onbekend", "").Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/books/genesis/chapter/1?annotations=true", "bjs_onbekend", "").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/books", "", "").Code)
+
+	rr = do(http.MethodGet, "/me", session.Token, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var me account.User
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &me))
+	require.Equal(t, "Maria", me.Name)
+
+	rr = do(http.MethodPost, "/me/api-keys", session.Token, `{"name":"backup"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var key account.APIKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &key))
+	require.NotEmpty(t, key.Key)
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/me", key.Key, "").Code)
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/me/api-keys", key.Key, `{"name":"meer"}`).Code,
+		"an API key can't create more keys")
+
+	rr = do(http.MethodGet, "/me/api-keys", session.Token, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotContains(t, rr.Body.String(), key.Key)
+
+	require.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/me/api-keys/999", session.Token, "").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/me/api-keys/"+strconv.FormatInt(key.Id, 10), session.Token, "").Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", key.Key, "").Code)
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/me/password", session.Token, `{"current":"fout1234","password":"nieuw1234"}`).Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodPut, "/me/password", session.Token, `{"current":"geheim123","password":"nieuw1234"}`).Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", session.Token, "").Code)
+
+	rr = do(http.MethodPost, "/sessions", "", `{"email":"maria@example.org","password":"nieuw1234"}`)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/sessions/current", session.Token, "").Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", session.Token, "").Code)
+
+	for range account.MaxFailedLogins {
+		require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/sessions", "", `{"email":"maria@example.org","password":"fout1234"}`).Code)
+	}
+	rr = do(http.MethodPost, "/sessions", "", `{"email":"maria@example.org","password":"nieuw1234"}`)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "900", rr.Header().Get("Retry-After"))
+}
+
+func TestAccountsDisabled(t *testing.T) {
+	router := newRouter(config.Default(), newTestRegistry(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions", strings.NewReader(`{}`)))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pschuurmans/bijbel-api/internal/account"
+	"github.com/pschuurmans/bijbel-api/internal/annotation"
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// Page sizes of /annotations.
+const (
+	defaultAnnotations = 100
+	maxAnnotations     = 1000
+)
+
+// maxSyncBody bounds the body of /annotations/sync, which carries the
+// changes a device made offline.
+const maxSyncBody = 4 << 20
+
+// annotationRoutes mounts the routes to manage the annotations of the
+// current user.
+func annotationRoutes(r chi.Router, accounts *account.Store, annotations *annotation.Store) {
+	r.Route("/annotations", func(r chi.Router) {
+		r.Use(authenticate(accounts), requireUser)
+		r.Get("/", GetAnnotationsHandler(annotations))
+		r.Post("/", CreateAnnotationHandler(annotations))
+		r.Get("/tags", GetAnnotationTagsHandler(annotations))
+		r.Post("/sync", SyncAnnotationsHandler(annotations))
+		r.Get("/{annotationId}", GetAnnotationHandler(annotations))
+		r.Put("/{annotationId}", UpdateAnnotationHandler(annotations))
+		r.Delete("/{annotationId}", DeleteAnnotationHandler(annotations))
+	})
+}
+
+// GetAnnotationsHandler lists the annotations of the user in canonical
+// order, e.g. /annotations?book=johannes&chapter=3&kind=highlight. It
+// filters by kind, book, chapter, tag and color; q searches the notes and
+// tags, best match first. limit and offset page through the results.
+func GetAnnotationsHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f := annotation.Filter{
+			Kind:  q.Get("kind"),
+			Book:  q.Get("book"),
+			Tag:   q.Get("tag"),
+			Color: q.Get("color"),
+			Query: q.Get("q"),
+			Limit: defaultAnnotations,
+		}
+		if s := q.Get("chapter"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || f.Book == "" {
+				http.Error(w, "chapter must be a number and needs book", http.StatusBadRequest)
+				return
+			}
+			f.Chapter = n
+		}
+		if s := q.Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxAnnotations {
+				http.Error(w, "limit must be a number from 1 to "+strconv.Itoa(maxAnnotations), http.StatusBadRequest)
+				return
+			}
+			f.Limit = n
+		}
+		if s := q.Get("offset"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, "offset must be a positive number", http.StatusBadRequest)
+				return
+			}
+			f.Offset = n
+		}
+
+		u, _ := currentUser(r)
+		list, err := annotations.List(r.Context(), u.Id, f)
+		if err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// CreateAnnotationHandler adds an annotation, e.g. {"kind": "highlight",
+// "start": "johannes.3.16", "end": "johannes.3.17", "color": "yellow"}.
+// The id may be chosen by the client.
+func CreateAnnotationHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var a annotation.Annotation
+		if !decodeBody(w, r, &a) {
+			return
+		}
+		u, _ := currentUser(r)
+		a, err := annotations.Create(r.Context(), u.Id, a)
+		if err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, a)
+	}
+}
+
+func GetAnnotationHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, _ := currentUser(r)
+		a, err := annotations.Get(r.Context(), u.Id, chi.URLParam(r, "annotationId"))
+		if err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+	}
+}
+
+// UpdateAnnotationHandler replaces an annotation with the body, which has
+// the fields of CreateAnnotationHandler.
+func UpdateAnnotationHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var a annotation.Annotation
+		if !decodeBody(w, r, &a) {
+			return
+		}
+		id := chi.URLParam(r, "annotationId")
+		if a.Id != "" && a.Id != id {
+			http.Error(w, "id does not match the URL", http.StatusBadRequest)
+			return
+		}
+		a.Id = id
+		u, _ := currentUser(r)
+		a, err := annotations.Update(r.Context(), u.Id, a)
+		if err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+	}
+}
+
+func DeleteAnnotationHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, _ := currentUser(r)
+		if err := annotations.Delete(r.Context(), u.Id, chi.URLParam(r, "annotationId")); err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetAnnotationTagsHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, _ := currentUser(r)
+		tags, err := annotations.Tags(r.Context(), u.Id)
+		if err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, tags)
+	}
+}
+
+// SyncAnnotationsHandler pushes the changes a device made offline and
+// pulls the annotations changed since its cursor, e.g. {"device": "iphone",
+// "cursor": 41, "changes": [{"id": "...", "modifiedAt": "...", "color":
+// "green"}]}. See annotation.Store.Sync.
+func SyncAnnotationsHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req annotation.SyncRequest
+		if !decodeLimited(w, r, &req, maxSyncBody) {
+			return
+		}
+		u, _ := currentUser(r)
+		resp, err := annotations.Sync(r.Context(), u.Id, req)
+		if err != nil {
+			annotationError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// canon checks annotations against the default translation, whose
+// numbering they use.
+type canon struct{}
+
+func (canon) Order(book string) int {
+	return bible.GetBookOrder(book)
+}
+
+func (canon) Check(rg annotation.Range) error {
+	repo := bible.Current()
+	if repo.GetBook(rg.Book).Id == "" {
+		return &annotation.ValidationError{Field: "start", Reason: "unknown book " + rg.Book}
+	}
+	for _, end := range []struct {
+		field, id string
+		point     passage.Point
+	}{
+		{"start", rg.Start(), passage.Point{Chapter: rg.StartChapter, Verse: rg.StartVerse}},
+		{"end", rg.End(), passage.Point{Chapter: rg.EndChapter, Verse: rg.EndVerse}},
+	} {
+		p := passage.Passage{Book: rg.Book, Ranges: []passage.Range{{Start: end.point, End: end.point}}}
+		if _, err := passage.Read(repo, p); err != nil {
+			return &annotation.ValidationError{Field: end.field, Reason: end.id + " does not exist"}
+		}
+	}
+	return nil
+}
+
+// annotationError maps the errors of the annotation package to a status.
+func annotationError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *annotation.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, annotation.ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, annotation.ErrNotFound):
+		http.Error(w, "Annotation not found", http.StatusNotFound)
+	default:
+		internalError(w, r, err)
+	}
+}
+
+// ChapterAnnotation is an annotation of a chapter with the numbers of the
+// verses it covers in the chapter, as numbered by its translation.
+type ChapterAnnotation struct {
+	annotation.Annotation
+	Verses []int `json:"verses"`
+}
+
+type AnnotatedChapterResponse struct {
+	bible.Chapter
+	Annotations []ChapterAnnotation `json:"annotations"`
+}
+
+// chapterAnnotations returns the annotations of the user that cover verses
+// of chapter, which is numbered by the scheme of its translation.
+func chapterAnnotations(r *http.Request, annotations *annotation.Store, chapter bible.Chapter) ([]ChapterAnnotation, error) {
+	scheme, ok := versification.Lookup(requestTranslation(r).Versification)
+	if !ok {
+		scheme, _ = versification.Lookup(versification.Standard)
+	}
+
+	// The standard verses of every verse of the chapter, and the standard
+	// chapters to look up.
+	type chapterKey struct {
+		book    string
+		chapter int
+	}
+	standard := make(map[int][]versification.Verse)
+	var chapters []chapterKey
+	for _, v := range chapter.Verses {
+		verses := scheme.ToStandard(versification.Verse{Book: chapter.Id, Chapter: bible.ChapterNumber(v.Chapter), Verse: v.Verse})
+		standard[v.Verse] = verses
+		for _, sv := range verses {
+			if key := (chapterKey{sv.Book, sv.Chapter}); !slices.Contains(chapters, key) {
+				chapters = append(chapters, key)
+			}
+		}
+	}
+
+	u, _ := currentUser(r)
+	list := []ChapterAnnotation{}
+	seen := make(map[string]bool)
+	for _, c := range chapters {
+		found, err := annotations.List(r.Context(), u.Id, annotation.Filter{Book: c.book, Chapter: c.chapter})
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range found {
+			if seen[a.Id] {
+				continue
+			}
+			seen[a.Id] = true
+			rg, err := annotation.ParseRange(a.Start, a.End)
+			if err != nil {
+				return nil, err
+			}
+			ca := ChapterAnnotation{Annotation: a, Verses: []int{}}
+			for _, v := range chapter.Verses {
+				if slices.ContainsFunc(standard[v.Verse], func(sv versification.Verse) bool {
+					return sv.Book == rg.Book && rg.Contains(sv.Chapter, sv.Verse)
+				}) {
+					ca.Verses = append(ca.Verses, v.Verse)
+				}
+			}
+			if len(ca.Verses) > 0 {
+				list = append(list, ca)
+			}
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/account"
+	"github.com/pschuurmans/bijbel-api/internal/annotation"
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+func TestAnnotations(t *testing.T) {
+	// An English translation that numbers Malachi 3:19-24 as 4:1-6.
+	repo, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"maleachi","name":"Malachi","order":1}]`)},
+		"books/maleachi.json": {Data: []byte(`{"id":"maleachi","name":"Malachi","chapters":4,"verseCount":2,"verses":[
+			{"chapter":4,"verse":1,"id":"maleachi.4.1","text":"For, behold, the day cometh"},
+			{"chapter":4,"verse":2,"id":"maleachi.4.2","text":"But unto you that fear my name"}]}`)},
+	})
+	require.NoError(t, err)
+	registry := translation.NewRegistry()
+	require.NoError(t, registry.Replace([]translation.Entry{{
+		Translation: translation.Translation{Id: "kjv", Name: "King James", Language: "en", Versification: "kjv"},
+		Repository:  repo,
+	}}))
+	router := newRouter(config.Default(), registry, newTestUsers(t), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	signIn := func(email string) string {
+		t.Helper()
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/accounts", "", `{"email":"`+email+`","password":"geheim123"}`).Code)
+		rr := do(http.MethodPost, "/sessions", "", `{"email":"`+email+`","password":"geheim123"}`)
+		var session account.Session
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+		return session.Token
+	}
+	maria, jozef := signIn("maria@example.org"), signIn("jozef@example.org")
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/annotations", "", "").Code)
+
+	rr := do(http.MethodPost, "/annotations", maria, `{"kind":"highlight","start":"genesis.1.1","end":"genesis.1.3","color":"yellow","tags":["Schepping"]}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var a annotation.Annotation
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &a))
+	require.Equal(t, []string{"schepping"}, a.Tags)
+
+	rr = do(http.MethodPost, "/annotations", maria, `{"id":"licht","kind":"note","start":"genesis.1.3","note":"Er zij licht"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	require.Equal(t, http.StatusConflict, do(http.MethodPost, "/annotations", maria, `{"id":"licht","kind":"bookmark","start":"genesis.1"}`).Code)
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/annotations", maria, `{"id":"dag","kind":"bookmark","start":"maleachi.3.19"}`).Code)
+
+	for _, body := range []string{
+		`{"kind":"bookmark","start":"onbekend.1"}`,
+		`{"kind":"bookmark","start":"genesis.51"}`,
+		`{"kind":"bookmark","start":"genesis.1.1","end":"genesis.1.99"}`,
+		`{"kind":"highlight","start":"genesis.1.1","color":"rood"}`,
+	} {
+		require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/annotations", maria, body).Code, body)
+	}
+
+	rr = do(http.MethodGet, "/annotations?book=genesis&chapter=1", maria, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list []annotation.Annotation
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list, 2)
+	require.Equal(t, a.Id, list[0].Id)
+
+	rr = do(http.MethodGet, "/annotations?q=licht", maria, "")
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	require.Equal(t, "licht", list[0].Id)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/annotations?chapter=1", maria, "").Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/annotations?limit=0", maria, "").Code)
+
+	rr = do(http.MethodGet, "/annotations", jozef, "")
+	require.JSONEq(t, `[]`, rr.Body.String(), "annotations are private")
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/annotations/licht", jozef, "").Code)
+
+	rr = do(http.MethodGet, "/annotations/tags", maria, "")
+	require.JSONEq(t, `[{"tag":"schepping","count":1}]`, rr.Body.String())
+
+	rr = do(http.MethodPut, "/annotations/licht", maria, `{"kind":"note","start":"genesis.1.3","note":"En er was licht","tags":["licht"]}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/annotations/licht", maria, `{"id":"ander","kind":"bookmark","start":"genesis.1"}`).Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodPut, "/annotations/licht", jozef, `{"kind":"bookmark","start":"genesis.1"}`).Code)
+	rr = do(http.MethodGet, "/annotations/licht", maria, "")
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &a))
+	require.Equal(t, "En er was licht", a.Note)
+
+	t.Run("chapter", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/books/genesis/chapter/1?annotations=true", "", "").Code)
+		require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/books/genesis/chapter/1?annotations=true&format=text", maria, "").Code)
+
+		rr := do(http.MethodGet, "/books/genesis/chapter/1?annotations=true", maria, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var chapter AnnotatedChapterResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &chapter))
+		require.NotEmpty(t, chapter.Verses)
+		require.Len(t, chapter.Annotations, 2)
+		require.Equal(t, []int{1, 2, 3}, chapter.Annotations[0].Verses)
+		require.Equal(t, []int{3}, chapter.Annotations[1].Verses)
+
+		rr = do(http.MethodGet, "/books/genesis/chapter/1", maria, "")
+		require.NotContains(t, rr.Body.String(), `"annotations"`)
+
+		rr = do(http.MethodGet, "/translations/kjv/books/maleachi/chapter/4?annotations=true", maria, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &chapter))
+		require.Len(t, chapter.Annotations, 1)
+		require.Equal(t, "dag", chapter.Annotations[0].Id)
+		require.Equal(t, []int{1}, chapter.Annotations[0].Verses, "verses are numbered by the translation")
+	})
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/annotations/licht", maria, "").Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/annotations/licht", maria, "").Code)
+}
+
+func TestAnnotationSync(t *testing.T) {
+	router := newRouter(config.Default(), newTestRegistry(t), newTestUsers(t), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/accounts", "", `{"email":"maria@example.org","password":"geheim123"}`).Code)
+	var session account.Session
+	require.NoError(t, json.Unmarshal(do(http.MethodPost, "/sessions", "", `{"email":"maria@example.org","password":"geheim123"}`).Body.Bytes(), &session))
+	sync := func(body string) annotation.SyncResponse {
+		t.Helper()
+		rr := do(http.MethodPost, "/annotations/sync", session.Token, body)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp annotation.SyncResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/annotations/sync", "", `{"device":"iphone"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/annotations/sync", session.Token, `{"cursor":0}`).Code)
+
+	phone := sync(`{"device":"iphone","cursor":0,"changes":[
+		{"id":"gen","modifiedAt":"2026-10-18T10:00:00Z","kind":"highlight","start":"genesis.1.1","color":"yellow"},
+		{"id":"fout","modifiedAt":"2026-10-18T10:00:00Z","kind":"bookmark","start":"genesis.1.999"}]}`)
+	require.Len(t, phone.Changes, 1)
+	require.Equal(t, "yellow", phone.Changes[0].Annotation.Color)
+	require.Equal(t, []annotation.Rejection{{Id: "fout", Error: "start: genesis.1.999 does not exist"}}, phone.Rejected)
+
+	// Edits from the web app reach the phone, and the phone's offline
+	// edit of another field merges with them.
+	rr := do(http.MethodPut, "/annotations/gen", session.Token, `{"kind":"highlight","start":"genesis.1.1","color":"yellow","tags":["schepping"]}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := sync(`{"device":"iphone","cursor":` + strconv.FormatInt(phone.Cursor, 10) + `,"changes":[
+		{"id":"gen","modifiedAt":"2099-01-01T00:00:00Z","color":"green"}]}`)
+	require.Len(t, resp.Changes, 1)
+	require.Equal(t, "green", resp.Changes[0].Annotation.Color)
+	require.Equal(t, []string{"schepping"}, resp.Changes[0].Annotation.Tags)
+	require.Greater(t, resp.Cursor, phone.Cursor)
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/annotations/gen", session.Token, "").Code)
+	resp = sync(`{"device":"iphone","cursor":` + strconv.FormatInt(resp.Cursor, 10) + `}`)
+	require.Equal(t, []annotation.Entry{{Id: "gen", Version: resp.Cursor, Deleted: true}}, resp.Changes)
+}
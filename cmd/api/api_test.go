@@ -1,13 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/middleware"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
 	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func containsGenesis(body string) bool {
@@ -53,7 +65,7 @@ func TestGetBookEndpoint(t *testing.T) {
 
 func TestGetChapterEndpoint(t *testing.T) {
 	router := chi.NewRouter()
-	router.Get("/books/{bookId}/chapter/{chapterId}", GetChapterHandler)
+	router.Get("/books/{bookId}/chapter/{chapterId}", GetChapterHandler(nil))
 
 	req := httptest.NewRequest("GET", "/books/genesis/chapter/1", nil)
 	rr := httptest.NewRecorder()
@@ -102,3 +114,122 @@ func TestGetCrossRefsChapterEndpoint(t *testing.T) {
 	require.Equal(t, http.StatusOK, rr.Code)
 	require.Contains(t, rr.Body.String(), "prediker") // not the best test ever
 }
+
+func TestGetCrossRefsChapterVersification(t *testing.T) {
+	type entry struct {
+		From struct{ Chapter, Verse int } `json:"from"`
+	}
+	chapter := func(path string) []entry {
+		rr := testGet(t, path)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var list []entry
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+		return list
+	}
+
+	// Malachi 4 of the KJV is 3:19-24 in the standard numbering.
+	require.Empty(t, chapter("/crossrefs/maleachi/chapter/4"))
+	var last int
+	for _, e := range chapter("/crossrefs/maleachi/chapter/3") {
+		last = max(last, e.From.Verse)
+	}
+	require.Equal(t, 24, last)
+
+	// Joel 2:28-32 and 3 of the KJV are Joel 3 and 4.
+	require.NotEmpty(t, chapter("/crossrefs/joel/chapter/4"))
+	for _, e := range chapter("/crossrefs/joel/chapter/3") {
+		require.LessOrEqual(t, e.From.Verse, 5)
+	}
+
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/crossrefs/maleachi/chapter/x").Code)
+}
+
+func TestGetBookChaptersEndpointStreamsValidJSON(t *testing.T) {
+	router := chi.NewRouter()
+	router.Get("/books/{bookId}/chapters", GetBookChaptersHandler)
+
+	req := httptest.NewRequest("GET", "/books/ruth/chapters", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	var book bible.Book
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &book))
+	require.Equal(t, "Ruth", book.Name)
+	require.Len(t, book.Verses, book.VerseCount)
+}
+
+func TestGetCrossRefsEndpointCompressed(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(middleware.Compress)
+	router.Get("/crossrefs/{bookId}", GetCrossRefsHandler)
+
+	req := httptest.NewRequest("GET", "/crossrefs/ruth", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+
+	gr, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	var refs crossref.BookCrossReferences
+	require.NoError(t, json.NewDecoder(gr).Decode(&refs))
+	require.Equal(t, "Ruth", refs.Book)
+	require.Len(t, refs.CrossReferences, refs.TotalReferences)
+}
+
+func TestInternalErrorHidesDetails(t *testing.T) {
+	var buf bytes.Buffer
+	handler := middleware.AccessLog(slog.New(slog.NewJSONHandler(&buf, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalError(w, r, errors.New("database is locked"))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Equal(t, "Internal server error\n", rr.Body.String())
+	require.Contains(t, buf.String(), "database is locked")
+}
+
+func TestRunShutsDownGracefully(t *testing.T) {
+	cfg := config.Default()
+	cfg.Addr = "127.0.0.1:0"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(cfg.Timeouts.Shutdown):
+		t.Fatal("server did not shut down in time")
+	}
+}
+
+func TestRouterMetricsAndRequestID(t *testing.T) {
+	router := newRouter(config.Default(), translation.NewRegistry(), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	req := httptest.NewRequest("GET", "/books/genesis/chapter/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotEmpty(t, rr.Header().Get(middleware.RequestIDHeader))
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	body := rr.Body.String()
+	require.Contains(t, body, `http_requests_total{method="GET",route="/books/{bookId}/chapter/{chapterId}",status="200"} 1`)
+	require.Contains(t, body, `bijbel_cache_hit_ratio{store="books"}`)
+	require.Contains(t, body, `bijbel_cache_hit_ratio{store="crossrefs"}`)
+}
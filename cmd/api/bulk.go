@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/pschuurmans/bijbel-api/internal/bulk"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+// bulkFormat reads the format query parameter of the export routes, CSV
+// when absent, and sets the headers of the download.
+func bulkFormat(w http.ResponseWriter, r *http.Request, name string) (bulk.Format, bool) {
+	format := bulk.CSV
+	if s := r.URL.Query().Get("format"); s != "" {
+		var err error
+		if format, err = bulk.ParseFormat(s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return "", false
+		}
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+"."+string(format)+`"`)
+	return format, true
+}
+
+// GetVersesExportHandler streams every verse of the requested translation
+// as a table.
+func GetVersesExportHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := bulkFormat(w, r, requestTranslation(r).Id+"-verses")
+	if !ok {
+		return
+	}
+	bulk.Verses(w, format, repository(r))
+}
+
+// GetCrossRefsExportHandler streams every cross-reference as a table.
+func GetCrossRefsExportHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := bulkFormat(w, r, "crossrefs")
+	if !ok {
+		return
+	}
+	bulk.CrossReferences(w, format, repository(r), crossref.Current())
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bundle"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+)
+
+func TestExportRoutes(t *testing.T) {
+	rr := testGet(t, "/translations/en/export/verses?format=tsv")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "text/tab-separated-values; charset=utf-8", rr.Header().Get("Content-Type"))
+	require.Equal(t, `attachment; filename="en-verses.tsv"`, rr.Header().Get("Content-Disposition"))
+	require.Equal(t, "id\tbook\tbook_order\tchapter\tverse\ttitle\tparagraph\ttext\n"+
+		"ruth.1.1\truth\t1\t1\t1\t\ttrue\tIn the days when the judges ruled\n", rr.Body.String())
+
+	rr = testGet(t, "/export/crossrefs?format=ndjson")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	first, _, _ := strings.Cut(rr.Body.String(), "\n")
+	require.True(t, strings.HasPrefix(first, `{"from_book":"genesis","from_chapter":1,"from_verse":1,"to_book":`), first)
+
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/export/verses?format=xlsx").Code)
+}
+
+func TestBundleRoutes(t *testing.T) {
+	router := newRouter(config.Default(), newTestRegistry(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		maps.Copy(req.Header, header)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/translations/en/export/sqlite/version", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var version struct {
+		Version       string `json:"version"`
+		SchemaVersion int    `json:"schemaVersion"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &version))
+	require.Equal(t, bundle.SchemaVersion, version.SchemaVersion)
+
+	rr = get("/translations/en/export/sqlite", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, bundle.MediaType, rr.Header().Get("Content-Type"))
+	require.Equal(t, `"`+version.Version+`"`, rr.Header().Get("ETag"))
+	require.True(t, strings.HasPrefix(rr.Body.String(), "SQLite format 3\x00"))
+
+	rr = get("/translations/en/export/sqlite", http.Header{"If-None-Match": {`"` + version.Version + `"`}})
+	require.Equal(t, http.StatusNotModified, rr.Code)
+
+	require.Equal(t, http.StatusBadRequest, get("/export/sqlite?minVotes=x", nil).Code)
+	require.Equal(t, http.StatusBadRequest, get("/export/sqlite?minVotes=11", nil).Code, "only the listed thresholds")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/bundle"
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+// bundles holds built SQLite bundles by version. With all cross-references
+// a bundle is close to 30 MB and takes several seconds to build, so
+// concurrent requests for the same version share one build.
+var (
+	bundles      = cache.NewLRU[string, []byte](4)
+	bundleBuilds singleflight.Group
+)
+
+// bundleOptions reads the minVotes query parameter of the bundle routes
+// into the options of the requested translation.
+func bundleOptions(w http.ResponseWriter, r *http.Request) (bundle.Options, bool) {
+	minVotes, ok := parseMinVotes(w, r)
+	if !ok {
+		return bundle.Options{}, false
+	}
+	return bundle.Options{
+		Translation:     requestTranslation(r),
+		CrossReferences: crossref.Current(),
+		MinVotes:        minVotes,
+	}, true
+}
+
+// GetBundleHandler serves the requested translation as a SQLite database
+// for the offline apps. The ETag is the bundle version, so clients can
+// check for an update with If-None-Match and resume with Range.
+func GetBundleHandler(w http.ResponseWriter, r *http.Request) {
+	opts, ok := bundleOptions(w, r)
+	if !ok {
+		return
+	}
+	repo := repository(r)
+	version := bundle.Version(repo, opts)
+
+	body, err := bundles.GetOrLoad(version, func(version string) ([]byte, error) {
+		body, err, _ := bundleBuilds.Do(version, func() (any, error) {
+			return buildBundle(repo, opts)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return body.([]byte), nil
+	})
+	if err != nil {
+		http.Error(w, "Bundle not available", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", bundle.MediaType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.sqlite"`, opts.Translation.Id, version))
+	w.Header().Set("ETag", `"`+version+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// buildBundle writes a bundle to a temporary file and returns its contents.
+func buildBundle(repo bible.Repository, opts bundle.Options) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "bundle")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bundle.sqlite")
+	if err := bundle.Write(path, repo, opts); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// GetBundleVersionHandler returns the version that GetBundleHandler would
+// serve with the same parameters, without building the bundle.
+func GetBundleVersionHandler(w http.ResponseWriter, r *http.Request) {
+	opts, ok := bundleOptions(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Version       string `json:"version"`
+		SchemaVersion int    `json:"schemaVersion"`
+	}{bundle.Version(repository(r), opts), bundle.SchemaVersion})
+}
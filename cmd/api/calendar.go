@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+)
+
+// calendarLocation decides which date "today" is; the image has no zoneinfo,
+// so the database is embedded.
+var calendarLocation, _ = time.LoadLocation("Europe/Amsterdam")
+
+var (
+	dutchCalendar   = liturgy.New(liturgy.DutchRules)
+	generalCalendar = liturgy.New(liturgy.GeneralRules)
+)
+
+// requestDate parses the {date} URL parameter: a YYYY-MM-DD date or "today".
+func requestDate(r *http.Request) (time.Time, bool) {
+	param := chi.URLParam(r, "date")
+	if param == "today" {
+		return time.Now().In(calendarLocation), true
+	}
+	d, err := time.Parse(time.DateOnly, param)
+	return d, err == nil
+}
+
+// requestCalendar returns the calendar of the rules query parameter: the
+// Dutch and Belgian rules by default, or "general" for the General Roman
+// Calendar.
+func requestCalendar(r *http.Request) (*liturgy.Calendar, bool) {
+	switch r.URL.Query().Get("rules") {
+	case "", "nl", "be":
+		return dutchCalendar, true
+	case "general":
+		return generalCalendar, true
+	}
+	return nil, false
+}
+
+func GetCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	d, ok := requestDate(r)
+	if !ok {
+		http.Error(w, "date must be YYYY-MM-DD or today", http.StatusBadRequest)
+		return
+	}
+	cal, ok := requestCalendar(r)
+	if !ok {
+		http.Error(w, "rules must be nl, be or general", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cal.Day(d))
+}
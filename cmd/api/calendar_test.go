@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+)
+
+func TestCalendarRoute(t *testing.T) {
+	rr := testGet(t, "/calendar/2026-06-07")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	var day liturgy.Day
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &day))
+	require.Equal(t, "corpus-christi", day.Celebration.Key)
+	require.Equal(t, liturgy.Ordinary, day.Season)
+	require.Equal(t, 10, day.Week)
+	require.Equal(t, "A", day.SundayCycle)
+	require.Equal(t, "II", day.WeekdayCycle)
+
+	rr = testGet(t, "/calendar/2026-06-07?rules=general")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &day))
+	require.Equal(t, "ordinary-10-sunday", day.Celebration.Key)
+
+	require.Equal(t, http.StatusOK, testGet(t, "/calendar/today").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/calendar/2026-13-01").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/calendar/2026-06-07?rules=us").Code)
+}
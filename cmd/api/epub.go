@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/epub"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// defaultMinVotes is the cross-reference threshold of the EPUB downloads;
+// below it the notes of popular verses run to hundreds of links.
+const defaultMinVotes = 20
+
+// minVotesChoices are the cross-reference thresholds a download can be
+// built with. Every threshold is a build of its own, so they are few.
+var minVotesChoices = []int{0, 5, 10, defaultMinVotes, 50, 100}
+
+// parseMinVotes reads the minVotes query parameter, answering 400 when it
+// is not one of minVotesChoices.
+func parseMinVotes(w http.ResponseWriter, r *http.Request) (int, bool) {
+	s := r.URL.Query().Get("minVotes")
+	if s == "" {
+		return defaultMinVotes, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || !slices.Contains(minVotesChoices, n) {
+		http.Error(w, "minVotes must be one of "+strings.Trim(fmt.Sprint(minVotesChoices), "[]"), http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
+
+// epubs holds generated publications by translation, data versions, book
+// and threshold. A whole Bible takes a few seconds to build, so concurrent
+// requests for the same publication share one build.
+var (
+	epubs      = cache.NewLRU[string, []byte](8)
+	epubBuilds singleflight.Group
+)
+
+// GetEpubHandler serves the requested translation as an EPUB 3 file, the
+// whole Bible or, with a {bookId}, a single book. The minVotes query
+// parameter sets the cross-reference threshold, 0 for all of them.
+func GetEpubHandler(w http.ResponseWriter, r *http.Request) {
+	minVotes, ok := parseMinVotes(w, r)
+	if !ok {
+		return
+	}
+
+	t := requestTranslation(r)
+	repo := repository(r)
+	refs := crossref.Current()
+	bookId := chi.URLParam(r, "bookId")
+	if bookId != "" && repo.GetBook(bookId).Id == "" {
+		http.Error(w, "Book not found", http.StatusNotFound)
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%s/%d", t.Id, repo.DataVersion(), refs.DataVersion(), bookId, minVotes)
+	body, err := epubs.GetOrLoad(key, func(key string) ([]byte, error) {
+		body, err, _ := epubBuilds.Do(key, func() (any, error) {
+			return buildEpub(t, repo, refs, bookId, minVotes)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return body.([]byte), nil
+	})
+	if err != nil {
+		http.Error(w, "EPUB not available", http.StatusInternalServerError)
+		return
+	}
+
+	name := t.Id
+	if bookId != "" {
+		name += "-" + bookId
+	}
+	w.Header().Set("Content-Type", epub.MediaType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.epub"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
+// buildEpub writes the publication of a book of t, or of all its books when
+// bookId is empty.
+func buildEpub(t translation.Translation, repo bible.Repository, refs crossref.Repository, bookId string, minVotes int) ([]byte, error) {
+	var ids []string
+	if bookId != "" {
+		ids = []string{bookId}
+	} else {
+		for _, b := range repo.GetBooks() {
+			ids = append(ids, b.Id)
+		}
+	}
+	books := make([]bible.SourceBook, 0, len(ids))
+	for _, id := range ids {
+		book, err := repo.ReadSourceBook(id)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+
+	title := t.Name
+	if bookId != "" {
+		title = books[0].Name + " (" + t.Name + ")"
+	}
+	scheme, _ := versification.Lookup(t.Versification)
+	var buf bytes.Buffer
+	err := epub.Write(&buf, books, epub.Options{
+		Title:           title,
+		Language:        t.Language,
+		CrossReferences: refs,
+		MinVotes:        minVotes,
+		Versification:   scheme,
+	})
+	return buf.Bytes(), err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/epub"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+func TestEpubRoutes(t *testing.T) {
+	// packageDocument returns the package document of an EPUB response.
+	packageDocument := func(rr *httptest.ResponseRecorder) string {
+		zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		require.NoError(t, err)
+		f, err := zr.Open("OEBPS/package.opf")
+		require.NoError(t, err)
+		defer f.Close()
+		opf, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(opf)
+	}
+
+	rr := testGet(t, "/books/ruth/epub")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, epub.MediaType, rr.Header().Get("Content-Type"))
+	require.Equal(t, `attachment; filename="wv75-ruth.epub"`, rr.Header().Get("Content-Disposition"))
+	opf := packageDocument(rr)
+	require.Contains(t, opf, "<dc:title>Ruth (Willibrordvertaling)</dc:title>")
+	require.Contains(t, opf, "<dc:language>nl</dc:language>")
+
+	rr = testGet(t, "/translations/en/books/ruth/epub?minVotes=0")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `attachment; filename="en-ruth.epub"`, rr.Header().Get("Content-Disposition"))
+	require.Contains(t, packageDocument(rr), "<dc:language>en</dc:language>")
+
+	require.Equal(t, http.StatusNotFound, testGet(t, "/books/onbekend/epub").Code)
+	require.Equal(t, http.StatusNotFound, testGet(t, "/translations/en/books/genesis/epub").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/epub?minVotes=veel").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/epub?minVotes=-1").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/epub?minVotes=7").Code, "only the listed thresholds")
+
+	// Translations that share their data are cached apart.
+	registry := newTestRegistry(t)
+	en, repo, _ := registry.Get("en")
+	engels := en
+	engels.Id, engels.Name = "engels", "Engels"
+	require.NoError(t, registry.Replace([]translation.Entry{{Translation: en, Repository: repo}, {Translation: engels, Repository: repo}}))
+	router := newRouter(config.Default(), registry, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for id, title := range map[string]string{"en": "Ruth (English)", "engels": "Ruth (Engels)"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/translations/"+id+"/books/ruth/epub", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, packageDocument(rr), "<dc:title>"+title+"</dc:title>")
+	}
+}
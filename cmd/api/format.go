@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/pschuurmans/bijbel-api/internal/render"
+)
+
+// negotiateFormat picks the response format from the format query
+// parameter or the Accept header. It answers 400 to an unknown format and
+// reports whether the handler should go on.
+func negotiateFormat(w http.ResponseWriter, r *http.Request) (render.Format, bool) {
+	w.Header().Add("Vary", "Accept")
+	format, err := render.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
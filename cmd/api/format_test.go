@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/config"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	router := newRouter(config.Default(), newTestRegistry(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		path, accept string
+		contentType  string
+		body         string
+	}{
+		{"/books/genesis/chapter/1", "", "application/json", `"verse":1`},
+		{"/books/genesis/chapter/1", "text/plain", "text/plain; charset=utf-8", "\n1 In het begin schiep God de hemel en de aarde"},
+		{"/books/genesis/chapter/1?format=md", "", "text/markdown; charset=utf-8", "# Genesis 1\n"},
+		{"/books/genesis/chapter/1", "text/html", "text/html; charset=utf-8", `<sup class="verse" id="v1-1">1</sup> In het begin`},
+		{"/books/ruth", "text/plain", "text/plain; charset=utf-8", "Ruth\n"},
+		{"/books/ruth/chapters?format=html", "", "text/html; charset=utf-8", `<article class="book">`},
+		{"/translations/en/books/ruth/chapter/1?format=text", "", "text/plain; charset=utf-8", "1 In the days when the judges ruled\n"},
+		{"/parallel?ref=Ruth+1:1&translations=wv75,en&format=text", "", "text/plain; charset=utf-8", "en 1,1 In the days when the judges ruled\n"},
+		{"/parallel?ref=Ruth+1:1&translations=wv75,en", "application/json", "application/json", `"rows"`},
+	}
+	for _, tt := range tests {
+		rr := get(tt.path, tt.accept)
+		require.Equal(t, http.StatusOK, rr.Code, tt.path)
+		require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"), tt.path)
+		require.Contains(t, rr.Header().Values("Vary"), "Accept", tt.path)
+		if !strings.Contains(rr.Body.String(), tt.body) {
+			t.Errorf("GET %s (Accept %q): body does not contain %q:\n%s", tt.path, tt.accept, tt.body, rr.Body.String())
+		}
+	}
+
+	require.Equal(t, http.StatusBadRequest, get("/books/genesis/chapter/1?format=xml", "").Code)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/health"
+)
+
+// Build information, set at link time with
+// -ldflags "-X main.version=... -X main.commit=...".
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// BuildInfo describes the running binary and the data it serves.
+type BuildInfo struct {
+	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+	DataVersion string `json:"dataVersion"`
+}
+
+// buildInfo falls back to the VCS revision recorded by the Go toolchain when
+// no commit was set at link time.
+func buildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:     version,
+		Commit:      commit,
+		DataVersion: bible.DataVersion() + "-" + crossref.DataVersion(),
+	}
+	if info.Commit == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range bi.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// readiness verifies the embedded data once at startup.
+var readiness = health.NewChecker(
+	health.Check{Name: "books", Run: bible.Verify},
+	health.Check{Name: "crossref-index", Run: crossref.VerifyIndex},
+	health.Check{Name: "crossref-mapping", Run: func() error {
+		var ids []string
+		for _, b := range bible.GetBooks() {
+			ids = append(ids, b.Id)
+		}
+		return crossref.VerifyMapping(ids)
+	}},
+)
+
+// LivenessHandler reports that the process is up and able to serve requests.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":  "healthy",
+		"service": "bijbel-api",
+		"version": version,
+	})
+}
+
+// ReadinessHandler reports whether the data integrity checks passed. It
+// answers 503 until the checks have run and while a required check fails.
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	ready, results := readiness.Ready()
+
+	status := "ready"
+	for _, result := range results {
+		if result.Status == health.StatusWarn {
+			status = "degraded"
+		}
+	}
+	if !ready {
+		status = "not ready"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(struct {
+		Status  string          `json:"status"`
+		Service string          `json:"service"`
+		Build   BuildInfo       `json:"build"`
+		Checks  []health.Result `json:"checks"`
+	}{status, "bijbel-api", buildInfo(), results})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/health"
+)
+
+func TestLivenessEndpoint(t *testing.T) {
+	rr := httptest.NewRecorder()
+	LivenessHandler(rr, httptest.NewRequest("GET", "/livez", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), "healthy")
+}
+
+func TestReadinessEndpoint(t *testing.T) {
+	readiness.Run()
+
+	rr := httptest.NewRecorder()
+	ReadinessHandler(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+	// The cross-reference index lists the Isaiah and Psalms files, which are
+	// not bundled.
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var body struct {
+		Status string          `json:"status"`
+		Build  BuildInfo       `json:"build"`
+		Checks []health.Result `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+	require.Equal(t, "not ready", body.Status)
+	require.Equal(t, "dev", body.Build.Version)
+	require.Len(t, body.Build.DataVersion, 25)
+	require.Len(t, body.Checks, 3)
+	require.Equal(t, health.StatusPass, body.Checks[0].Status)
+	require.Equal(t, "crossref-index", body.Checks[1].Name)
+	require.Equal(t, health.StatusFail, body.Checks[1].Status)
+	require.Contains(t, body.Checks[1].Error, "isa.json")
+	require.Contains(t, body.Checks[1].Error, "ps.json")
+	require.Equal(t, health.StatusPass, body.Checks[2].Status)
+}
+
+func TestReadinessEndpointBeforeChecks(t *testing.T) {
+	checker := readiness
+	defer func() { readiness = checker }()
+	readiness = health.NewChecker(health.Check{Name: "books", Run: func() error { return nil }})
+
+	rr := httptest.NewRecorder()
+	ReadinessHandler(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Contains(t, rr.Body.String(), "not ready")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/ical"
+	"github.com/pschuurmans/bijbel-api/internal/lectionary"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+// maxFeedWeeks bounds the weeks query parameter of the lectionary feed.
+const maxFeedWeeks = 520
+
+// GetPlanCalendarHandler returns a plan as an iCalendar feed with an
+// all-day event per day, e.g. /plans/bijbel-in-een-jaar/calendar.ics?start=2027-01-01.
+// The plan starts on the start query parameter, or today. It takes the
+// parameters of requestPlan.
+func GetPlanCalendarHandler(frontend string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, ok := requestStart(r)
+		if !ok {
+			http.Error(w, "start must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		p, summary, ok := requestPlan(w, r)
+		if !ok {
+			return
+		}
+
+		repo, books := repository(r), bookResolver()
+		cal := ical.Calendar{Name: summary.Name, Stamp: time.Now()}
+		for i, refs := range p.Days {
+			if len(refs) == 0 {
+				continue
+			}
+			d := start.AddDate(0, 0, i)
+			event, err := passageEvent(repo, books, frontend, refs[0])
+			if err != nil {
+				internalError(w, r, err)
+				return
+			}
+			event.UID = fmt.Sprintf("plan-%s-%s@bijbel-api", summary.Id, d.Format(time.DateOnly))
+			event.Date = d
+			event.Summary = "Dag " + strconv.Itoa(i+1) + ": " + strings.Join(refs, "; ")
+			cal.Events = append(cal.Events, event)
+		}
+		writeCalendar(w, cal)
+	}
+}
+
+// GetLectionaryCalendarHandler returns the Gospel of the Sundays as an
+// iCalendar feed, e.g. /lectionary/sundays.ics. It starts on the start query
+// parameter, or today, and runs for weeks weeks, 52 by default. It takes the
+// rules parameter of /calendar/{date}.
+func GetLectionaryCalendarHandler(frontend string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, ok := requestStart(r)
+		if !ok {
+			http.Error(w, "start must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		weeks := 52
+		if s := r.URL.Query().Get("weeks"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxFeedWeeks {
+				http.Error(w, "weeks must be a number from 1 to "+strconv.Itoa(maxFeedWeeks), http.StatusBadRequest)
+				return
+			}
+			weeks = n
+		}
+		liturgical, ok := requestCalendar(r)
+		if !ok {
+			http.Error(w, "rules must be nl, be or general", http.StatusBadRequest)
+			return
+		}
+
+		repo, books := repository(r), bookResolver()
+		cal := ical.Calendar{Name: "Evangelie van de zondag", Stamp: time.Now()}
+		sunday := start.AddDate(0, 0, (7-int(start.Weekday()))%7)
+		for range weeks {
+			day := liturgical.Day(sunday)
+			if readings, ok := lectionary.Default().Readings(day); ok && readings.Gospel != "" {
+				event, err := passageEvent(repo, books, frontend, readings.Gospel)
+				if err != nil {
+					internalError(w, r, err)
+					return
+				}
+				event.UID = "lectionary-" + day.Date + "@bijbel-api"
+				event.Date = sunday
+				event.Summary = day.Celebration.Name + ": " + readings.Gospel
+				cal.Events = append(cal.Events, event)
+			}
+			sunday = sunday.AddDate(0, 0, 7)
+		}
+		writeCalendar(w, cal)
+	}
+}
+
+// requestStart parses the start query parameter, a YYYY-MM-DD date, or
+// returns today.
+func requestStart(r *http.Request) (time.Time, bool) {
+	s := r.URL.Query().Get("start")
+	if s == "" {
+		now := time.Now().In(calendarLocation)
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), true
+	}
+	d, err := time.Parse(time.DateOnly, s)
+	return d, err == nil
+}
+
+// passageEvent returns an event with the first verse of ref as description,
+// linking to that verse in the frontend.
+func passageEvent(repo bible.Repository, books reference.BookResolver, frontend, ref string) (ical.Event, error) {
+	p, err := passage.Parse(ref, books)
+	if err != nil {
+		return ical.Event{}, err
+	}
+	first := p.Ranges[0]
+	if first.Start.Verse == 0 {
+		first.End = first.Start
+	}
+	verses, err := passage.Read(repo, passage.Passage{Book: p.Book, Ranges: []passage.Range{first}})
+	if err != nil {
+		return ical.Event{}, err
+	}
+	v := verses[0]
+	return ical.Event{
+		Description: v.Text,
+		URL:         fmt.Sprintf("%s/#%s/%d/%d", strings.TrimSuffix(frontend, "/"), p.Book, v.Chapter, v.Verse),
+	}, nil
+}
+
+func writeCalendar(w http.ResponseWriter, cal ical.Calendar) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	ical.Write(w, cal)
+}
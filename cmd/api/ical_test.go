@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeeds(t *testing.T) {
+	rr := testGet(t, "/plans/custom/calendar.ics?books=ruth&days=2&start=2026-12-31")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+	body := rr.Body.String()
+	require.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT\r\n"))
+	require.Contains(t, body, "UID:plan-custom-2027-01-01@bijbel-api\r\n")
+	require.Contains(t, body, "DTSTART;VALUE=DATE:20270101\r\n")
+	require.Contains(t, body, "SUMMARY:Dag 2: ruth 3-4\r\n")
+	require.Contains(t, body, "URL:https://bijbel.fido21.nl/#ruth/3/1\r\n")
+	for _, line := range strings.Split(body, "\r\n") {
+		require.LessOrEqual(t, len(line), 75)
+	}
+
+	rr = testGet(t, "/lectionary/sundays.ics?start=2026-10-14&weeks=2")
+	require.Equal(t, http.StatusOK, rr.Code)
+	body = rr.Body.String()
+	require.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT\r\n"))
+	require.Contains(t, body, "UID:lectionary-2026-10-18@bijbel-api\r\n")
+	require.Contains(t, body, "matteus 22:15-21\r\n")
+	require.Contains(t, body, "URL:https://bijbel.fido21.nl/#matteus/22/15\r\n")
+
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/plans/nieuwe-testament/calendar.ics?start=morgen").Code)
+	require.Equal(t, http.StatusNotFound, testGet(t, "/plans/onbekend/calendar.ics").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/lectionary/sundays.ics?weeks=0").Code)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/lectionary"
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+// Reading is a reading of a Mass, or a psalm or canticle of an hour,
+// resolved to its verses.
+type Reading struct {
+	Kind      string          `json:"kind"`
+	Reference string          `json:"reference"`
+	Book      string          `json:"book"`
+	BookName  string          `json:"bookName"`
+	Verses    []passage.Verse `json:"verses"`
+}
+
+type LectionaryResponse struct {
+	Day      liturgy.Day `json:"day"`
+	Readings []Reading   `json:"readings"`
+}
+
+// GetLectionaryHandler returns the readings of the Mass of a day, e.g.
+// /lectionary/2026-10-18, with their text. It takes the rules parameter of
+// /calendar/{date}.
+func GetLectionaryHandler(w http.ResponseWriter, r *http.Request) {
+	d, ok := requestDate(r)
+	if !ok {
+		http.Error(w, "date must be YYYY-MM-DD or today", http.StatusBadRequest)
+		return
+	}
+	cal, ok := requestCalendar(r)
+	if !ok {
+		http.Error(w, "rules must be nl, be or general", http.StatusBadRequest)
+		return
+	}
+
+	day := cal.Day(d)
+	readings, ok := lectionary.Default().Readings(day)
+	if !ok {
+		http.Error(w, "No readings for "+day.Celebration.Name, http.StatusNotFound)
+		return
+	}
+
+	repo, books, names := repository(r), bookResolver(), bookNames()
+	resp := LectionaryResponse{Day: day, Readings: []Reading{}}
+	for _, reading := range []struct{ kind, ref string }{
+		{"first", readings.First},
+		{"psalm", readings.Psalm},
+		{"second", readings.Second},
+		{"gospel", readings.Gospel},
+	} {
+		if reading.ref == "" {
+			continue
+		}
+		rd, err := readPassage(repo, books, names, reading.kind, reading.ref)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		resp.Readings = append(resp.Readings, rd)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// bookNames maps book ids to their names.
+func bookNames() map[string]string {
+	names := make(map[string]string)
+	for _, b := range bible.GetBooks() {
+		names[b.Id] = b.Name
+	}
+	return names
+}
+
+// readPassage reads a liturgical reference such as "jesaja 45:1, 4-6" in
+// repo.
+func readPassage(repo bible.Repository, books reference.BookResolver, names map[string]string, kind, ref string) (Reading, error) {
+	p, err := passage.Parse(ref, books)
+	if err != nil {
+		return Reading{}, err
+	}
+	verses, err := passage.Read(repo, p)
+	if err != nil {
+		return Reading{}, err
+	}
+	return Reading{Kind: kind, Reference: ref, Book: p.Book, BookName: names[p.Book], Verses: verses}, nil
+}
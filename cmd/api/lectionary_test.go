@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLectionaryRoute(t *testing.T) {
+	rr := testGet(t, "/lectionary/2026-10-18")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp LectionaryResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "ordinary-29-sunday", resp.Day.Celebration.Key)
+	require.Len(t, resp.Readings, 4)
+
+	first := resp.Readings[0]
+	require.Equal(t, "first", first.Kind)
+	require.Equal(t, "jesaja 45:1, 4-6", first.Reference)
+	require.Equal(t, "Jesaja", first.BookName)
+	var verses []int
+	for _, v := range first.Verses {
+		verses = append(verses, v.Verse)
+	}
+	require.Equal(t, []int{1, 4, 5, 6}, verses)
+
+	second := resp.Readings[2]
+	require.Equal(t, "second", second.Kind)
+	last := second.Verses[len(second.Verses)-1]
+	require.Equal(t, 5, last.Verse)
+	require.Equal(t, "b", last.Part)
+
+	// Weekdays have no second reading.
+	rr = testGet(t, "/lectionary/2026-10-19")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.Readings, 3)
+	require.Equal(t, "gospel", resp.Readings[2].Kind)
+
+	require.Equal(t, http.StatusNotFound, testGet(t, "/lectionary/2026-04-04").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/lectionary/2026-13-01").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/lectionary/2026-10-18?rules=us").Code)
+}
@@ -1,56 +1,152 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/pschuurmans/bijbel-api/internal/annotation"
 	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/config"
 	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/datasource"
+	"github.com/pschuurmans/bijbel-api/internal/jsonstream"
+	"github.com/pschuurmans/bijbel-api/internal/metrics"
+	"github.com/pschuurmans/bijbel-api/internal/middleware"
+	"github.com/pschuurmans/bijbel-api/internal/render"
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
 )
 
+// booksResponses holds the precompressed book lists by data version, so that
+// a reload or another translation gets its own entry.
+var booksResponses = cache.NewLRU[string, *middleware.Precompressed](8)
+
+// booksResponse returns the precompressed book list of repo.
+func booksResponse(repo bible.Repository) (*middleware.Precompressed, error) {
+	return booksResponses.GetOrLoad(repo.DataVersion(), func(string) (*middleware.Precompressed, error) {
+		body, err := json.Marshal(repo.GetBooks())
+		if err != nil {
+			return nil, err
+		}
+		return middleware.NewPrecompressed(body, "application/json")
+	})
+}
+
 func GetBooksHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(bible.GetBooks())
+	books, err := booksResponse(repository(r))
+	if err != nil {
+		http.Error(w, "Books not available", http.StatusInternalServerError)
+		return
+	}
+	books.ServeHTTP(w, r)
 }
 
 func GetBookHandler(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "bookId")
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(bible.GetBook(id))
+	book := repository(r).GetBook(id)
+	w.Header().Set("Content-Type", format.ContentType())
+	if format != render.JSON {
+		render.BookInfo(w, format, book)
+		return
+	}
+	json.NewEncoder(w).Encode(book)
 }
 
-func GetChapterHandler(w http.ResponseWriter, r *http.Request) {
-	bookId := chi.URLParam(r, "bookId")
-	chapterId := chi.URLParam(r, "chapterId")
-	chapterNum, err := strconv.Atoi(chapterId)
+// GetChapterHandler returns a chapter. With ?annotations=true the JSON
+// response includes the annotations of the signed-in user on it.
+func GetChapterHandler(annotations *annotation.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookId := chi.URLParam(r, "bookId")
+		chapterId := chi.URLParam(r, "chapterId")
+		chapterNum, err := strconv.Atoi(chapterId)
+		format, ok := negotiateFormat(w, r)
+		if !ok {
+			return
+		}
 
-	if err == nil {
-		w.Header().Set("Content-Type", "application/json")
-		chapter, err := bible.GetChapter(bookId, chapterNum)
-		if err == nil {
-			json.NewEncoder(w).Encode(chapter)
+		withAnnotations := r.URL.Query().Get("annotations") == "true"
+		if withAnnotations {
+			if annotations == nil || format != render.JSON {
+				http.Error(w, "Annotations are only available as JSON with accounts enabled", http.StatusBadRequest)
+				return
+			}
+			if _, ok := currentUser(r); !ok {
+				unauthorized(w, "Sign in required")
+				return
+			}
 		}
-	} else {
 
+		if err == nil {
+			w.Header().Set("Content-Type", format.ContentType())
+			chapter, err := repository(r).GetChapter(bookId, chapterNum)
+			if err == nil && format != render.JSON {
+				render.Chapter(w, format, chapter)
+			} else if err == nil && withAnnotations {
+				list, err := chapterAnnotations(r, annotations, chapter)
+				if err != nil {
+					internalError(w, r, err)
+					return
+				}
+				json.NewEncoder(w).Encode(AnnotatedChapterResponse{chapter, list})
+			} else if err == nil {
+				json.NewEncoder(w).Encode(chapter)
+			}
+		} else {
+
+		}
 	}
 }
 
+// GetBookChaptersHandler returns a book with all its verses. The JSON is
+// encoded verse by verse, so no encoded copy of the response is held, but the
+// repository still loads and copies the whole book for every request.
 func GetBookChaptersHandler(w http.ResponseWriter, r *http.Request) {
 	bookId := chi.URLParam(r, "bookId")
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	chapter, err := bible.GetChapters(bookId)
-	if err == nil {
-		json.NewEncoder(w).Encode(chapter)
+	w.Header().Set("Content-Type", format.ContentType())
+	book, err := repository(r).GetChapters(bookId)
+	if err == nil && format != render.JSON {
+		render.Book(w, format, book)
+	} else if err == nil {
+		head := struct {
+			Id         string `json:"id"`
+			Name       string `json:"name"`
+			Chapters   int    `json:"chapters"`
+			VerseCount int    `json:"verseCount"`
+		}{book.Id, book.Name, book.Chapters, book.VerseCount}
+		jsonstream.Object(w, head, "verses", book.Verses)
 	}
 }
 
+// GetCrossRefsHandler returns all cross-references of a book. Like
+// GetBookChaptersHandler it streams the encoding, not the data: the whole
+// cross-reference file is loaded and copied first.
 func GetCrossRefsHandler(w http.ResponseWriter, r *http.Request) {
 	bookId := chi.URLParam(r, "bookId")
 	crossref, err := crossref.GetCrossReferences(bookId)
@@ -59,15 +155,27 @@ func GetCrossRefsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Cross references not found", http.StatusNotFound)
 		return
 	}
+	head := struct {
+		Book            string `json:"book"`
+		TotalReferences int    `json:"totalReferences"`
+	}{crossref.Book, crossref.TotalReferences}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(crossref)
+	jsonstream.Object(w, head, "crossReferences", crossref.CrossReferences)
 }
 
+// GetCrossRefsChapterHandler returns the cross-references from one chapter.
+// The data is numbered like the KJV, so the verses are converted to the
+// numbering of the requested translation before the chapter is selected.
 func GetCrossRefsChapterHandler(w http.ResponseWriter, r *http.Request) {
 	bookId := chi.URLParam(r, "bookId")
-	chapterId := chi.URLParam(r, "chapterId")
-	chapterNum, err := strconv.Atoi(chapterId)
-	crossrefs, err := crossref.GetCrossReferences(bookId)
+	chapterNum, err := strconv.Atoi(chi.URLParam(r, "chapterId"))
+	if err != nil {
+		http.Error(w, "Invalid chapter", http.StatusBadRequest)
+		return
+	}
+	refs := crossref.Current()
+	crossrefs, err := refs.GetCrossReferences(bookId)
 
 	if err != nil {
 		http.Error(w, "Cross references not found", http.StatusNotFound)
@@ -75,76 +183,251 @@ func GetCrossRefsChapterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type CrossRefEntry struct {
-		From struct {
-			Book    string `json:"book"`
-			Chapter int    `json:"chapter"`
-			Verse   int    `json:"verse"`
-		} `json:"from"`
-		To struct {
-			Book    string `json:"book"`
-			Chapter int    `json:"chapter"`
-			Verse   int    `json:"verse"`
-		} `json:"to"`
-		Votes int `json:"votes"`
+		From  versification.Verse `json:"from"`
+		To    versification.Verse `json:"to"`
+		Votes int                 `json:"votes"`
 	}
 
+	kjv, _ := versification.Lookup(versification.KJV)
+	scheme, _ := versification.Lookup(requestTranslation(r).Versification)
 	crossrefChapter := make([]CrossRefEntry, 0)
 	for _, value := range crossrefs.CrossReferences {
-		if value.From.Chapter == chapterNum {
-			bookReference, err := crossref.EnglishToDutch(value.To.Book)
-			if err != nil {
-				continue
-			}
+		bookReference, err := refs.EnglishToDutch(value.To.Book)
+		if err != nil {
+			continue
+		}
+		to := versification.Verse{Book: bookReference, Chapter: value.To.Chapter, Verse: value.To.Verse}
+		if c := versification.Convert(to, kjv, scheme); len(c) > 0 {
+			to = c[0]
+		}
 
-			entry := CrossRefEntry{
-				Votes: value.Votes,
+		for _, from := range versification.Convert(versification.Verse{Book: bookId, Chapter: value.From.Chapter, Verse: value.From.Verse}, kjv, scheme) {
+			if from.Chapter == chapterNum {
+				crossrefChapter = append(crossrefChapter, CrossRefEntry{From: from, To: to, Votes: value.Votes})
 			}
-			entry.From.Book = bookId
-			entry.From.Chapter = value.From.Chapter
-			entry.From.Verse = value.From.Verse
-			entry.To.Book = bookReference
-			entry.To.Chapter = value.To.Chapter
-			entry.To.Verse = value.To.Verse
-			crossrefChapter = append(crossrefChapter, entry)
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(crossrefChapter)
+	jsonstream.Array(w, crossrefChapter)
 }
 
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]interface{}{
-		"status":  "healthy",
-		"service": "bijbel-api",
-		"version": "1.0.0",
-	}
-	json.NewEncoder(w).Encode(response)
+// internalError logs err with the request logger and answers 500 without
+// the details, which may reveal database or file system internals.
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	middleware.GetLogger(r.Context()).ErrorContext(r.Context(), "request failed", slog.Any("error", err))
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
 }
 
-func main() {
+// newRouter wires all routes and middleware. Without user stores the
+// account and annotation routes are not mounted.
+func newRouter(cfg config.Config, translations *translation.Registry, users *userStores, logger *slog.Logger) http.Handler {
+	registry := prometheus.NewRegistry()
+	httpMetrics := metrics.NewHTTP(registry)
+	metrics.RegisterCaches(registry, map[string]func() cache.Stats{
+		"books":     bible.CacheStats,
+		"crossrefs": crossref.CacheStats,
+	})
+
 	r := chi.NewRouter()
 
+	r.Use(middleware.RequestID)
+	r.Use(middleware.AccessLog(logger))
+	r.Use(httpMetrics.Middleware)
+	r.Use(middleware.Recover(logger))
+	r.Use(middleware.Compress)
+
 	// CORS middleware
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins: []string{"*"}, // ← dev: allow all
-		// AllowedOrigins:   []string{"http://localhost:4173", "http://localhost:*", "http://10.0.0.212:4173", "http://bijbel.fido21.nl", "https://bijbel.fido21.nl"},
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", middleware.RequestIDHeader},
+		ExposedHeaders:   []string{"Link", middleware.RequestIDHeader},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
+	var annotations *annotation.Store
+	var annotated []func(http.Handler) http.Handler
+	if users != nil {
+		accountRoutes(r, users.accounts)
+		annotationRoutes(r, users.accounts, users.annotations)
+		annotations = users.annotations
+		annotated = append(annotated, authenticateAnnotated(users.accounts))
+	}
 
-	r.Get("/health", HealthCheckHandler)
+	r.Get("/health", LivenessHandler)
+	r.Get("/livez", LivenessHandler)
+	r.Get("/readyz", ReadinessHandler)
+	r.Method(http.MethodGet, "/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		// Compress already negotiates the encoding.
+		DisableCompression: true,
+	}))
 	r.Get("/books", GetBooksHandler)
 	r.Get("/books/{bookId}", GetBookHandler)
 	r.Get("/books/{bookId}/chapters", GetBookChaptersHandler)
-	r.Get("/books/{bookId}/chapter/{chapterId}", GetChapterHandler)
+	r.With(annotated...).Get("/books/{bookId}/chapter/{chapterId}", GetChapterHandler(annotations))
+	r.Get("/books/{bookId}/epub", GetEpubHandler)
+	r.Get("/epub", GetEpubHandler)
+	r.Get("/export/verses", GetVersesExportHandler)
+	r.Get("/export/crossrefs", GetCrossRefsExportHandler)
+	r.Get("/export/sqlite", GetBundleHandler)
+	r.Get("/export/sqlite/version", GetBundleVersionHandler)
+	r.Get("/translations", GetTranslationsHandler(translations))
+	r.Get("/parallel", GetParallelHandler(translations))
+	r.Route("/translations/{translationId}", func(r chi.Router) {
+		r.Use(withTranslation(translations))
+		r.Get("/", GetTranslationHandler)
+		r.Get("/books", GetBooksHandler)
+		r.Get("/books/{bookId}", GetBookHandler)
+		r.Get("/books/{bookId}/chapters", GetBookChaptersHandler)
+		r.With(annotated...).Get("/books/{bookId}/chapter/{chapterId}", GetChapterHandler(annotations))
+		r.Get("/books/{bookId}/epub", GetEpubHandler)
+		r.Get("/epub", GetEpubHandler)
+		r.Get("/export/verses", GetVersesExportHandler)
+		r.Get("/export/sqlite", GetBundleHandler)
+		r.Get("/export/sqlite/version", GetBundleVersionHandler)
+	})
+	r.Get("/calendar/{date}", GetCalendarHandler)
+	r.Get("/lectionary/sundays.ics", GetLectionaryCalendarHandler(cfg.FrontendURL))
+	r.Get("/lectionary/{date}", GetLectionaryHandler)
+	r.Get("/psalter/{date}", GetPsalterHandler)
+	r.Get("/plans", GetPlansHandler)
+	r.Get("/plans/{planId}/day/{day}", GetPlanDayHandler)
+	r.Get("/plans/{planId}/calendar.ics", GetPlanCalendarHandler(cfg.FrontendURL))
+	r.Get("/verse-of-the-day/atom.xml", GetVerseOfTheDayAtomHandler(cfg.FrontendURL))
+	r.Get("/verse-of-the-day/rss.xml", GetVerseOfTheDayRSSHandler(cfg.FrontendURL))
+	r.Get("/verse-of-the-day/{date}", GetVerseOfTheDayHandler)
 	r.Get("/crossrefs/{bookId}", GetCrossRefsHandler)
 	r.Get("/crossrefs/{bookId}/chapter/{chapterId}", GetCrossRefsChapterHandler)
 
-	log.Println("Starting server on :3000")
-	http.ListenAndServe(":3000", r)
+	return r
+}
+
+// run starts the server and blocks until ctx is cancelled, after which it
+// drains in-flight requests for at most the configured shutdown timeout.
+func run(ctx context.Context, cfg config.Config, logger *slog.Logger) error {
+	source := datasource.New(cfg.Data, logger)
+	if source.Reloadable() {
+		if err := source.Load(); err != nil {
+			return err
+		}
+	} else if cfg.Data.BooksDir != "" || cfg.Data.CrossrefDir != "" {
+		logger.Warn("data directories are configured but data.source is embedded")
+	}
+
+	if _, err := booksResponse(bible.Current()); err != nil {
+		return fmt.Errorf("failed to precompress book list: %w", err)
+	}
+	if err := loadPlans(cfg.Data.PlansDir); err != nil {
+		return fmt.Errorf("failed to load reading plans: %w", err)
+	}
+	if err := loadVerseOfTheDay(cfg.Data.VersesFile); err != nil {
+		return fmt.Errorf("failed to load verses of the day: %w", err)
+	}
+
+	var users *userStores
+	if cfg.Accounts.Database != "" {
+		db, err := storage.Open(cfg.Accounts.Database)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if users, err = newUserStores(db, cfg.Accounts.SessionTTL); err != nil {
+			return fmt.Errorf("failed to migrate the accounts database: %w", err)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           newRouter(cfg, source.Registry(), users, logger),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+	}
+
+	go readiness.Run()
+
+	// Reloads re-run the readiness checks against the new data and rebuild
+	// the book list before it is requested.
+	source.OnReload(readiness.Run)
+	source.OnReload(func() {
+		if _, err := booksResponse(bible.Current()); err != nil {
+			logger.Error("failed to precompress book list", slog.Any("error", err))
+		}
+		if err := loadPlans(cfg.Data.PlansDir); err != nil {
+			logger.Error("failed to load reading plans", slog.Any("error", err))
+		}
+		if err := loadVerseOfTheDay(cfg.Data.VersesFile); err != nil {
+			logger.Error("failed to load verses of the day", slog.Any("error", err))
+		}
+	})
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go source.Watch(watchCtx, hup)
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", slog.String("addr", cfg.Addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down, draining connections")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.Info("server stopped")
+	return nil
+}
+
+// newLogger builds the structured logger described by cfg.
+func newLogger(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	level, _ := cfg.SlogLevel()
+	opts := &slog.HandlerOptions{Level: level}
+
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func main() {
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+
+	logger := newLogger(cfg.Log, os.Stdout)
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, logger); err != nil {
+		logger.Error("server failed", slog.Any("error", err))
+		stop()
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/parallel"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+	"github.com/pschuurmans/bijbel-api/internal/render"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+// bookResolver resolves book names against the books of the default
+// translation, whose ids all translations share.
+func bookResolver() reference.BookResolver {
+	books := bible.GetBooks()
+	ids := make([]string, len(books))
+	names := make([]string, len(books))
+	for i, b := range books {
+		ids[i], names[i] = b.Id, b.Name
+	}
+	return reference.Resolver(ids, names)
+}
+
+// GetParallelHandler compares a passage across translations, e.g.
+// /parallel?ref=maleachi+3:19-24&translations=wv75,sv. The reference is
+// numbered as in the first translation; without translations all are
+// compared.
+func GetParallelHandler(registry *translation.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, ok := negotiateFormat(w, r)
+		if !ok {
+			return
+		}
+		ref, err := reference.Parse(r.URL.Query().Get("ref"), bookResolver())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var ids []string
+		if list := r.URL.Query().Get("translations"); list != "" {
+			ids = strings.Split(list, ",")
+		} else {
+			for _, t := range registry.List() {
+				ids = append(ids, t.Id)
+			}
+		}
+
+		columns := make([]parallel.Column, 0, len(ids))
+		for _, id := range ids {
+			t, repo, ok := registry.Get(strings.TrimSpace(id))
+			if !ok {
+				http.Error(w, "Translation not found: "+id, http.StatusNotFound)
+				return
+			}
+			columns = append(columns, parallel.Column{Translation: t, Repository: repo})
+		}
+
+		passage, err := parallel.Align(ref, columns)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		if format != render.JSON {
+			render.Passage(w, format, passage)
+			return
+		}
+		json.NewEncoder(w).Encode(passage)
+	}
+}
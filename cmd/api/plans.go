@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/plan"
+)
+
+// readingPlans holds the generated plans and the curated plans of the
+// plans directory.
+var readingPlans = plan.NewRegistry()
+
+// customPlan is the id under which a plan is generated from the query.
+const customPlan = "custom"
+
+// maxPlanDays bounds the days query parameter.
+const maxPlanDays = 3660
+
+// loadPlans reads the curated plans of dir, if any.
+func loadPlans(dir string) error {
+	var plans []*plan.Plan
+	if dir != "" {
+		var err error
+		if plans, err = plan.Load(os.DirFS(dir), bookResolver()); err != nil {
+			return err
+		}
+	}
+	return readingPlans.SetCurated(plans)
+}
+
+type PlanDayResponse struct {
+	Plan     plan.Summary `json:"plan"`
+	Day      int          `json:"day"`
+	Readings []Reading    `json:"readings"`
+}
+
+func GetPlansHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(readingPlans.List())
+}
+
+// GetPlanDayHandler returns the readings of a day of a plan with their text,
+// e.g. /plans/bijbel-in-een-jaar/day/1. It takes the parameters of
+// requestPlan.
+func GetPlanDayHandler(w http.ResponseWriter, r *http.Request) {
+	p, summary, ok := requestPlan(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := strconv.Atoi(chi.URLParam(r, "day"))
+	if err != nil {
+		http.Error(w, "Invalid day", http.StatusBadRequest)
+		return
+	}
+	refs, ok := p.Day(n)
+	if !ok {
+		http.Error(w, "Day not found", http.StatusNotFound)
+		return
+	}
+
+	repo, books, names := repository(r), bookResolver(), bookNames()
+	resp := PlanDayResponse{Plan: summary, Day: n, Readings: []Reading{}}
+	for _, ref := range refs {
+		rd, err := readPassage(repo, books, names, "reading", ref)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		resp.Readings = append(resp.Readings, rd)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// requestPlan returns the plan of the {planId} URL parameter, writing an
+// error response when there is none. Generated plans take the days query
+// parameter to spread over another number of days. The custom plan is
+// generated from the books query parameter, a comma separated list of book
+// names, read in the order given or in that of order=canonical or
+// order=chronological.
+func requestPlan(w http.ResponseWriter, r *http.Request) (*plan.Plan, plan.Summary, bool) {
+	days := 0
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPlanDays {
+			http.Error(w, "days must be a number from 1 to "+strconv.Itoa(maxPlanDays), http.StatusBadRequest)
+			return nil, plan.Summary{}, false
+		}
+		days = n
+	}
+
+	repo := repository(r)
+	id := chi.URLParam(r, "planId")
+	var p *plan.Plan
+	summary := plan.Summary{Id: id, Generated: true}
+	if id == customPlan {
+		spec, err := customSpec(r, repo, days)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, plan.Summary{}, false
+		}
+		if p, err = plan.Generate(repo, spec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, plan.Summary{}, false
+		}
+		summary.Name = "Eigen leesplan"
+	} else {
+		var err error
+		p, err = readingPlans.Get(repo, id, days)
+		if errors.Is(err, plan.ErrNotFound) {
+			http.Error(w, "Plan not found", http.StatusNotFound)
+			return nil, plan.Summary{}, false
+		}
+		if err != nil {
+			internalError(w, r, err)
+			return nil, plan.Summary{}, false
+		}
+		for _, s := range readingPlans.List() {
+			if s.Id == id {
+				summary = s
+				break
+			}
+		}
+	}
+	summary.Days = len(p.Days)
+	return p, summary, true
+}
+
+// customSpec reads the custom plan from the books, order and days query
+// parameters; it is spread over 365 days unless days is given.
+func customSpec(r *http.Request, repo bible.Repository, days int) (plan.Spec, error) {
+	query := r.URL.Query()
+	resolve := bookResolver()
+	var books []string
+	for _, name := range strings.Split(query.Get("books"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		id, ok := resolve(name)
+		if !ok {
+			return plan.Spec{}, errors.New("unknown book " + strconv.Quote(name))
+		}
+		books = append(books, id)
+	}
+	if len(books) == 0 {
+		return plan.Spec{}, errors.New("books is required")
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "canonical":
+		slices.SortStableFunc(books, func(a, b string) int {
+			return repo.GetBookOrder(a) - repo.GetBookOrder(b)
+		})
+	case "chronological":
+		books = plan.Chronological(books)
+	default:
+		return plan.Spec{}, errors.New("order must be canonical or chronological")
+	}
+
+	if days == 0 {
+		days = 365
+	}
+	return plan.Spec{Id: customPlan, Days: days, Streams: [][]string{books}}, nil
+}
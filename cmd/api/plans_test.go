@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/plan"
+)
+
+func TestPlanRoutes(t *testing.T) {
+	rr := testGet(t, "/plans")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list []plan.Summary
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list, len(plan.Standard))
+	require.Equal(t, "bijbel-in-een-jaar", list[0].Id)
+	require.Equal(t, 365, list[0].Days)
+
+	rr = testGet(t, "/plans/nieuwe-testament/day/1?days=28")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp PlanDayResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, 28, resp.Plan.Days)
+	require.True(t, resp.Plan.Generated)
+	require.Equal(t, 1, resp.Day)
+	require.Len(t, resp.Readings, 1)
+	require.Equal(t, "matteus", resp.Readings[0].Book)
+	require.Equal(t, 1, resp.Readings[0].Verses[0].Chapter)
+
+	rr = testGet(t, "/plans/custom/day/2?books=ruth,jona&days=3&order=chronological")
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp = PlanDayResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "ruth 3-4", resp.Readings[0].Reference)
+
+	require.Equal(t, http.StatusNotFound, testGet(t, "/plans/onbekend/day/1").Code)
+	require.Equal(t, http.StatusNotFound, testGet(t, "/plans/nieuwe-testament/day/91").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/plans/nieuwe-testament/day/een").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/plans/nieuwe-testament/day/1?days=0").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/plans/custom/day/1?books=henoch").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/plans/custom/day/1?books=ruth&order=random").Code)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+	"github.com/pschuurmans/bijbel-api/internal/psalter"
+)
+
+type OfficeResponse struct {
+	Hour     psalter.Hour `json:"hour"`
+	Name     string       `json:"name"`
+	Proper   bool         `json:"proper,omitempty"`
+	Psalmody []Reading    `json:"psalmody"`
+}
+
+type PsalterResponse struct {
+	Day     liturgy.Day      `json:"day"`
+	Week    int              `json:"week"`
+	Offices []OfficeResponse `json:"offices"`
+}
+
+// GetPsalterHandler returns the psalms and canticles of Lauds, Vespers and
+// Compline of a day, e.g. /psalter/2026-10-18, with their text. It takes
+// the rules parameter of /calendar/{date}.
+func GetPsalterHandler(w http.ResponseWriter, r *http.Request) {
+	d, ok := requestDate(r)
+	if !ok {
+		http.Error(w, "date must be YYYY-MM-DD or today", http.StatusBadRequest)
+		return
+	}
+	cal, ok := requestCalendar(r)
+	if !ok {
+		http.Error(w, "rules must be nl, be or general", http.StatusBadRequest)
+		return
+	}
+
+	schedule := psalter.For(cal, d)
+	repo, books, names := repository(r), bookResolver(), bookNames()
+	resp := PsalterResponse{Day: schedule.Day, Week: schedule.Week}
+	for _, office := range schedule.Offices {
+		o := OfficeResponse{Hour: office.Hour, Name: office.Name, Proper: office.Proper}
+		for _, p := range office.Psalmody {
+			rd, err := readPassage(repo, books, names, string(p.Kind), p.Reference)
+			if err != nil {
+				internalError(w, r, err)
+				return
+			}
+			o.Psalmody = append(o.Psalmody, rd)
+		}
+		resp.Offices = append(resp.Offices, o)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/psalter"
+)
+
+func TestPsalterRoute(t *testing.T) {
+	rr := testGet(t, "/psalter/2026-10-18")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp PsalterResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.Week)
+	require.Len(t, resp.Offices, 3)
+
+	lauds := resp.Offices[0]
+	require.Equal(t, psalter.Lauds, lauds.Hour)
+	require.Equal(t, "psalmen 63:1-9", lauds.Psalmody[0].Reference)
+	require.Equal(t, "Psalmen", lauds.Psalmody[0].BookName)
+	require.Len(t, lauds.Psalmody[0].Verses, 9)
+	require.Equal(t, "canticle", lauds.Psalmody[1].Kind)
+	require.Equal(t, "gospel-canticle", lauds.Psalmody[3].Kind)
+
+	compline := resp.Offices[2]
+	require.Equal(t, "Completen", compline.Name)
+	require.Equal(t, "psalmen 91", compline.Psalmody[0].Reference)
+	require.Len(t, compline.Psalmody[0].Verses, 16)
+
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/psalter/2026-13-01").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/psalter/2026-10-18?rules=us").Code)
+}
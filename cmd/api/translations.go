@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+type translationKey struct{}
+
+type translationValue struct {
+	translation translation.Translation
+	repository  bible.Repository
+}
+
+// withTranslation resolves the {translationId} URL parameter and makes the
+// translation's repository available to the book handlers. The repository
+// is looked up once, so a reload during the request doesn't mix data.
+func withTranslation(registry *translation.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, repo, ok := registry.Get(chi.URLParam(r, "translationId"))
+			if !ok {
+				http.Error(w, "Translation not found", http.StatusNotFound)
+				return
+			}
+			ctx := context.WithValue(r.Context(), translationKey{}, translationValue{t, repo})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// repository returns the repository of the requested translation, or the
+// default translation for routes without a translation id.
+func repository(r *http.Request) bible.Repository {
+	if v, ok := r.Context().Value(translationKey{}).(translationValue); ok {
+		return v.repository
+	}
+	return bible.Current()
+}
+
+// requestTranslation returns the requested translation, or the default
+// translation for routes without a translation id.
+func requestTranslation(r *http.Request) translation.Translation {
+	if v, ok := r.Context().Value(translationKey{}).(translationValue); ok {
+		return v.translation
+	}
+	return translation.DefaultTranslation
+}
+
+func GetTranslationsHandler(registry *translation.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(registry.List())
+	}
+}
+
+func GetTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	v, _ := r.Context().Value(translationKey{}).(translationValue)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		translation.Translation
+		Canon []string `json:"canon"`
+	}{v.translation, translation.Canon(v.repository)})
+}
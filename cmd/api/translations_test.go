@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/parallel"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+func newTestRegistry(t *testing.T) *translation.Registry {
+	t.Helper()
+	repo, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"ruth","name":"Ruth","order":1}]`)},
+		"books/ruth.json": {Data: []byte(`{"id":"ruth","name":"Ruth","chapters":1,"verseCount":1,"verses":[
+			{"chapter":1,"verse":1,"id":"ruth.1.1","text":"In the days when the judges ruled","paragraph":"y"}]}`)},
+	})
+	require.NoError(t, err)
+
+	registry := translation.NewRegistry()
+	require.NoError(t, registry.Replace([]translation.Entry{{
+		Translation: translation.Translation{Id: "en", Name: "English", Language: "en", Versification: "kjv"},
+		Repository:  repo,
+	}}))
+	return registry
+}
+
+// testGet answers a GET request for path with a router over the test
+// registry, without accounts.
+func testGet(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := newRouter(config.Default(), newTestRegistry(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestTranslationRoutes(t *testing.T) {
+	rr := testGet(t, "/translations")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list []translation.Translation
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list, 2)
+	require.Equal(t, "wv75", list[0].Id)
+	require.True(t, list[0].Default)
+	require.Equal(t, "en", list[1].Id)
+
+	rr = testGet(t, "/translations/en")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"id":"en","name":"English","abbreviation":"","language":"en","versification":"kjv","default":false,"canon":["ruth"]}`, rr.Body.String())
+
+	rr = testGet(t, "/translations/en/books/ruth/chapter/1")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var chapter bible.Chapter
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &chapter))
+	require.Equal(t, "In the days when the judges ruled", chapter.Verses[0].Text)
+
+	rr = testGet(t, "/translations/en/books")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotContains(t, rr.Body.String(), "Genesis")
+
+	// The default translation is also reachable by id.
+	rr = testGet(t, "/translations/wv75/books/genesis")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), "Genesis")
+
+	rr = testGet(t, "/translations/xx/books")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestParallelRoute(t *testing.T) {
+	rr := testGet(t, "/parallel?ref=Ruth+1:1-2&translations=wv75,en")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var passage parallel.Passage
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &passage))
+	require.Len(t, passage.Translations, 2)
+	require.Len(t, passage.Rows, 2)
+	require.Equal(t, "In the days when the judges ruled", passage.Rows[0].Cells[1][0].Text)
+	require.Nil(t, passage.Rows[1].Cells[1]) // the test translation has only verse 1
+
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/parallel?ref=Ruth").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/parallel?ref=Onbekend+1:1").Code)
+	require.Equal(t, http.StatusNotFound, testGet(t, "/parallel?ref=Ruth+1:1&translations=xx").Code)
+	require.Equal(t, http.StatusNotFound, testGet(t, "/parallel?ref=Ruth+9:1").Code)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/feed"
+	"github.com/pschuurmans/bijbel-api/internal/votd"
+)
+
+// feedDays is the number of days, up to today, that the verse of the day
+// feeds list.
+const feedDays = 30
+
+// verseOfTheDay picks from the configured list and the current data. It is
+// replaced when either changes.
+var verseOfTheDay atomic.Pointer[votd.Picker]
+
+// loadVerseOfTheDay reads the list of file, or the bundled list if file is
+// empty, against the current data.
+func loadVerseOfTheDay(file string) error {
+	list := votd.DefaultList()
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if list, err = votd.ParseList(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	p, err := votd.New(list, bible.Current(), crossref.Current())
+	if err != nil {
+		return err
+	}
+	verseOfTheDay.Store(p)
+	return nil
+}
+
+// versePicker returns the loaded picker, loading the bundled list on first
+// use if none was.
+func versePicker() (*votd.Picker, error) {
+	if p := verseOfTheDay.Load(); p != nil {
+		return p, nil
+	}
+	if err := loadVerseOfTheDay(""); err != nil {
+		return nil, err
+	}
+	return verseOfTheDay.Load(), nil
+}
+
+type VerseOfTheDayResponse struct {
+	Date   string      `json:"date"`
+	Source votd.Source `json:"source"`
+	Reading
+}
+
+// GetVerseOfTheDayHandler returns the verse of the day of a date with its
+// text, e.g. /verse-of-the-day/today. Every user gets the same verse on a
+// date.
+func GetVerseOfTheDayHandler(w http.ResponseWriter, r *http.Request) {
+	d, ok := requestDate(r)
+	if !ok {
+		http.Error(w, "date must be YYYY-MM-DD or today", http.StatusBadRequest)
+		return
+	}
+	resp, err := readVerseOfTheDay(d)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetVerseOfTheDayAtomHandler returns the verses of the last days as an
+// Atom feed, linking to the verses in the frontend.
+func GetVerseOfTheDayAtomHandler(frontend string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := verseOfTheDayFeed(frontend)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		feed.WriteAtom(w, f)
+	}
+}
+
+// GetVerseOfTheDayRSSHandler returns the feed of
+// GetVerseOfTheDayAtomHandler as RSS 2.0.
+func GetVerseOfTheDayRSSHandler(frontend string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := verseOfTheDayFeed(frontend)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		feed.WriteRSS(w, f)
+	}
+}
+
+func readVerseOfTheDay(d time.Time) (VerseOfTheDayResponse, error) {
+	p, err := versePicker()
+	if err != nil {
+		return VerseOfTheDayResponse{}, err
+	}
+	c, err := p.Pick(d)
+	if err != nil {
+		return VerseOfTheDayResponse{}, err
+	}
+	rd, err := readPassage(bible.Current(), bookResolver(), bookNames(), "verse", c.Reference)
+	if err != nil {
+		return VerseOfTheDayResponse{}, err
+	}
+	return VerseOfTheDayResponse{Date: c.Date, Source: c.Source, Reading: rd}, nil
+}
+
+// verseOfTheDayFeed returns the verses of the last feedDays days, newest
+// first. The entries are identified by tag URIs of the frontend host.
+func verseOfTheDayFeed(frontend string) (feed.Feed, error) {
+	frontend = strings.TrimSuffix(frontend, "/")
+	host := "bijbel-api"
+	if u, err := url.Parse(frontend); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	now := time.Now().In(calendarLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, calendarLocation)
+	f := feed.Feed{
+		Id:          "tag:" + host + ",2026:verse-of-the-day",
+		Title:       "Tekst van de dag",
+		Description: "Elke dag een vers uit de Bijbel.",
+		Link:        frontend + "/",
+		Updated:     today,
+	}
+	for i := range feedDays {
+		d := today.AddDate(0, 0, -i)
+		resp, err := readVerseOfTheDay(d)
+		if err != nil {
+			return feed.Feed{}, err
+		}
+		var text []string
+		for _, v := range resp.Verses {
+			text = append(text, v.Text)
+		}
+		first := resp.Verses[0]
+		f.Items = append(f.Items, feed.Item{
+			Id:        fmt.Sprintf("tag:%s,%s:verse-of-the-day", host, resp.Date),
+			Title:     resp.Reference,
+			Link:      fmt.Sprintf("%s/#%s/%d/%d", frontend, resp.Book, first.Chapter, first.Verse),
+			Content:   strings.Join(text, " "),
+			Published: d,
+		})
+	}
+	return f, nil
+}
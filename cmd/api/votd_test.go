@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerseOfTheDay(t *testing.T) {
+	rr := testGet(t, "/verse-of-the-day/2026-10-17")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp VerseOfTheDayResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "2026-10-17", resp.Date)
+	require.EqualValues(t, "curated", resp.Source)
+	require.Equal(t, "1kronieken 16:34", resp.Reference)
+	require.Equal(t, "verse", resp.Kind)
+	require.Len(t, resp.Verses, 1)
+
+	rr = testGet(t, "/verse-of-the-day/2030-06-01")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.EqualValues(t, "cross-references", resp.Source)
+	require.NotEmpty(t, resp.Verses)
+	require.Equal(t, rr.Body.String(), testGet(t, "/verse-of-the-day/2030-06-01").Body.String())
+
+	require.Equal(t, http.StatusOK, testGet(t, "/verse-of-the-day/today").Code)
+	require.Equal(t, http.StatusBadRequest, testGet(t, "/verse-of-the-day/morgen").Code)
+
+	rr = testGet(t, "/verse-of-the-day/atom.xml")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/atom+xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	require.Equal(t, feedDays, strings.Count(rr.Body.String(), "<entry>"))
+	require.Contains(t, rr.Body.String(), `<link href="https://bijbel.fido21.nl/#`)
+
+	rr = testGet(t, "/verse-of-the-day/rss.xml")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/rss+xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	require.Equal(t, feedDays, strings.Count(rr.Body.String(), "<item>"))
+	require.Contains(t, rr.Body.String(), "tag:bijbel.fido21.nl,")
+}
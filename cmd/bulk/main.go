@@ -0,0 +1,75 @@
+// Command bulk exports all verses or all cross-references as one CSV, TSV
+// or NDJSON table.
+//
+//	bulk verses [-books-dir dir] [-format csv] [-o file]
+//	bulk crossrefs [-books-dir dir] [-crossref-dir dir] [-format csv] [-o file]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/bulk"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "verses" && os.Args[1] != "crossrefs") {
+		fmt.Fprintln(os.Stderr, "usage: bulk verses|crossrefs [flags]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	booksDir := flags.String("books-dir", "", "directory with books.json and books/*.json, empty for the embedded data")
+	crossrefDir := flags.String("crossref-dir", "", "directory with the cross-reference files, empty for the embedded data")
+	format := flags.String("format", "csv", "csv, tsv or ndjson")
+	out := flags.String("o", "", "file to write, empty for stdout")
+	flags.Parse(os.Args[2:])
+
+	if err := run(os.Args[1], *booksDir, *crossrefDir, *format, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(table, booksDir, crossrefDir, formatName, out string) error {
+	format, err := bulk.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	books := bible.Current()
+	if booksDir != "" {
+		if books, err = bible.NewDirRepository(booksDir); err != nil {
+			return err
+		}
+	}
+	refs := crossref.Current()
+	if crossrefDir != "" {
+		if refs, err = crossref.NewDirRepository(crossrefDir); err != nil {
+			return err
+		}
+	}
+
+	write := func(w io.Writer) error {
+		if table == "verses" {
+			return bulk.Verses(w, format, books)
+		}
+		return bulk.CrossReferences(w, format, books, refs)
+	}
+	if out == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,62 @@
+// Command bundle builds the SQLite database for the offline apps.
+//
+//	bundle -o file [-books-dir dir] [-crossref-dir dir] [-min-votes 20] [-no-crossrefs]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/bundle"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+func main() {
+	booksDir := flag.String("books-dir", "", "directory with books.json and books/*.json, empty for the embedded data")
+	crossrefDir := flag.String("crossref-dir", "", "directory with the cross-reference files, empty for the embedded data")
+	out := flag.String("o", "", "database file to create")
+	minVotes := flag.Int("min-votes", 20, "minimum votes of the cross-references to include")
+	noCrossRefs := flag.Bool("no-crossrefs", false, "leave the cross-references out")
+	flag.Parse()
+
+	if err := run(*booksDir, *crossrefDir, *out, *minVotes, !*noCrossRefs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(booksDir, crossrefDir, out string, minVotes int, crossRefs bool) error {
+	if out == "" {
+		return errors.New("-o is required")
+	}
+
+	books := bible.Current()
+	if booksDir != "" {
+		var err error
+		if books, err = bible.NewDirRepository(booksDir); err != nil {
+			return err
+		}
+	}
+
+	opts := bundle.Options{Translation: translation.DefaultTranslation, MinVotes: minVotes}
+	if crossRefs {
+		opts.CrossReferences = crossref.Current()
+		if crossrefDir != "" {
+			var err error
+			if opts.CrossReferences, err = crossref.NewDirRepository(crossrefDir); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := bundle.Write(out, books, opts); err != nil {
+		os.Remove(out)
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s, version %s\n", out, bundle.Version(books, opts))
+	return nil
+}
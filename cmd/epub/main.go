@@ -0,0 +1,78 @@
+// Command epub builds an EPUB 3 file of the whole Bible or of some books.
+//
+//	epub [-books-dir dir] [-book ids] [-o file] [-title title] [-lang nl] [-min-votes 20] [-no-crossrefs]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/epub"
+)
+
+func main() {
+	booksDir := flag.String("books-dir", "", "directory with books.json and books/*.json, empty for the embedded data")
+	books := flag.String("book", "", "comma-separated book ids to include, empty for the whole Bible")
+	out := flag.String("o", "", "file to write, empty for stdout")
+	title := flag.String("title", "Willibrordvertaling", "title of the publication")
+	lang := flag.String("lang", "nl", "language of the text")
+	minVotes := flag.Int("min-votes", 20, "minimum votes of the cross-references to include")
+	noCrossRefs := flag.Bool("no-crossrefs", false, "leave the cross-references out")
+	flag.Parse()
+
+	if err := run(*booksDir, *books, *out, *title, *lang, *minVotes, !*noCrossRefs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(booksDir, bookIds, out, title, lang string, minVotes int, crossRefs bool) error {
+	repo := bible.Current()
+	if booksDir != "" {
+		var err error
+		if repo, err = bible.NewDirRepository(booksDir); err != nil {
+			return err
+		}
+	}
+
+	var ids []string
+	if bookIds != "" {
+		ids = strings.Split(bookIds, ",")
+	} else {
+		for _, b := range repo.GetBooks() {
+			ids = append(ids, b.Id)
+		}
+	}
+
+	var books []bible.SourceBook
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		book, err := repo.ReadSourceBook(id)
+		if err != nil {
+			return fmt.Errorf("book %s: %w", id, err)
+		}
+		books = append(books, book)
+	}
+
+	opts := epub.Options{Title: title, Language: lang, MinVotes: minVotes}
+	if crossRefs {
+		opts.CrossReferences = crossref.Current()
+	}
+
+	if out == "" {
+		return epub.Write(os.Stdout, books, opts)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := epub.Write(f, books, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,60 @@
+// Command markdown exports the books as a vault of Markdown notes, one per
+// chapter.
+//
+//	markdown [-books-dir dir] [-book ids] [-out dir] [-name pattern] [-min-votes 20] [-no-crossrefs]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/markdown"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+func main() {
+	booksDir := flag.String("books-dir", "", "directory with books.json and books/*.json, empty for the embedded data")
+	books := flag.String("book", "", "comma-separated book ids to export, empty for the whole Bible")
+	outDir := flag.String("out", ".", "vault directory to write the notes to")
+	name := flag.String("name", markdown.DefaultFileName, "note path pattern with {id}, {name}, {order} and {chapter}")
+	minVotes := flag.Int("min-votes", 20, "minimum votes of the cross-references to include")
+	noCrossRefs := flag.Bool("no-crossrefs", false, "leave the cross-references out")
+	scheme := flag.String("versification", versification.Standard, "numbering of the books: "+strings.Join(versification.Names(), ", "))
+	flag.Parse()
+
+	if err := run(*booksDir, *books, *outDir, *name, *minVotes, !*noCrossRefs, *scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(booksDir, books, outDir, name string, minVotes int, crossRefs bool, scheme string) error {
+	repo := bible.Current()
+	if booksDir != "" {
+		var err error
+		if repo, err = bible.NewDirRepository(booksDir); err != nil {
+			return err
+		}
+	}
+
+	opts := markdown.Options{FileName: name, CrossReferences: crossRefs, MinVotes: minVotes}
+	if books != "" {
+		for _, id := range strings.Split(books, ",") {
+			opts.Books = append(opts.Books, strings.TrimSpace(id))
+		}
+	}
+	var ok bool
+	if opts.Versification, ok = versification.Lookup(scheme); !ok {
+		return fmt.Errorf("unknown versification %q", scheme)
+	}
+
+	n, err := markdown.Export(outDir, repo, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d notes to %s\n", n, outDir)
+	return nil
+}
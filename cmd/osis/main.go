@@ -0,0 +1,119 @@
+// Command osis converts the book files to and from OSIS XML.
+//
+//	osis export [-books-dir dir] [-book id] [-o file]
+//	osis import [-out dir] file
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/osis"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = export(os.Args[2:])
+	case "import":
+		err = importBooks(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: osis export [flags] | osis import [flags] file")
+	os.Exit(2)
+}
+
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	booksDir := flags.String("books-dir", "", "directory with books.json and books/*.json, empty for the embedded data")
+	books := flags.String("book", "", "comma-separated book ids to export, empty for the whole Bible")
+	out := flags.String("o", "", "output file, empty for standard output")
+	work := osis.Work{}
+	flags.StringVar(&work.Id, "work", "WV75", "OSIS work id")
+	flags.StringVar(&work.Title, "title", "Willibrordvertaling", "title of the work")
+	flags.StringVar(&work.Language, "lang", "nl", "language of the work")
+	flags.Parse(args)
+
+	repo := bible.Current()
+	if *booksDir != "" {
+		var err error
+		if repo, err = bible.NewDirRepository(*booksDir); err != nil {
+			return err
+		}
+	}
+
+	var ids []string
+	if *books != "" {
+		ids = strings.Split(*books, ",")
+	} else {
+		for _, b := range repo.GetBooks() {
+			ids = append(ids, b.Id)
+		}
+	}
+
+	var source []bible.SourceBook
+	for _, id := range ids {
+		book, err := repo.ReadSourceBook(strings.TrimSpace(id))
+		if err != nil {
+			return fmt.Errorf("book %s: %w", id, err)
+		}
+		source = append(source, book)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return osis.Export(w, work, source)
+}
+
+// importBooks writes the books of an OSIS file as books.json and
+// books/<id>.json, the layout that -books-dir and cmd/validate read.
+func importBooks(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	outDir := flags.String("out", ".", "directory to write books.json and books/ to")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	books, err := osis.Import(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+
+	if err := bible.WriteBooks(*outDir, books); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d books into %s\n", len(books), *outDir)
+	return nil
+}
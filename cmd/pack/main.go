@@ -0,0 +1,67 @@
+// Command pack compiles the JSON data files to the compact binary files that
+// builds with the packed tag embed. It is run by go generate:
+//
+//	pack books -dir internal/bible -o internal/bible/books.bin
+//	pack crossrefs -dir internal/crossref -o internal/crossref/crossrefs.bin
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "books" && os.Args[1] != "crossrefs") {
+		fmt.Fprintln(os.Stderr, "usage: pack books|crossrefs -dir dir -o file")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory with the JSON files")
+	out := flags.String("o", "", "file to write")
+	flags.Parse(os.Args[2:])
+
+	if err := run(os.Args[1], *dir, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(kind, dir, out string) error {
+	if out == "" {
+		return errors.New("-o is required")
+	}
+	write := func(w io.Writer, fsys fs.FS) error {
+		if kind == "books" {
+			return bible.WritePacked(w, fsys)
+		}
+		return crossref.WritePacked(w, fsys)
+	}
+
+	// Write next to the output and rename, so that a failed run doesn't
+	// leave a truncated file to be embedded.
+	f, err := os.CreateTemp(filepath.Dir(out), ".pack-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f, os.DirFS(dir)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), out)
+}
@@ -0,0 +1,159 @@
+// Command usfm converts between a directory of USFM files and the book
+// files.
+//
+//	usfm import [-out dir] usfm-dir
+//	usfm export [-books-dir dir] [-book id] [-out dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/usfm"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = importDir(os.Args[2:])
+	case "export":
+		err = export(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: usfm import [flags] usfm-dir | usfm export [flags]")
+	os.Exit(2)
+}
+
+// importDir converts every .usfm and .sfm file in a directory and writes
+// the books in canonical order as books.json and books/<id>.json.
+func importDir(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	outDir := flags.String("out", ".", "directory to write books.json and books/ to")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	entries, err := os.ReadDir(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var books []bible.SourceBook
+	files := map[string]string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".usfm" && ext != ".sfm") {
+			continue
+		}
+
+		path := filepath.Join(flags.Arg(0), entry.Name())
+		book, err := importFile(path)
+		if err != nil {
+			return err
+		}
+		if other, dup := files[book.Id]; dup {
+			return fmt.Errorf("%s: book %s is also in %s", path, book.Id, other)
+		}
+		files[book.Id] = path
+		books = append(books, book)
+	}
+	if len(books) == 0 {
+		return fmt.Errorf("%s: no .usfm or .sfm files found", flags.Arg(0))
+	}
+
+	// The embedded data knows the canonical order of every book id.
+	slices.SortFunc(books, func(a, b bible.SourceBook) int {
+		return bible.GetBookOrder(a.Id) - bible.GetBookOrder(b.Id)
+	})
+	if err := bible.WriteBooks(*outDir, books); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d books into %s\n", len(books), *outDir)
+	return nil
+}
+
+func importFile(path string) (bible.SourceBook, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return bible.SourceBook{}, err
+	}
+	defer f.Close()
+
+	book, err := usfm.Import(f)
+	if err != nil {
+		return bible.SourceBook{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return book, nil
+}
+
+// export writes one USFM file per book, named after its order and code as
+// Paratext does, such as 01GEN.usfm.
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	booksDir := flags.String("books-dir", "", "directory with books.json and books/*.json, empty for the embedded data")
+	books := flags.String("book", "", "comma-separated book ids to export, empty for the whole Bible")
+	outDir := flags.String("out", ".", "directory to write the USFM files to")
+	flags.Parse(args)
+
+	repo := bible.Current()
+	if *booksDir != "" {
+		var err error
+		if repo, err = bible.NewDirRepository(*booksDir); err != nil {
+			return err
+		}
+	}
+
+	var ids []string
+	if *books != "" {
+		ids = strings.Split(*books, ",")
+	} else {
+		for _, b := range repo.GetBooks() {
+			ids = append(ids, b.Id)
+		}
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		book, err := repo.ReadSourceBook(id)
+		if err != nil {
+			return fmt.Errorf("book %s: %w", id, err)
+		}
+		code, ok := usfm.Code(id)
+		if !ok {
+			return fmt.Errorf("book %s has no USFM code", id)
+		}
+
+		var sb strings.Builder
+		if err := usfm.Export(&sb, book); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%02d%s.usfm", repo.GetBookOrder(id), code)
+		if err := os.WriteFile(filepath.Join(*outDir, name), []byte(sb.String()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/validate"
+)
+
+func main() {
+	booksDir := flag.String("books-dir", "internal/bible", "directory with books.json and books/*.json")
+	crossRefDir := flag.String("crossref-dir", "internal/crossref", "directory with the cross-reference files, empty to skip")
+	format := flag.String("format", "json", "report format: json or text")
+	strict := flag.Bool("strict", false, "treat warnings as errors")
+	allowMissing := flag.String("allow-missing", "", "comma-separated files that may be missing, reported as warnings")
+	flag.Parse()
+
+	if *format != "json" && *format != "text" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+
+	var crossRefFS = os.DirFS(*crossRefDir)
+	if *crossRefDir == "" {
+		crossRefFS = nil
+	}
+	report := validate.Run(os.DirFS(*booksDir), crossRefFS)
+	if *allowMissing != "" {
+		report.Allow("missing-file", strings.Split(*allowMissing, ",")...)
+	}
+
+	if *format == "text" {
+		writeText(os.Stdout, report)
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+
+	if !report.OK(*strict) {
+		os.Exit(1)
+	}
+}
+
+func writeText(w io.Writer, report *validate.Report) {
+	for _, issue := range report.Issues {
+		location := issue.File
+		if issue.Ref != "" {
+			location += " " + issue.Ref
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s: %s\n", issue.Severity, issue.Code, location, issue.Message)
+	}
+
+	s := report.Summary
+	fmt.Fprintf(w, "checked %d books, %d verses, %d cross-reference files with %d references: %d errors, %d warnings\n",
+		s.Books, s.Verses, s.CrossRefFiles, s.CrossReferences, s.Errors, s.Warnings)
+}
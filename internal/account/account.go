@@ -0,0 +1,386 @@
+// Package account manages the users of the API. Passwords are hashed with
+// argon2id. Users sign in for a session token that expires, and create API
+// keys for scripts that don't. Both are random bearer tokens of which only
+// a SHA-256 hash is stored, so a copy of the database reveals none of them.
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+)
+
+// Token prefixes tell session tokens and API keys apart, also to secret
+// scanners.
+const (
+	sessionPrefix = "bjs_"
+	apiKeyPrefix  = "bjk_"
+)
+
+// Password length limits. The upper bound keeps hashing cheap to call.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 256
+)
+
+var (
+	ErrEmailTaken         = errors.New("email address is already registered")
+	ErrInvalidCredentials = errors.New("invalid email address or password")
+	ErrUnauthenticated    = errors.New("invalid or expired token")
+	ErrNotFound           = errors.New("not found")
+)
+
+// ValidationError reports invalid input, such as a short password.
+type ValidationError struct {
+	Field, Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// User is a registered user.
+type User struct {
+	Id        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Session is a signed in session. Token is only known when it is created.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      User      `json:"user"`
+}
+
+// APIKey is a key for scripts. Key is only known when it is created; Prefix
+// holds its first characters to recognise it in a list.
+type APIKey struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+var migrations = []string{
+	`CREATE TABLE users (
+		id       INTEGER PRIMARY KEY,
+		email    TEXT NOT NULL UNIQUE,
+		name     TEXT NOT NULL,
+		password TEXT NOT NULL,
+		created  INTEGER NOT NULL
+	);
+	CREATE TABLE sessions (
+		token   BLOB PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created INTEGER NOT NULL,
+		expires INTEGER NOT NULL
+	);
+	CREATE INDEX sessions_user ON sessions (user_id);
+	CREATE TABLE api_keys (
+		id        INTEGER PRIMARY KEY,
+		user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		name      TEXT NOT NULL,
+		prefix    TEXT NOT NULL,
+		key       BLOB NOT NULL UNIQUE,
+		created   INTEGER NOT NULL,
+		last_used INTEGER
+	);
+	CREATE INDEX api_keys_user ON api_keys (user_id);`,
+}
+
+// Store keeps the users, sessions and API keys in the database.
+type Store struct {
+	db         *sql.DB
+	sessionTTL time.Duration
+	now        func() time.Time
+	// dummyHash is checked against when an email address is unknown, so
+	// that sign in takes as long as for a known one.
+	dummyHash string
+	failures  *failedLogins
+}
+
+// New migrates the account tables of db and returns a store whose sessions
+// last sessionTTL.
+func New(db *sql.DB, sessionTTL time.Duration) (*Store, error) {
+	if err := storage.Migrate(db, "account", migrations); err != nil {
+		return nil, err
+	}
+	dummyHash, err := hashPassword(context.Background(), "")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, sessionTTL: sessionTTL, now: time.Now, dummyHash: dummyHash, failures: newFailedLogins()}, nil
+}
+
+// Register creates a user.
+func (s *Store) Register(ctx context.Context, email, name, password string) (User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return User{}, err
+	}
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 100 {
+		return User{}, &ValidationError{"name", "must be at most 100 characters"}
+	}
+	if err := validatePassword(password); err != nil {
+		return User{}, err
+	}
+
+	hash, err := hashPassword(ctx, password)
+	if err != nil {
+		return User{}, err
+	}
+	u := User{Email: email, Name: name, CreatedAt: s.now().UTC().Truncate(time.Second)}
+	res, err := s.db.ExecContext(ctx, "INSERT INTO users (email, name, password, created) VALUES (?, ?, ?, ?) ON CONFLICT (email) DO NOTHING",
+		u.Email, u.Name, hash, u.CreatedAt.Unix())
+	if err != nil {
+		return User{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return User{}, ErrEmailTaken
+	}
+	u.Id, err = res.LastInsertId()
+	return u, err
+}
+
+// Login checks the password of the user with email and starts a session.
+// After MaxFailedLogins failed attempts it returns ErrTooManyAttempts for a
+// while, known email address or not.
+func (s *Store) Login(ctx context.Context, email, password string) (Session, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return Session{}, ErrInvalidCredentials
+	}
+	if s.failures.locked(email, s.now()) {
+		return Session{}, ErrTooManyAttempts
+	}
+	var u User
+	var hash string
+	var created int64
+	err = s.db.QueryRowContext(ctx, "SELECT id, email, name, password, created FROM users WHERE email = ?", email).
+		Scan(&u.Id, &u.Email, &u.Name, &hash, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := checkPassword(ctx, s.dummyHash, password); err != nil {
+			return Session{}, err
+		}
+		s.failures.add(email, s.now())
+		return Session{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	if ok, err := checkPassword(ctx, hash, password); err != nil {
+		return Session{}, fmt.Errorf("user %d: %w", u.Id, err)
+	} else if !ok {
+		s.failures.add(email, s.now())
+		return Session{}, ErrInvalidCredentials
+	}
+	s.failures.reset(email)
+	u.CreatedAt = time.Unix(created, 0).UTC()
+
+	token := newToken(sessionPrefix)
+	now := s.now().UTC().Truncate(time.Second)
+	expires := now.Add(s.sessionTTL)
+	if _, err := s.db.ExecContext(ctx, "INSERT INTO sessions (token, user_id, created, expires) VALUES (?, ?, ?, ?)",
+		hashToken(token), u.Id, now.Unix(), expires.Unix()); err != nil {
+		return Session{}, err
+	}
+	// Expired sessions are removed as new ones start.
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires <= ?", now.Unix()); err != nil {
+		return Session{}, err
+	}
+	return Session{Token: token, ExpiresAt: expires, User: u}, nil
+}
+
+// Logout ends the session of token. Ending an unknown session is not an
+// error.
+func (s *Store) Logout(ctx context.Context, token string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE token = ?", hashToken(token))
+	return err
+}
+
+// IsAPIKey reports whether token is an API key rather than a session
+// token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// Authenticate returns the user of a session token or API key.
+func (s *Store) Authenticate(ctx context.Context, token string) (User, error) {
+	now := s.now().Unix()
+	var u User
+	var created int64
+	switch {
+	case strings.HasPrefix(token, sessionPrefix):
+		err := s.db.QueryRowContext(ctx, `SELECT u.id, u.email, u.name, u.created FROM sessions s
+			JOIN users u ON u.id = s.user_id WHERE s.token = ? AND s.expires > ?`, hashToken(token), now).
+			Scan(&u.Id, &u.Email, &u.Name, &created)
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrUnauthenticated
+		} else if err != nil {
+			return User{}, err
+		}
+
+	case strings.HasPrefix(token, apiKeyPrefix):
+		var keyId int64
+		var lastUsed sql.NullInt64
+		err := s.db.QueryRowContext(ctx, `SELECT u.id, u.email, u.name, u.created, k.id, k.last_used FROM api_keys k
+			JOIN users u ON u.id = k.user_id WHERE k.key = ?`, hashToken(token)).
+			Scan(&u.Id, &u.Email, &u.Name, &created, &keyId, &lastUsed)
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrUnauthenticated
+		} else if err != nil {
+			return User{}, err
+		}
+		// The last use is recorded to the minute, so that scripts don't
+		// write on every request.
+		if !lastUsed.Valid || now-lastUsed.Int64 >= 60 {
+			if _, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used = ? WHERE id = ?", now, keyId); err != nil {
+				return User{}, err
+			}
+		}
+
+	default:
+		return User{}, ErrUnauthenticated
+	}
+	u.CreatedAt = time.Unix(created, 0).UTC()
+	return u, nil
+}
+
+// ChangePassword replaces the password of a user after checking the current
+// one, and ends all of the user's sessions.
+func (s *Store) ChangePassword(ctx context.Context, userId int64, current, password string) error {
+	var hash string
+	err := s.db.QueryRowContext(ctx, "SELECT password FROM users WHERE id = ?", userId).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if ok, err := checkPassword(ctx, hash, current); err != nil {
+		return fmt.Errorf("user %d: %w", userId, err)
+	} else if !ok {
+		return ErrInvalidCredentials
+	}
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	hash, err = hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE users SET password = ? WHERE id = ?", hash, userId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = ?", userId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateAPIKey creates a named API key for a user. The returned key holds
+// the secret, which can't be retrieved later.
+func (s *Store) CreateAPIKey(ctx context.Context, userId int64, name string) (APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return APIKey{}, &ValidationError{"name", "must be 1 to 100 characters"}
+	}
+	key := newToken(apiKeyPrefix)
+	k := APIKey{Name: name, Prefix: key[:len(apiKeyPrefix)+6], Key: key, CreatedAt: s.now().UTC().Truncate(time.Second)}
+	res, err := s.db.ExecContext(ctx, "INSERT INTO api_keys (user_id, name, prefix, key, created) VALUES (?, ?, ?, ?, ?)",
+		userId, k.Name, k.Prefix, hashToken(key), k.CreatedAt.Unix())
+	if err != nil {
+		return APIKey{}, err
+	}
+	k.Id, err = res.LastInsertId()
+	return k, err
+}
+
+// APIKeys lists the API keys of a user, oldest first, without their secrets.
+func (s *Store) APIKeys(ctx context.Context, userId int64) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, prefix, created, last_used FROM api_keys WHERE user_id = ? ORDER BY id", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		var created int64
+		var lastUsed sql.NullInt64
+		if err := rows.Scan(&k.Id, &k.Name, &k.Prefix, &created, &lastUsed); err != nil {
+			return nil, err
+		}
+		k.CreatedAt = time.Unix(created, 0).UTC()
+		if lastUsed.Valid {
+			t := time.Unix(lastUsed.Int64, 0).UTC()
+			k.LastUsedAt = &t
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteAPIKey revokes an API key of a user.
+func (s *Store) DeleteAPIKey(ctx context.Context, userId, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 254 {
+		return "", &ValidationError{"email", "must be a valid email address"}
+	}
+	return email, nil
+}
+
+func validatePassword(password string) error {
+	if n := utf8.RuneCountInString(password); n < MinPasswordLength || len(password) > MaxPasswordLength {
+		return &ValidationError{"password", fmt.Sprintf("must be %d to %d characters", MinPasswordLength, MaxPasswordLength)}
+	}
+	return nil
+}
+
+// newToken returns prefix followed by 256 random bits.
+func newToken(prefix string) string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return prefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashToken returns the stored form of a token. The tokens are random, so
+// an unsalted fast hash is enough.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,220 @@
+package account
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "users.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	s, err := New(db, time.Hour)
+	require.NoError(t, err)
+	return s
+}
+
+func TestPassword(t *testing.T) {
+	ctx := context.Background()
+	hash, err := hashPassword(ctx, "correct horse")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$"))
+	again, err := hashPassword(ctx, "correct horse")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, again, "salted")
+
+	ok, err := checkPassword(ctx, hash, "correct horse")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = checkPassword(ctx, hash, "correct horsE")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Hashes with other parameters still verify.
+	key := argon2.IDKey([]byte("password"), []byte("somesalt"), 1, 8, 1, 16)
+	ok, err = checkPassword(ctx, "$argon2id$v=19$m=8,t=1,p=1$c29tZXNhbHQ$"+base64.RawStdEncoding.EncodeToString(key), "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = checkPassword(ctx, "$2a$10$abc", "password")
+	require.ErrorIs(t, err, errMalformedHash)
+}
+
+func TestHashingIsBounded(t *testing.T) {
+	// Take every slot, as if maxHashing sign ins were running.
+	for range maxHashing {
+		hashSlots <- struct{}{}
+	}
+	t.Cleanup(func() {
+		for range maxHashing {
+			<-hashSlots
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := hashPassword(ctx, "geheim123")
+	require.ErrorIs(t, err, context.DeadlineExceeded, "waits for a slot")
+
+	hashWaiting.Add(maxHashWaiting)
+	defer hashWaiting.Add(-maxHashWaiting)
+	_, err = checkPassword(context.Background(), "$argon2id$v=19$m=8,t=1,p=1$c29tZXNhbHQ$AAAA", "geheim123")
+	require.ErrorIs(t, err, ErrBusy, "refuses when too many wait")
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	u, err := s.Register(ctx, " Maria@Example.org ", "Maria", "geheim123")
+	require.NoError(t, err)
+	require.Equal(t, "maria@example.org", u.Email)
+	require.NotZero(t, u.Id)
+
+	_, err = s.Register(ctx, "maria@example.org", "Maria", "anders123")
+	require.ErrorIs(t, err, ErrEmailTaken)
+
+	var verr *ValidationError
+	_, err = s.Register(ctx, "geen adres", "", "geheim123")
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "email", verr.Field)
+	_, err = s.Register(ctx, "jozef@example.org", "", "kort")
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "password", verr.Field)
+
+	_, err = s.Login(ctx, "maria@example.org", "fout1234")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = s.Login(ctx, "onbekend@example.org", "geheim123")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	session, err := s.Login(ctx, "MARIA@example.org", "geheim123")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(session.Token, sessionPrefix))
+	require.Equal(t, u, session.User)
+
+	got, err := s.Authenticate(ctx, session.Token)
+	require.NoError(t, err)
+	require.Equal(t, u, got)
+
+	require.NoError(t, s.Logout(ctx, session.Token))
+	_, err = s.Authenticate(ctx, session.Token)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestSessionExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	_, err := s.Register(ctx, "maria@example.org", "", "geheim123")
+	require.NoError(t, err)
+	session, err := s.Login(ctx, "maria@example.org", "geheim123")
+	require.NoError(t, err)
+	require.Equal(t, now.Add(time.Hour), session.ExpiresAt)
+
+	now = now.Add(59 * time.Minute)
+	_, err = s.Authenticate(ctx, session.Token)
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = s.Authenticate(ctx, session.Token)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestFailedLoginLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	_, err := s.Register(ctx, "maria@example.org", "", "geheim123")
+	require.NoError(t, err)
+	for range MaxFailedLogins {
+		_, err = s.Login(ctx, "maria@example.org", "fout1234")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		_, err = s.Login(ctx, "jozef@example.org", "fout1234")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = s.Login(ctx, "Maria@example.org", "geheim123")
+	require.ErrorIs(t, err, ErrTooManyAttempts, "even with the right password")
+	_, err = s.Login(ctx, "jozef@example.org", "fout1234")
+	require.ErrorIs(t, err, ErrTooManyAttempts, "unknown addresses are limited alike")
+
+	now = now.Add(FailedLoginWindow)
+	_, err = s.Login(ctx, "maria@example.org", "geheim123")
+	require.NoError(t, err)
+
+	// A successful sign in clears the failures.
+	for range MaxFailedLogins - 1 {
+		_, err = s.Login(ctx, "maria@example.org", "fout1234")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = s.Login(ctx, "maria@example.org", "geheim123")
+	require.NoError(t, err)
+	_, err = s.Login(ctx, "maria@example.org", "fout1234")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	u, err := s.Register(ctx, "maria@example.org", "", "geheim123")
+	require.NoError(t, err)
+	session, err := s.Login(ctx, "maria@example.org", "geheim123")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, s.ChangePassword(ctx, u.Id, "fout1234", "nieuw1234"), ErrInvalidCredentials)
+	require.NoError(t, s.ChangePassword(ctx, u.Id, "geheim123", "nieuw1234"))
+
+	_, err = s.Authenticate(ctx, session.Token)
+	require.ErrorIs(t, err, ErrUnauthenticated, "sessions end with a new password")
+	_, err = s.Login(ctx, "maria@example.org", "nieuw1234")
+	require.NoError(t, err)
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	maria, err := s.Register(ctx, "maria@example.org", "", "geheim123")
+	require.NoError(t, err)
+	jozef, err := s.Register(ctx, "jozef@example.org", "", "geheim123")
+	require.NoError(t, err)
+
+	key, err := s.CreateAPIKey(ctx, maria.Id, "backup")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key.Key, key.Prefix))
+	require.True(t, strings.HasPrefix(key.Key, apiKeyPrefix))
+
+	u, err := s.Authenticate(ctx, key.Key)
+	require.NoError(t, err)
+	require.Equal(t, maria.Id, u.Id)
+
+	keys, err := s.APIKeys(ctx, maria.Id)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Empty(t, keys[0].Key)
+	require.NotNil(t, keys[0].LastUsedAt)
+
+	require.ErrorIs(t, s.DeleteAPIKey(ctx, jozef.Id, key.Id), ErrNotFound)
+	require.NoError(t, s.DeleteAPIKey(ctx, maria.Id, key.Id))
+	_, err = s.Authenticate(ctx, key.Key)
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = s.Authenticate(ctx, "bjk_onbekend")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+	_, err = s.Authenticate(ctx, "iets anders")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
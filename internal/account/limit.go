@@ -0,0 +1,74 @@
+package account
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Signing in to an email address is refused after MaxFailedLogins failed
+// attempts within FailedLoginWindow, until the oldest of them is that long
+// ago, so that passwords can't be guessed at the speed of the server.
+const (
+	MaxFailedLogins   = 5
+	FailedLoginWindow = 15 * time.Minute
+)
+
+// ErrTooManyAttempts is returned by Login while an email address is locked
+// after failed attempts.
+var ErrTooManyAttempts = errors.New("too many failed sign ins, try again later")
+
+// failedLogins remembers the recent failed sign ins per email address.
+type failedLogins struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+}
+
+func newFailedLogins() *failedLogins {
+	return &failedLogins{attempts: make(map[string][]time.Time)}
+}
+
+// locked reports whether email has MaxFailedLogins failures within the
+// window before now.
+func (f *failedLogins) locked(email string, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.recent(email, now)) >= MaxFailedLogins
+}
+
+// add records a failed sign in to email at now.
+func (f *failedLogins) add(email string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts[email] = append(f.recent(email, now), now)
+
+	// Attempts on many addresses would otherwise pile up; the hashing limit
+	// bounds how many arrive within a window.
+	for e := range f.attempts {
+		if len(f.recent(e, now)) == 0 {
+			delete(f.attempts, e)
+		}
+	}
+}
+
+// reset forgets the failures of email after a successful sign in.
+func (f *failedLogins) reset(email string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.attempts, email)
+}
+
+// recent drops the failures of email older than the window and returns the
+// others. f.mu must be held.
+func (f *failedLogins) recent(email string, now time.Time) []time.Time {
+	attempts := f.attempts[email]
+	for len(attempts) > 0 && !attempts[0].After(now.Add(-FailedLoginWindow)) {
+		attempts = attempts[1:]
+	}
+	if len(attempts) == 0 {
+		delete(f.attempts, email)
+		return nil
+	}
+	f.attempts[email] = attempts
+	return attempts
+}
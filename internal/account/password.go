@@ -0,0 +1,106 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters, the second recommended option of RFC 9106: 64 MiB,
+// 3 passes and 4 lanes.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+// Every hash takes argonMemory, so at most maxHashing are computed at once
+// and at most maxHashWaiting more wait for their turn; beyond that signing
+// in and registering fail with ErrBusy rather than exhaust the memory of
+// the server.
+const (
+	maxHashing     = 4
+	maxHashWaiting = 64
+)
+
+var (
+	hashSlots   = make(chan struct{}, maxHashing)
+	hashWaiting atomic.Int32
+)
+
+// ErrBusy is returned when too many passwords are being hashed.
+var ErrBusy = errors.New("too many sign ins, try again later")
+
+var errMalformedHash = errors.New("malformed password hash")
+
+// acquireHash waits for a hash slot and returns the function that frees it.
+func acquireHash(ctx context.Context) (func(), error) {
+	if hashWaiting.Add(1) > maxHashWaiting {
+		hashWaiting.Add(-1)
+		return nil, ErrBusy
+	}
+	defer hashWaiting.Add(-1)
+	select {
+	case hashSlots <- struct{}{}:
+		return func() { <-hashSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// hashPassword returns the argon2id hash of password in the PHC string
+// format, e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	release, err := acquireHash(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	salt := make([]byte, argonSaltLen)
+	rand.Read(salt)
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// checkPassword reports whether password matches hash. Hashes made with
+// other parameters still verify, so the parameters can be raised later.
+func checkPassword(ctx context.Context, hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, errMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, errMalformedHash
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, errMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errMalformedHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, errMalformedHash
+	}
+	release, err := acquireHash(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
@@ -0,0 +1,624 @@
+// Package annotation keeps the bookmarks, highlights and notes of users.
+// An annotation covers a range of verses of a book, given by canonical
+// verse ids in the numbering of the default translation, so that it shows
+// in every translation: "johannes.3.16" is a verse and "johannes.3" a whole
+// chapter. Apps that edit annotations offline keep up with Sync.
+package annotation
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+)
+
+// Kinds of annotations.
+const (
+	Bookmark  = "bookmark"
+	Highlight = "highlight"
+	Note      = "note"
+)
+
+// Colors are the highlight colors the apps offer.
+var Colors = []string{"yellow", "green", "blue", "pink", "purple", "orange"}
+
+// Limits on the contents of an annotation.
+const (
+	MaxNoteLength = 10000
+	MaxTags       = 20
+	MaxTagLength  = 50
+)
+
+var (
+	ErrNotFound = errors.New("annotation not found")
+	ErrExists   = errors.New("annotation already exists")
+)
+
+// ValidationError reports an invalid annotation.
+type ValidationError struct {
+	Field, Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// Annotation is a bookmark, highlight or note of a range of verses. Id is
+// chosen by the client or generated; it is unique per user.
+type Annotation struct {
+	Id        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Start     string    `json:"start"`
+	End       string    `json:"end"`
+	Color     string    `json:"color,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Range is the verses an annotation covers. A zero StartVerse starts at
+// the beginning of StartChapter, a zero EndVerse runs to the end of
+// EndChapter.
+type Range struct {
+	Book         string
+	StartChapter int
+	StartVerse   int
+	EndChapter   int
+	EndVerse     int
+}
+
+var verseId = regexp.MustCompile(`^([0-9a-z]+)\.([1-9][0-9]{0,2})(?:\.([1-9][0-9]{0,2}))?$`)
+
+// ParseRange reads the start and end verse ids of an annotation. An empty
+// end is the start.
+func ParseRange(start, end string) (Range, error) {
+	if end == "" {
+		end = start
+	}
+	s := verseId.FindStringSubmatch(start)
+	if s == nil {
+		return Range{}, &ValidationError{"start", fmt.Sprintf("%q is not a verse id such as johannes.3.16 or johannes.3", start)}
+	}
+	e := verseId.FindStringSubmatch(end)
+	if e == nil {
+		return Range{}, &ValidationError{"end", fmt.Sprintf("%q is not a verse id such as johannes.3.16 or johannes.3", end)}
+	}
+	if s[1] != e[1] {
+		return Range{}, &ValidationError{"end", "must be in the book of start"}
+	}
+	r := Range{Book: s[1]}
+	r.StartChapter, _ = strconv.Atoi(s[2])
+	r.StartVerse, _ = strconv.Atoi(s[3])
+	r.EndChapter, _ = strconv.Atoi(e[2])
+	r.EndVerse, _ = strconv.Atoi(e[3])
+	if r.last() < r.first() {
+		return Range{}, &ValidationError{"end", "must not come before start"}
+	}
+	return r, nil
+}
+
+// Start and End return the verse ids of the range.
+func (r Range) Start() string { return formatId(r.Book, r.StartChapter, r.StartVerse) }
+func (r Range) End() string   { return formatId(r.Book, r.EndChapter, r.EndVerse) }
+
+// Contains reports whether the range covers a verse of its book.
+func (r Range) Contains(chapter, verse int) bool {
+	n := position(chapter, verse)
+	return n >= r.first() && n <= r.last()
+}
+
+// first and last are the positions of the first and last verse within the
+// book, which the database compares to find the annotations of a chapter.
+func (r Range) first() int { return position(r.StartChapter, r.StartVerse) }
+
+func (r Range) last() int {
+	if r.EndVerse == 0 {
+		return position(r.EndChapter, 999)
+	}
+	return position(r.EndChapter, r.EndVerse)
+}
+
+func position(chapter, verse int) int {
+	return chapter*1000 + verse
+}
+
+func formatId(book string, chapter, verse int) string {
+	if verse == 0 {
+		return book + "." + strconv.Itoa(chapter)
+	}
+	return book + "." + strconv.Itoa(chapter) + "." + strconv.Itoa(verse)
+}
+
+var migrations = []string{
+	`CREATE TABLE annotations (
+		seq        INTEGER PRIMARY KEY,
+		user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		id         TEXT NOT NULL,
+		kind       TEXT NOT NULL,
+		book       TEXT NOT NULL,
+		book_order INTEGER NOT NULL,
+		first      INTEGER NOT NULL,
+		last       INTEGER NOT NULL,
+		start_id   TEXT NOT NULL,
+		end_id     TEXT NOT NULL,
+		color      TEXT NOT NULL,
+		note       TEXT NOT NULL,
+		tags       TEXT NOT NULL,
+		created    INTEGER NOT NULL,
+		updated    INTEGER NOT NULL,
+		UNIQUE (user_id, id)
+	);
+	CREATE INDEX annotations_verses ON annotations (user_id, book, first, last);
+	CREATE TABLE annotation_tags (
+		seq INTEGER NOT NULL REFERENCES annotations (seq) ON DELETE CASCADE,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (seq, tag)
+	);
+	CREATE INDEX annotation_tags_tag ON annotation_tags (tag);
+	CREATE VIRTUAL TABLE annotations_search USING fts5 (
+		note, tags,
+		content = 'annotations',
+		content_rowid = 'seq',
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+	CREATE TRIGGER annotations_insert AFTER INSERT ON annotations BEGIN
+		INSERT INTO annotations_search (rowid, note, tags) VALUES (new.seq, new.note, new.tags);
+	END;
+	CREATE TRIGGER annotations_delete AFTER DELETE ON annotations BEGIN
+		INSERT INTO annotations_search (annotations_search, rowid, note, tags) VALUES ('delete', old.seq, old.note, old.tags);
+	END;
+	CREATE TRIGGER annotations_update AFTER UPDATE ON annotations BEGIN
+		INSERT INTO annotations_search (annotations_search, rowid, note, tags) VALUES ('delete', old.seq, old.note, old.tags);
+		INSERT INTO annotations_search (rowid, note, tags) VALUES (new.seq, new.note, new.tags);
+	END;`,
+	// Sync: every change of an annotation takes the next version of its
+	// user, deletes leave a tombstone, and clocks holds when each field was
+	// last written, as JSON. Fields without a clock were written at
+	// updated.
+	`ALTER TABLE annotations ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE annotations ADD COLUMN deleted INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE annotations ADD COLUMN clocks TEXT NOT NULL DEFAULT '{}';
+	UPDATE annotations SET version = (SELECT count(*) FROM annotations b WHERE b.user_id = annotations.user_id AND b.seq <= annotations.seq);
+	CREATE INDEX annotations_version ON annotations (user_id, version);
+	CREATE TABLE annotation_versions (
+		user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+		version INTEGER NOT NULL
+	);
+	INSERT INTO annotation_versions (user_id, version) SELECT user_id, max(version) FROM annotations GROUP BY user_id;`,
+}
+
+// Canon knows the books and verses annotations refer to.
+type Canon interface {
+	// Order returns the position of a book in the canon, which lists sort
+	// by.
+	Order(book string) int
+	// Check returns a *ValidationError unless the book and the first and
+	// last verse of a range exist.
+	Check(r Range) error
+}
+
+// Store keeps the annotations in the database of the accounts, whose users
+// they belong to.
+type Store struct {
+	db    *sql.DB
+	canon Canon
+	now   func() time.Time
+	// syncLimit is the most annotations a sync returns at once.
+	syncLimit int
+}
+
+// New migrates the annotation tables of db, in which the account tables
+// must exist.
+func New(db *sql.DB, canon Canon) (*Store, error) {
+	if err := storage.Migrate(db, "annotation", migrations); err != nil {
+		return nil, err
+	}
+	return &Store{db: db, canon: canon, now: time.Now, syncLimit: MaxSyncChanges}, nil
+}
+
+// Create adds an annotation for a user, with a generated id if it has none.
+func (s *Store) Create(ctx context.Context, userId int64, a Annotation) (Annotation, error) {
+	if a.Id == "" {
+		a.Id = newId()
+	}
+	r, err := s.validate(&a)
+	if err != nil {
+		return Annotation{}, err
+	}
+	a.CreatedAt = s.now().UTC().Truncate(time.Millisecond)
+	a.UpdatedAt = a.CreatedAt
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Annotation{}, err
+	}
+	defer tx.Rollback()
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM annotations WHERE user_id = ? AND id = ?)", userId, a.Id).Scan(&exists); err != nil {
+		return Annotation{}, err
+	}
+	if exists {
+		// Also when deleted: other devices may still hold the id.
+		return Annotation{}, ErrExists
+	}
+	if err := s.insert(tx, userId, a, r, clocks{}); err != nil {
+		return Annotation{}, err
+	}
+	return a, tx.Commit()
+}
+
+// Get returns an annotation of a user.
+func (s *Store) Get(ctx context.Context, userId int64, id string) (Annotation, error) {
+	list, err := s.query(ctx, "", "WHERE a.user_id = ? AND a.id = ? AND a.deleted = 0", userId, id)
+	if err != nil {
+		return Annotation{}, err
+	}
+	if len(list) == 0 {
+		return Annotation{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+// Update replaces the kind, range, color, note and tags of an annotation of
+// a user.
+func (s *Store) Update(ctx context.Context, userId int64, a Annotation) (Annotation, error) {
+	r, err := s.validate(&a)
+	if err != nil {
+		return Annotation{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Annotation{}, err
+	}
+	defer tx.Rollback()
+	var seq, created int64
+	err = tx.QueryRow("SELECT seq, created FROM annotations WHERE user_id = ? AND id = ? AND deleted = 0", userId, a.Id).Scan(&seq, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Annotation{}, ErrNotFound
+	} else if err != nil {
+		return Annotation{}, err
+	}
+	a.CreatedAt = time.UnixMilli(created).UTC()
+	a.UpdatedAt = s.now().UTC().Truncate(time.Millisecond)
+	if err := s.update(tx, userId, seq, a, r, clocks{}); err != nil {
+		return Annotation{}, err
+	}
+	return a, tx.Commit()
+}
+
+// Delete removes an annotation of a user, leaving a tombstone for the
+// devices that sync.
+func (s *Store) Delete(ctx context.Context, userId int64, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var seq int64
+	err = tx.QueryRow("SELECT seq FROM annotations WHERE user_id = ? AND id = ? AND deleted = 0", userId, id).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if err := s.tombstone(tx, userId, seq, s.now().UnixMilli()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insert adds an annotation with the next version of its user.
+func (s *Store) insert(tx *sql.Tx, userId int64, a Annotation, r Range, c clocks) error {
+	version, err := nextVersion(tx, userId)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`INSERT INTO annotations (user_id, id, kind, book, book_order, first, last, start_id, end_id, color, note, tags,
+		created, updated, version, clocks) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userId, a.Id, a.Kind, r.Book, s.canon.Order(r.Book), r.first(), r.last(), a.Start, a.End, a.Color, a.Note, strings.Join(a.Tags, " "),
+		a.CreatedAt.UnixMilli(), a.UpdatedAt.UnixMilli(), version, c.encode())
+	if err != nil {
+		return err
+	}
+	seq, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return insertTags(tx, seq, a.Tags)
+}
+
+// update replaces the annotation seq with a, which takes the next version
+// of its user.
+func (s *Store) update(tx *sql.Tx, userId, seq int64, a Annotation, r Range, c clocks) error {
+	version, err := nextVersion(tx, userId)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE annotations SET kind = ?, book = ?, book_order = ?, first = ?, last = ?, start_id = ?, end_id = ?,
+		color = ?, note = ?, tags = ?, updated = ?, version = ?, clocks = ? WHERE seq = ?`,
+		a.Kind, r.Book, s.canon.Order(r.Book), r.first(), r.last(), a.Start, a.End, a.Color, a.Note, strings.Join(a.Tags, " "),
+		a.UpdatedAt.UnixMilli(), version, c.encode(), seq); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM annotation_tags WHERE seq = ?", seq); err != nil {
+		return err
+	}
+	return insertTags(tx, seq, a.Tags)
+}
+
+// tombstone marks the annotation seq deleted at a time in milliseconds.
+// Its note and tags are dropped; the row remains so that devices learn of
+// the delete and the id is not reused.
+func (s *Store) tombstone(tx *sql.Tx, userId, seq, at int64) error {
+	version, err := nextVersion(tx, userId)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE annotations SET note = '', tags = '', deleted = ?, version = ? WHERE seq = ?", max(at, 1), version, seq); err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM annotation_tags WHERE seq = ?", seq)
+	return err
+}
+
+// nextVersion increments the version of the annotations of a user.
+func nextVersion(tx *sql.Tx, userId int64) (int64, error) {
+	var version int64
+	err := tx.QueryRow(`INSERT INTO annotation_versions (user_id, version) VALUES (?, 1)
+		ON CONFLICT (user_id) DO UPDATE SET version = version + 1 RETURNING version`, userId).Scan(&version)
+	return version, err
+}
+
+// Filter selects annotations. Empty fields select all.
+type Filter struct {
+	Kind string
+	Book string
+	// Chapter selects the annotations that cover a verse of a chapter of
+	// Book.
+	Chapter int
+	Tag     string
+	Color   string
+	// Query searches the notes and tags; the results come best match
+	// first instead of in canonical order.
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// List returns the annotations of a user that match f, in canonical order.
+func (s *Store) List(ctx context.Context, userId int64, f Filter) ([]Annotation, error) {
+	where := []string{"a.user_id = ?", "a.deleted = 0"}
+	args := []any{userId}
+	if f.Kind != "" {
+		where = append(where, "a.kind = ?")
+		args = append(args, f.Kind)
+	}
+	if f.Book != "" {
+		where = append(where, "a.book = ?")
+		args = append(args, f.Book)
+		if f.Chapter > 0 {
+			where = append(where, "a.first <= ? AND a.last >= ?")
+			args = append(args, position(f.Chapter, 999), position(f.Chapter, 0))
+		}
+	}
+	if f.Tag != "" {
+		where = append(where, "a.seq IN (SELECT seq FROM annotation_tags WHERE tag = ?)")
+		args = append(args, normalizeTag(f.Tag))
+	}
+	if f.Color != "" {
+		where = append(where, "a.color = ?")
+		args = append(args, f.Color)
+	}
+
+	join, order := "", "a.book_order, a.first, a.last, a.id"
+	if f.Query != "" {
+		q := searchQuery(f.Query)
+		if q == "" {
+			return []Annotation{}, nil
+		}
+		join = "JOIN annotations_search s ON s.rowid = a.seq "
+		where = append(where, "annotations_search MATCH ?")
+		args = append(args, q)
+		order = "s.rank, " + order
+	}
+
+	clause := "WHERE " + strings.Join(where, " AND ") + " ORDER BY " + order
+	if f.Limit > 0 {
+		clause += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+	return s.query(ctx, join, clause, args...)
+}
+
+// Tag is a tag with the number of annotations it is on.
+type Tag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Tags lists the tags of a user, most used first.
+func (s *Store) Tags(ctx context.Context, userId int64) ([]Tag, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT t.tag, count(*) FROM annotation_tags t JOIN annotations a ON a.seq = t.seq
+		WHERE a.user_id = ? GROUP BY t.tag ORDER BY count(*) DESC, t.tag`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := []Tag{}
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// query returns the annotations a of a join and a where clause.
+func (s *Store) query(ctx context.Context, join, clause string, args ...any) ([]Annotation, error) {
+	rows, err := queryRows(ctx, s.db, join, clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Annotation, len(rows))
+	for i, r := range rows {
+		list[i] = r.Annotation
+	}
+	return list, nil
+}
+
+// stored is an annotation as stored, with the version of its last change
+// and when it was deleted, if it was.
+type stored struct {
+	Annotation
+	version int64
+	deleted int64
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryRows returns the annotations a of a join and a where clause.
+func queryRows(ctx context.Context, q querier, join, clause string, args ...any) ([]stored, error) {
+	rows, err := q.QueryContext(ctx, `SELECT a.id, a.kind, a.start_id, a.end_id, a.color, a.note, a.tags, a.created, a.updated, a.version, a.deleted
+		FROM annotations a `+join+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []stored{}
+	for rows.Next() {
+		var a stored
+		var tags string
+		var created, updated int64
+		if err := rows.Scan(&a.Id, &a.Kind, &a.Start, &a.End, &a.Color, &a.Note, &tags, &created, &updated, &a.version, &a.deleted); err != nil {
+			return nil, err
+		}
+		a.Tags = splitTags(tags)
+		a.CreatedAt = time.UnixMilli(created).UTC()
+		a.UpdatedAt = time.UnixMilli(updated).UTC()
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// splitTags reads the tags as stored.
+func splitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Fields(tags)
+}
+
+func insertTags(tx *sql.Tx, seq int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO annotation_tags (seq, tag) VALUES (?, ?)", seq, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// validate normalizes a and checks that its verses exist.
+func (s *Store) validate(a *Annotation) (Range, error) {
+	r, err := normalize(a)
+	if err != nil {
+		return Range{}, err
+	}
+	return r, s.canon.Check(r)
+}
+
+// normalize checks a and brings its range and tags in their stored form.
+func normalize(a *Annotation) (Range, error) {
+	if !idPattern.MatchString(a.Id) {
+		return Range{}, &ValidationError{"id", "must be 1 to 64 letters, digits, - or _"}
+	}
+	r, err := ParseRange(a.Start, a.End)
+	if err != nil {
+		return Range{}, err
+	}
+	a.Start, a.End = r.Start(), r.End()
+
+	if a.Color != "" && !slices.Contains(Colors, a.Color) {
+		return Range{}, &ValidationError{"color", "must be one of " + strings.Join(Colors, ", ")}
+	}
+	a.Note = strings.TrimSpace(a.Note)
+	if utf8.RuneCountInString(a.Note) > MaxNoteLength {
+		return Range{}, &ValidationError{"note", fmt.Sprintf("must be at most %d characters", MaxNoteLength)}
+	}
+	switch a.Kind {
+	case Bookmark:
+	case Highlight:
+		if a.Color == "" {
+			return Range{}, &ValidationError{"color", "is required for a highlight"}
+		}
+	case Note:
+		if a.Note == "" {
+			return Range{}, &ValidationError{"note", "is required for a note"}
+		}
+	default:
+		return Range{}, &ValidationError{"kind", "must be bookmark, highlight or note"}
+	}
+
+	tags := []string{}
+	for _, tag := range a.Tags {
+		tag = normalizeTag(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+			return Range{}, &ValidationError{"tags", fmt.Sprintf("must be 1 to %d characters without spaces", MaxTagLength)}
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxTags {
+		return Range{}, &ValidationError{"tags", fmt.Sprintf("at most %d", MaxTags)}
+	}
+	slices.Sort(tags)
+	a.Tags = tags
+	return r, nil
+}
+
+// normalizeTag lowercases a tag and joins its words with dashes, so that
+// tags are stored space separated.
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// searchQuery turns the words of a search into an FTS5 query that matches
+// notes with all of them, the last word as a prefix as one types. Quoting
+// every word keeps operators in the input from being interpreted.
+func searchQuery(s string) string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '\'' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f)
+	}) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"`)
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
+
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
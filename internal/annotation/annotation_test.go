@@ -0,0 +1,193 @@
+package annotation
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/account"
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+)
+
+var testBooks = []string{"genesis", "psalmen", "johannes", "romeinen"}
+
+// testCanon has the books of testBooks with any number of verses.
+type testCanon struct{}
+
+func (testCanon) Order(book string) int { return slices.Index(testBooks, book) }
+
+func (testCanon) Check(r Range) error {
+	if !slices.Contains(testBooks, r.Book) {
+		return &ValidationError{"start", "unknown book " + r.Book}
+	}
+	return nil
+}
+
+// newTestStore returns a store with two users, 1 and 2.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "users.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	accounts, err := account.New(db, time.Hour)
+	require.NoError(t, err)
+	for _, email := range []string{"maria@example.org", "jozef@example.org"} {
+		_, err := accounts.Register(context.Background(), email, "", "geheim123")
+		require.NoError(t, err)
+	}
+	s, err := New(db, testCanon{})
+	require.NoError(t, err)
+	return s
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := ParseRange("johannes.3.16", "")
+	require.NoError(t, err)
+	require.Equal(t, Range{Book: "johannes", StartChapter: 3, StartVerse: 16, EndChapter: 3, EndVerse: 16}, r)
+	require.True(t, r.Contains(3, 16))
+	require.False(t, r.Contains(3, 17))
+
+	r, err = ParseRange("johannes.3", "johannes.4.2")
+	require.NoError(t, err)
+	require.Equal(t, "johannes.3", r.Start())
+	require.True(t, r.Contains(3, 1))
+	require.True(t, r.Contains(4, 2))
+	require.False(t, r.Contains(4, 3))
+
+	r, err = ParseRange("psalmen.119", "")
+	require.NoError(t, err)
+	require.True(t, r.Contains(119, 176))
+
+	for _, tt := range [][2]string{
+		{"johannes 3:16", ""},
+		{"johannes.0.1", ""},
+		{"johannes.3.16", "romeinen.1.1"},
+		{"johannes.3.16", "johannes.3.15"},
+		{"johannes.4", "johannes.3.20"},
+	} {
+		_, err := ParseRange(tt[0], tt[1])
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr, tt)
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	a, err := s.Create(ctx, 1, Annotation{Kind: Highlight, Start: "johannes.3.16", Color: "yellow", Tags: []string{"Liefde", "geloof", "liefde"}})
+	require.NoError(t, err)
+	require.Len(t, a.Id, 32)
+	require.Equal(t, "johannes.3.16", a.End)
+	require.Equal(t, []string{"geloof", "liefde"}, a.Tags)
+	require.Equal(t, a.CreatedAt, a.UpdatedAt)
+
+	got, err := s.Get(ctx, 1, a.Id)
+	require.NoError(t, err)
+	require.Equal(t, a, got)
+	_, err = s.Get(ctx, 2, a.Id)
+	require.ErrorIs(t, err, ErrNotFound, "annotations are private")
+
+	_, err = s.Create(ctx, 1, Annotation{Id: a.Id, Kind: Bookmark, Start: "johannes.3"})
+	require.ErrorIs(t, err, ErrExists)
+	_, err = s.Create(ctx, 2, Annotation{Id: a.Id, Kind: Bookmark, Start: "johannes.3"})
+	require.NoError(t, err, "ids are unique per user")
+
+	a.Kind, a.Note, a.Tags = Note, "God heeft de wereld lief", []string{"liefde"}
+	a.Color = ""
+	updated, err := s.Update(ctx, 1, a)
+	require.NoError(t, err)
+	require.Equal(t, a.CreatedAt, updated.CreatedAt)
+	got, err = s.Get(ctx, 1, a.Id)
+	require.NoError(t, err)
+	require.Equal(t, Note, got.Kind)
+	require.Equal(t, []string{"liefde"}, got.Tags)
+
+	_, err = s.Update(ctx, 2, Annotation{Id: "onbekend", Kind: Bookmark, Start: "johannes.3"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Delete(ctx, 1, a.Id))
+	require.ErrorIs(t, s.Delete(ctx, 1, a.Id), ErrNotFound)
+	_, err = s.Get(ctx, 2, a.Id)
+	require.NoError(t, err)
+}
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	for field, a := range map[string]Annotation{
+		"kind":  {Kind: "streep", Start: "johannes.3.16"},
+		"color": {Kind: Highlight, Start: "johannes.3.16"},
+		"note":  {Kind: Note, Start: "johannes.3.16", Note: "  "},
+		"id":    {Id: "met spatie", Kind: Bookmark, Start: "johannes.3"},
+		"start": {Kind: Bookmark, Start: "Johannes.3"},
+		"end":   {Kind: Bookmark, Start: "johannes.3", End: "johannes.2"},
+		"tags":  {Kind: Bookmark, Start: "johannes.3", Tags: []string{""}},
+	} {
+		_, err := s.Create(ctx, 1, a)
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr, field)
+		require.Equal(t, field, verr.Field)
+	}
+
+	_, err := s.Create(ctx, 1, Annotation{Kind: Highlight, Start: "johannes.3.16", Color: "rood"})
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "color", verr.Field)
+
+	_, err = s.Create(ctx, 1, Annotation{Kind: Bookmark, Start: "openbaring.1"})
+	require.ErrorAs(t, err, &verr, "the canon checks the verses")
+	require.Equal(t, "start", verr.Field)
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	create := func(user int64, a Annotation) {
+		t.Helper()
+		_, err := s.Create(ctx, user, a)
+		require.NoError(t, err)
+	}
+	create(1, Annotation{Id: "rom", Kind: Note, Start: "romeinen.8.28", Note: "Alles werkt mee ten goede", Tags: []string{"troost"}})
+	create(1, Annotation{Id: "joh-3", Kind: Bookmark, Start: "johannes.3"})
+	create(1, Annotation{Id: "joh-3-16", Kind: Highlight, Start: "johannes.3.16", End: "johannes.3.17", Color: "yellow", Tags: []string{"liefde"}})
+	create(1, Annotation{Id: "joh-2-4", Kind: Highlight, Start: "johannes.2.25", End: "johannes.4.1", Color: "blue"})
+	create(1, Annotation{Id: "ps", Kind: Note, Start: "psalmen.23.1", Note: "De Héér is mijn herder, liefde en troost", Tags: []string{"troost", "liefde"}})
+	create(2, Annotation{Id: "ander", Kind: Note, Start: "johannes.3.16", Note: "liefde"})
+
+	ids := func(f Filter) []string {
+		t.Helper()
+		list, err := s.List(ctx, 1, f)
+		require.NoError(t, err)
+		var ids []string
+		for _, a := range list {
+			ids = append(ids, a.Id)
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{"ps", "joh-2-4", "joh-3", "joh-3-16", "rom"}, ids(Filter{}), "canonical order")
+	require.Equal(t, []string{"joh-2-4", "joh-3", "joh-3-16"}, ids(Filter{Book: "johannes", Chapter: 3}))
+	require.Equal(t, []string{"joh-2-4"}, ids(Filter{Book: "johannes", Chapter: 4}))
+	require.Equal(t, []string{"joh-2-4", "joh-3-16"}, ids(Filter{Kind: Highlight}))
+	require.Equal(t, []string{"joh-2-4"}, ids(Filter{Color: "blue"}))
+	require.Equal(t, []string{"ps", "joh-3-16"}, ids(Filter{Tag: "Liefde"}))
+	require.Equal(t, []string{"ps", "joh-2-4"}, ids(Filter{Limit: 2}))
+	require.Equal(t, []string{"joh-3", "joh-3-16"}, ids(Filter{Limit: 2, Offset: 2}))
+
+	require.Equal(t, []string{"ps"}, ids(Filter{Query: "heer herder"}), "diacritics are ignored")
+	require.Equal(t, []string{"ps"}, ids(Filter{Query: "herd"}), "the last word is a prefix")
+	require.ElementsMatch(t, []string{"rom", "ps"}, ids(Filter{Query: "troost"}), "tags are searched")
+	require.Empty(t, ids(Filter{Query: `"NOT" OR *`}))
+	require.Empty(t, ids(Filter{Query: "!!"}))
+
+	tags, err := s.Tags(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []Tag{{"liefde", 2}, {"troost", 2}}, tags)
+}
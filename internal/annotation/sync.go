@@ -0,0 +1,281 @@
+package annotation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxSyncChanges is the most changes a device pushes, and the most
+// annotations it pulls, in one sync.
+const MaxSyncChanges = 1000
+
+// Change is an edit of an annotation made on a device, possibly offline.
+// Fields left nil are unchanged. Start and End change together; a nil End
+// is Start. A change of an annotation the server does not have yet creates
+// it.
+type Change struct {
+	Id         string    `json:"id"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+	Deleted    bool      `json:"deleted,omitempty"`
+	Kind       *string   `json:"kind,omitempty"`
+	Start      *string   `json:"start,omitempty"`
+	End        *string   `json:"end,omitempty"`
+	Color      *string   `json:"color,omitempty"`
+	Note       *string   `json:"note,omitempty"`
+	Tags       []string  `json:"tags"`
+}
+
+// SyncRequest pushes the changes of a device and asks for the annotations
+// changed since Cursor, the cursor of its last sync or 0 at first.
+type SyncRequest struct {
+	Device  string   `json:"device"`
+	Cursor  int64    `json:"cursor"`
+	Changes []Change `json:"changes"`
+}
+
+// Entry is the state of an annotation after its latest change, which had
+// Version. Deleted annotations have no Annotation.
+type Entry struct {
+	Id         string      `json:"id"`
+	Version    int64       `json:"version"`
+	Deleted    bool        `json:"deleted,omitempty"`
+	Annotation *Annotation `json:"annotation,omitempty"`
+}
+
+// Rejection is a change that was not applied.
+type Rejection struct {
+	Id    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// SyncResponse has the annotations changed since the cursor of the request,
+// in the order of their versions, including those of the pushed changes.
+// Cursor goes in the next request; while More is set, there are more
+// annotations to pull.
+type SyncResponse struct {
+	Cursor   int64       `json:"cursor"`
+	Changes  []Entry     `json:"changes"`
+	Rejected []Rejection `json:"rejected"`
+	More     bool        `json:"more"`
+}
+
+// Sync applies the changes a device pushes and returns the annotations of
+// the user changed since its cursor.
+//
+// Every change of an annotation takes the next version of its user, so
+// that a cursor is the position in the user's change log. Conflicting
+// changes from devices resolve per field, the last write winning: by
+// ModifiedAt, then by device, so that the outcome does not depend on the
+// order in which the devices sync. Deletes leave a tombstone and win over
+// every edit, earlier or later. An annotation a change touches always
+// takes a new version, so that the device pulls the state that won.
+func (s *Store) Sync(ctx context.Context, userId int64, req SyncRequest) (SyncResponse, error) {
+	if !idPattern.MatchString(req.Device) {
+		return SyncResponse{}, &ValidationError{"device", "must be 1 to 64 letters, digits, - or _"}
+	}
+	if req.Cursor < 0 {
+		return SyncResponse{}, &ValidationError{"cursor", "must not be negative"}
+	}
+	if len(req.Changes) > MaxSyncChanges {
+		return SyncResponse{}, &ValidationError{"changes", fmt.Sprintf("at most %d per sync", MaxSyncChanges)}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return SyncResponse{}, err
+	}
+	defer tx.Rollback()
+
+	resp := SyncResponse{Changes: []Entry{}, Rejected: []Rejection{}}
+	now := s.now().UnixMilli()
+	for _, c := range req.Changes {
+		err := s.apply(tx, userId, req.Device, now, c)
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			resp.Rejected = append(resp.Rejected, Rejection{c.Id, err.Error()})
+		} else if err != nil {
+			return SyncResponse{}, err
+		}
+	}
+
+	// A device without a cursor has nothing to delete.
+	rows, err := queryRows(ctx, tx, "", "WHERE a.user_id = ? AND a.version > ? AND (? > 0 OR a.deleted = 0) ORDER BY a.version LIMIT ?",
+		userId, req.Cursor, req.Cursor, s.syncLimit+1)
+	if err != nil {
+		return SyncResponse{}, err
+	}
+	if len(rows) > s.syncLimit {
+		rows, resp.More = rows[:s.syncLimit], true
+	}
+	for _, r := range rows {
+		e := Entry{Id: r.Id, Version: r.version, Deleted: r.deleted != 0}
+		if !e.Deleted {
+			e.Annotation = &r.Annotation
+		}
+		resp.Changes = append(resp.Changes, e)
+	}
+
+	if resp.More {
+		resp.Cursor = rows[len(rows)-1].version
+	} else {
+		err := tx.QueryRow("SELECT version FROM annotation_versions WHERE user_id = ?", userId).Scan(&resp.Cursor)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return SyncResponse{}, err
+		}
+	}
+	return resp, tx.Commit()
+}
+
+// apply merges a change of a device into the annotation it is of. now is
+// the time of the server in milliseconds.
+func (s *Store) apply(tx *sql.Tx, userId int64, device string, now int64, c Change) error {
+	if !idPattern.MatchString(c.Id) {
+		return &ValidationError{"id", "must be 1 to 64 letters, digits, - or _"}
+	}
+	if c.ModifiedAt.UnixMilli() <= 0 {
+		return &ValidationError{"modifiedAt", "is required"}
+	}
+	if c.End != nil && c.Start == nil {
+		return &ValidationError{"start", "is required with end"}
+	}
+	// A device whose clock runs ahead would otherwise win every conflict
+	// until the server caught up.
+	at := clock{min(c.ModifiedAt.UnixMilli(), now), device}
+
+	var a Annotation
+	var seq, created, updated, deleted int64
+	var tags, encoded string
+	err := tx.QueryRow(`SELECT seq, kind, start_id, end_id, color, note, tags, created, updated, deleted, clocks
+		FROM annotations WHERE user_id = ? AND id = ?`, userId, c.Id).
+		Scan(&seq, &a.Kind, &a.Start, &a.End, &a.Color, &a.Note, &tags, &created, &updated, &deleted, &encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		if c.Deleted {
+			// Created and deleted before the device synced.
+			return nil
+		}
+		a = Annotation{Id: c.Id, CreatedAt: time.UnixMilli(at.At).UTC(), UpdatedAt: time.UnixMilli(at.At).UTC()}
+		cs := clocks{}
+		merge(&a, cs, c, at)
+		r, err := s.validate(&a)
+		if err != nil {
+			return err
+		}
+		for _, f := range fields {
+			cs[f] = at
+		}
+		return s.insert(tx, userId, a, r, cs)
+	} else if err != nil {
+		return err
+	}
+
+	if deleted != 0 {
+		return touch(tx, userId, seq)
+	}
+	if c.Deleted {
+		return s.tombstone(tx, userId, seq, at.At)
+	}
+
+	a.Id = c.Id
+	a.Tags = splitTags(tags)
+	a.CreatedAt = time.UnixMilli(created).UTC()
+	cs, err := decodeClocks(encoded, updated)
+	if err != nil {
+		return err
+	}
+	merge(&a, cs, c, at)
+	r, err := s.validate(&a)
+	if err != nil {
+		// The device pulls the annotation to undo its change.
+		if terr := touch(tx, userId, seq); terr != nil {
+			return terr
+		}
+		return err
+	}
+	for _, cl := range cs {
+		updated = max(updated, cl.At)
+	}
+	a.UpdatedAt = time.UnixMilli(updated).UTC()
+	return s.update(tx, userId, seq, a, r, cs)
+}
+
+// touch gives the annotation seq the next version of its user without
+// changing it.
+func touch(tx *sql.Tx, userId, seq int64) error {
+	version, err := nextVersion(tx, userId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE annotations SET version = ? WHERE seq = ?", version, seq)
+	return err
+}
+
+// The fields of an annotation that resolve conflicts separately.
+const (
+	fieldKind  = "kind"
+	fieldRange = "range"
+	fieldColor = "color"
+	fieldNote  = "note"
+	fieldTags  = "tags"
+)
+
+var fields = []string{fieldKind, fieldRange, fieldColor, fieldNote, fieldTags}
+
+// merge sets the fields of a that c changes and that were last written
+// before at.
+func merge(a *Annotation, cs clocks, c Change, at clock) {
+	take := func(field string, changed bool, set func()) {
+		if changed && !at.before(cs[field]) {
+			set()
+			cs[field] = at
+		}
+	}
+	take(fieldKind, c.Kind != nil, func() { a.Kind = *c.Kind })
+	take(fieldRange, c.Start != nil, func() {
+		a.Start, a.End = *c.Start, ""
+		if c.End != nil {
+			a.End = *c.End
+		}
+	})
+	take(fieldColor, c.Color != nil, func() { a.Color = *c.Color })
+	take(fieldNote, c.Note != nil, func() { a.Note = *c.Note })
+	take(fieldTags, c.Tags != nil, func() { a.Tags = c.Tags })
+}
+
+// clock orders the writes of a field: by time in milliseconds, then by
+// device, so that writes at the same time resolve the same way whichever
+// syncs first.
+type clock struct {
+	At     int64  `json:"at"`
+	Device string `json:"device,omitempty"`
+}
+
+func (c clock) before(o clock) bool {
+	return c.At < o.At || c.At == o.At && c.Device < o.Device
+}
+
+// clocks holds the clock of the last write of each field.
+type clocks map[string]clock
+
+func (c clocks) encode() string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+// decodeClocks reads the clocks of an annotation. Fields without one were
+// last written by Create or Update, at updated.
+func decodeClocks(s string, updated int64) (clocks, error) {
+	c := clocks{}
+	if err := json.Unmarshal([]byte(s), &c); err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if _, ok := c[f]; !ok {
+			c[f] = clock{At: updated}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,303 @@
+package annotation
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/account"
+	"github.com/pschuurmans/bijbel-api/internal/storage"
+)
+
+var t0 = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// device simulates an app that edits annotations offline and syncs them.
+type device struct {
+	name   string
+	cursor int64
+	outbox []Change
+	local  map[string]Annotation
+}
+
+func newDevice(name string) *device {
+	return &device{name: name, local: make(map[string]Annotation)}
+}
+
+// edit records a change made at t0 plus offset.
+func (d *device) edit(offset time.Duration, c Change) {
+	c.ModifiedAt = t0.Add(offset)
+	d.outbox = append(d.outbox, c)
+}
+
+// sync pushes the outbox and pulls until the device is up to date.
+func (d *device) sync(t *testing.T, s *Store) []Rejection {
+	t.Helper()
+	var rejected []Rejection
+	for {
+		resp, err := s.Sync(context.Background(), 1, SyncRequest{Device: d.name, Cursor: d.cursor, Changes: d.outbox})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, resp.Cursor, d.cursor)
+		d.outbox, d.cursor = nil, resp.Cursor
+		rejected = append(rejected, resp.Rejected...)
+		for _, e := range resp.Changes {
+			if e.Deleted {
+				delete(d.local, e.Id)
+			} else {
+				d.local[e.Id] = *e.Annotation
+			}
+		}
+		if !resp.More {
+			return rejected
+		}
+	}
+}
+
+// requireConverged checks that every device holds what the server has.
+func requireConverged(t *testing.T, s *Store, devices ...*device) map[string]Annotation {
+	t.Helper()
+	list, err := s.List(context.Background(), 1, Filter{})
+	require.NoError(t, err)
+	server := make(map[string]Annotation)
+	for _, a := range list {
+		server[a.Id] = a
+	}
+	for _, d := range devices {
+		require.Equal(t, server, d.local, d.name)
+	}
+	return server
+}
+
+func ptr(s string) *string { return &s }
+
+func TestSyncConcurrentEdits(t *testing.T) {
+	// run lets a phone and a tablet edit the same note offline and sync in
+	// the given order.
+	run := func(t *testing.T, order ...string) map[string]Annotation {
+		s := newTestStore(t)
+		s.now = func() time.Time { return t0.Add(time.Hour) }
+		devices := map[string]*device{"phone": newDevice("phone"), "tablet": newDevice("tablet")}
+		phone, tablet := devices["phone"], devices["tablet"]
+
+		phone.edit(0, Change{Id: "ps23", Kind: ptr(Note), Start: ptr("psalmen.23.1"), Note: ptr("herder"), Tags: []string{"troost"}})
+		phone.sync(t, s)
+		tablet.sync(t, s)
+		requireConverged(t, s, phone, tablet)
+
+		phone.edit(2*time.Minute, Change{Id: "ps23", Note: ptr("De Heer is mijn herder")})
+		tablet.edit(1*time.Minute, Change{Id: "ps23", Note: ptr("Mijn herder"), Start: ptr("psalmen.23.1"), End: ptr("psalmen.23.4")})
+		tablet.edit(3*time.Minute, Change{Id: "ps23", Tags: []string{"troost", "vertrouwen"}})
+		phone.edit(4*time.Minute, Change{Id: "gen", Kind: ptr(Bookmark), Start: ptr("genesis.1")})
+		for _, name := range order {
+			devices[name].sync(t, s)
+		}
+		phone.sync(t, s)
+		tablet.sync(t, s)
+		return requireConverged(t, s, phone, tablet)
+	}
+
+	first := run(t, "phone", "tablet")
+	require.Equal(t, "De Heer is mijn herder", first["ps23"].Note, "the later note wins")
+	require.Equal(t, "psalmen.23.4", first["ps23"].End, "fields merge")
+	require.Equal(t, []string{"troost", "vertrouwen"}, first["ps23"].Tags)
+	require.Equal(t, t0.Add(3*time.Minute), first["ps23"].UpdatedAt)
+	require.Equal(t, t0, first["ps23"].CreatedAt)
+	require.Contains(t, first, "gen")
+
+	require.Equal(t, first, run(t, "tablet", "phone"), "the order of syncing does not matter")
+}
+
+func TestSyncSameTime(t *testing.T) {
+	run := func(t *testing.T, order ...string) string {
+		s := newTestStore(t)
+		s.now = func() time.Time { return t0 }
+		devices := map[string]*device{"a": newDevice("a"), "b": newDevice("b")}
+		_, err := s.Create(context.Background(), 1, Annotation{Id: "joh", Kind: Highlight, Start: "johannes.3.16", Color: "yellow"})
+		require.NoError(t, err)
+
+		devices["a"].edit(time.Hour, Change{Id: "joh", Color: ptr("green")})
+		devices["b"].edit(time.Hour, Change{Id: "joh", Color: ptr("blue")})
+		s.now = func() time.Time { return t0.Add(2 * time.Hour) }
+		for _, name := range order {
+			devices[name].sync(t, s)
+		}
+		devices[order[0]].sync(t, s)
+		return requireConverged(t, s, devices["a"], devices["b"])["joh"].Color
+	}
+	require.Equal(t, "blue", run(t, "a", "b"))
+	require.Equal(t, "blue", run(t, "b", "a"))
+}
+
+func TestSyncDeletes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	s.now = func() time.Time { return t0.Add(time.Hour) }
+	phone, tablet := newDevice("phone"), newDevice("tablet")
+
+	phone.edit(0, Change{Id: "rom", Kind: ptr(Note), Start: ptr("romeinen.8.28"), Note: ptr("Alles werkt mee"), Tags: []string{"troost"}})
+	phone.edit(0, Change{Id: "kort", Kind: ptr(Bookmark), Start: ptr("romeinen.8")})
+	phone.edit(time.Second, Change{Id: "kort", Deleted: true})
+	phone.sync(t, s)
+	tablet.sync(t, s)
+	require.Equal(t, []string{"rom"}, keys(tablet.local), "deleted before syncing")
+
+	phone.edit(time.Minute, Change{Id: "rom", Deleted: true})
+	tablet.edit(2*time.Minute, Change{Id: "rom", Note: ptr("Alles werkt mee ten goede")})
+	phone.sync(t, s)
+	tablet.sync(t, s)
+	phone.sync(t, s)
+	requireConverged(t, s, phone, tablet)
+	require.Empty(t, tablet.local, "a delete wins over a later edit")
+
+	_, err := s.Get(ctx, 1, "rom")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Delete(ctx, 1, "rom"), ErrNotFound)
+	_, err = s.Create(ctx, 1, Annotation{Id: "rom", Kind: Bookmark, Start: "romeinen.8"})
+	require.ErrorIs(t, err, ErrExists, "the id of a tombstone is not reused")
+	tags, err := s.Tags(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+	list, err := s.List(ctx, 1, Filter{Query: "alles"})
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	// A new device starts without the tombstones.
+	resp, err := s.Sync(ctx, 1, SyncRequest{Device: "laptop"})
+	require.NoError(t, err)
+	require.Empty(t, resp.Changes)
+	require.Equal(t, tablet.cursor, resp.Cursor)
+
+	// Deletes through the API reach the devices.
+	_, err = s.Create(ctx, 1, Annotation{Id: "ps", Kind: Bookmark, Start: "psalmen.23"})
+	require.NoError(t, err)
+	tablet.sync(t, s)
+	require.Contains(t, tablet.local, "ps")
+	require.NoError(t, s.Delete(ctx, 1, "ps"))
+	tablet.sync(t, s)
+	require.Empty(t, tablet.local)
+}
+
+func TestSyncCursor(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	s.syncLimit = 2
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		_, err := s.Create(ctx, 1, Annotation{Id: id, Kind: Bookmark, Start: "johannes.3"})
+		require.NoError(t, err)
+	}
+	_, err := s.Create(ctx, 2, Annotation{Id: "ander", Kind: Bookmark, Start: "johannes.3"})
+	require.NoError(t, err)
+
+	resp, err := s.Sync(ctx, 1, SyncRequest{Device: "phone"})
+	require.NoError(t, err)
+	require.True(t, resp.More)
+	require.Len(t, resp.Changes, 2)
+	require.Equal(t, "a", resp.Changes[0].Id)
+	require.Equal(t, int64(1), resp.Changes[0].Version)
+	require.Equal(t, "b", resp.Changes[1].Id)
+	require.Equal(t, int64(2), resp.Changes[1].Version)
+	require.Equal(t, int64(2), resp.Cursor)
+
+	phone := newDevice("phone")
+	phone.sync(t, s)
+	require.Equal(t, []string{"a", "b", "c", "d", "e"}, keys(phone.local), "versions are per user")
+	require.Equal(t, int64(5), phone.cursor)
+
+	_, err = s.Update(ctx, 1, Annotation{Id: "c", Kind: Bookmark, Start: "johannes.4"})
+	require.NoError(t, err)
+	resp, err = s.Sync(ctx, 1, SyncRequest{Device: "phone", Cursor: phone.cursor})
+	require.NoError(t, err)
+	require.Len(t, resp.Changes, 1)
+	require.Equal(t, int64(6), resp.Changes[0].Version)
+	require.Equal(t, "johannes.4", resp.Changes[0].Annotation.Start)
+}
+
+func TestSyncValidation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	now := t0
+	s.now = func() time.Time { return now }
+
+	for field, req := range map[string]SyncRequest{
+		"device":  {},
+		"cursor":  {Device: "phone", Cursor: -1},
+		"changes": {Device: "phone", Changes: make([]Change, MaxSyncChanges+1)},
+	} {
+		_, err := s.Sync(ctx, 1, req)
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr, field)
+		require.Equal(t, field, verr.Field)
+	}
+
+	phone := newDevice("phone")
+	phone.edit(0, Change{Id: "joh", Kind: ptr(Highlight), Start: ptr("johannes.3.16"), Color: ptr("yellow")})
+	phone.edit(0, Change{Id: "streep", Kind: ptr("streep"), Start: ptr("johannes.3.16")})
+	phone.edit(0, Change{Id: "joh", End: ptr("johannes.3.17")})
+	phone.edit(time.Second, Change{Id: "joh", Color: ptr("")})
+	phone.outbox = append(phone.outbox, Change{Id: "joh", Note: ptr("zonder tijd")})
+	now = now.Add(time.Minute)
+	rejected := phone.sync(t, s)
+	require.Len(t, rejected, 4)
+	require.Equal(t, Rejection{"streep", "kind: must be bookmark, highlight or note"}, rejected[0])
+	require.Equal(t, Rejection{"joh", "start: is required with end"}, rejected[1])
+	require.Equal(t, Rejection{"joh", "color: is required for a highlight"}, rejected[2])
+	require.Equal(t, Rejection{"joh", "modifiedAt: is required"}, rejected[3])
+	server := requireConverged(t, s, phone)
+	require.Equal(t, "yellow", server["joh"].Color)
+
+	// A clock that runs ahead counts as the time of the server, so that
+	// the device does not win every later conflict.
+	tablet := newDevice("tablet")
+	tablet.edit(time.Hour, Change{Id: "joh", Color: ptr("blue")})
+	tablet.sync(t, s)
+	now = now.Add(time.Second)
+	phone.edit(time.Minute+time.Second, Change{Id: "joh", Color: ptr("green")})
+	phone.sync(t, s)
+	tablet.sync(t, s)
+	require.Equal(t, "green", requireConverged(t, s, phone, tablet)["joh"].Color)
+}
+
+func TestMigrateVersions(t *testing.T) {
+	ctx := context.Background()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "users.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	accounts, err := account.New(db, time.Hour)
+	require.NoError(t, err)
+	_, err = accounts.Register(ctx, "maria@example.org", "", "geheim123")
+	require.NoError(t, err)
+
+	// Annotations from before sync.
+	require.NoError(t, storage.Migrate(db, "annotation", migrations[:1]))
+	for _, id := range []string{"a", "b"} {
+		_, err := db.Exec(`INSERT INTO annotations (user_id, id, kind, book, book_order, first, last, start_id, end_id, color, note, tags, created, updated)
+			VALUES (1, ?, 'bookmark', 'johannes', 0, 3000, 3999, 'johannes.3', 'johannes.3', '', '', '', 1, 1)`, id)
+		require.NoError(t, err)
+	}
+
+	s, err := New(db, testCanon{})
+	require.NoError(t, err)
+	resp, err := s.Sync(ctx, 1, SyncRequest{Device: "phone"})
+	require.NoError(t, err)
+	require.Len(t, resp.Changes, 2)
+	require.Equal(t, int64(2), resp.Cursor)
+	_, err = s.Create(ctx, 1, Annotation{Id: "c", Kind: Bookmark, Start: "johannes.4"})
+	require.NoError(t, err)
+	resp, err = s.Sync(ctx, 1, SyncRequest{Device: "phone", Cursor: resp.Cursor})
+	require.NoError(t, err)
+	require.Len(t, resp.Changes, 1)
+	require.Equal(t, "c", resp.Changes[0].Id)
+}
+
+func keys(m map[string]Annotation) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
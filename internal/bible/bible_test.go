@@ -37,6 +37,13 @@ func TestCleanVerseText(t *testing.T) {
 			input:    "Hij zei: &#39;Kom hier&#39; en &#39;ga daar&#39;.",
 			expected: "Hij zei: 'Kom hier' en 'ga daar'.",
 		},
+		{
+			// Removing the line break instead used to give
+			// "Die u roept is getrouw:Hij zal zijn woord gestand doen."
+			name:     "Line breaks",
+			input:    "Die u roept is getrouw:<br />\r\nHij zal zijn woord gestand doen.",
+			expected: "Die u roept is getrouw: Hij zal zijn woord gestand doen.",
+		},
 		{
 			name:     "HTML tags",
 			input:    "Dit is <b>vet</b> en <i>cursief</i> tekst.",
@@ -357,3 +364,41 @@ func TestGetChapterVerseTitle(t *testing.T) {
 		}
 	}
 }
+
+func TestGetChaptersIsCached(t *testing.T) {
+	before := CacheStats()
+
+	first, err := GetChapters("ruth")
+	if err != nil {
+		t.Fatalf("error: %v", err.Error())
+	}
+	first.Verses[0].Text = "changed"
+
+	second, err := GetChapters("ruth")
+	if err != nil {
+		t.Fatalf("error: %v", err.Error())
+	}
+
+	if second.Verses[0].Text == "changed" {
+		t.Fatalf("modifying a returned book must not change the cache")
+	}
+	if after := CacheStats(); after.Hits <= before.Hits {
+		t.Fatalf("expected a cache hit, got %+v", after)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	if err := Verify(); err != nil {
+		t.Fatalf("embedded data failed verification: %v", err)
+	}
+}
+
+func TestDataVersion(t *testing.T) {
+	version := DataVersion()
+	if len(version) != 12 {
+		t.Fatalf("expected 12 character data version, got %q", version)
+	}
+	if DataVersion() != version {
+		t.Fatalf("data version must be stable")
+	}
+}
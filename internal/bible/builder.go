@@ -0,0 +1,122 @@
+package bible
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewSourceVerse returns an empty verse of book with the id and defaults
+// the book files use.
+func NewSourceVerse(book string, chapter, verse int) SourceVerse {
+	return SourceVerse{
+		Chapter:         chapter,
+		Verse:           verse,
+		Id:              fmt.Sprintf("%s.%d.%d", book, chapter, verse),
+		Paragraph:       "n",
+		CrossReferences: []any{},
+	}
+}
+
+// SetText sets the textJson tree of v and the matching HTML text.
+func (v *SourceVerse) SetText(root *Node) {
+	v.TextJson = root
+	v.Text = root.InnerHTML()
+}
+
+// UpdateCounts sets Chapters and VerseCount from the verses of b.
+func (b *SourceBook) UpdateCounts() {
+	chapters := map[int]bool{}
+	for _, v := range b.Verses {
+		chapters[v.Chapter] = true
+	}
+	b.Chapters = len(chapters)
+	b.VerseCount = len(b.Verses)
+}
+
+// TextBuilder assembles the textJson tree of a verse from a stream of text
+// and markup, as the importers of other formats read it. The zero value is
+// an empty p element.
+type TextBuilder struct {
+	root Node
+	// open holds the path of child indexes from root to the innermost open
+	// element.
+	open []int
+}
+
+// Text appends a text node.
+func (b *TextBuilder) Text(s string) {
+	b.Append(Node{Text: s})
+}
+
+// Append appends n to the innermost open element.
+func (b *TextBuilder) Append(n Node) {
+	parent := b.current()
+	parent.Children = append(parent.Children, n)
+}
+
+// Break ends the current line, unless no text has been written yet.
+func (b *TextBuilder) Break() {
+	if !b.Empty() {
+		b.Append(Node{Tag: "br"})
+	}
+}
+
+// Open starts an element; following nodes become its children.
+func (b *TextBuilder) Open(tag string) {
+	parent := b.current()
+	parent.Children = append(parent.Children, Node{Tag: tag})
+	b.open = append(b.open, len(parent.Children)-1)
+}
+
+// Close ends the innermost open element if it has the given tag. Ends that
+// don't match, as when an element spans verses, are ignored.
+func (b *TextBuilder) Close(tag string) {
+	if len(b.open) > 0 && b.current().Tag == tag {
+		b.open = b.open[:len(b.open)-1]
+	}
+}
+
+// Empty reports whether no text has been written.
+func (b *TextBuilder) Empty() bool {
+	return strings.TrimSpace(b.tree().PlainText()) == ""
+}
+
+// Finish returns the tree with the surrounding whitespace removed and
+// resets the builder.
+func (b *TextBuilder) Finish() *Node {
+	root := b.tree()
+	root.Children = trimNodes(root.Children)
+	*b = TextBuilder{}
+	return &root
+}
+
+func (b *TextBuilder) tree() Node {
+	root := b.root
+	root.Tag = "p"
+	return root
+}
+
+func (b *TextBuilder) current() *Node {
+	n := &b.root
+	for _, i := range b.open {
+		n = &n.Children[i]
+	}
+	return n
+}
+
+// trimNodes removes the whitespace around the content of nodes.
+func trimNodes(nodes []Node) []Node {
+	for len(nodes) > 0 && nodes[0].IsText() && strings.TrimSpace(nodes[0].Text) == "" {
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && nodes[len(nodes)-1].IsText() && strings.TrimSpace(nodes[len(nodes)-1].Text) == "" {
+		nodes = nodes[:len(nodes)-1]
+	}
+	if len(nodes) > 0 && nodes[0].IsText() {
+		nodes[0].Text = strings.TrimLeft(nodes[0].Text, " \t\r\n")
+	}
+	if n := len(nodes); n > 0 && nodes[n-1].IsText() {
+		nodes[n-1].Text = strings.TrimRight(nodes[n-1].Text, " \t\r\n")
+	}
+	return nodes
+}
@@ -0,0 +1,42 @@
+package bible
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextBuilder(t *testing.T) {
+	var b TextBuilder
+	b.Break()
+	b.Text("\n  Zalig de man,")
+	b.Break()
+	b.Open("em")
+	b.Text("die niet wandelt")
+	b.Open("blockquote")
+	b.Close("em") // not the innermost element, ignored
+	b.Close("blockquote")
+	b.Close("em")
+	b.Append(Node{Tag: "abbr", Ref: "Ps. 2"})
+	b.Text(" \n")
+
+	root := b.Finish()
+	require.Equal(t, &Node{Tag: "p", Children: []Node{
+		{Text: "Zalig de man,"},
+		{Tag: "br"},
+		{Tag: "em", Children: []Node{{Text: "die niet wandelt"}, {Tag: "blockquote"}}},
+		{Tag: "abbr", Ref: "Ps. 2"},
+	}}, root)
+	require.True(t, b.Empty(), "Finish must reset the builder")
+
+	v := NewSourceVerse("psalmen", 1, 1)
+	v.SetText(root)
+	require.Equal(t, "psalmen.1.1", v.Id)
+	require.Equal(t, "Zalig de man,<br />\r\n<em>die niet wandelt<blockquote></blockquote></em><abbr ref=\"Ps. 2\"></abbr>", v.Text)
+	require.Equal(t, root.PlainText(), v.CleanText())
+
+	book := SourceBook{Verses: []SourceVerse{v, NewSourceVerse("psalmen", 1, 2), NewSourceVerse("psalmen", 2, 1)}}
+	book.UpdateCounts()
+	require.Equal(t, 2, book.Chapters)
+	require.Equal(t, 3, book.VerseCount)
+}
@@ -0,0 +1,41 @@
+package bible
+
+import "slices"
+
+// Books with a single chapter number it 0 in the book files, while
+// references, cross-references and the export formats call it chapter 1.
+
+// ChapterNumber returns the number a chapter of the book files is cited by.
+func ChapterNumber(chapter int) int {
+	return max(chapter, 1)
+}
+
+// FindChapter returns the number the book files give a cited chapter. has
+// reports whether the files have a chapter; chapter 1 is also looked up as
+// chapter 0.
+func FindChapter(chapter int, has func(chapter int) bool) (int, bool) {
+	if has(chapter) {
+		return chapter, true
+	}
+	if chapter == 1 && has(0) {
+		return 0, true
+	}
+	return chapter, false
+}
+
+// ReadChapter returns the verses of a cited chapter sorted by number.
+func ReadChapter(repo Repository, book string, chapter int) ([]Verse, error) {
+	var verses []Verse
+	var err error
+	FindChapter(chapter, func(n int) bool {
+		var c Chapter
+		c, err = repo.GetChapter(book, n)
+		verses = c.Verses
+		return err != nil || len(verses) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(verses, func(a, b Verse) int { return a.Verse - b.Verse })
+	return verses, nil
+}
@@ -0,0 +1,44 @@
+package bible
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadChapter(t *testing.T) {
+	repo, err := NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"judas","name":"Judas","order":1}]`)},
+		"books/judas.json": {Data: []byte(`{"id":"judas","name":"Judas","chapters":1,"verseCount":2,"verses":[
+			{"chapter":0,"verse":2,"id":"judas.0.2","text":"Barmhartigheid, vrede en liefde"},
+			{"chapter":0,"verse":1,"id":"judas.0.1","text":"Judas, dienaar van Jezus Christus"}]}`)},
+	})
+	if err != nil {
+		t.Fatalf("NewFSRepository() error = %v", err)
+	}
+
+	for _, chapter := range []int{0, 1} {
+		verses, err := ReadChapter(repo, "judas", chapter)
+		if err != nil {
+			t.Fatalf("ReadChapter(%d) error = %v", chapter, err)
+		}
+		if len(verses) != 2 || verses[0].Verse != 1 || verses[1].Verse != 2 {
+			t.Errorf("ReadChapter(%d) verses = %+v", chapter, verses)
+		}
+	}
+	if verses, err := ReadChapter(repo, "judas", 2); err != nil || len(verses) != 0 {
+		t.Errorf("ReadChapter(2) = %+v, %v", verses, err)
+	}
+	if _, err := ReadChapter(repo, "onbekend", 1); err == nil {
+		t.Error("ReadChapter() accepted an unknown book")
+	}
+
+	if got := ChapterNumber(0); got != 1 {
+		t.Errorf("ChapterNumber(0) = %d, want 1", got)
+	}
+	if got, ok := FindChapter(1, func(c int) bool { return c == 0 }); got != 0 || !ok {
+		t.Errorf("FindChapter(1) = %d, %v, want 0, true", got, ok)
+	}
+	if got, ok := FindChapter(2, func(c int) bool { return c == 0 }); got != 2 || ok {
+		t.Errorf("FindChapter(2) = %d, %v, want 2, false", got, ok)
+	}
+}
@@ -0,0 +1,93 @@
+package bible
+
+import "slices"
+
+// BookCode holds the names interchange formats give a book of this project.
+type BookCode struct {
+	Id   string
+	OSIS string
+	// USFM writes Ester and Daniel with the Greek additions, in the Vulgate
+	// numbering, under the EST and DAN codes.
+	USFM string
+}
+
+var bookCodes = []BookCode{
+	{Id: "genesis", OSIS: "Gen", USFM: "GEN"},
+	{Id: "exodus", OSIS: "Exod", USFM: "EXO"},
+	{Id: "leviticus", OSIS: "Lev", USFM: "LEV"},
+	{Id: "numeri", OSIS: "Num", USFM: "NUM"},
+	{Id: "deuteronomium", OSIS: "Deut", USFM: "DEU"},
+	{Id: "jozua", OSIS: "Josh", USFM: "JOS"},
+	{Id: "rechters", OSIS: "Judg", USFM: "JDG"},
+	{Id: "ruth", OSIS: "Ruth", USFM: "RUT"},
+	{Id: "1samuel", OSIS: "1Sam", USFM: "1SA"},
+	{Id: "2samuel", OSIS: "2Sam", USFM: "2SA"},
+	{Id: "1koningen", OSIS: "1Kgs", USFM: "1KI"},
+	{Id: "2koningen", OSIS: "2Kgs", USFM: "2KI"},
+	{Id: "1kronieken", OSIS: "1Chr", USFM: "1CH"},
+	{Id: "2kronieken", OSIS: "2Chr", USFM: "2CH"},
+	{Id: "ezra", OSIS: "Ezra", USFM: "EZR"},
+	{Id: "nehemia", OSIS: "Neh", USFM: "NEH"},
+	{Id: "tobit", OSIS: "Tob", USFM: "TOB"},
+	{Id: "judit", OSIS: "Jdt", USFM: "JDT"},
+	{Id: "ester", OSIS: "Esth", USFM: "EST"},
+	{Id: "1makkabeeen", OSIS: "1Macc", USFM: "1MA"},
+	{Id: "2makkabeeen", OSIS: "2Macc", USFM: "2MA"},
+	{Id: "job", OSIS: "Job", USFM: "JOB"},
+	{Id: "psalmen", OSIS: "Ps", USFM: "PSA"},
+	{Id: "spreuken", OSIS: "Prov", USFM: "PRO"},
+	{Id: "prediker", OSIS: "Eccl", USFM: "ECC"},
+	{Id: "hooglied", OSIS: "Song", USFM: "SNG"},
+	{Id: "wijsheid", OSIS: "Wis", USFM: "WIS"},
+	{Id: "jezussirach", OSIS: "Sir", USFM: "SIR"},
+	{Id: "jesaja", OSIS: "Isa", USFM: "ISA"},
+	{Id: "jeremia", OSIS: "Jer", USFM: "JER"},
+	{Id: "klaagliederen", OSIS: "Lam", USFM: "LAM"},
+	{Id: "baruch", OSIS: "Bar", USFM: "BAR"},
+	{Id: "ezechiel", OSIS: "Ezek", USFM: "EZK"},
+	{Id: "daniel", OSIS: "Dan", USFM: "DAN"},
+	{Id: "hosea", OSIS: "Hos", USFM: "HOS"},
+	{Id: "joel", OSIS: "Joel", USFM: "JOL"},
+	{Id: "amos", OSIS: "Amos", USFM: "AMO"},
+	{Id: "obadja", OSIS: "Obad", USFM: "OBA"},
+	{Id: "jonas", OSIS: "Jonah", USFM: "JON"},
+	{Id: "micha", OSIS: "Mic", USFM: "MIC"},
+	{Id: "nahum", OSIS: "Nah", USFM: "NAM"},
+	{Id: "habakuk", OSIS: "Hab", USFM: "HAB"},
+	{Id: "sefanja", OSIS: "Zeph", USFM: "ZEP"},
+	{Id: "haggai", OSIS: "Hag", USFM: "HAG"},
+	{Id: "zacharias", OSIS: "Zech", USFM: "ZEC"},
+	{Id: "maleachi", OSIS: "Mal", USFM: "MAL"},
+	{Id: "matteus", OSIS: "Matt", USFM: "MAT"},
+	{Id: "marcus", OSIS: "Mark", USFM: "MRK"},
+	{Id: "lucas", OSIS: "Luke", USFM: "LUK"},
+	{Id: "johannes", OSIS: "John", USFM: "JHN"},
+	{Id: "handelingen", OSIS: "Acts", USFM: "ACT"},
+	{Id: "romeinen", OSIS: "Rom", USFM: "ROM"},
+	{Id: "1korintiers", OSIS: "1Cor", USFM: "1CO"},
+	{Id: "2korintiers", OSIS: "2Cor", USFM: "2CO"},
+	{Id: "galaten", OSIS: "Gal", USFM: "GAL"},
+	{Id: "efesiers", OSIS: "Eph", USFM: "EPH"},
+	{Id: "filippenzen", OSIS: "Phil", USFM: "PHP"},
+	{Id: "kolossenzen", OSIS: "Col", USFM: "COL"},
+	{Id: "1tessalonicenzen", OSIS: "1Thess", USFM: "1TH"},
+	{Id: "2tessalonicenzen", OSIS: "2Thess", USFM: "2TH"},
+	{Id: "1timoteus", OSIS: "1Tim", USFM: "1TI"},
+	{Id: "2timoteus", OSIS: "2Tim", USFM: "2TI"},
+	{Id: "titus", OSIS: "Titus", USFM: "TIT"},
+	{Id: "filemon", OSIS: "Phlm", USFM: "PHM"},
+	{Id: "hebreeen", OSIS: "Heb", USFM: "HEB"},
+	{Id: "jacobus", OSIS: "Jas", USFM: "JAS"},
+	{Id: "1petrus", OSIS: "1Pet", USFM: "1PE"},
+	{Id: "2petrus", OSIS: "2Pet", USFM: "2PE"},
+	{Id: "1johannes", OSIS: "1John", USFM: "1JN"},
+	{Id: "2johannes", OSIS: "2John", USFM: "2JN"},
+	{Id: "3johannes", OSIS: "3John", USFM: "3JN"},
+	{Id: "judas", OSIS: "Jude", USFM: "JUD"},
+	{Id: "apokalyps", OSIS: "Rev", USFM: "REV"},
+}
+
+// BookCodes returns the codes of all books in canonical order.
+func BookCodes() []BookCode {
+	return slices.Clone(bookCodes)
+}
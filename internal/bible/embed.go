@@ -0,0 +1,13 @@
+//go:build !packed
+
+package bible
+
+import "embed"
+
+//go:embed books.json books/*
+var embeddedFS embed.FS
+
+// NewEmbeddedRepository returns the repository compiled into the binary.
+func NewEmbeddedRepository() (Repository, error) {
+	return newFSRepository(embeddedFS)
+}
@@ -0,0 +1,15 @@
+//go:build packed
+
+package bible
+
+import _ "embed"
+
+// embeddedData is books.bin, compiled from the JSON files by go generate.
+//
+//go:embed books.bin
+var embeddedData string
+
+// NewEmbeddedRepository returns the repository compiled into the binary.
+func NewEmbeddedRepository() (Repository, error) {
+	return newPackedRepository(embeddedData)
+}
@@ -0,0 +1,467 @@
+package bible
+
+//go:generate go run ../../cmd/pack books -dir . -o books.bin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/binpack"
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+)
+
+// packedMagic starts a packed books file; the digit is the format version.
+const packedMagic = "BIJBELB1"
+
+// The root of a packed books file holds the data version of the JSON it was
+// compiled from, the book list of books.json and an entry per book file:
+//
+//	file id, id, name, chapters, verse count
+//	number of verses, their offsets
+//	number of chapters, per chapter: number, number of verses, their indexes
+//
+// The offsets and indexes follow the order of the book file and are delta
+// encoded. Each verse is preceded by its textJson tree and stored as
+//
+//	flags, chapter, verse, [id], paragraph, text, clean text, [title],
+//	cross_references as JSON, [distance back to the tree]
+//
+// where the flags tell which of the bracketed fields are present. The
+// cleaned text is computed at build time; without markup to remove it is
+// the same string as the text and is stored once.
+const (
+	verseHasTitle = 1 << iota
+	verseHasId
+	verseHasTree
+)
+
+// The flags of a packed node tell which of its fields are present.
+const (
+	nodeHasTag = 1 << iota
+	nodeHasText
+	nodeHasClass
+	nodeHasRef
+	nodeHasTitle
+	nodeHasKey
+	nodeHasChildren
+)
+
+// WritePacked compiles the books.json and books/*.json files of fsys to the
+// packed format that NewPackedRepository loads.
+func WritePacked(w io.Writer, fsys fs.FS) error {
+	src, err := newFSRepository(fsys)
+	if err != nil {
+		return err
+	}
+	names, err := fs.Glob(fsys, "books/*.json")
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		fileId  string
+		book    SourceBook
+		offsets []int
+	}
+	var e binpack.Encoder
+	entries := make([]entry, len(names))
+	for i, name := range names {
+		id := strings.TrimSuffix(path.Base(name), ".json")
+		book, err := src.ReadSourceBook(id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		entries[i] = entry{fileId: id, book: book, offsets: make([]int, len(book.Verses))}
+		for j, v := range book.Verses {
+			offset, err := packVerse(&e, id, v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", v.Id, err)
+			}
+			entries[i].offsets[j] = offset
+		}
+	}
+
+	root := e.Offset()
+	e.String(src.DataVersion())
+	e.Len(len(src.books))
+	for _, b := range src.books {
+		e.String(b.Id)
+		e.String(b.Name)
+		e.Int(b.Order)
+	}
+	e.Len(len(entries))
+	for _, entry := range entries {
+		book := entry.book
+		e.String(entry.fileId)
+		e.String(book.Id)
+		e.String(book.Name)
+		e.Int(book.Chapters)
+		e.Int(book.VerseCount)
+
+		e.Len(len(entry.offsets))
+		prev := 0
+		for _, offset := range entry.offsets {
+			e.Len(offset - prev)
+			prev = offset
+		}
+
+		var chapters []int
+		indexes := map[int][]int{}
+		for i, v := range book.Verses {
+			if _, ok := indexes[v.Chapter]; !ok {
+				chapters = append(chapters, v.Chapter)
+			}
+			indexes[v.Chapter] = append(indexes[v.Chapter], i)
+		}
+		e.Len(len(chapters))
+		for _, c := range chapters {
+			e.Int(c)
+			e.Len(len(indexes[c]))
+			prev := 0
+			for _, i := range indexes[c] {
+				e.Len(i - prev)
+				prev = i
+			}
+		}
+	}
+	return e.WriteTo(w, packedMagic, root)
+}
+
+// packVerse writes v and returns its offset.
+func packVerse(e *binpack.Encoder, bookId string, v SourceVerse) (int, error) {
+	crossRefs, err := json.Marshal(v.CrossReferences)
+	if err != nil {
+		return 0, err
+	}
+
+	tree := e.Offset()
+	if v.TextJson != nil {
+		packNode(e, *v.TextJson)
+	}
+	offset := e.Offset()
+
+	flags := 0
+	if v.Title != nil {
+		flags |= verseHasTitle
+	}
+	if v.Id != fmt.Sprintf("%s.%d.%d", bookId, v.Chapter, v.Verse) {
+		flags |= verseHasId
+	}
+	if v.TextJson != nil {
+		flags |= verseHasTree
+	}
+	e.Len(flags)
+	e.Int(v.Chapter)
+	e.Int(v.Verse)
+	if flags&verseHasId != 0 {
+		e.String(v.Id)
+	}
+	e.String(v.Paragraph)
+	e.String(v.Text)
+	e.String(cleanVerseText(v.Text))
+	if v.Title != nil {
+		e.String(*v.Title)
+	}
+	e.String(string(crossRefs))
+	if v.TextJson != nil {
+		e.Len(offset - tree)
+	}
+	return offset, nil
+}
+
+func packNode(e *binpack.Encoder, n Node) {
+	fields := []struct {
+		flag  int
+		value string
+	}{
+		{nodeHasTag, n.Tag},
+		{nodeHasText, n.Text},
+		{nodeHasClass, n.Class},
+		{nodeHasRef, n.Ref},
+		{nodeHasTitle, n.Title},
+		{nodeHasKey, n.IvertalingKey},
+	}
+	flags := 0
+	for _, f := range fields {
+		if f.value != "" {
+			flags |= f.flag
+		}
+	}
+	if n.Children != nil {
+		flags |= nodeHasChildren
+	}
+
+	e.Len(flags)
+	for _, f := range fields {
+		if f.value != "" {
+			e.String(f.value)
+		}
+	}
+	if n.Children != nil {
+		e.Len(len(n.Children))
+		for _, child := range n.Children {
+			packNode(e, child)
+		}
+	}
+}
+
+// NewPackedRepository loads a repository from data written by WritePacked.
+// Only the index is decoded up front; verses are decoded when requested.
+func NewPackedRepository(data []byte) (Repository, error) {
+	return newPackedRepository(string(data))
+}
+
+// packedRepository reads books from a packed file. The strings it returns
+// share the memory of the file.
+type packedRepository struct {
+	catalog
+	file        *binpack.File
+	files       map[string]*packedBook
+	dataVersion string
+
+	// cache keeps the verses of whole books, as GetChapters returns them.
+	cache *cache.LRU[string, Book]
+}
+
+// packedBook is the index entry of a book file.
+type packedBook struct {
+	fileId     string
+	id         string
+	name       string
+	chapters   int
+	verseCount int
+	offsets    []int
+	// chapterVerses holds the indexes of the verses of each chapter.
+	chapterVerses map[int][]int
+}
+
+func newPackedRepository(data string) (*packedRepository, error) {
+	f, err := binpack.Open(data, packedMagic)
+	if err != nil {
+		return nil, err
+	}
+	r := &packedRepository{
+		file:  f,
+		files: make(map[string]*packedBook),
+		cache: cache.NewLRU[string, Book](24),
+	}
+
+	root := f.Root()
+	r.dataVersion = root.String()
+	books := make([]BookMetadata, root.Len())
+	for i := range books {
+		books[i] = BookMetadata{Id: root.String(), Name: root.String(), Order: root.Int()}
+	}
+	r.catalog = newCatalog(books)
+
+	for range root.Len() {
+		fileId := root.String()
+		b := &packedBook{
+			fileId:        fileId,
+			id:            root.String(),
+			name:          root.String(),
+			chapters:      root.Int(),
+			verseCount:    root.Int(),
+			chapterVerses: make(map[int][]int),
+		}
+		b.offsets = make([]int, root.Len())
+		offset := 0
+		for i := range b.offsets {
+			offset += root.Len()
+			b.offsets[i] = offset
+		}
+		for range root.Len() {
+			chapter := root.Int()
+			indexes := make([]int, root.Len())
+			index := 0
+			for i := range indexes {
+				index += root.Len()
+				if index >= len(b.offsets) {
+					return nil, binpack.ErrCorrupt
+				}
+				indexes[i] = index
+			}
+			b.chapterVerses[chapter] = indexes
+		}
+		r.files[fileId] = b
+	}
+	if err := root.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *packedRepository) book(id string) (*packedBook, error) {
+	b, ok := r.files[id]
+	if !ok {
+		return nil, fmt.Errorf("book %q: %w", id, fs.ErrNotExist)
+	}
+	return b, nil
+}
+
+func (r *packedRepository) GetChapters(id string) (Book, error) {
+	book, err := r.cache.GetOrLoad(id, r.loadBook)
+	if err != nil {
+		return Book{}, err
+	}
+
+	book.Verses = slices.Clone(book.Verses)
+	return book, nil
+}
+
+func (r *packedRepository) GetChapter(id string, chapterNumber int) (Chapter, error) {
+	b, err := r.book(id)
+	if err != nil {
+		return Chapter{}, err
+	}
+
+	chapter := Chapter{Id: id, Name: b.name, Chapter: chapterNumber}
+	for _, i := range b.chapterVerses[chapterNumber] {
+		v, err := r.verse(b, b.offsets[i])
+		if err != nil {
+			return Chapter{}, err
+		}
+		chapter.Verses = append(chapter.Verses, v)
+	}
+	return chapter, nil
+}
+
+// loadBook decodes the cleaned verses of a book.
+func (r *packedRepository) loadBook(id string) (Book, error) {
+	b, err := r.book(id)
+	if err != nil {
+		return Book{}, err
+	}
+
+	book := Book{Id: b.id, Name: b.name, Chapters: b.chapters, VerseCount: b.verseCount}
+	book.Verses = make([]Verse, len(b.offsets))
+	for i, offset := range b.offsets {
+		if book.Verses[i], err = r.verse(b, offset); err != nil {
+			return Book{}, err
+		}
+	}
+	return book, nil
+}
+
+// verse decodes the cleaned verse at offset, skipping its markup.
+func (r *packedRepository) verse(b *packedBook, offset int) (Verse, error) {
+	d := r.file.Reader(offset)
+	flags := d.Len()
+	v := Verse{Chapter: d.Int(), Verse: d.Int()}
+	v.Id = b.verseId(d, flags, v.Chapter, v.Verse)
+	v.Paragraph = d.String()
+	_ = d.String() // the text with markup
+	v.Text = d.String()
+	if flags&verseHasTitle != 0 {
+		v.Title = d.String()
+	}
+	return v, d.Err()
+}
+
+func (r *packedRepository) ReadSourceBook(id string) (SourceBook, error) {
+	b, err := r.book(id)
+	if err != nil {
+		return SourceBook{}, err
+	}
+
+	book := SourceBook{Id: b.id, Name: b.name, Chapters: b.chapters, VerseCount: b.verseCount}
+	book.Verses = make([]SourceVerse, len(b.offsets))
+	for i, offset := range b.offsets {
+		if book.Verses[i], err = r.sourceVerse(b, offset); err != nil {
+			return SourceBook{}, err
+		}
+	}
+	return book, nil
+}
+
+// sourceVerse decodes the verse at offset as stored in the book file.
+func (r *packedRepository) sourceVerse(b *packedBook, offset int) (SourceVerse, error) {
+	d := r.file.Reader(offset)
+	flags := d.Len()
+	v := SourceVerse{Chapter: d.Int(), Verse: d.Int()}
+	v.Id = b.verseId(d, flags, v.Chapter, v.Verse)
+	v.Paragraph = d.String()
+	v.Text = d.String()
+	_ = d.String() // the clean text
+	if flags&verseHasTitle != 0 {
+		title := d.String()
+		v.Title = &title
+	}
+	crossRefs := d.String()
+	if flags&verseHasTree != 0 {
+		tree := r.file.Reader(offset - d.Len())
+		root := unpackNode(tree)
+		v.TextJson = &root
+		if err := tree.Err(); err != nil {
+			return SourceVerse{}, err
+		}
+	}
+	if err := d.Err(); err != nil {
+		return SourceVerse{}, err
+	}
+
+	switch crossRefs {
+	case "null":
+	case "[]":
+		v.CrossReferences = []any{}
+	default:
+		if err := json.Unmarshal([]byte(crossRefs), &v.CrossReferences); err != nil {
+			return SourceVerse{}, err
+		}
+	}
+	return v, nil
+}
+
+func unpackNode(d *binpack.Reader) Node {
+	flags := d.Len()
+	var n Node
+	for _, f := range []struct {
+		flag  int
+		value *string
+	}{
+		{nodeHasTag, &n.Tag},
+		{nodeHasText, &n.Text},
+		{nodeHasClass, &n.Class},
+		{nodeHasRef, &n.Ref},
+		{nodeHasTitle, &n.Title},
+		{nodeHasKey, &n.IvertalingKey},
+	} {
+		if flags&f.flag != 0 {
+			*f.value = d.String()
+		}
+	}
+	if flags&nodeHasChildren != 0 {
+		n.Children = make([]Node, d.Len())
+		for i := range n.Children {
+			n.Children[i] = unpackNode(d)
+		}
+	}
+	return n
+}
+
+// verseId reads the id of a verse, which is only stored when it differs
+// from the one its book, chapter and verse number give.
+func (b *packedBook) verseId(d *binpack.Reader, flags, chapter, verse int) string {
+	if flags&verseHasId != 0 {
+		return d.String()
+	}
+	return b.fileId + "." + strconv.Itoa(chapter) + "." + strconv.Itoa(verse)
+}
+
+func (r *packedRepository) Verify() error {
+	return verifyBooks(r.books, r.loadBook)
+}
+
+func (r *packedRepository) DataVersion() string {
+	return r.dataVersion
+}
+
+func (r *packedRepository) CacheStats() cache.Stats {
+	return r.cache.Stats()
+}
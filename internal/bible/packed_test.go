@@ -0,0 +1,70 @@
+package bible
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPackedRepository compiles the JSON data of this package and checks
+// that the packed repository returns exactly what the JSON one does.
+func TestPackedRepository(t *testing.T) {
+	fsys := os.DirFS(".")
+	var buf bytes.Buffer
+	require.NoError(t, WritePacked(&buf, fsys))
+
+	packed, err := NewPackedRepository(buf.Bytes())
+	require.NoError(t, err)
+	source, err := NewFSRepository(fsys)
+	require.NoError(t, err)
+
+	require.Equal(t, source.DataVersion(), packed.DataVersion())
+	require.Equal(t, source.GetBooks(), packed.GetBooks())
+	require.NoError(t, packed.Verify())
+
+	for _, b := range source.GetBooks() {
+		want, err := source.ReadSourceBook(b.Id)
+		require.NoError(t, err)
+		got, err := packed.ReadSourceBook(b.Id)
+		require.NoError(t, err)
+		require.Equal(t, want, got, b.Id)
+
+		book, err := source.GetChapters(b.Id)
+		require.NoError(t, err)
+		packedBook, err := packed.GetChapters(b.Id)
+		require.NoError(t, err)
+		require.Equal(t, book, packedBook, b.Id)
+
+		for chapter := range book.Chapters + 1 {
+			want, err := source.GetChapter(b.Id, chapter)
+			require.NoError(t, err)
+			got, err := packed.GetChapter(b.Id, chapter)
+			require.NoError(t, err)
+			require.Equal(t, want, got, "%s %d", b.Id, chapter)
+		}
+	}
+
+	_, err = packed.GetChapters("../books")
+	require.Error(t, err)
+	t.Logf("packed %d bytes", buf.Len())
+}
+
+func TestNewPackedRepositoryCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePacked(&buf, fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"ruth","name":"Ruth","order":1}]`)},
+		"books/ruth.json": {Data: []byte(`{"id":"ruth","name":"Ruth","chapters":1,"verseCount":1,"verses":[
+			{"chapter":1,"verse":1,"id":"ruth.1.1","text":"Ten tijde van de rechters","paragraph":"y"}]}`)},
+	}))
+	data := buf.Bytes()
+
+	_, err := NewPackedRepository(data)
+	require.NoError(t, err)
+	_, err = NewPackedRepository(data[:len(data)-1])
+	require.Error(t, err)
+	_, err = NewPackedRepository([]byte("{}"))
+	require.Error(t, err)
+}
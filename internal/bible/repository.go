@@ -0,0 +1,244 @@
+package bible
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/memfs"
+)
+
+// Repository gives access to the books of one Bible text.
+type Repository interface {
+	// GetBooks returns all the books in canonical order.
+	GetBooks() []BookMetadata
+	// GetBook returns the metadata of a book, or the zero value if unknown.
+	GetBook(id string) BookMetadata
+	// GetBookOrder returns the order of a book given its Id.
+	GetBookOrder(id string) int
+	// GetBookId returns the Id of a book given its order.
+	GetBookId(order int) string
+	// GetChapters returns a book with all of its cleaned verses.
+	GetChapters(id string) (Book, error)
+	// GetChapter returns the cleaned verses of one chapter of a book.
+	GetChapter(id string, chapterNumber int) (Chapter, error)
+	// ReadSourceBook returns a book file including its verse markup.
+	ReadSourceBook(id string) (SourceBook, error)
+	// Verify checks every book against its declared verse and chapter counts.
+	Verify() error
+	// DataVersion returns a short content hash of the data files.
+	DataVersion() string
+	// CacheStats reports the hit ratio of the parsed book cache.
+	CacheStats() cache.Stats
+}
+
+// NewDirRepository loads books.json and books/*.json from dir. All files are
+// read into memory up front, so later edits on disk only take effect in a
+// newly created repository.
+func NewDirRepository(dir string) (Repository, error) {
+	fsys, err := memfs.Snapshot(dir, "books.json", "books/*.json")
+	if err != nil {
+		return nil, err
+	}
+	return newFSRepository(fsys)
+}
+
+// NewFSRepository loads a repository from any file system laid out like
+// this package's data directory.
+func NewFSRepository(fsys fs.FS) (Repository, error) {
+	return newFSRepository(fsys)
+}
+
+var current atomic.Pointer[Repository]
+
+func init() {
+	repo, err := NewEmbeddedRepository()
+	if err != nil {
+		panic(err.Error())
+	}
+	SetRepository(repo)
+}
+
+// Current returns the repository used by the package level functions.
+func Current() Repository {
+	return *current.Load()
+}
+
+// SetRepository atomically replaces the repository used by the package level
+// functions. Requests that already hold the previous repository keep using
+// it until they finish.
+func SetRepository(repo Repository) {
+	current.Store(&repo)
+}
+
+// catalog holds the book list of a repository and answers the lookups by
+// id and order.
+type catalog struct {
+	books    []BookMetadata
+	bookMap  map[string]BookMetadata
+	orderMap map[int]string
+}
+
+func newCatalog(books []BookMetadata) catalog {
+	c := catalog{
+		books:    books,
+		bookMap:  make(map[string]BookMetadata),
+		orderMap: make(map[int]string),
+	}
+	for _, b := range books {
+		c.bookMap[b.Id] = b
+		c.orderMap[b.Order] = b.Id
+	}
+	return c
+}
+
+func (c catalog) GetBooks() []BookMetadata {
+	return c.books
+}
+
+func (c catalog) GetBook(id string) BookMetadata {
+	return c.bookMap[id]
+}
+
+func (c catalog) GetBookOrder(id string) int {
+	return c.bookMap[id].Order
+}
+
+func (c catalog) GetBookId(order int) string {
+	return c.orderMap[order]
+}
+
+// fsRepository reads books from a JSON file system. Directory-backed
+// repositories, and the embedded one unless built with the packed tag, are
+// fsRepositories.
+type fsRepository struct {
+	catalog
+	fsys fs.FS
+
+	// cache keeps recently parsed books so that chapter requests don't
+	// unmarshal the whole book file every time.
+	cache *cache.LRU[string, Book]
+
+	dataVersion func() string
+}
+
+func newFSRepository(fsys fs.FS) (*fsRepository, error) {
+	data, err := fs.ReadFile(fsys, "books.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read books.json: %w", err)
+	}
+
+	var books []BookMetadata
+	if err := json.Unmarshal(data, &books); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal books.json: %w", err)
+	}
+
+	r := &fsRepository{
+		catalog: newCatalog(books),
+		fsys:    fsys,
+		cache:   cache.NewLRU[string, Book](24),
+	}
+	r.dataVersion = sync.OnceValue(r.hashFiles)
+
+	return r, nil
+}
+
+func (r *fsRepository) GetChapters(id string) (Book, error) {
+	book, err := r.cache.GetOrLoad(id, r.loadBook)
+	if err != nil {
+		return Book{}, err
+	}
+
+	book.Verses = slices.Clone(book.Verses)
+	return book, nil
+}
+
+func (r *fsRepository) GetChapter(id string, chapterNumber int) (Chapter, error) {
+	book, err := r.cache.GetOrLoad(id, r.loadBook)
+	if err != nil {
+		return Chapter{}, err
+	}
+
+	var chapter Chapter
+	for _, vs := range book.Verses {
+		if vs.Chapter == chapterNumber {
+			chapter.Verses = append(chapter.Verses, vs)
+		}
+	}
+
+	chapter.Id = id
+	chapter.Name = book.Name
+	chapter.Chapter = chapterNumber
+
+	return chapter, nil
+}
+
+func (r *fsRepository) ReadSourceBook(id string) (SourceBook, error) {
+	data, err := r.readBookFile(id)
+	if err != nil {
+		return SourceBook{}, err
+	}
+	return ParseSourceBook(data)
+}
+
+func (r *fsRepository) CacheStats() cache.Stats {
+	return r.cache.Stats()
+}
+
+func (r *fsRepository) DataVersion() string {
+	return r.dataVersion()
+}
+
+func (r *fsRepository) readBookFile(id string) ([]byte, error) {
+	if !fs.ValidPath(id) || id == "" {
+		return nil, fmt.Errorf("invalid book id: %q", id)
+	}
+	return fs.ReadFile(r.fsys, "books/"+id+".json")
+}
+
+// loadBook reads and parses a book file, cleaning the text of every verse.
+func (r *fsRepository) loadBook(id string) (Book, error) {
+	data, err := r.readBookFile(id)
+	if err != nil {
+		return Book{}, err // file not found or read error
+	}
+
+	var book Book
+	if err := json.Unmarshal(data, &book); err != nil {
+		return Book{}, err
+	}
+
+	// Clean verse text
+	for i := range book.Verses {
+		book.Verses[i].Text = cleanVerseText(book.Verses[i].Text)
+	}
+
+	return book, nil
+}
+
+// hashFiles computes the data version over books.json and every book file.
+func (r *fsRepository) hashFiles() string {
+	h := sha256.New()
+
+	names := []string{"books.json"}
+	books, _ := fs.Glob(r.fsys, "books/*.json")
+	sort.Strings(books)
+	names = append(names, books...)
+
+	for _, name := range names {
+		data, err := fs.ReadFile(r.fsys, name)
+		if err != nil {
+			continue
+		}
+		h.Write([]byte(name))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
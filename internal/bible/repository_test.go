@@ -0,0 +1,61 @@
+package bible
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSRepository(t *testing.T) {
+	fsys := fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"ruth","name":"Ruth","order":1}]`)},
+		"books/ruth.json": {Data: []byte(`{"id":"ruth","name":"Ruth","chapters":1,"verseCount":2,"verses":[
+			{"chapter":1,"verse":1,"id":"ruth.1.1","text":"Ten tijde van de rechters&#39;","paragraph":"y"},
+			{"chapter":1,"verse":2,"id":"ruth.1.2","text":"De man heette Elimelek.","paragraph":"n"}]}`)},
+	}
+
+	repo, err := NewFSRepository(fsys)
+	if err != nil {
+		t.Fatalf("NewFSRepository() error = %v", err)
+	}
+
+	if got := repo.GetBookId(1); got != "ruth" {
+		t.Errorf("GetBookId(1) = %q, want ruth", got)
+	}
+	chapter, err := repo.GetChapter("ruth", 1)
+	if err != nil {
+		t.Fatalf("GetChapter() error = %v", err)
+	}
+	if len(chapter.Verses) != 2 || chapter.Verses[0].Text != "Ten tijde van de rechters'" {
+		t.Errorf("GetChapter() verses = %+v", chapter.Verses)
+	}
+	if err := repo.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if repo.DataVersion() == Current().DataVersion() {
+		t.Errorf("DataVersion() equals the embedded data version")
+	}
+	if _, err := repo.GetChapters("../books"); err == nil {
+		t.Errorf("GetChapters() accepted a path outside books/")
+	}
+}
+
+func TestSetRepository(t *testing.T) {
+	embedded := Current()
+	t.Cleanup(func() { SetRepository(embedded) })
+
+	repo, err := NewFSRepository(fstest.MapFS{"books.json": {Data: []byte(`[]`)}})
+	if err != nil {
+		t.Fatalf("NewFSRepository() error = %v", err)
+	}
+	SetRepository(repo)
+
+	if got := len(GetBooks()); got != 0 {
+		t.Errorf("GetBooks() returned %d books after SetRepository, want 0", got)
+	}
+}
+
+func TestNewDirRepositoryMissingDir(t *testing.T) {
+	if _, err := NewDirRepository(t.TempDir() + "/missing"); err == nil {
+		t.Errorf("NewDirRepository() error = nil for a missing directory")
+	}
+}
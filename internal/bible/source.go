@@ -0,0 +1,174 @@
+package bible
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// SourceBook is a book file exactly as stored under books/, including the
+// markup of every verse. The API serves the cleaned Book instead.
+type SourceBook struct {
+	Id         string        `json:"id"`
+	Name       string        `json:"name"`
+	Chapters   int           `json:"chapters"`
+	VerseCount int           `json:"verseCount"`
+	Verses     []SourceVerse `json:"verses"`
+}
+
+// SourceVerse is a verse as stored in a book file. Text holds the original
+// HTML and TextJson the same content as a tree of nodes.
+type SourceVerse struct {
+	Chapter         int     `json:"chapter"`
+	Verse           int     `json:"verse"`
+	Id              string  `json:"id"`
+	Text            string  `json:"text"`
+	TextJson        *Node   `json:"textJson"`
+	Paragraph       string  `json:"paragraph"`
+	Title           *string `json:"title"`
+	CrossReferences []any   `json:"cross_references"`
+}
+
+// Node is an element or text node of a verse's textJson tree. Text nodes
+// have no tag.
+type Node struct {
+	Tag           string `json:"tag,omitempty"`
+	Text          string `json:"text,omitempty"`
+	Class         string `json:"class,omitempty"`
+	Ref           string `json:"ref,omitempty"`
+	Title         string `json:"title,omitempty"`
+	IvertalingKey string `json:"ivertalingkey,omitempty"`
+	Children      []Node `json:"children,omitempty"`
+}
+
+// IsText reports whether n is a text node.
+func (n Node) IsText() bool {
+	return n.Tag == ""
+}
+
+// PlainText flattens the tree to its readable text. Footnote markers (abbr)
+// are dropped and line breaks become spaces, matching the cleaned verse text.
+func (n Node) PlainText() string {
+	var sb strings.Builder
+	n.writePlainText(&sb)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func (n Node) writePlainText(sb *strings.Builder) {
+	switch n.Tag {
+	case "":
+		sb.WriteString(n.Text)
+	case "abbr":
+		return
+	case "br":
+		sb.WriteByte(' ')
+	case "div", "p", "blockquote":
+		sb.WriteByte(' ')
+		for _, child := range n.Children {
+			child.writePlainText(sb)
+		}
+		sb.WriteByte(' ')
+	default:
+		for _, child := range n.Children {
+			child.writePlainText(sb)
+		}
+	}
+}
+
+// Lines splits the content of n at its line breaks, as used for poetry.
+// Elements that span a break, such as an em around several lines, are
+// split into one copy per line. Lines without any text are dropped.
+func (n Node) Lines() [][]Node {
+	var lines [][]Node
+	for _, line := range splitLines(n.Children) {
+		if strings.TrimSpace(Node{Tag: "p", Children: line}.PlainText()) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func splitLines(nodes []Node) [][]Node {
+	lines := [][]Node{nil}
+	for _, node := range nodes {
+		switch {
+		case node.Tag == "br":
+			lines = append(lines, nil)
+		case node.IsText() || node.Tag == "abbr":
+			lines[len(lines)-1] = append(lines[len(lines)-1], node)
+		default:
+			for i, children := range splitLines(node.Children) {
+				if i > 0 {
+					lines = append(lines, nil)
+				}
+				if len(children) > 0 {
+					part := node
+					part.Children = children
+					lines[len(lines)-1] = append(lines[len(lines)-1], part)
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// InnerHTML renders the children of n in the HTML dialect of the verse text
+// field, so that a tree built by an importer gets a matching text.
+func (n Node) InnerHTML() string {
+	var sb strings.Builder
+	for _, child := range n.Children {
+		child.writeHTML(&sb)
+	}
+	return sb.String()
+}
+
+func (n Node) writeHTML(sb *strings.Builder) {
+	switch n.Tag {
+	case "":
+		sb.WriteString(html.EscapeString(n.Text))
+		return
+	case "br":
+		// The source data always follows a line break by CRLF.
+		sb.WriteString("<br />\r\n")
+		return
+	}
+
+	sb.WriteString("<" + n.Tag)
+	for _, attr := range []struct{ name, value string }{
+		{"ivertalingkey", n.IvertalingKey},
+		{"ref", n.Ref},
+		{"title", n.Title},
+		{"class", n.Class},
+	} {
+		if attr.value != "" {
+			sb.WriteString(" " + attr.name + `="` + html.EscapeString(attr.value) + `"`)
+		}
+	}
+	sb.WriteString(">")
+	sb.WriteString(n.InnerHTML())
+	sb.WriteString("</" + n.Tag + ">")
+}
+
+// CleanText returns the verse text with markup removed, as served by the API.
+func (v SourceVerse) CleanText() string {
+	return cleanVerseText(v.Text)
+}
+
+// ParseSourceBook decodes a book file. Unknown fields are rejected so that
+// hand edits with typos in field names are caught.
+func ParseSourceBook(data []byte) (SourceBook, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var book SourceBook
+	if err := dec.Decode(&book); err != nil {
+		return SourceBook{}, err
+	}
+	return book, nil
+}
+
+// ReadSourceBook returns the book file for the given Id.
+func ReadSourceBook(id string) (SourceBook, error) {
+	return Current().ReadSourceBook(id)
+}
@@ -0,0 +1,93 @@
+package bible
+
+import (
+	"testing"
+)
+
+func TestReadSourceBook(t *testing.T) {
+	book, err := ReadSourceBook("genesis")
+	if err != nil {
+		t.Fatalf("error: %v", err.Error())
+	}
+
+	if book.VerseCount != len(book.Verses) {
+		t.Fatalf("expected %v verses, got %v", book.VerseCount, len(book.Verses))
+	}
+
+	first := book.Verses[0]
+	if first.Id != "genesis.1.1" || first.TextJson == nil {
+		t.Fatalf("unexpected first verse: %+v", first)
+	}
+	if first.Title == nil || *first.Title != "De schepping" {
+		t.Fatalf("expected title %q, got %v", "De schepping", first.Title)
+	}
+
+	footnote := first.TextJson.Children[1]
+	if footnote.Tag != "abbr" || footnote.Ref == "" || footnote.Title == "" {
+		t.Fatalf("expected abbr footnote node, got %+v", footnote)
+	}
+}
+
+func TestNodePlainText(t *testing.T) {
+	node := Node{Tag: "p", Children: []Node{
+		{Text: "Die u roept is getrouw:"},
+		{Tag: "br"},
+		{Text: "Hij zal zijn woord"},
+		{Tag: "abbr", Ref: "Ps. 1", Children: []Node{{Text: "*"}}},
+		{Tag: "em", Children: []Node{{Text: " gestand  doen"}}},
+		{Text: "."},
+	}}
+
+	want := "Die u roept is getrouw: Hij zal zijn woord gestand doen."
+	if got := node.PlainText(); got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+}
+
+func TestNodeLines(t *testing.T) {
+	node := Node{Tag: "p", Children: []Node{
+		{Text: "zeide:"},
+		{Tag: "blockquote", Children: []Node{
+			{Tag: "em", Children: []Node{{Text: "Een stem"}, {Tag: "br"}, {Text: "\r\nBereidt de weg"}}},
+			{Tag: "abbr", Ref: "Jes. 40,3."},
+		}},
+		{Tag: "br"},
+		{Text: "\r\n "},
+	}}
+
+	lines := node.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %+v", len(lines), lines)
+	}
+	if got := (Node{Tag: "p", Children: lines[0]}).PlainText(); got != "zeide: Een stem" {
+		t.Errorf("first line = %q", got)
+	}
+	second := lines[1][0]
+	if second.Tag != "blockquote" || second.Children[0].Tag != "em" || second.Children[1].Tag != "abbr" {
+		t.Errorf("second line lost its markup: %+v", second)
+	}
+}
+
+func TestNodeInnerHTML(t *testing.T) {
+	node := Node{Tag: "p", Children: []Node{
+		{Text: "Hij rustte"},
+		{Tag: "abbr", IvertalingKey: "1", Ref: "Ex. 20,11", Children: []Node{{Text: "*"}}},
+		{Tag: "br"},
+		{Tag: "em", Children: []Node{{Text: "Gods 'rust'"}}},
+	}}
+
+	want := `Hij rustte<abbr ivertalingkey="1" ref="Ex. 20,11">*</abbr><br />` + "\r\n" + `<em>Gods &#39;rust&#39;</em>`
+	if got := node.InnerHTML(); got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+
+	if got, want := cleanVerseText(node.InnerHTML()), node.PlainText(); got != want {
+		t.Errorf("cleaned HTML %q does not match plain text %q", got, want)
+	}
+}
+
+func TestParseSourceBookRejectsUnknownFields(t *testing.T) {
+	if _, err := ParseSourceBook([]byte(`{"id": "ruth", "naam": "Ruth"}`)); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
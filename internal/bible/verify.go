@@ -0,0 +1,51 @@
+package bible
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Verify loads every book listed in books.json and checks that its verse and
+// chapter counts match the counts stated in the book file.
+func Verify() error {
+	return Current().Verify()
+}
+
+// DataVersion returns a short content hash of the book data. It changes
+// whenever any book file changes.
+func DataVersion() string {
+	return Current().DataVersion()
+}
+
+func (r *fsRepository) Verify() error {
+	return verifyBooks(r.books, r.loadBook)
+}
+
+// verifyBooks checks the books loaded by load against their metadata and
+// their declared counts.
+func verifyBooks(books []BookMetadata, load func(id string) (Book, error)) error {
+	var errs []error
+	for _, meta := range books {
+		book, err := load(meta.Id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to load: %w", meta.Id, err))
+			continue
+		}
+
+		if book.Id != meta.Id {
+			errs = append(errs, fmt.Errorf("%s: book file has id %q", meta.Id, book.Id))
+		}
+		if len(book.Verses) != book.VerseCount {
+			errs = append(errs, fmt.Errorf("%s: has %d verses, verseCount is %d", meta.Id, len(book.Verses), book.VerseCount))
+		}
+
+		chapters := make(map[int]bool)
+		for _, v := range book.Verses {
+			chapters[v.Chapter] = true
+		}
+		if len(chapters) != book.Chapters {
+			errs = append(errs, fmt.Errorf("%s: has %d chapters, chapters is %d", meta.Id, len(chapters), book.Chapters))
+		}
+	}
+	return errors.Join(errs...)
+}
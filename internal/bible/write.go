@@ -0,0 +1,32 @@
+package bible
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// WriteBooks writes books to dir as books.json and books/<id>.json, the
+// layout NewDirRepository reads. Books are numbered in the given order.
+func WriteBooks(dir string, books []SourceBook) error {
+	if err := os.MkdirAll(filepath.Join(dir, "books"), 0o755); err != nil {
+		return err
+	}
+
+	metadata := make([]BookMetadata, len(books))
+	for i, book := range books {
+		metadata[i] = BookMetadata{Id: book.Id, Name: book.Name, Order: i + 1}
+		if err := writeJSON(filepath.Join(dir, "books", book.Id+".json"), book); err != nil {
+			return err
+		}
+	}
+	return writeJSON(filepath.Join(dir, "books.json"), metadata)
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
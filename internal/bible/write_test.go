@@ -0,0 +1,29 @@
+package bible
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBooks(t *testing.T) {
+	ruth, err := ReadSourceBook("ruth")
+	require.NoError(t, err)
+	judas, err := ReadSourceBook("judas")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, WriteBooks(dir, []SourceBook{ruth, judas}))
+
+	repo, err := NewDirRepository(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.Verify())
+	require.Equal(t, []BookMetadata{
+		{Id: "ruth", Name: "Ruth", Order: 1},
+		{Id: "judas", Name: "Judas", Order: 2},
+	}, repo.GetBooks())
+
+	book, err := repo.ReadSourceBook("judas")
+	require.NoError(t, err)
+	require.Equal(t, judas, book)
+}
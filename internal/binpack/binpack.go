@@ -0,0 +1,235 @@
+// Package binpack implements the container of the compact data files that
+// the bible and crossref packages embed instead of their JSON: a table of
+// distinct strings followed by a body of varints, in which strings are
+// stored as their index in the table.
+//
+// A file is laid out as
+//
+//	magic
+//	uvarint string count, uvarint length of each string, string bytes
+//	uvarint root offset, uvarint body length, body
+//
+// The root offset points into the body at the record the reader starts
+// from, typically an index written after the data it refers to.
+package binpack
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// ErrCorrupt is returned for data that isn't a valid file.
+var ErrCorrupt = errors.New("binpack: corrupt data")
+
+// Encoder builds a file. The zero value is ready to use.
+type Encoder struct {
+	body    []byte
+	index   map[string]uint64
+	strings []string
+}
+
+// Uvarint appends an unsigned integer.
+func (e *Encoder) Uvarint(x uint64) {
+	for x >= 0x80 {
+		e.body = append(e.body, byte(x)|0x80)
+		x >>= 7
+	}
+	e.body = append(e.body, byte(x))
+}
+
+// Int appends a signed integer, zigzag encoded so that small negative
+// numbers stay short.
+func (e *Encoder) Int(x int) {
+	e.Uvarint(uint64(x<<1) ^ uint64(x>>63))
+}
+
+// Len appends a length or count.
+func (e *Encoder) Len(n int) {
+	e.Uvarint(uint64(n))
+}
+
+// String appends the index of s in the string table, adding it the first
+// time it is seen.
+func (e *Encoder) String(s string) {
+	i, ok := e.index[s]
+	if !ok {
+		if e.index == nil {
+			e.index = make(map[string]uint64)
+		}
+		i = uint64(len(e.strings))
+		e.index[s] = i
+		e.strings = append(e.strings, s)
+	}
+	e.Uvarint(i)
+}
+
+// Offset returns the position in the body at which the next value is
+// written.
+func (e *Encoder) Offset() int {
+	return len(e.body)
+}
+
+// WriteTo writes the file with the given magic and root offset.
+func (e *Encoder) WriteTo(w io.Writer, magic string, root int) error {
+	var head Encoder
+	head.Len(len(e.strings))
+	for _, s := range e.strings {
+		head.Len(len(s))
+	}
+	if _, err := io.WriteString(w, magic); err != nil {
+		return err
+	}
+	if _, err := w.Write(head.body); err != nil {
+		return err
+	}
+	for _, s := range e.strings {
+		if _, err := io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+
+	var tail Encoder
+	tail.Len(root)
+	tail.Len(len(e.body))
+	if _, err := w.Write(tail.body); err != nil {
+		return err
+	}
+	_, err := w.Write(e.body)
+	return err
+}
+
+// File is a decoded file. Strings and the body share the memory of the
+// data they were opened from.
+type File struct {
+	strings []string
+	body    string
+	root    int
+}
+
+// Open decodes the string table of data, which must start with magic.
+func Open(data, magic string) (*File, error) {
+	if len(data) < len(magic) || data[:len(magic)] != magic {
+		return nil, errors.New("binpack: not a " + strconv.Quote(magic) + " file")
+	}
+	r := &Reader{f: &File{body: data}, pos: len(magic)}
+
+	n := r.Len()
+	lengths := make([]int, n)
+	for i := range lengths {
+		lengths[i] = r.Len()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	f := &File{strings: make([]string, n)}
+	for i, l := range lengths {
+		if l > len(data)-r.pos {
+			return nil, ErrCorrupt
+		}
+		f.strings[i] = data[r.pos : r.pos+l]
+		r.pos += l
+	}
+
+	f.root = r.Len()
+	size := r.Len()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if size != len(data)-r.pos || f.root > size {
+		return nil, ErrCorrupt
+	}
+	f.body = data[r.pos:]
+	return f, nil
+}
+
+// Root returns a reader at the root offset.
+func (f *File) Root() *Reader {
+	return f.Reader(f.root)
+}
+
+// Reader returns a reader at offset in the body.
+func (f *File) Reader(offset int) *Reader {
+	r := &Reader{f: f, pos: offset}
+	if offset < 0 || offset > len(f.body) {
+		r.err = ErrCorrupt
+	}
+	return r
+}
+
+// Reader reads values in the order the Encoder wrote them. The first error
+// sticks: later reads return zero values and Err reports it.
+type Reader struct {
+	f   *File
+	pos int
+	err error
+}
+
+// Uvarint reads an unsigned integer.
+func (r *Reader) Uvarint() uint64 {
+	var x uint64
+	for shift := 0; r.err == nil; shift += 7 {
+		if r.pos >= len(r.f.body) || shift > 63 {
+			r.err = ErrCorrupt
+			break
+		}
+		b := r.f.body[r.pos]
+		r.pos++
+		x |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return x
+		}
+	}
+	return 0
+}
+
+// Int reads a signed integer.
+func (r *Reader) Int() int {
+	u := r.Uvarint()
+	return int(u>>1) ^ -int(u&1)
+}
+
+// Len reads a length or count, which must fit in the remaining data so
+// that a corrupt count can't make the caller allocate without bounds.
+func (r *Reader) Len() int {
+	n := r.Uvarint()
+	if r.err == nil && n > uint64(len(r.f.body)) {
+		r.err = ErrCorrupt
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// String reads a string by its index in the string table.
+func (r *Reader) String() string {
+	i := r.Uvarint()
+	if r.err == nil && i >= uint64(len(r.f.strings)) {
+		r.err = ErrCorrupt
+	}
+	if r.err != nil {
+		return ""
+	}
+	return r.f.strings[i]
+}
+
+// Skip moves past n bytes.
+func (r *Reader) Skip(n int) {
+	if r.err == nil && n > len(r.f.body)-r.pos {
+		r.err = ErrCorrupt
+	}
+	if r.err == nil {
+		r.pos += n
+	}
+}
+
+// Offset returns the position of the reader in the body.
+func (r *Reader) Offset() int {
+	return r.pos
+}
+
+// Err returns the first error the reader ran into.
+func (r *Reader) Err() error {
+	return r.err
+}
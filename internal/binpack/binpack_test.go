@@ -0,0 +1,71 @@
+package binpack
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var e Encoder
+	e.String("genesis")
+	data := e.Offset()
+	e.Int(-1)
+	e.Int(math.MaxInt64)
+	e.Uvarint(300)
+	e.Len(2)
+	e.String("genesis")
+	e.String("")
+	root := e.Offset()
+	e.Len(data)
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteTo(&buf, "TEST", root))
+	// The string table holds "genesis" once.
+	require.Equal(t, 1, strings.Count(buf.String(), "genesis"))
+
+	f, err := Open(buf.String(), "TEST")
+	require.NoError(t, err)
+	r := f.Reader(f.Root().Len())
+	require.Equal(t, -1, r.Int())
+	require.Equal(t, math.MaxInt64, r.Int())
+	require.Equal(t, uint64(300), r.Uvarint())
+	require.Equal(t, 2, r.Len())
+	require.Equal(t, "genesis", r.String())
+	require.Equal(t, "", r.String())
+	require.Equal(t, data, r.Len())
+	require.NoError(t, r.Err())
+
+	// Reading past the end sticks to an error.
+	require.Equal(t, 0, r.Int())
+	require.Equal(t, "", r.String())
+	require.ErrorIs(t, r.Err(), ErrCorrupt)
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	var e Encoder
+	e.String("ruth")
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteTo(&buf, "TEST", 0))
+	data := buf.String()
+
+	_, err := Open(data, "TEST")
+	require.NoError(t, err)
+	_, err = Open(data, "XXXX")
+	require.Error(t, err)
+	for i := len("TEST"); i < len(data); i++ {
+		_, err := Open(data[:i], "TEST")
+		require.Error(t, err, "truncated to %d bytes", i)
+	}
+
+	// A string index outside the table.
+	f, err := Open(data, "TEST")
+	require.NoError(t, err)
+	r := f.Reader(0)
+	r.Skip(1)
+	require.Equal(t, "", r.String())
+	require.ErrorIs(t, r.Err(), ErrCorrupt)
+}
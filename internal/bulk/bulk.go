@@ -0,0 +1,209 @@
+// Package bulk streams all verses and cross-references as CSV, TSV or
+// newline-delimited JSON tables, for loading into pandas, spreadsheets and
+// SQL tools.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+// Format is a table format.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	TSV    Format = "tsv"
+	NDJSON Format = "ndjson"
+)
+
+// ParseFormat reads a format name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case CSV, TSV, NDJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected csv, tsv or ndjson", s)
+}
+
+// ContentType returns the Content-Type header of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case TSV:
+		return "text/tab-separated-values; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// VerseColumns are the columns of the verse table.
+var VerseColumns = []string{"id", "book", "book_order", "chapter", "verse", "title", "paragraph", "text"}
+
+// Verses writes every verse of repo in canonical order, with the cleaned
+// text. Chapters are numbered as in the book files, so the single-chapter
+// books Filemon, 2 and 3 Johannes and Judas have chapter 0.
+func Verses(w io.Writer, f Format, repo bible.Repository) error {
+	t, err := newTable(w, f, VerseColumns)
+	if err != nil {
+		return err
+	}
+	for _, meta := range repo.GetBooks() {
+		book, err := repo.GetChapters(meta.Id)
+		if err != nil {
+			return fmt.Errorf("book %s: %w", meta.Id, err)
+		}
+		verses := slices.Clone(book.Verses)
+		slices.SortStableFunc(verses, func(a, b bible.Verse) int {
+			if a.Chapter != b.Chapter {
+				return a.Chapter - b.Chapter
+			}
+			return a.Verse - b.Verse
+		})
+		for _, v := range verses {
+			err := t.row(v.Id, meta.Id, meta.Order, v.Chapter, v.Verse, v.Title, v.Paragraph == "y", v.Text)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return t.flush()
+}
+
+// CrossReferenceColumns are the columns of the cross-reference table.
+var CrossReferenceColumns = []string{
+	"from_book", "from_chapter", "from_verse",
+	"to_book", "to_chapter", "to_verse",
+	"to_end_book", "to_end_chapter", "to_end_verse",
+	"votes",
+}
+
+// CrossReferences writes the cross-references of every book of books that
+// has them, with Dutch book ids. Chapters and verses keep the English
+// numbering of the source data. The end columns are empty unless the
+// target is a range.
+func CrossReferences(w io.Writer, f Format, books bible.Repository, refs crossref.Repository) error {
+	t, err := newTable(w, f, CrossReferenceColumns)
+	if err != nil {
+		return err
+	}
+	for _, meta := range books.GetBooks() {
+		bookRefs, err := refs.GetCrossReferences(meta.Id)
+		if err != nil {
+			// Not every book has cross-reference data.
+			continue
+		}
+		for _, ref := range bookRefs.CrossReferences {
+			to, err := refs.EnglishToDutch(ref.To.Book)
+			if err != nil {
+				continue
+			}
+			var endBook, endChapter, endVerse any
+			if ref.To.EndVerse > 0 {
+				endBook, endChapter, endVerse = to, cmp.Or(ref.To.EndChapter, ref.To.Chapter), ref.To.EndVerse
+				if ref.To.EndBook != "" {
+					if endBook, err = refs.EnglishToDutch(ref.To.EndBook); err != nil {
+						continue
+					}
+				}
+			}
+			err = t.row(meta.Id, ref.From.Chapter, ref.From.Verse,
+				to, ref.To.Chapter, ref.To.Verse,
+				endBook, endChapter, endVerse,
+				ref.Votes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return t.flush()
+}
+
+// table writes rows in one of the formats. CSV and TSV start with a
+// header; NDJSON writes an object per row with the columns as keys, in
+// order. Nil values are empty cells or null.
+type table struct {
+	w   *bufio.Writer
+	csv *csv.Writer
+	// keys holds the JSON encoded column names for NDJSON.
+	keys [][]byte
+	buf  bytes.Buffer
+}
+
+func newTable(w io.Writer, f Format, columns []string) (*table, error) {
+	t := &table{w: bufio.NewWriter(w)}
+	switch f {
+	case CSV, TSV:
+		t.csv = csv.NewWriter(t.w)
+		if f == TSV {
+			t.csv.Comma = '\t'
+		}
+		return t, t.csv.Write(columns)
+	case NDJSON:
+		for _, c := range columns {
+			key, _ := json.Marshal(c)
+			t.keys = append(t.keys, key)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", f)
+}
+
+func (t *table) row(values ...any) error {
+	if t.csv != nil {
+		record := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				record[i] = v
+			case int:
+				record[i] = strconv.Itoa(v)
+			case bool:
+				record[i] = strconv.FormatBool(v)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		return t.csv.Write(record)
+	}
+
+	t.buf.Reset()
+	t.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			t.buf.WriteByte(',')
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		t.buf.Write(t.keys[i])
+		t.buf.WriteByte(':')
+		t.buf.Write(value)
+	}
+	t.buf.WriteString("}\n")
+	_, err := t.w.Write(t.buf.Bytes())
+	return err
+}
+
+func (t *table) flush() error {
+	if t.csv != nil {
+		t.csv.Flush()
+		if err := t.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return t.w.Flush()
+}
@@ -0,0 +1,90 @@
+package bulk
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+func newTestRepositories(t *testing.T) (bible.Repository, crossref.Repository) {
+	t.Helper()
+	books, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"genesis","name":"Genesis","order":1},{"id":"ruth","name":"Ruth","order":8}]`)},
+		"books/genesis.json": {Data: []byte(`{"id":"genesis","name":"Genesis","chapters":1,"verseCount":1,"verses":[
+			{"chapter":1,"verse":1,"id":"genesis.1.1","text":"In het begin","paragraph":"y","title":"De schepping"}]}`)},
+		"books/ruth.json": {Data: []byte(`{"id":"ruth","name":"Ruth","chapters":1,"verseCount":2,"verses":[
+			{"chapter":1,"verse":2,"id":"ruth.1.2","text":"De man heette \"Elimelek\",\tzijn vrouw Noomi","paragraph":"n"},
+			{"chapter":1,"verse":1,"id":"ruth.1.1","text":"In de tijd van de rechters","paragraph":"y"}]}`)},
+	})
+	require.NoError(t, err)
+
+	refs, err := crossref.NewFSRepository(fstest.MapFS{
+		"book-mapping.json": {Data: []byte(`{"mappings":{"Ruth":"ruth","Gen":"genesis","Matt":"matteus"}}`)},
+		"index.json":        {Data: []byte(`{"totalBooks":1,"books":[{"book":"Ruth","file":"ruth.json","referenceCount":3}]}`)},
+		"ruth.json": {Data: []byte(`{"book":"Ruth","totalReferences":3,"crossReferences":[
+			{"from":{"chapter":1,"verse":1},"to":{"book":"Gen","chapter":12,"verse":10},"votes":5},
+			{"from":{"chapter":1,"verse":1},"to":{"book":"Matt","chapter":1,"verse":5,"endVerse":6},"votes":12},
+			{"from":{"chapter":1,"verse":2},"to":{"book":"Xyz","chapter":1,"verse":1},"votes":1}]}`)},
+	})
+	require.NoError(t, err)
+	return books, refs
+}
+
+func TestVerses(t *testing.T) {
+	books, _ := newTestRepositories(t)
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{CSV, `id,book,book_order,chapter,verse,title,paragraph,text
+genesis.1.1,genesis,1,1,1,De schepping,true,In het begin
+ruth.1.1,ruth,8,1,1,,true,In de tijd van de rechters
+ruth.1.2,ruth,8,1,2,,false,"De man heette ""Elimelek"", zijn vrouw Noomi"
+`},
+		{TSV, "id\tbook\tbook_order\tchapter\tverse\ttitle\tparagraph\ttext\n" +
+			"genesis.1.1\tgenesis\t1\t1\t1\tDe schepping\ttrue\tIn het begin\n" +
+			"ruth.1.1\truth\t8\t1\t1\t\ttrue\tIn de tijd van de rechters\n" +
+			"ruth.1.2\truth\t8\t1\t2\t\tfalse\t\"De man heette \"\"Elimelek\"\", zijn vrouw Noomi\"\n"},
+		{NDJSON, `{"id":"genesis.1.1","book":"genesis","book_order":1,"chapter":1,"verse":1,"title":"De schepping","paragraph":true,"text":"In het begin"}
+{"id":"ruth.1.1","book":"ruth","book_order":8,"chapter":1,"verse":1,"title":"","paragraph":true,"text":"In de tijd van de rechters"}
+{"id":"ruth.1.2","book":"ruth","book_order":8,"chapter":1,"verse":2,"title":"","paragraph":false,"text":"De man heette \"Elimelek\", zijn vrouw Noomi"}
+`},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		require.NoError(t, Verses(&sb, tt.format, books))
+		require.Equal(t, tt.want, sb.String(), tt.format)
+	}
+}
+
+func TestCrossReferences(t *testing.T) {
+	books, refs := newTestRepositories(t)
+
+	var sb strings.Builder
+	require.NoError(t, CrossReferences(&sb, CSV, books, refs))
+	require.Equal(t, `from_book,from_chapter,from_verse,to_book,to_chapter,to_verse,to_end_book,to_end_chapter,to_end_verse,votes
+ruth,1,1,genesis,12,10,,,,5
+ruth,1,1,matteus,1,5,matteus,1,6,12
+`, sb.String())
+
+	sb.Reset()
+	require.NoError(t, CrossReferences(&sb, NDJSON, books, refs))
+	require.Equal(t, `{"from_book":"ruth","from_chapter":1,"from_verse":1,"to_book":"genesis","to_chapter":12,"to_verse":10,"to_end_book":null,"to_end_chapter":null,"to_end_verse":null,"votes":5}
+{"from_book":"ruth","from_chapter":1,"from_verse":1,"to_book":"matteus","to_chapter":1,"to_verse":5,"to_end_book":"matteus","to_end_chapter":1,"to_end_verse":6,"votes":12}
+`, sb.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"csv", "TSV", " ndjson"} {
+		_, err := ParseFormat(s)
+		require.NoError(t, err, s)
+	}
+	_, err := ParseFormat("xlsx")
+	require.Error(t, err)
+}
@@ -0,0 +1,330 @@
+// Package bundle builds a SQLite database with the whole Bible for offline
+// use: books, verses, section titles, notes and cross-references in
+// normalized tables, with an FTS5 index over the verse text.
+package bundle
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// SchemaVersion is stored as the user_version of the database and changes
+// whenever the tables do.
+const SchemaVersion = 1
+
+// MediaType is the media type of a bundle.
+const MediaType = "application/vnd.sqlite3"
+
+const schema = `
+CREATE TABLE meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE books (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	position    INTEGER NOT NULL,
+	chapters    INTEGER NOT NULL,
+	verse_count INTEGER NOT NULL
+);
+CREATE TABLE verses (
+	id        INTEGER PRIMARY KEY,
+	ref       TEXT NOT NULL UNIQUE,
+	book_id   TEXT NOT NULL REFERENCES books(id),
+	chapter   INTEGER NOT NULL,
+	verse     INTEGER NOT NULL,
+	paragraph INTEGER NOT NULL,
+	text      TEXT NOT NULL,
+	html      TEXT NOT NULL,
+	UNIQUE (book_id, chapter, verse)
+);
+CREATE TABLE section_titles (
+	verse_id INTEGER PRIMARY KEY REFERENCES verses(id),
+	title    TEXT NOT NULL
+);
+CREATE TABLE notes (
+	id       INTEGER PRIMARY KEY,
+	verse_id INTEGER NOT NULL REFERENCES verses(id),
+	seq      INTEGER NOT NULL,
+	ref      TEXT NOT NULL,
+	title    TEXT NOT NULL
+);
+CREATE INDEX notes_verse ON notes(verse_id);
+CREATE TABLE cross_references (
+	id             INTEGER PRIMARY KEY,
+	from_verse_id  INTEGER NOT NULL REFERENCES verses(id),
+	to_book_id     TEXT NOT NULL,
+	to_chapter     INTEGER NOT NULL,
+	to_verse       INTEGER NOT NULL,
+	to_end_chapter INTEGER,
+	to_end_verse   INTEGER,
+	to_verse_id    INTEGER REFERENCES verses(id),
+	votes          INTEGER NOT NULL
+);
+CREATE INDEX cross_references_from ON cross_references(from_verse_id, votes DESC);
+CREATE VIRTUAL TABLE verses_fts USING fts5(
+	text,
+	content = 'verses',
+	content_rowid = 'id',
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+`
+
+// Options configures a bundle.
+type Options struct {
+	// Translation describes the text in the meta table; its versification
+	// is the numbering that the cross-references are converted to.
+	Translation translation.Translation
+	// CrossReferences, when set, adds the cross-references with at least
+	// MinVotes votes.
+	CrossReferences crossref.Repository
+	MinVotes        int
+}
+
+// Version returns the version of the bundle that Write builds from the
+// same data and options: a hash of the schema version, the data versions
+// and the options. Clients compare it to decide whether to download again.
+func Version(books bible.Repository, opts Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s", SchemaVersion, opts.Translation.Id, opts.Translation.Versification, books.DataVersion())
+	if opts.CrossReferences != nil {
+		fmt.Fprintf(h, "\x00%s\x00%d", opts.CrossReferences.DataVersion(), opts.MinVotes)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Write builds a bundle of every book of books in a new database at path,
+// which must not exist yet.
+func Write(path string, books bible.Repository, opts Options) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	scheme, ok := versification.Lookup(cmp.Or(opts.Translation.Versification, versification.Standard))
+	if !ok {
+		return fmt.Errorf("unknown versification %q", opts.Translation.Versification)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// One connection, so that the pragmas apply to all statements.
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		"PRAGMA journal_mode = OFF",
+		"PRAGMA synchronous = OFF",
+		"PRAGMA user_version = " + strconv.Itoa(SchemaVersion),
+		schema,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	w := &writer{tx: tx, scheme: scheme, ids: map[versification.Verse]int64{}}
+	meta := map[string]string{
+		"schema_version":        strconv.Itoa(SchemaVersion),
+		"version":               Version(books, opts),
+		"data_version":          books.DataVersion(),
+		"translation":           opts.Translation.Id,
+		"translation_name":      opts.Translation.Name,
+		"language":              opts.Translation.Language,
+		"versification":         scheme.Name(),
+		"cross_reference_votes": strconv.Itoa(opts.MinVotes),
+	}
+	if opts.CrossReferences != nil {
+		meta["cross_reference_version"] = opts.CrossReferences.DataVersion()
+	}
+	for key, value := range meta {
+		if _, err := tx.Exec("INSERT INTO meta (key, value) VALUES (?, ?)", key, value); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range books.GetBooks() {
+		book, err := books.ReadSourceBook(b.Id)
+		if err != nil {
+			return fmt.Errorf("book %s: %w", b.Id, err)
+		}
+		if err := w.book(b, book); err != nil {
+			return fmt.Errorf("book %s: %w", b.Id, err)
+		}
+	}
+	if opts.CrossReferences != nil {
+		for _, b := range books.GetBooks() {
+			if err := w.crossReferences(b.Id, opts.CrossReferences, opts.MinVotes); err != nil {
+				return fmt.Errorf("cross-references of %s: %w", b.Id, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO verses_fts (verses_fts) VALUES ('rebuild')"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		"INSERT INTO verses_fts (verses_fts) VALUES ('optimize')",
+		"VACUUM",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return db.Close()
+}
+
+type writer struct {
+	tx     *sql.Tx
+	scheme *versification.Scheme
+	// ids maps the verses written so far to their row id.
+	ids map[versification.Verse]int64
+}
+
+func (w *writer) book(meta bible.BookMetadata, book bible.SourceBook) error {
+	_, err := w.tx.Exec("INSERT INTO books (id, name, position, chapters, verse_count) VALUES (?, ?, ?, ?, ?)",
+		meta.Id, meta.Name, meta.Order, book.Chapters, book.VerseCount)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range book.Verses {
+		var html string
+		if v.TextJson != nil {
+			html = v.TextJson.InnerHTML()
+		}
+		ref := fmt.Sprintf("%s.%d.%d", meta.Id, v.Chapter, v.Verse)
+		res, err := w.tx.Exec("INSERT INTO verses (ref, book_id, chapter, verse, paragraph, text, html) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			ref, meta.Id, v.Chapter, v.Verse, v.Paragraph == "y", v.CleanText(), html)
+		if err != nil {
+			return fmt.Errorf("verse %s: %w", ref, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		w.ids[versification.Verse{Book: meta.Id, Chapter: v.Chapter, Verse: v.Verse}] = id
+
+		if v.Title != nil && strings.TrimSpace(*v.Title) != "" {
+			title := strings.Join(strings.Fields(*v.Title), " ")
+			if _, err := w.tx.Exec("INSERT INTO section_titles (verse_id, title) VALUES (?, ?)", id, title); err != nil {
+				return err
+			}
+		}
+		if v.TextJson != nil {
+			if err := w.notes(id, *v.TextJson); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notes writes the abbr footnotes of a verse in reading order.
+func (w *writer) notes(verseId int64, root bible.Node) error {
+	seq := 0
+	var walk func(n bible.Node) error
+	walk = func(n bible.Node) error {
+		if n.Tag == "abbr" {
+			seq++
+			_, err := w.tx.Exec("INSERT INTO notes (verse_id, seq, ref, title) VALUES (?, ?, ?, ?)",
+				verseId, seq, strings.Join(strings.Fields(n.Ref), " "), strings.Join(strings.Fields(n.Title), " "))
+			return err
+		}
+		for _, child := range n.Children {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root)
+}
+
+// find returns the row id of a verse cited as in the cross-references.
+func (w *writer) find(v versification.Verse) (int64, bool) {
+	v.Chapter, _ = bible.FindChapter(v.Chapter, func(c int) bool {
+		_, ok := w.ids[versification.Verse{Book: v.Book, Chapter: c, Verse: v.Verse}]
+		return ok
+	})
+	id, ok := w.ids[v]
+	return id, ok
+}
+
+// crossReferences writes the cross-references of a book, converted from
+// the English numbering of the source data to the bundle's versification.
+// Targets outside the bundle keep their numbers without a to_verse_id.
+func (w *writer) crossReferences(bookId string, repo crossref.Repository, minVotes int) error {
+	refs, err := repo.GetCrossReferences(bookId)
+	if err != nil {
+		// Not every book has cross-reference data.
+		return nil
+	}
+	kjv, _ := versification.Lookup(versification.KJV)
+	stmt, err := w.tx.Prepare(`INSERT INTO cross_references
+		(from_verse_id, to_book_id, to_chapter, to_verse, to_end_chapter, to_end_verse, to_verse_id, votes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	convert := func(v versification.Verse) versification.Verse {
+		if c := versification.Convert(v, kjv, w.scheme); len(c) > 0 {
+			return c[0]
+		}
+		return v
+	}
+
+	for _, ref := range refs.CrossReferences {
+		if ref.Votes < minVotes {
+			continue
+		}
+		toBook, err := repo.EnglishToDutch(ref.To.Book)
+		if err != nil {
+			continue
+		}
+		to := convert(versification.Verse{Book: toBook, Chapter: ref.To.Chapter, Verse: ref.To.Verse})
+		var endChapter, endVerse, toId any
+		if ref.To.EndVerse > 0 && (ref.To.EndBook == "" || ref.To.EndBook == ref.To.Book) {
+			end := convert(versification.Verse{Book: toBook, Chapter: cmp.Or(ref.To.EndChapter, ref.To.Chapter), Verse: ref.To.EndVerse})
+			endChapter, endVerse = end.Chapter, end.Verse
+		}
+		if id, ok := w.find(to); ok {
+			toId = id
+		}
+
+		for _, from := range versification.Convert(versification.Verse{Book: bookId, Chapter: ref.From.Chapter, Verse: ref.From.Verse}, kjv, w.scheme) {
+			fromId, ok := w.find(from)
+			if !ok {
+				continue
+			}
+			if _, err := stmt.Exec(fromId, to.Book, to.Chapter, to.Verse, endChapter, endVerse, toId, ref.Votes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package bundle
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+func newTestRepositories(t *testing.T) (bible.Repository, crossref.Repository) {
+	t.Helper()
+	books, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"genesis","name":"Genesis","order":1},{"id":"judas","name":"Judas","order":72}]`)},
+		"books/genesis.json": {Data: []byte(`{"id":"genesis","name":"Genesis","chapters":1,"verseCount":2,"verses":[
+			{"chapter":1,"verse":2,"id":"genesis.1.2","text":"De aarde was woest en leeg","paragraph":"n",
+			 "textJson":{"tag":"p","children":[{"text":"De aarde was woest en leeg"}]}},
+			{"chapter":1,"verse":1,"id":"genesis.1.1","text":"In het begin schiep God","paragraph":"y","title":"De  schepping",
+			 "textJson":{"tag":"p","children":[{"text":"In het begin schiep God"},{"tag":"abbr","ref":"Joh. 1,1","title":"Vgl. Joh. 1,1","children":[{"text":"*"}]}]}}]}`)},
+		"books/judas.json": {Data: []byte(`{"id":"judas","name":"Judas","chapters":1,"verseCount":1,"verses":[
+			{"chapter":0,"verse":1,"id":"judas.0.1","text":"Judas, dienaar van Jezus Christus","paragraph":"y",
+			 "textJson":{"tag":"p","children":[{"text":"Judas, dienaar van Jezus Christus"}]}}]}`)},
+	})
+	require.NoError(t, err)
+
+	refs, err := crossref.NewFSRepository(fstest.MapFS{
+		"book-mapping.json": {Data: []byte(`{"mappings":{"Gen":"genesis","Jude":"judas","Matt":"matteus"}}`)},
+		"index.json":        {Data: []byte(`{"totalBooks":1,"books":[{"book":"Gen","file":"genesis.json","referenceCount":3}]}`)},
+		"genesis.json": {Data: []byte(`{"book":"Gen","totalReferences":3,"crossReferences":[
+			{"from":{"chapter":1,"verse":1},"to":{"book":"Jude","chapter":1,"verse":1},"votes":30},
+			{"from":{"chapter":1,"verse":1},"to":{"book":"Matt","chapter":1,"verse":1,"endVerse":3},"votes":25},
+			{"from":{"chapter":1,"verse":2},"to":{"book":"Gen","chapter":1,"verse":1},"votes":2}]}`)},
+	})
+	require.NoError(t, err)
+	return books, refs
+}
+
+func TestWrite(t *testing.T) {
+	books, refs := newTestRepositories(t)
+	opts := Options{Translation: translation.DefaultTranslation, CrossReferences: refs, MinVotes: 10}
+	path := filepath.Join(t.TempDir(), "bundle.sqlite")
+	require.NoError(t, Write(path, books, opts))
+	require.Error(t, Write(path, books, opts), "existing file")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	queryInt := func(query string) int {
+		var n int
+		require.NoError(t, db.QueryRow(query).Scan(&n), query)
+		return n
+	}
+	queryString := func(query string) string {
+		var s string
+		require.NoError(t, db.QueryRow(query).Scan(&s), query)
+		return s
+	}
+
+	require.Equal(t, SchemaVersion, queryInt("PRAGMA user_version"))
+	require.Equal(t, Version(books, opts), queryString("SELECT value FROM meta WHERE key = 'version'"))
+	require.Equal(t, books.DataVersion(), queryString("SELECT value FROM meta WHERE key = 'data_version'"))
+
+	require.Equal(t, 2, queryInt("SELECT count(*) FROM books"))
+	require.Equal(t, 3, queryInt("SELECT count(*) FROM verses"))
+	require.Equal(t, "De schepping", queryString(
+		"SELECT title FROM section_titles JOIN verses ON verses.id = verse_id WHERE ref = 'genesis.1.1'"))
+	require.Equal(t, "Vgl. Joh. 1,1", queryString(
+		"SELECT notes.title FROM notes JOIN verses ON verses.id = verse_id WHERE verses.ref = 'genesis.1.1' AND seq = 1"))
+
+	// Only the references above the threshold; the one to Judas resolves to
+	// its chapter 0, the one to Matteus has no verse in the bundle.
+	require.Equal(t, 2, queryInt("SELECT count(*) FROM cross_references"))
+	require.Equal(t, "judas.0.1", queryString(
+		"SELECT v.ref FROM cross_references c JOIN verses v ON v.id = c.to_verse_id"))
+	require.Equal(t, 3, queryInt("SELECT to_end_verse FROM cross_references WHERE to_book_id = 'matteus' AND to_verse_id IS NULL"))
+
+	// The full-text index ignores case and diacritics.
+	require.Equal(t, "genesis.1.2", queryString(
+		"SELECT ref FROM verses WHERE id IN (SELECT rowid FROM verses_fts WHERE verses_fts MATCH 'WOEST')"))
+	require.Equal(t, 3, queryInt("SELECT count(*) FROM verses_fts WHERE verses_fts MATCH 'God OR aarde OR dienaar'"))
+}
+
+func TestVersion(t *testing.T) {
+	books, refs := newTestRepositories(t)
+	opts := Options{Translation: translation.DefaultTranslation, CrossReferences: refs}
+
+	v := Version(books, opts)
+	require.Len(t, v, 16)
+	require.Equal(t, v, Version(books, opts))
+
+	opts.MinVotes = 5
+	require.NotEqual(t, v, Version(books, opts))
+	opts.CrossReferences = nil
+	require.NotEqual(t, v, Version(books, opts))
+}
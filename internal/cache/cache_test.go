@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Add("genesis", 1)
+	c.Add("exodus", 2)
+
+	// Touch genesis so exodus becomes the least recently used entry.
+	if _, ok := c.Get("genesis"); !ok {
+		t.Fatal("expected genesis to be cached")
+	}
+	c.Add("leviticus", 3)
+
+	if _, ok := c.Get("exodus"); ok {
+		t.Error("expected exodus to be evicted")
+	}
+	if v, ok := c.Get("genesis"); !ok || v != 1 {
+		t.Errorf("Get(genesis) = %v, %v, want 1, true", v, ok)
+	}
+	if v, ok := c.Get("leviticus"); !ok || v != 3 {
+		t.Errorf("Get(leviticus) = %v, %v, want 3, true", v, ok)
+	}
+	if got := c.Stats().Entries; got != 2 {
+		t.Errorf("Entries = %d, want 2", got)
+	}
+}
+
+func TestLRUGetOrLoad(t *testing.T) {
+	c := NewLRU[string, string](4)
+	loads := 0
+	load := func(key string) (string, error) {
+		loads++
+		if key == "missing" {
+			return "", errors.New("not found")
+		}
+		return "book:" + key, nil
+	}
+
+	for range 3 {
+		v, err := c.GetOrLoad("ruth", load)
+		if err != nil || v != "book:ruth" {
+			t.Fatalf("GetOrLoad(ruth) = %q, %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("load called %d times, want 1", loads)
+	}
+
+	if _, err := c.GetOrLoad("missing", load); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("errors must not be cached")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("Stats() = %+v, want 2 hits and 3 misses", stats)
+	}
+	if got, want := stats.HitRatio(), 0.4; got != want {
+		t.Errorf("HitRatio() = %v, want %v", got, want)
+	}
+}
+
+func TestLRUPurge(t *testing.T) {
+	c := NewLRU[int, int](4)
+	c.Add(1, 1)
+	c.Purge()
+
+	if _, ok := c.Get(1); ok {
+		t.Error("expected cache to be empty after Purge")
+	}
+	if (Stats{}).HitRatio() != 0 {
+		t.Error("expected zero hit ratio for unused cache")
+	}
+}
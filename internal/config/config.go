@@ -0,0 +1,438 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to every environment variable the service reads.
+const EnvPrefix = "BIJBEL_"
+
+// Config holds all runtime settings of the API server.
+type Config struct {
+	Addr string `yaml:"addr"`
+	// FrontendURL is the address of the web app, which calendar feeds link
+	// to.
+	FrontendURL string         `yaml:"frontendURL"`
+	CORS        CORSConfig     `yaml:"cors"`
+	Timeouts    TimeoutsConfig `yaml:"timeouts"`
+	Data        DataConfig     `yaml:"data"`
+	Accounts    AccountsConfig `yaml:"accounts"`
+	Log         LogConfig      `yaml:"log"`
+}
+
+// CORSConfig lists the origins that may call the API from a browser.
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+}
+
+// TimeoutsConfig holds the http.Server timeouts and the graceful shutdown
+// window.
+type TimeoutsConfig struct {
+	ReadHeader time.Duration `yaml:"readHeader"`
+	Read       time.Duration `yaml:"read"`
+	Write      time.Duration `yaml:"write"`
+	Idle       time.Duration `yaml:"idle"`
+	Shutdown   time.Duration `yaml:"shutdown"`
+}
+
+// Data sources.
+const (
+	SourceEmbedded  = "embedded"
+	SourceDirectory = "directory"
+)
+
+// DataConfig selects where books and cross-references are read from. With
+// the directory source, BooksDir and CrossrefDir point to on-disk copies of
+// the bundled data files; a directory left empty falls back to the embedded
+// data. Translations lists additional texts, which are always read from
+// disk. The directories are polled every WatchInterval, zero disables
+// polling so that only SIGHUP triggers a reload. PlansDir holds curated
+// reading plans as *.json files, next to the generated plans. VersesFile
+// replaces the bundled list of verses of the day.
+type DataConfig struct {
+	Source        string              `yaml:"source"`
+	BooksDir      string              `yaml:"booksDir"`
+	CrossrefDir   string              `yaml:"crossrefDir"`
+	PlansDir      string              `yaml:"plansDir"`
+	VersesFile    string              `yaml:"versesFile"`
+	WatchInterval time.Duration       `yaml:"watchInterval"`
+	Translations  []TranslationConfig `yaml:"translations"`
+}
+
+// TranslationConfig describes an additional translation. Dir has the same
+// layout as BooksDir: books.json and books/*.json.
+type TranslationConfig struct {
+	Id            string `yaml:"id"`
+	Name          string `yaml:"name"`
+	Abbreviation  string `yaml:"abbreviation"`
+	Language      string `yaml:"language"`
+	Year          int    `yaml:"year"`
+	Versification string `yaml:"versification"`
+	Dir           string `yaml:"dir"`
+}
+
+// AccountsConfig sets where user accounts are kept. Database is the path of
+// an SQLite file, created if needed; left empty, accounts are disabled.
+// Sessions last SessionTTL after signing in.
+type AccountsConfig struct {
+	Database   string        `yaml:"database"`
+	SessionTTL time.Duration `yaml:"sessionTTL"`
+}
+
+// LogConfig selects the level and output format of the structured logs.
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() Config {
+	return Config{
+		Addr:        ":3000",
+		FrontendURL: "https://bijbel.fido21.nl",
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		Timeouts: TimeoutsConfig{
+			ReadHeader: 5 * time.Second,
+			Read:       15 * time.Second,
+			Write:      60 * time.Second,
+			Idle:       120 * time.Second,
+			Shutdown:   20 * time.Second,
+		},
+		Data: DataConfig{
+			Source:        SourceEmbedded,
+			WatchInterval: 2 * time.Second,
+		},
+		Accounts: AccountsConfig{
+			SessionTTL: 30 * 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, an optional YAML file, the
+// environment and command line flags, in increasing order of precedence.
+// The YAML file is taken from the -config flag or BIJBEL_CONFIG.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("bijbel-api", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		configFile = fs.String("config", "", "path to a YAML configuration file")
+		addr       = fs.String("addr", "", "listen address")
+		frontend   = fs.String("frontend-url", "", "address of the web app that calendar feeds link to")
+		origins    = fs.String("cors-origins", "", "comma separated list of allowed CORS origins")
+		readHeader = fs.Duration("read-header-timeout", 0, "time allowed to read request headers")
+		read       = fs.Duration("read-timeout", 0, "time allowed to read a full request")
+		write      = fs.Duration("write-timeout", 0, "time allowed to write a response")
+		idle       = fs.Duration("idle-timeout", 0, "how long idle keep-alive connections are kept")
+		shutdown   = fs.Duration("shutdown-timeout", 0, "how long to wait for in-flight requests on shutdown")
+		source     = fs.String("data-source", "", "where data is read from: embedded or directory")
+		booksDir   = fs.String("books-dir", "", "directory with books.json and books/*.json")
+		crossDir   = fs.String("crossref-dir", "", "directory with the cross-reference JSON files")
+		plansDir   = fs.String("plans-dir", "", "directory with curated reading plans as *.json files")
+		versesFile = fs.String("verses-file", "", "list of verses of the day replacing the bundled one")
+		watch      = fs.Duration("watch-interval", 0, "how often data directories are checked for changes, 0 disables")
+		accountsDB = fs.String("accounts-db", "", "SQLite database of user accounts, empty disables accounts")
+		sessionTTL = fs.Duration("session-ttl", 0, "how long a session lasts after signing in")
+		logLevel   = fs.String("log-level", "", "log level: debug, info, warn or error")
+		logFormat  = fs.String("log-format", "", "log format: json or text")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path = getenv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.loadEnv(getenv); err != nil {
+		return Config{}, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Addr = *addr
+		case "frontend-url":
+			cfg.FrontendURL = *frontend
+		case "cors-origins":
+			cfg.CORS.AllowedOrigins = splitList(*origins)
+		case "read-header-timeout":
+			cfg.Timeouts.ReadHeader = *readHeader
+		case "read-timeout":
+			cfg.Timeouts.Read = *read
+		case "write-timeout":
+			cfg.Timeouts.Write = *write
+		case "idle-timeout":
+			cfg.Timeouts.Idle = *idle
+		case "shutdown-timeout":
+			cfg.Timeouts.Shutdown = *shutdown
+		case "data-source":
+			cfg.Data.Source = *source
+		case "watch-interval":
+			cfg.Data.WatchInterval = *watch
+		case "books-dir":
+			cfg.Data.BooksDir = *booksDir
+		case "crossref-dir":
+			cfg.Data.CrossrefDir = *crossDir
+		case "plans-dir":
+			cfg.Data.PlansDir = *plansDir
+		case "verses-file":
+			cfg.Data.VersesFile = *versesFile
+		case "accounts-db":
+			cfg.Accounts.Database = *accountsDB
+		case "session-ttl":
+			cfg.Accounts.SessionTTL = *sessionTTL
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv(getenv func(string) string) error {
+	texts := map[string]*string{
+		"ADDR":         &c.Addr,
+		"FRONTEND_URL": &c.FrontendURL,
+		"DATA_SOURCE":  &c.Data.Source,
+		"BOOKS_DIR":    &c.Data.BooksDir,
+		"CROSSREF_DIR": &c.Data.CrossrefDir,
+		"PLANS_DIR":    &c.Data.PlansDir,
+		"VERSES_FILE":  &c.Data.VersesFile,
+		"ACCOUNTS_DB":  &c.Accounts.Database,
+		"LOG_LEVEL":    &c.Log.Level,
+		"LOG_FORMAT":   &c.Log.Format,
+	}
+	for name, field := range texts {
+		if value := getenv(EnvPrefix + name); value != "" {
+			*field = value
+		}
+	}
+
+	if value := getenv(EnvPrefix + "CORS_ORIGINS"); value != "" {
+		c.CORS.AllowedOrigins = splitList(value)
+	}
+
+	durations := map[string]*time.Duration{
+		"READ_HEADER_TIMEOUT": &c.Timeouts.ReadHeader,
+		"READ_TIMEOUT":        &c.Timeouts.Read,
+		"WRITE_TIMEOUT":       &c.Timeouts.Write,
+		"IDLE_TIMEOUT":        &c.Timeouts.Idle,
+		"SHUTDOWN_TIMEOUT":    &c.Timeouts.Shutdown,
+		"WATCH_INTERVAL":      &c.Data.WatchInterval,
+		"SESSION_TTL":         &c.Accounts.SessionTTL,
+	}
+	for name, field := range durations {
+		value := getenv(EnvPrefix + name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s%s: %w", EnvPrefix, name, err)
+		}
+		*field = d
+	}
+	return nil
+}
+
+// Validate reports every problem with the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	if _, port, err := net.SplitHostPort(c.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("addr %q: %w", c.Addr, err))
+	} else if port == "" {
+		errs = append(errs, fmt.Errorf("addr %q: missing port", c.Addr))
+	}
+
+	if u, err := url.Parse(c.FrontendURL); err != nil {
+		errs = append(errs, fmt.Errorf("frontendURL %q: %w", c.FrontendURL, err))
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("frontendURL %q: must be an http or https URL", c.FrontendURL))
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allowedOrigins: at least one origin is required"))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	timeouts := map[string]time.Duration{
+		"readHeader": c.Timeouts.ReadHeader,
+		"read":       c.Timeouts.Read,
+		"write":      c.Timeouts.Write,
+		"idle":       c.Timeouts.Idle,
+		"shutdown":   c.Timeouts.Shutdown,
+	}
+	for _, name := range []string{"readHeader", "read", "write", "idle", "shutdown"} {
+		if timeouts[name] <= 0 {
+			errs = append(errs, fmt.Errorf("timeouts.%s: must be positive, got %s", name, timeouts[name]))
+		}
+	}
+
+	switch c.Data.Source {
+	case SourceEmbedded:
+	case SourceDirectory:
+		if c.Data.BooksDir == "" && c.Data.CrossrefDir == "" {
+			errs = append(errs, errors.New("data.source: directory requires data.booksDir or data.crossrefDir"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("data.source: must be embedded or directory, got %q", c.Data.Source))
+	}
+	if c.Data.WatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("data.watchInterval: must not be negative, got %s", c.Data.WatchInterval))
+	}
+
+	dirs := []struct{ name, path string }{
+		{"data.booksDir", c.Data.BooksDir},
+		{"data.crossrefDir", c.Data.CrossrefDir},
+		{"data.plansDir", c.Data.PlansDir},
+	}
+	ids := make(map[string]bool)
+	for i, t := range c.Data.Translations {
+		name := fmt.Sprintf("data.translations[%d]", i)
+		if t.Id == "" {
+			errs = append(errs, fmt.Errorf("%s.id: is required", name))
+		} else if ids[t.Id] {
+			errs = append(errs, fmt.Errorf("%s.id: %s is used twice", name, t.Id))
+		}
+		ids[t.Id] = true
+		if t.Dir == "" {
+			errs = append(errs, fmt.Errorf("%s.dir: is required", name))
+		}
+		dirs = append(dirs, struct{ name, path string }{name + ".dir", t.Dir})
+	}
+	for _, dir := range dirs {
+		if dir.path == "" {
+			continue
+		}
+		info, err := os.Stat(dir.path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dir.name, err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("%s: %s is not a directory", dir.name, dir.path))
+		}
+	}
+	if c.Data.VersesFile != "" {
+		info, err := os.Stat(c.Data.VersesFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("data.versesFile: %w", err))
+		} else if !info.Mode().IsRegular() {
+			errs = append(errs, fmt.Errorf("data.versesFile: %s is not a file", c.Data.VersesFile))
+		}
+	}
+
+	if c.Accounts.Database != "" {
+		dir := filepath.Dir(c.Accounts.Database)
+		if info, err := os.Stat(dir); err != nil {
+			errs = append(errs, fmt.Errorf("accounts.database: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("accounts.database: %s is not a directory", dir))
+		}
+	}
+	if c.Accounts.SessionTTL <= 0 {
+		errs = append(errs, fmt.Errorf("accounts.sessionTTL: must be positive, got %s", c.Accounts.SessionTTL))
+	}
+
+	if _, err := c.Log.SlogLevel(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("log.format: must be json or text, got %q", c.Log.Format))
+	}
+
+	return errors.Join(errs...)
+}
+
+// SlogLevel parses the configured log level.
+func (l LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return level, fmt.Errorf("log.level: %w", err)
+	}
+	return level, nil
+}
+
+// originSchemes are the schemes of the origins browsers send: http and https
+// for the web app, capacitor and ionic for the iOS app under frontend/ios.
+var originSchemes = []string{"http", "https", "capacitor", "ionic"}
+
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "*", "0", 1))
+	if err != nil {
+		return fmt.Errorf("cors origin %q: %w", origin, err)
+	}
+	if !slices.Contains(originSchemes, u.Scheme) {
+		return fmt.Errorf("cors origin %q: scheme must be one of %s", origin, strings.Join(originSchemes, ", "))
+	}
+	if u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return fmt.Errorf("cors origin %q: must be of the form scheme://host[:port]", origin)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, env(nil))
+	require.NoError(t, err)
+	require.Equal(t, Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+addr: ":4000"
+cors:
+  allowedOrigins:
+    - https://bijbel.fido21.nl
+timeouts:
+  write: 45s
+  idle: 90s
+`), 0o644))
+
+	cfg, err := Load(
+		[]string{"-config", file, "-idle-timeout", "30s"},
+		env(map[string]string{
+			"BIJBEL_ADDR":          ":5000",
+			"BIJBEL_WRITE_TIMEOUT": "10s",
+		}),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, ":5000", cfg.Addr)                                             // env beats file
+	require.Equal(t, []string{"https://bijbel.fido21.nl"}, cfg.CORS.AllowedOrigins) // file beats default
+	require.Equal(t, 10*time.Second, cfg.Timeouts.Write)                            // env beats file
+	require.Equal(t, 30*time.Second, cfg.Timeouts.Idle)                             // flag beats file
+	require.Equal(t, Default().Timeouts.Read, cfg.Timeouts.Read)                    // untouched default
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("addr: \":4100\"\n"), 0o644))
+
+	cfg, err := Load(nil, env(map[string]string{
+		"BIJBEL_CONFIG":       file,
+		"BIJBEL_CORS_ORIGINS": "https://a.example, https://b.example",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, ":4100", cfg.Addr)
+	require.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORS.AllowedOrigins)
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("adress: \":4000\"\n"), 0o644))
+
+	_, err := Load([]string{"-config", file}, env(nil))
+	require.Error(t, err)
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"bad duration env", nil, map[string]string{"BIJBEL_READ_TIMEOUT": "soon"}},
+		{"missing port", []string{"-addr", "localhost"}, nil},
+		{"relative frontend url", []string{"-frontend-url", "/app"}, nil},
+		{"negative timeout", []string{"-write-timeout", "-1s"}, nil},
+		{"bad origin", []string{"-cors-origins", "bijbel.fido21.nl"}, nil},
+		{"origin with path", []string{"-cors-origins", "https://bijbel.fido21.nl/app"}, nil},
+		{"missing data dir", []string{"-books-dir", "/does/not/exist"}, nil},
+		{"missing plans dir", nil, map[string]string{"BIJBEL_PLANS_DIR": "/does/not/exist"}},
+		{"missing verses file", []string{"-verses-file", "/does/not/exist.txt"}, nil},
+		{"verses file is a directory", []string{"-verses-file", os.TempDir()}, nil},
+		{"missing accounts dir", []string{"-accounts-db", "/does/not/exist/users.db"}, nil},
+		{"zero session ttl", nil, map[string]string{"BIJBEL_SESSION_TTL": "0s"}},
+		{"unknown data source", []string{"-data-source", "s3"}, nil},
+		{"directory source without dirs", []string{"-data-source", "directory"}, nil},
+		{"negative watch interval", []string{"-watch-interval", "-1s"}, nil},
+		{"missing config file", []string{"-config", "/does/not/exist.yaml"}, nil},
+		{"unknown flag", []string{"-port", "3000"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.args, env(tt.env))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestValidateOrigins(t *testing.T) {
+	cfg := Default()
+	require.Equal(t, []string{"*"}, cfg.CORS.AllowedOrigins)
+	cfg.CORS.AllowedOrigins = []string{"*", "http://localhost:*", "https://bijbel.fido21.nl", "http://10.0.0.212:4173",
+		"capacitor://localhost", "ionic://localhost"}
+	require.NoError(t, cfg.Validate())
+
+	cfg.CORS.AllowedOrigins = []string{"ftp://bijbel.fido21.nl"}
+	require.Error(t, cfg.Validate())
+
+	cfg.CORS.AllowedOrigins = nil
+	require.Error(t, cfg.Validate())
+}
+
+func TestLoadLogSettings(t *testing.T) {
+	cfg, err := Load([]string{"-log-format", "text"}, env(map[string]string{"BIJBEL_LOG_LEVEL": "debug"}))
+	require.NoError(t, err)
+	require.Equal(t, "text", cfg.Log.Format)
+
+	level, err := cfg.Log.SlogLevel()
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelDebug, level)
+
+	_, err = Load([]string{"-log-level", "verbose"}, env(nil))
+	require.Error(t, err)
+
+	_, err = Load([]string{"-log-format", "xml"}, env(nil))
+	require.Error(t, err)
+}
+
+func TestLoadDataSource(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := Load([]string{"-data-source", "directory", "-books-dir", dir}, env(map[string]string{"BIJBEL_WATCH_INTERVAL": "500ms"}))
+	require.NoError(t, err)
+	require.Equal(t, SourceDirectory, cfg.Data.Source)
+	require.Equal(t, dir, cfg.Data.BooksDir)
+	require.Equal(t, 500*time.Millisecond, cfg.Data.WatchInterval)
+
+	cfg, err = Load([]string{"-plans-dir", dir}, env(nil))
+	require.NoError(t, err)
+	require.Equal(t, dir, cfg.Data.PlansDir)
+
+	file := filepath.Join(dir, "verses.txt")
+	require.NoError(t, os.WriteFile(file, []byte("start 2027-01-01\njohannes 3:16\n"), 0o644))
+	cfg, err = Load(nil, env(map[string]string{"BIJBEL_VERSES_FILE": file}))
+	require.NoError(t, err)
+	require.Equal(t, file, cfg.Data.VersesFile)
+}
+
+func TestLoadAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db")
+
+	cfg, err := Load([]string{"-session-ttl", "24h"}, env(map[string]string{"BIJBEL_ACCOUNTS_DB": path}))
+	require.NoError(t, err)
+	require.Equal(t, path, cfg.Accounts.Database)
+	require.Equal(t, 24*time.Hour, cfg.Accounts.SessionTTL)
+}
+
+func TestLoadTranslations(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+data:
+  translations:
+    - id: sv
+      name: Statenvertaling
+      language: nl
+      versification: kjv
+      dir: `+dir+`
+`), 0o644))
+
+	cfg, err := Load([]string{"-config", file}, env(nil))
+	require.NoError(t, err)
+	require.Len(t, cfg.Data.Translations, 1)
+	require.Equal(t, "sv", cfg.Data.Translations[0].Id)
+
+	cfg.Data.Translations = append(cfg.Data.Translations, TranslationConfig{Id: "sv"})
+	require.Error(t, cfg.Validate())
+}
@@ -1,21 +1,9 @@
 package crossref
 
 import (
-	"embed"
-	_ "embed"
-	"encoding/json"
-	"fmt"
+	"github.com/pschuurmans/bijbel-api/internal/cache"
 )
 
-//go:embed book-mapping.json
-var bookMappingData []byte
-
-//go:embed index.json
-var indexData []byte
-
-//go:embed *.json
-var crossRefFS embed.FS
-
 // BookMapping represents the mapping between English abbreviations and Dutch book IDs
 type BookMapping struct {
 	Description   string            `json:"description"`
@@ -66,79 +54,34 @@ type BookCrossReferences struct {
 	CrossReferences []CrossReference `json:"crossReferences"`
 }
 
-var mapping BookMapping
-var index CrossRefIndex
-
-func init() {
-	if err := json.Unmarshal(bookMappingData, &mapping); err != nil {
-		panic("failed to unmarshal book-mapping.json: " + err.Error())
-	}
-
-	if err := json.Unmarshal(indexData, &index); err != nil {
-		panic("failed to unmarshal index.json: " + err.Error())
-	}
-}
-
 // EnglishToDutch converts an English book abbreviation to a Dutch book ID
 func EnglishToDutch(englishAbbr string) (string, error) {
-	if dutchId, ok := mapping.Mappings[englishAbbr]; ok {
-		return dutchId, nil
-	}
-	return "", fmt.Errorf("no mapping found for book: %s", englishAbbr)
+	return Current().EnglishToDutch(englishAbbr)
 }
 
 // DutchToEnglish converts a Dutch book ID to an English abbreviation
 func DutchToEnglish(dutchId string) (string, error) {
-	for eng, dutch := range mapping.Mappings {
-		if dutch == dutchId {
-			return eng, nil
-		}
-	}
-	return "", fmt.Errorf("no mapping found for Dutch book: %s", dutchId)
+	return Current().DutchToEnglish(dutchId)
 }
 
-// GetCrossReferences loads cross-references for a Dutch book ID from embedded files
+// GetCrossReferences loads cross-references for a Dutch book ID using the index
 func GetCrossReferences(dutchBookId string) (*BookCrossReferences, error) {
-	englishAbbr, err := DutchToEnglish(dutchBookId)
-	if err != nil {
-		return nil, err
-	}
-
-	// Find the file in the index
-	var fileName string
-	for _, book := range index.Books {
-		if book.Book == englishAbbr {
-			fileName = book.File
-			break
-		}
-	}
-
-	if fileName == "" {
-		return nil, fmt.Errorf("cross-references not found for book: %s", dutchBookId)
-	}
-
-	// Load the JSON file from embedded filesystem
-	data, err := crossRefFS.ReadFile(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read cross-references for %s: %w", dutchBookId, err)
-	}
-
-	var refs BookCrossReferences
-	if err := json.Unmarshal(data, &refs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cross-references for %s: %w", dutchBookId, err)
-	}
+	return Current().GetCrossReferences(dutchBookId)
+}
 
-	return &refs, nil
+// CacheStats reports the hit ratio of the parsed cross-reference cache.
+func CacheStats() cache.Stats {
+	return Current().CacheStats()
 }
 
 // GetBookMapping returns the complete book mapping
 func GetBookMapping() BookMapping {
-	return mapping
+	return Current().GetBookMapping()
 }
 
 // GetIndex returns the cross-reference index
 func GetIndex() CrossRefIndex {
-	return index
+	return Current().GetIndex()
 }
 
 // HasCrossReferences checks if a Dutch book ID has cross-references available
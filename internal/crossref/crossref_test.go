@@ -1,6 +1,9 @@
 package crossref
 
 import (
+	"errors"
+	"io/fs"
+	"strings"
 	"testing"
 )
 
@@ -117,3 +120,50 @@ func TestGetIndex(t *testing.T) {
 		t.Errorf("Expected 66 books in index, got %d", len(index.Books))
 	}
 }
+
+func TestVerifyIndex(t *testing.T) {
+	err := VerifyIndex()
+
+	// The Isaiah and Psalms files are not bundled, so the index check has to
+	// report exactly those two entries.
+	if err == nil {
+		t.Fatal("expected VerifyIndex() to report the missing Isaiah and Psalms files")
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected missing file errors, got %v", err)
+	}
+	for _, file := range []string{"isa.json", "ps.json"} {
+		if !strings.Contains(err.Error(), file) {
+			t.Errorf("expected VerifyIndex() to report %s, got %v", file, err)
+		}
+	}
+	if got := strings.Count(err.Error(), "\n") + 1; got != 2 {
+		t.Errorf("expected 2 problems, got %d: %v", got, err)
+	}
+}
+
+func TestVerifyMapping(t *testing.T) {
+	ids := []string{}
+	for _, dutch := range GetBookMapping().Mappings {
+		ids = append(ids, dutch)
+	}
+	ids = append(ids, GetBookMapping().UnmappedBooks.Books...)
+
+	if err := VerifyMapping(ids); err != nil {
+		t.Errorf("VerifyMapping() = %v", err)
+	}
+
+	if err := VerifyMapping(append(ids, "henoch")); err == nil {
+		t.Error("expected error for a book without mapping")
+	}
+
+	if err := VerifyMapping(ids[1:]); err == nil {
+		t.Error("expected error for a mapping to an unknown book")
+	}
+}
+
+func TestDataVersion(t *testing.T) {
+	if got := DataVersion(); len(got) != 12 {
+		t.Errorf("DataVersion() = %q, want 12 characters", got)
+	}
+}
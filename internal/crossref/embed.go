@@ -0,0 +1,14 @@
+//go:build !packed
+
+package crossref
+
+import "embed"
+
+//go:embed *.json
+var embeddedFS embed.FS
+
+// NewEmbeddedRepository returns the cross-reference data compiled into the
+// binary.
+func NewEmbeddedRepository() (Repository, error) {
+	return newFSRepository(embeddedFS)
+}
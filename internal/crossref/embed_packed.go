@@ -0,0 +1,17 @@
+//go:build packed
+
+package crossref
+
+import _ "embed"
+
+// embeddedData is crossrefs.bin, compiled from the JSON files by go
+// generate.
+//
+//go:embed crossrefs.bin
+var embeddedData string
+
+// NewEmbeddedRepository returns the cross-reference data compiled into the
+// binary.
+func NewEmbeddedRepository() (Repository, error) {
+	return newPackedRepository(embeddedData)
+}
@@ -1,35 +1,13 @@
 package crossref
 
 import (
-	"embed"
-	"encoding/json"
 	"fmt"
-	"strings"
 )
 
-//go:embed *.json
-var crossRefFilesFS embed.FS
-
-// LoadCrossReferencesFromFS loads cross-references for a Dutch book ID from embedded files
+// LoadCrossReferencesFromFS loads cross-references for a Dutch book ID from
+// the file named after its English abbreviation
 func LoadCrossReferencesFromFS(dutchBookId string) (*BookCrossReferences, error) {
-	englishAbbr, err := DutchToEnglish(dutchBookId)
-	if err != nil {
-		return nil, err
-	}
-
-	fileName := strings.ToLower(englishAbbr) + ".json"
-
-	data, err := crossRefFilesFS.ReadFile(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read cross-references for %s: %w", dutchBookId, err)
-	}
-
-	var refs BookCrossReferences
-	if err := json.Unmarshal(data, &refs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cross-references for %s: %w", dutchBookId, err)
-	}
-
-	return &refs, nil
+	return Current().LoadCrossReferences(dutchBookId)
 }
 
 // GetCrossReferencesForVerse returns all cross-references for a specific verse
@@ -163,3 +163,25 @@ func TestFormatVerseRef(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadCrossReferencesIsCached(t *testing.T) {
+	before := CacheStats()
+
+	first, err := LoadCrossReferencesFromFS("ruth")
+	if err != nil {
+		t.Fatalf("LoadCrossReferencesFromFS failed: %v", err)
+	}
+	first.CrossReferences[0].Votes = -1
+
+	second, err := GetCrossReferences("ruth")
+	if err != nil {
+		t.Fatalf("GetCrossReferences failed: %v", err)
+	}
+
+	if second.CrossReferences[0].Votes == -1 {
+		t.Error("modifying returned cross-references must not change the cache")
+	}
+	if after := CacheStats(); after.Hits <= before.Hits {
+		t.Errorf("expected a cache hit, got %+v", after)
+	}
+}
@@ -0,0 +1,272 @@
+package crossref
+
+//go:generate go run ../../cmd/pack crossrefs -dir . -o crossrefs.bin
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+
+	"github.com/pschuurmans/bijbel-api/internal/binpack"
+)
+
+// packedMagic starts a packed cross-reference file; the digit is the format
+// version.
+const packedMagic = "BIJBELX1"
+
+// The root of a packed cross-reference file holds the data version of the
+// JSON it was compiled from, book-mapping.json, index.json and the name and
+// offset of every other JSON file. A file is stored as its book, total and
+// references; a reference as its from and to verses and votes. A verse is
+//
+//	flags, chapter, verse, [book], [end book], [end chapter], [end verse]
+//
+// where the flags tell which of the bracketed fields are present. An end
+// book equal to the book is only flagged.
+const (
+	refHasBook = 1 << iota
+	refHasEndBook
+	refEndBookIsBook
+	refHasEndChapter
+	refHasEndVerse
+)
+
+// WritePacked compiles the JSON files of fsys to the packed format that
+// NewPackedRepository loads.
+func WritePacked(w io.Writer, fsys fs.FS) error {
+	src, err := newFSRepository(fsys)
+	if err != nil {
+		return err
+	}
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return name == "book-mapping.json" || name == "index.json"
+	})
+
+	var e binpack.Encoder
+	offsets := make([]int, len(names))
+	for i, name := range names {
+		refs, err := src.readFile(name)
+		if err != nil {
+			return err
+		}
+		offsets[i] = e.Offset()
+		e.String(refs.Book)
+		e.Int(refs.TotalReferences)
+		e.Len(len(refs.CrossReferences))
+		for _, ref := range refs.CrossReferences {
+			packVerseRef(&e, ref.From)
+			packVerseRef(&e, ref.To)
+			e.Int(ref.Votes)
+		}
+	}
+
+	root := e.Offset()
+	e.String(src.DataVersion())
+
+	mapping := src.mapping
+	e.String(mapping.Description)
+	keys := slices.Sorted(maps.Keys(mapping.Mappings))
+	e.Len(len(keys))
+	for _, k := range keys {
+		e.String(k)
+		e.String(mapping.Mappings[k])
+	}
+	e.String(mapping.UnmappedBooks.Note)
+	packStrings(&e, mapping.UnmappedBooks.Books)
+
+	index := src.index
+	e.String(index.Source)
+	e.String(index.GeneratedDate)
+	e.Int(index.TotalBooks)
+	e.Len(len(index.Books))
+	for _, b := range index.Books {
+		e.String(b.Book)
+		e.String(b.File)
+		e.Int(b.ReferenceCount)
+	}
+
+	e.Len(len(names))
+	for i, name := range names {
+		e.String(name)
+		e.Len(offsets[i])
+	}
+	return e.WriteTo(w, packedMagic, root)
+}
+
+func packVerseRef(e *binpack.Encoder, v VerseRef) {
+	flags := 0
+	if v.Book != "" {
+		flags |= refHasBook
+	}
+	if v.EndBook != "" {
+		if v.EndBook == v.Book {
+			flags |= refEndBookIsBook
+		} else {
+			flags |= refHasEndBook
+		}
+	}
+	if v.EndChapter != 0 {
+		flags |= refHasEndChapter
+	}
+	if v.EndVerse != 0 {
+		flags |= refHasEndVerse
+	}
+
+	e.Len(flags)
+	e.Int(v.Chapter)
+	e.Int(v.Verse)
+	if flags&refHasBook != 0 {
+		e.String(v.Book)
+	}
+	if flags&refHasEndBook != 0 {
+		e.String(v.EndBook)
+	}
+	if flags&refHasEndChapter != 0 {
+		e.Int(v.EndChapter)
+	}
+	if flags&refHasEndVerse != 0 {
+		e.Int(v.EndVerse)
+	}
+}
+
+// packStrings writes a list that stays nil when decoded if it was nil.
+func packStrings(e *binpack.Encoder, list []string) {
+	if list == nil {
+		e.Len(0)
+		return
+	}
+	e.Len(len(list) + 1)
+	for _, s := range list {
+		e.String(s)
+	}
+}
+
+func unpackStrings(d *binpack.Reader) []string {
+	n := d.Len()
+	if n == 0 {
+		return nil
+	}
+	list := make([]string, n-1)
+	for i := range list {
+		list[i] = d.String()
+	}
+	return list
+}
+
+// NewPackedRepository loads a repository from data written by WritePacked.
+// The reference files are decoded when they are first requested.
+func NewPackedRepository(data []byte) (Repository, error) {
+	return newPackedRepository(string(data))
+}
+
+type packedRepository struct {
+	catalog
+	file        *binpack.File
+	files       map[string]int
+	dataVersion string
+}
+
+func newPackedRepository(data string) (*packedRepository, error) {
+	f, err := binpack.Open(data, packedMagic)
+	if err != nil {
+		return nil, err
+	}
+	r := &packedRepository{file: f, files: make(map[string]int)}
+
+	root := f.Root()
+	r.dataVersion = root.String()
+
+	var mapping BookMapping
+	mapping.Description = root.String()
+	if n := root.Len(); n > 0 {
+		mapping.Mappings = make(map[string]string, n)
+		for range n {
+			english, dutch := root.String(), root.String()
+			mapping.Mappings[english] = dutch
+		}
+	}
+	mapping.UnmappedBooks.Note = root.String()
+	mapping.UnmappedBooks.Books = unpackStrings(root)
+
+	var index CrossRefIndex
+	index.Source = root.String()
+	index.GeneratedDate = root.String()
+	index.TotalBooks = root.Int()
+	if n := root.Len(); n > 0 {
+		index.Books = make([]BookEntry, n)
+		for i := range index.Books {
+			index.Books[i] = BookEntry{Book: root.String(), File: root.String(), ReferenceCount: root.Int()}
+		}
+	}
+
+	for range root.Len() {
+		name, offset := root.String(), root.Len()
+		r.files[name] = offset
+	}
+	if err := root.Err(); err != nil {
+		return nil, err
+	}
+	r.catalog = newCatalog(mapping, index, r.readFile)
+	return r, nil
+}
+
+// readFile decodes a cross-reference file.
+func (r *packedRepository) readFile(fileName string) (*BookCrossReferences, error) {
+	offset, ok := r.files[fileName]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", fileName, fs.ErrNotExist)
+	}
+
+	d := r.file.Reader(offset)
+	refs := &BookCrossReferences{Book: d.String(), TotalReferences: d.Int()}
+	if n := d.Len(); n > 0 {
+		refs.CrossReferences = make([]CrossReference, n)
+		for i := range refs.CrossReferences {
+			refs.CrossReferences[i] = CrossReference{From: unpackVerseRef(d), To: unpackVerseRef(d), Votes: d.Int()}
+		}
+	}
+	if err := d.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return refs, nil
+}
+
+func unpackVerseRef(d *binpack.Reader) VerseRef {
+	flags := d.Len()
+	v := VerseRef{Chapter: d.Int(), Verse: d.Int()}
+	if flags&refHasBook != 0 {
+		v.Book = d.String()
+	}
+	switch {
+	case flags&refHasEndBook != 0:
+		v.EndBook = d.String()
+	case flags&refEndBookIsBook != 0:
+		v.EndBook = v.Book
+	}
+	if flags&refHasEndChapter != 0 {
+		v.EndChapter = d.Int()
+	}
+	if flags&refHasEndVerse != 0 {
+		v.EndVerse = d.Int()
+	}
+	return v
+}
+
+func (r *packedRepository) VerifyIndex() error {
+	return r.verifyIndex(func(fileName string) error {
+		if _, ok := r.files[fileName]; !ok {
+			return fmt.Errorf("%s: %w", fileName, fs.ErrNotExist)
+		}
+		return nil
+	})
+}
+
+func (r *packedRepository) DataVersion() string {
+	return r.dataVersion
+}
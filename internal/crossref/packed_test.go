@@ -0,0 +1,42 @@
+package crossref
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPackedRepository compiles the JSON data of this package and checks
+// that the packed repository returns exactly what the JSON one does.
+func TestPackedRepository(t *testing.T) {
+	fsys := os.DirFS(".")
+	var buf bytes.Buffer
+	require.NoError(t, WritePacked(&buf, fsys))
+
+	packed, err := NewPackedRepository(buf.Bytes())
+	require.NoError(t, err)
+	source, err := NewFSRepository(fsys)
+	require.NoError(t, err)
+
+	require.Equal(t, source.DataVersion(), packed.DataVersion())
+	require.Equal(t, source.GetBookMapping(), packed.GetBookMapping())
+	require.Equal(t, source.GetIndex(), packed.GetIndex())
+	// The data lacks the Isaiah and Psalms files listed in the index.
+	require.ErrorContains(t, source.VerifyIndex(), "isa.json")
+	require.ErrorContains(t, packed.VerifyIndex(), "isa.json")
+
+	for _, dutch := range source.GetBookMapping().Mappings {
+		want, wantErr := source.GetCrossReferences(dutch)
+		got, err := packed.GetCrossReferences(dutch)
+		require.Equal(t, wantErr == nil, err == nil, dutch)
+		require.Equal(t, want, got, dutch)
+
+		want, wantErr = source.LoadCrossReferences(dutch)
+		got, err = packed.LoadCrossReferences(dutch)
+		require.Equal(t, wantErr == nil, err == nil, dutch)
+		require.Equal(t, want, got, dutch)
+	}
+	t.Logf("packed %d bytes", buf.Len())
+}
@@ -0,0 +1,247 @@
+package crossref
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/memfs"
+)
+
+// Repository gives access to one set of cross-reference data: the book
+// mapping, the index and the per-book reference files.
+type Repository interface {
+	// EnglishToDutch converts an English book abbreviation to a Dutch book ID.
+	EnglishToDutch(englishAbbr string) (string, error)
+	// DutchToEnglish converts a Dutch book ID to an English abbreviation.
+	DutchToEnglish(dutchId string) (string, error)
+	// GetCrossReferences loads the cross-references of a book via the index.
+	GetCrossReferences(dutchBookId string) (*BookCrossReferences, error)
+	// LoadCrossReferences loads the cross-references of a book from the
+	// file named after its English abbreviation.
+	LoadCrossReferences(dutchBookId string) (*BookCrossReferences, error)
+	// GetBookMapping returns the complete book mapping.
+	GetBookMapping() BookMapping
+	// GetIndex returns the cross-reference index.
+	GetIndex() CrossRefIndex
+	// VerifyIndex checks the index against the files present.
+	VerifyIndex() error
+	// VerifyMapping checks the mapping against the given Dutch book IDs.
+	VerifyMapping(dutchBookIds []string) error
+	// DataVersion returns a short content hash of the data files.
+	DataVersion() string
+	// CacheStats reports the hit ratio of the parsed cross-reference cache.
+	CacheStats() cache.Stats
+}
+
+// NewDirRepository loads index.json, book-mapping.json and the per-book
+// files from dir into memory.
+func NewDirRepository(dir string) (Repository, error) {
+	fsys, err := memfs.Snapshot(dir, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	return newFSRepository(fsys)
+}
+
+// NewFSRepository loads a repository from any file system laid out like
+// this package's data directory.
+func NewFSRepository(fsys fs.FS) (Repository, error) {
+	return newFSRepository(fsys)
+}
+
+var current atomic.Pointer[Repository]
+
+func init() {
+	repo, err := NewEmbeddedRepository()
+	if err != nil {
+		panic(err.Error())
+	}
+	SetRepository(repo)
+}
+
+// Current returns the repository used by the package level functions.
+func Current() Repository {
+	return *current.Load()
+}
+
+// SetRepository atomically replaces the repository used by the package level
+// functions.
+func SetRepository(repo Repository) {
+	current.Store(&repo)
+}
+
+// catalog holds the book mapping and the index of a repository and
+// answers the lookups through them. load reads a cross-reference file by
+// name; the catalog caches its results.
+type catalog struct {
+	mapping BookMapping
+	index   CrossRefIndex
+	load    func(fileName string) (*BookCrossReferences, error)
+
+	// cache keeps recently parsed cross-reference files by file name.
+	cache *cache.LRU[string, *BookCrossReferences]
+}
+
+func newCatalog(mapping BookMapping, index CrossRefIndex, load func(string) (*BookCrossReferences, error)) catalog {
+	return catalog{
+		mapping: mapping,
+		index:   index,
+		load:    load,
+		cache:   cache.NewLRU[string, *BookCrossReferences](16),
+	}
+}
+
+// fsRepository reads the JSON files of a file system. Directory-backed
+// repositories, and the embedded one unless built with the packed tag, are
+// fsRepositories.
+type fsRepository struct {
+	catalog
+	fsys fs.FS
+
+	dataVersion func() string
+}
+
+func newFSRepository(fsys fs.FS) (*fsRepository, error) {
+	r := &fsRepository{fsys: fsys}
+
+	var mapping BookMapping
+	data, err := fs.ReadFile(fsys, "book-mapping.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read book-mapping.json: %w", err)
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal book-mapping.json: %w", err)
+	}
+
+	var index CrossRefIndex
+	data, err = fs.ReadFile(fsys, "index.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index.json: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index.json: %w", err)
+	}
+
+	r.catalog = newCatalog(mapping, index, r.readFile)
+	r.dataVersion = sync.OnceValue(r.hashFiles)
+	return r, nil
+}
+
+// readFile parses a cross-reference file.
+func (r *fsRepository) readFile(fileName string) (*BookCrossReferences, error) {
+	data, err := fs.ReadFile(r.fsys, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs BookCrossReferences
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", fileName, err)
+	}
+	return &refs, nil
+}
+
+func (c *catalog) EnglishToDutch(englishAbbr string) (string, error) {
+	if dutchId, ok := c.mapping.Mappings[englishAbbr]; ok {
+		return dutchId, nil
+	}
+	return "", fmt.Errorf("no mapping found for book: %s", englishAbbr)
+}
+
+func (c *catalog) DutchToEnglish(dutchId string) (string, error) {
+	for eng, dutch := range c.mapping.Mappings {
+		if dutch == dutchId {
+			return eng, nil
+		}
+	}
+	return "", fmt.Errorf("no mapping found for Dutch book: %s", dutchId)
+}
+
+func (c *catalog) GetCrossReferences(dutchBookId string) (*BookCrossReferences, error) {
+	englishAbbr, err := c.DutchToEnglish(dutchBookId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the file in the index
+	var fileName string
+	for _, book := range c.index.Books {
+		if book.Book == englishAbbr {
+			fileName = book.File
+			break
+		}
+	}
+
+	if fileName == "" {
+		return nil, fmt.Errorf("cross-references not found for book: %s", dutchBookId)
+	}
+
+	return c.readCrossReferences(dutchBookId, fileName)
+}
+
+func (c *catalog) LoadCrossReferences(dutchBookId string) (*BookCrossReferences, error) {
+	englishAbbr, err := c.DutchToEnglish(dutchBookId)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := strings.ToLower(englishAbbr) + ".json"
+
+	return c.readCrossReferences(dutchBookId, fileName)
+}
+
+// readCrossReferences returns the parsed contents of a cross-reference file,
+// served from the cache when possible. The returned value is a copy that the
+// caller may modify.
+func (c *catalog) readCrossReferences(dutchBookId, fileName string) (*BookCrossReferences, error) {
+	refs, err := c.cache.GetOrLoad(fileName, c.load)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cross-references for %s: %w", dutchBookId, err)
+	}
+
+	clone := *refs
+	clone.CrossReferences = slices.Clone(refs.CrossReferences)
+	return &clone, nil
+}
+
+func (c *catalog) GetBookMapping() BookMapping {
+	return c.mapping
+}
+
+func (c *catalog) GetIndex() CrossRefIndex {
+	return c.index
+}
+
+func (c *catalog) CacheStats() cache.Stats {
+	return c.cache.Stats()
+}
+
+func (r *fsRepository) DataVersion() string {
+	return r.dataVersion()
+}
+
+// hashFiles computes the data version over every JSON file.
+func (r *fsRepository) hashFiles() string {
+	h := sha256.New()
+
+	names, _ := fs.Glob(r.fsys, "*.json")
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := fs.ReadFile(r.fsys, name)
+		if err != nil {
+			continue
+		}
+		h.Write([]byte(name))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,41 @@
+package crossref
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSRepository(t *testing.T) {
+	fsys := fstest.MapFS{
+		"book-mapping.json": {Data: []byte(`{"mappings":{"Ruth":"ruth","Gen":"genesis"}}`)},
+		"index.json":        {Data: []byte(`{"totalBooks":1,"books":[{"book":"Ruth","file":"ruth.json","referenceCount":1}]}`)},
+		"ruth.json": {Data: []byte(`{"book":"Ruth","totalReferences":1,"crossReferences":[
+			{"from":{"chapter":1,"verse":1},"to":{"book":"Gen","chapter":12,"verse":10},"votes":5}]}`)},
+	}
+
+	repo, err := NewFSRepository(fsys)
+	if err != nil {
+		t.Fatalf("NewFSRepository() error = %v", err)
+	}
+
+	refs, err := repo.GetCrossReferences("ruth")
+	if err != nil {
+		t.Fatalf("GetCrossReferences() error = %v", err)
+	}
+	if len(refs.CrossReferences) != 1 || refs.CrossReferences[0].To.Book != "Gen" {
+		t.Errorf("GetCrossReferences() = %+v", refs.CrossReferences)
+	}
+	if err := repo.VerifyIndex(); err != nil {
+		t.Errorf("VerifyIndex() error = %v", err)
+	}
+	if err := repo.VerifyMapping([]string{"ruth"}); err == nil {
+		t.Errorf("VerifyMapping() error = nil, want unknown book genesis")
+	}
+}
+
+func TestNewFSRepositoryMissingIndex(t *testing.T) {
+	_, err := NewFSRepository(fstest.MapFS{"book-mapping.json": {Data: []byte(`{}`)}})
+	if err == nil {
+		t.Errorf("NewFSRepository() error = nil without index.json")
+	}
+}
@@ -0,0 +1,76 @@
+package crossref
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// VerifyIndex checks that every book in the index has a cross-reference file
+// and that every indexed book has a Dutch mapping.
+func VerifyIndex() error {
+	return Current().VerifyIndex()
+}
+
+// VerifyMapping checks that every Dutch book ID is either mapped to an
+// English abbreviation or explicitly listed as unmapped, and that the
+// mapping refers to no unknown books.
+func VerifyMapping(dutchBookIds []string) error {
+	return Current().VerifyMapping(dutchBookIds)
+}
+
+// DataVersion returns a short content hash of the cross-reference data.
+func DataVersion() string {
+	return Current().DataVersion()
+}
+
+func (r *fsRepository) VerifyIndex() error {
+	return r.verifyIndex(func(fileName string) error {
+		_, err := fs.Stat(r.fsys, fileName)
+		return err
+	})
+}
+
+// verifyIndex checks the index, using stat to check that a file exists.
+func (c *catalog) verifyIndex(stat func(fileName string) error) error {
+	var errs []error
+	for _, book := range c.index.Books {
+		if err := stat(book.File); err != nil {
+			errs = append(errs, fmt.Errorf("index entry %s: %w", book.Book, err))
+		}
+		if _, err := c.EnglishToDutch(book.Book); err != nil {
+			errs = append(errs, fmt.Errorf("index entry %s: %w", book.Book, err))
+		}
+	}
+	if len(c.index.Books) != c.index.TotalBooks {
+		errs = append(errs, fmt.Errorf("index lists %d books, totalBooks is %d", len(c.index.Books), c.index.TotalBooks))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *catalog) VerifyMapping(dutchBookIds []string) error {
+	var errs []error
+
+	known := make(map[string]bool, len(dutchBookIds))
+	for _, id := range dutchBookIds {
+		known[id] = true
+
+		_, err := c.DutchToEnglish(id)
+		mapped := err == nil
+		unmapped := slices.Contains(c.mapping.UnmappedBooks.Books, id)
+		switch {
+		case mapped && unmapped:
+			errs = append(errs, fmt.Errorf("book %s is both mapped and listed as unmapped", id))
+		case !mapped && !unmapped:
+			errs = append(errs, fmt.Errorf("book %s has no cross-reference mapping", id))
+		}
+	}
+
+	for eng, dutch := range c.mapping.Mappings {
+		if !known[dutch] {
+			errs = append(errs, fmt.Errorf("mapping %s refers to unknown book %s", eng, dutch))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,250 @@
+// Package datasource selects the book, cross-reference and translation
+// repositories configured for the server and swaps in fresh copies when the
+// data directories change.
+package datasource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+// Source loads the configured repositories and installs them as the ones
+// used by the bible and crossref packages.
+type Source struct {
+	cfg      config.DataConfig
+	logger   *slog.Logger
+	registry *translation.Registry
+
+	mu       sync.Mutex
+	onReload []func()
+}
+
+// New returns a Source for cfg. Nothing is loaded until Load is called.
+func New(cfg config.DataConfig, logger *slog.Logger) *Source {
+	return &Source{cfg: cfg, logger: logger, registry: translation.NewRegistry()}
+}
+
+// Registry returns the translations loaded by the source.
+func (s *Source) Registry() *translation.Registry {
+	return s.registry
+}
+
+// OnReload registers fn to be called after new repositories were installed.
+func (s *Source) OnReload(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onReload = append(s.onReload, fn)
+}
+
+// Directory reports whether the default text is read from disk.
+func (s *Source) Directory() bool {
+	return s.cfg.Source == config.SourceDirectory
+}
+
+// Reloadable reports whether any data is read from disk.
+func (s *Source) Reloadable() bool {
+	return s.Directory() || len(s.cfg.Translations) > 0
+}
+
+// Load reads the repositories and swaps them in. Books of every translation
+// must pass bible.Verify; when they don't, or a directory can't be read, the
+// repositories in use are kept and the error is returned. Requests that
+// already hold a repository finish with the data they started with.
+func (s *Source) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var books bible.Repository
+	var crossrefs crossref.Repository
+	if s.Directory() {
+		var err error
+		if books, crossrefs, err = s.open(); err != nil {
+			return err
+		}
+		if err := books.Verify(); err != nil {
+			return fmt.Errorf("books failed verification: %w", err)
+		}
+	}
+
+	translations, err := s.openTranslations()
+	if err != nil {
+		return err
+	}
+	if err := s.registry.Replace(translations); err != nil {
+		return err
+	}
+	if books != nil {
+		bible.SetRepository(books)
+		crossref.SetRepository(crossrefs)
+	}
+
+	for _, fn := range s.onReload {
+		fn()
+	}
+
+	attrs := []any{slog.String("source", s.cfg.Source), slog.Int("translations", len(translations))}
+	if books != nil {
+		attrs = append(attrs,
+			slog.String("books", books.DataVersion()),
+			slog.String("crossrefs", crossrefs.DataVersion()))
+	}
+	s.logger.Info("data loaded", attrs...)
+	return nil
+}
+
+func (s *Source) openTranslations() ([]translation.Entry, error) {
+	entries := make([]translation.Entry, 0, len(s.cfg.Translations))
+	for _, t := range s.cfg.Translations {
+		repo, err := bible.NewDirRepository(t.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load translation %s: %w", t.Id, err)
+		}
+		if err := repo.Verify(); err != nil {
+			return nil, fmt.Errorf("translation %s failed verification: %w", t.Id, err)
+		}
+		entries = append(entries, translation.Entry{
+			Translation: translation.Translation{
+				Id:            t.Id,
+				Name:          t.Name,
+				Abbreviation:  t.Abbreviation,
+				Language:      t.Language,
+				Year:          t.Year,
+				Versification: t.Versification,
+			},
+			Repository: repo,
+		})
+	}
+	return entries, nil
+}
+
+func (s *Source) open() (bible.Repository, crossref.Repository, error) {
+	books, err := bible.NewEmbeddedRepository()
+	if s.cfg.BooksDir != "" {
+		books, err = bible.NewDirRepository(s.cfg.BooksDir)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load books: %w", err)
+	}
+
+	crossrefs, err := crossref.NewEmbeddedRepository()
+	if s.cfg.CrossrefDir != "" {
+		crossrefs, err = crossref.NewDirRepository(s.cfg.CrossrefDir)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load cross-references: %w", err)
+	}
+	return books, crossrefs, nil
+}
+
+// Watch reloads the data whenever a signal arrives on signals and, for the
+// directory source and additional translations, whenever the directories
+// change. A change is only acted upon once two consecutive polls see the
+// same files, so that a copy in progress is not loaded halfway. Watch
+// returns when ctx is cancelled.
+func (s *Source) Watch(ctx context.Context, signals <-chan os.Signal) {
+	var tick <-chan time.Time
+	if s.Reloadable() && s.cfg.WatchInterval > 0 {
+		ticker := time.NewTicker(s.cfg.WatchInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	dirs := s.dirs()
+	loaded, _ := Fingerprint(dirs...)
+	pending := ""
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			if !s.Reloadable() {
+				s.logger.Info("ignoring reload signal, data is embedded", slog.String("signal", sig.String()))
+				continue
+			}
+			s.logger.Info("reloading data", slog.String("trigger", sig.String()))
+			loaded, _ = Fingerprint(dirs...)
+			s.reload()
+		case <-tick:
+			current, err := Fingerprint(dirs...)
+			if err != nil {
+				s.logger.Warn("failed to scan data directories", slog.Any("error", err))
+				continue
+			}
+			switch {
+			case current == loaded:
+				pending = ""
+			case current != pending:
+				pending = current
+			default:
+				s.logger.Info("reloading data", slog.String("trigger", "change"))
+				loaded, pending = current, ""
+				s.reload()
+			}
+		}
+	}
+}
+
+func (s *Source) reload() {
+	if err := s.Load(); err != nil {
+		s.logger.Error("reload failed, keeping current data", slog.Any("error", err))
+	}
+}
+
+func (s *Source) dirs() []string {
+	var candidates, dirs []string
+	if s.Directory() {
+		candidates = append(candidates, s.cfg.BooksDir, s.cfg.CrossrefDir)
+	}
+	for _, t := range s.cfg.Translations {
+		candidates = append(candidates, t.Dir)
+	}
+	for _, dir := range candidates {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Fingerprint summarises the names, sizes and modification times of every
+// file below dirs. It changes whenever a file is added, removed or written.
+func Fingerprint(dirs ...string) (string, error) {
+	h := sha256.New()
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			h.Write([]byte(path))
+			h.Write([]byte(strconv.FormatInt(info.Size(), 10)))
+			h.Write([]byte(strconv.FormatInt(info.ModTime().UnixNano(), 10)))
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
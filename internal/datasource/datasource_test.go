@@ -0,0 +1,177 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/config"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+const ruth = `{"id":"ruth","name":"Ruth","chapters":1,"verseCount":1,"verses":[
+	{"chapter":1,"verse":1,"id":"ruth.1.1","text":"%s","paragraph":"y"}]}`
+
+func writeBooks(t *testing.T, dir, text string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "books"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books.json"), []byte(`[{"id":"ruth","name":"Ruth","order":1}]`), 0o644))
+	data := []byte(fmt.Sprintf(ruth, text))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books", "ruth.json"), data, 0o644))
+}
+
+func restoreEmbedded(t *testing.T) {
+	books, refs := bible.Current(), crossref.Current()
+	t.Cleanup(func() {
+		bible.SetRepository(books)
+		crossref.SetRepository(refs)
+	})
+}
+
+func verseText(t *testing.T) string {
+	t.Helper()
+	chapter, err := bible.GetChapter("ruth", 1)
+	require.NoError(t, err)
+	require.Len(t, chapter.Verses, 1)
+	return chapter.Verses[0].Text
+}
+
+func newSource(dir string, interval time.Duration) *Source {
+	return New(config.DataConfig{
+		Source:        config.SourceDirectory,
+		BooksDir:      dir,
+		WatchInterval: interval,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestLoadDirectory(t *testing.T) {
+	restoreEmbedded(t)
+	dir := t.TempDir()
+	writeBooks(t, dir, "Eerste versie")
+
+	source := newSource(dir, 0)
+	reloads := 0
+	source.OnReload(func() { reloads++ })
+
+	require.NoError(t, source.Load())
+	require.Equal(t, "Eerste versie", verseText(t))
+	require.Equal(t, 1, reloads)
+
+	// Cross-references were not configured and stay embedded.
+	require.True(t, crossref.HasCrossReferences("genesis"))
+}
+
+func TestLoadKeepsDataOnFailure(t *testing.T) {
+	restoreEmbedded(t)
+	dir := t.TempDir()
+	writeBooks(t, dir, "Eerste versie")
+
+	source := newSource(dir, 0)
+	require.NoError(t, source.Load())
+
+	// A book whose verse count doesn't match fails verification.
+	broken := `{"id":"ruth","name":"Ruth","chapters":1,"verseCount":3,"verses":[]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books", "ruth.json"), []byte(broken), 0o644))
+
+	require.Error(t, source.Load())
+	require.Equal(t, "Eerste versie", verseText(t))
+}
+
+func TestWatchReloadsOnChange(t *testing.T) {
+	restoreEmbedded(t)
+	dir := t.TempDir()
+	writeBooks(t, dir, "Eerste versie")
+
+	source := newSource(dir, 10*time.Millisecond)
+	require.NoError(t, source.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go source.Watch(ctx, nil)
+
+	time.Sleep(30 * time.Millisecond)
+	writeBooks(t, dir, "Tweede versie")
+
+	require.Eventually(t, func() bool {
+		chapter, err := bible.GetChapter("ruth", 1)
+		return err == nil && len(chapter.Verses) == 1 && chapter.Verses[0].Text == "Tweede versie"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchReloadsOnSignal(t *testing.T) {
+	restoreEmbedded(t)
+	dir := t.TempDir()
+	writeBooks(t, dir, "Eerste versie")
+
+	source := newSource(dir, 0)
+	require.NoError(t, source.Load())
+
+	reloaded := make(chan struct{}, 1)
+	source.OnReload(func() { reloaded <- struct{}{} })
+
+	signals := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go source.Watch(ctx, signals)
+
+	writeBooks(t, dir, "Tweede versie")
+	signals <- syscall.SIGHUP
+
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reload after SIGHUP")
+	}
+	require.Equal(t, "Tweede versie", verseText(t))
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	writeBooks(t, dir, "Eerste versie")
+
+	before, err := Fingerprint(dir)
+	require.NoError(t, err)
+
+	writeBooks(t, dir, "Tweede, langere versie")
+	after, err := Fingerprint(dir)
+	require.NoError(t, err)
+	require.NotEqual(t, before, after)
+
+	_, err = Fingerprint(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestLoadTranslations(t *testing.T) {
+	restoreEmbedded(t)
+	dir := t.TempDir()
+	writeBooks(t, dir, "In the days when the judges ruled")
+
+	source := New(config.DataConfig{
+		Source: config.SourceEmbedded,
+		Translations: []config.TranslationConfig{
+			{Id: "en", Name: "English", Language: "en", Versification: "kjv", Dir: dir},
+		},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.True(t, source.Reloadable())
+	require.NoError(t, source.Load())
+
+	tr, repo, ok := source.Registry().Get("en")
+	require.True(t, ok)
+	require.Equal(t, "English", tr.Name)
+
+	chapter, err := repo.GetChapter("ruth", 1)
+	require.NoError(t, err)
+	require.Equal(t, "In the days when the judges ruled", chapter.Verses[0].Text)
+
+	// The default translation is untouched.
+	require.Len(t, bible.GetBooks(), 73)
+}
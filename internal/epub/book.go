@@ -0,0 +1,279 @@
+package epub
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// bookDocument renders a book as XHTML: a section per chapter with its
+// section titles, paragraphs and poetry lines, followed by the notes of the
+// chapter.
+func (p *publication) bookDocument(b *book) string {
+	crossRefs := p.crossReferences(b.Id)
+	w := &bookWriter{p: p, book: b}
+	w.sb.WriteString(p.documentHead(b.Name))
+	w.sb.WriteString("<h1>" + esc(b.Name) + "</h1>\n")
+
+	chapter := -1
+	for _, v := range b.Verses {
+		if v.Chapter != chapter {
+			w.endChapter()
+			chapter = v.Chapter
+			w.inSection = true
+			w.sb.WriteString(fmt.Sprintf(`<section id="c%d">`+"\n", chapter))
+			if b.Chapters > 1 {
+				w.sb.WriteString(fmt.Sprintf("<h2>%s %d</h2>\n", esc(b.Name), bible.ChapterNumber(chapter)))
+			}
+		}
+		if v.Title != nil && strings.TrimSpace(*v.Title) != "" {
+			w.endParagraph()
+			w.sections++
+			id := fmt.Sprintf("s%d", w.sections)
+			title := strings.Join(strings.Fields(*v.Title), " ")
+			w.sb.WriteString(`<h3 id="` + id + `">` + esc(title) + "</h3>\n")
+			b.sections = append(b.sections, section{title, b.file + "#" + id})
+		}
+		if v.Paragraph == "y" {
+			w.endParagraph()
+		}
+		w.verse(v, crossRefs[versification.Verse{Book: b.Id, Chapter: v.Chapter, Verse: v.Verse}])
+	}
+	w.endChapter()
+	w.sb.WriteString("</body>\n</html>\n")
+	return w.sb.String()
+}
+
+type bookWriter struct {
+	p        *publication
+	book     *book
+	sb       strings.Builder
+	sections int
+	notes    int
+	// inSection and open are set while a chapter section and a paragraph
+	// are open.
+	inSection, open bool
+	// asides holds the notes of the current chapter.
+	asides []string
+}
+
+func (w *bookWriter) endParagraph() {
+	if w.open {
+		w.sb.WriteString("</p>\n")
+		w.open = false
+	}
+}
+
+func (w *bookWriter) endChapter() {
+	if !w.inSection {
+		return
+	}
+	w.endParagraph()
+	for _, aside := range w.asides {
+		w.sb.WriteString(aside)
+	}
+	w.sb.WriteString("</section>\n")
+	w.asides, w.inSection = nil, false
+}
+
+// verse writes a verse with its number, footnote markers and the marker of
+// its cross-references. Poetry lines end in a line break.
+func (w *bookWriter) verse(v bible.SourceVerse, links []link) {
+	if !w.open {
+		w.sb.WriteString("<p>")
+		w.open = true
+	}
+	label := w.label(v)
+	id := verseId(v.Chapter, v.Verse)
+	w.sb.WriteString(`<span class="v" id="` + id + `">` + strconv.Itoa(v.Verse) + "</span> ")
+
+	var lines [][]bible.Node
+	if v.TextJson != nil {
+		lines = v.TextJson.Lines()
+	}
+	for i, line := range lines {
+		w.sb.WriteString(w.inline(line, true, id, label))
+		if i == len(lines)-1 && len(links) > 0 {
+			w.crossReferences(id, label, links)
+		}
+		if len(lines) > 1 {
+			w.sb.WriteString("<br/>\n")
+		} else {
+			w.sb.WriteString("\n")
+		}
+	}
+	if len(lines) == 0 && len(links) > 0 {
+		w.crossReferences(id, label, links)
+	}
+}
+
+// label returns the Dutch style chapter,verse label of a verse.
+func (w *bookWriter) label(v bible.SourceVerse) string {
+	if w.book.Chapters == 1 {
+		return strconv.Itoa(v.Verse)
+	}
+	return fmt.Sprintf("%d,%d", v.Chapter, v.Verse)
+}
+
+var whitespace = regexp.MustCompile(`[\s\x00-\x1F]+`)
+
+// inline renders nodes as XHTML phrasing content. Whitespace is collapsed;
+// trim drops the leading whitespace of the first text.
+func (w *bookWriter) inline(nodes []bible.Node, trim bool, verseId, label string) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.Tag {
+		case "":
+			text := whitespace.ReplaceAllString(n.Text, " ")
+			if trim {
+				text = strings.TrimLeft(text, " ")
+			}
+			sb.WriteString(esc(text))
+		case "br":
+			sb.WriteByte(' ')
+		case "abbr":
+			sb.WriteString(w.footnote(n, verseId, label))
+		case "em", "i":
+			sb.WriteString("<" + n.Tag + ">" + w.inline(n.Children, false, verseId, label) + "</" + n.Tag + ">")
+		case "blockquote":
+			sb.WriteString("<q>" + w.inline(n.Children, false, verseId, label) + "</q>")
+		case "div", "p":
+			// Block elements separate words, see Node.PlainText.
+			if !trim {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(w.inline(n.Children, trim, verseId, label))
+		default:
+			sb.WriteString(w.inline(n.Children, trim, verseId, label))
+		}
+		trim = false
+	}
+	return sb.String()
+}
+
+// footnote returns the marker of an abbr footnote and queues the note, which
+// readers show as a pop-up.
+func (w *bookWriter) footnote(n bible.Node, verseId, label string) string {
+	w.notes++
+	id := fmt.Sprintf("n%d", w.notes)
+
+	var note strings.Builder
+	note.WriteString(`<aside epub:type="footnote" id="` + id + `"><p><a href="#` + verseId + `">` + label + "</a>")
+	if ref := strings.TrimSpace(n.Ref); ref != "" {
+		note.WriteString(` <span class="ref">` + esc(strings.Join(strings.Fields(ref), " ")) + "</span>")
+	}
+	if title := strings.TrimSpace(n.Title); title != "" {
+		note.WriteString(" " + esc(strings.Join(strings.Fields(title), " ")))
+	}
+	note.WriteString("</p></aside>\n")
+	w.asides = append(w.asides, note.String())
+
+	return `<a epub:type="noteref" class="noteref" href="#` + id + `">*</a>`
+}
+
+// crossReferences writes the marker of the cross-references of a verse and
+// queues the note with their links.
+func (w *bookWriter) crossReferences(verseId, label string, links []link) {
+	id := "x" + strings.TrimPrefix(verseId, "v")
+	w.sb.WriteString(`<a epub:type="noteref" class="xref" href="#` + id + `">°</a>`)
+
+	var note strings.Builder
+	note.WriteString(`<aside epub:type="footnote" id="` + id + `"><p><a href="#` + verseId + `">` + label + "</a> ")
+	for i, l := range links {
+		if i > 0 {
+			note.WriteString("; ")
+		}
+		note.WriteString(`<a href="` + l.href + `">` + esc(l.label) + "</a>")
+	}
+	note.WriteString("</p></aside>\n")
+	w.asides = append(w.asides, note.String())
+}
+
+type link struct {
+	href, label string
+	votes       int
+}
+
+// crossReferences returns the links of the verses of a book, keyed by verse
+// and sorted by votes. Books without cross-reference data have none.
+func (p *publication) crossReferences(bookId string) map[versification.Verse][]link {
+	links := map[versification.Verse][]link{}
+	repo := p.opts.CrossReferences
+	if repo == nil {
+		return links
+	}
+	refs, err := repo.GetCrossReferences(bookId)
+	if err != nil {
+		// Not every book has cross-reference data.
+		return links
+	}
+	kjv, _ := versification.Lookup(versification.KJV)
+
+	for _, ref := range refs.CrossReferences {
+		if ref.Votes < p.opts.MinVotes {
+			continue
+		}
+		toBook, err := repo.EnglishToDutch(ref.To.Book)
+		if err != nil {
+			continue
+		}
+
+		to, ok := p.convert(versification.Verse{Book: toBook, Chapter: ref.To.Chapter, Verse: ref.To.Verse}, kjv)
+		if !ok {
+			continue
+		}
+		label := p.names[to.Book] + " " + chapterVerse(to)
+		if ref.To.EndVerse > 0 && (ref.To.EndBook == "" || ref.To.EndBook == ref.To.Book) {
+			end, ok := p.convert(versification.Verse{Book: toBook, Chapter: cmp.Or(ref.To.EndChapter, ref.To.Chapter), Verse: ref.To.EndVerse}, kjv)
+			switch {
+			case !ok || end.Compare(to) <= 0:
+			case end.Chapter == to.Chapter:
+				label += "-" + strconv.Itoa(end.Verse)
+			default:
+				label += "-" + chapterVerse(end)
+			}
+		}
+
+		l := link{href: p.anchors[to], label: label, votes: ref.Votes}
+		for _, from := range versification.Convert(versification.Verse{Book: bookId, Chapter: ref.From.Chapter, Verse: ref.From.Verse}, kjv, p.opts.Versification) {
+			if from, ok := p.find(from); ok {
+				links[from] = append(links[from], l)
+			}
+		}
+	}
+
+	for v, l := range links {
+		slices.SortStableFunc(l, func(a, b link) int { return b.votes - a.votes })
+		seen := map[string]bool{}
+		links[v] = slices.DeleteFunc(l, func(l link) bool {
+			dup := seen[l.href]
+			seen[l.href] = true
+			return dup
+		})
+	}
+	return links
+}
+
+// convert maps a verse in the English numbering of the cross-references to
+// the first matching verse of the publication.
+func (p *publication) convert(v versification.Verse, kjv *versification.Scheme) (versification.Verse, bool) {
+	for _, c := range versification.Convert(v, kjv, p.opts.Versification) {
+		if found, ok := p.find(c); ok {
+			return found, true
+		}
+	}
+	return v, false
+}
+
+func chapterVerse(v versification.Verse) string {
+	if v.Chapter == 0 {
+		return strconv.Itoa(v.Verse)
+	}
+	return fmt.Sprintf("%d,%d", v.Chapter, v.Verse)
+}
@@ -0,0 +1,250 @@
+// Package epub builds EPUB 3 publications of the Bible text for e-readers.
+package epub
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// MediaType is the media type of an EPUB file.
+const MediaType = "application/epub+zip"
+
+// Options describes the publication.
+type Options struct {
+	Title string
+	// Language is the BCP 47 language of the text, nl when empty.
+	Language string
+	// Identifier is the unique identifier of the publication. When empty, a
+	// urn:uuid is derived from the title and the book ids.
+	Identifier string
+	// Modified is the modification date in the metadata, now when zero.
+	Modified time.Time
+	// CrossReferences, when set, adds the cross-references of every verse
+	// with at least MinVotes votes as links to the verses they point at, as
+	// far as those are part of the publication.
+	CrossReferences crossref.Repository
+	MinVotes        int
+	// Versification is the numbering of the books, into which the English
+	// numbering of the cross-references is converted. Nil means the
+	// standard scheme.
+	Versification *versification.Scheme
+}
+
+// Write writes books as an EPUB 3 publication: one XHTML document per book,
+// a table of contents with the book names and section titles, footnotes as
+// pop-up notes and cross-references as internal links.
+func Write(w io.Writer, books []bible.SourceBook, opts Options) error {
+	if len(books) == 0 {
+		return fmt.Errorf("no books to publish")
+	}
+	if opts.Language == "" {
+		opts.Language = "nl"
+	}
+	if opts.Modified.IsZero() {
+		opts.Modified = time.Now()
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = identifier(opts.Title, books)
+	}
+	if opts.Versification == nil {
+		opts.Versification, _ = versification.Lookup(versification.Standard)
+	}
+
+	p := newPublication(books, opts)
+
+	zw := zip.NewWriter(w)
+	// The mimetype must come first and be stored uncompressed.
+	if err := p.add(zw, "mimetype", MediaType, zip.Store); err != nil {
+		return err
+	}
+	if err := p.add(zw, "META-INF/container.xml", containerXML, zip.Deflate); err != nil {
+		return err
+	}
+	if err := p.add(zw, "OEBPS/style.css", styleCSS, zip.Deflate); err != nil {
+		return err
+	}
+	for i := range p.books {
+		if err := p.add(zw, "OEBPS/"+p.books[i].file, p.bookDocument(&p.books[i]), zip.Deflate); err != nil {
+			return err
+		}
+	}
+	if err := p.add(zw, "OEBPS/nav.xhtml", p.navDocument(), zip.Deflate); err != nil {
+		return err
+	}
+	if err := p.add(zw, "OEBPS/package.opf", p.packageDocument(), zip.Deflate); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type publication struct {
+	opts  Options
+	books []book
+	// anchors maps every verse in the publication to its link target.
+	anchors map[versification.Verse]string
+	// names maps book ids to book names, for link labels.
+	names map[string]string
+}
+
+type book struct {
+	bible.SourceBook
+	file string
+	// sections lists the section titles for the table of contents, filled
+	// in while the document is written.
+	sections []section
+}
+
+type section struct {
+	title, href string
+}
+
+func newPublication(books []bible.SourceBook, opts Options) *publication {
+	p := &publication{opts: opts, anchors: map[versification.Verse]string{}, names: map[string]string{}}
+	for _, b := range books {
+		b.Verses = slices.Clone(b.Verses)
+		slices.SortStableFunc(b.Verses, func(a, b bible.SourceVerse) int {
+			if a.Chapter != b.Chapter {
+				return a.Chapter - b.Chapter
+			}
+			return a.Verse - b.Verse
+		})
+
+		file := b.Id + ".xhtml"
+		for _, v := range b.Verses {
+			p.anchors[versification.Verse{Book: b.Id, Chapter: v.Chapter, Verse: v.Verse}] = file + "#" + verseId(v.Chapter, v.Verse)
+		}
+		p.names[b.Id] = b.Name
+		p.books = append(p.books, book{SourceBook: b, file: file})
+	}
+	return p
+}
+
+func (p *publication) add(zw *zip.Writer, name, content string, method uint16) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: p.opts.Modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+// find returns the verse of the publication that v refers to.
+func (p *publication) find(v versification.Verse) (versification.Verse, bool) {
+	var ok bool
+	v.Chapter, ok = bible.FindChapter(v.Chapter, func(c int) bool {
+		_, ok := p.anchors[versification.Verse{Book: v.Book, Chapter: c, Verse: v.Verse}]
+		return ok
+	})
+	return v, ok
+}
+
+func verseId(chapter, verse int) string {
+	return fmt.Sprintf("v%d-%d", chapter, verse)
+}
+
+// identifier derives a stable urn:uuid (version 5 layout) from the title
+// and book ids.
+func identifier(title string, books []bible.SourceBook) string {
+	h := sha1.New()
+	io.WriteString(h, title)
+	for _, b := range books {
+		io.WriteString(h, "\x00"+b.Id)
+	}
+	sum := h.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/package.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const styleCSS = `body { font-family: serif; line-height: 1.4; }
+h1 { text-align: center; }
+h3 { font-size: 1em; font-style: italic; }
+.v { font-size: 0.7em; vertical-align: super; font-weight: bold; }
+a.noteref, a.xref { font-size: 0.7em; vertical-align: super; text-decoration: none; }
+q { quotes: none; font-style: italic; }
+aside { font-size: 0.85em; }
+`
+
+// packageDocument returns the package document with the metadata, the
+// manifest of all files and the reading order.
+func (p *publication) packageDocument() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="pub-id" xml:lang="` + esc(p.opts.Language) + `">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="pub-id">` + esc(p.opts.Identifier) + `</dc:identifier>
+    <dc:title>` + esc(p.opts.Title) + `</dc:title>
+    <dc:language>` + esc(p.opts.Language) + `</dc:language>
+    <meta property="dcterms:modified">` + p.opts.Modified.UTC().Format("2006-01-02T15:04:05Z") + `</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+`)
+	for _, b := range p.books {
+		sb.WriteString(`    <item id="b-` + b.Id + `" href="` + b.file + `" media-type="application/xhtml+xml"/>` + "\n")
+	}
+	sb.WriteString("  </manifest>\n  <spine>\n    <itemref idref=\"nav\"/>\n")
+	for _, b := range p.books {
+		sb.WriteString(`    <itemref idref="b-` + b.Id + `"/>` + "\n")
+	}
+	sb.WriteString("  </spine>\n</package>\n")
+	return sb.String()
+}
+
+// navDocument returns the table of contents: the books, each with its
+// section titles.
+func (p *publication) navDocument() string {
+	var sb strings.Builder
+	sb.WriteString(p.documentHead(p.opts.Title))
+	sb.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>" + esc(p.opts.Title) + "</h1>\n<ol>\n")
+	for _, b := range p.books {
+		sb.WriteString(`<li><a href="` + b.file + `">` + esc(b.Name) + "</a>")
+		if len(b.sections) > 0 {
+			sb.WriteString("\n<ol>\n")
+			for _, s := range b.sections {
+				sb.WriteString(`<li><a href="` + s.href + `">` + esc(s.title) + "</a></li>\n")
+			}
+			sb.WriteString("</ol>\n")
+		}
+		sb.WriteString("</li>\n")
+	}
+	sb.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	return sb.String()
+}
+
+func (p *publication) documentHead(title string) string {
+	lang := esc(p.opts.Language)
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + lang + `" lang="` + lang + `">
+<head>
+<meta charset="UTF-8"/>
+<title>` + esc(title) + `</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+`
+}
@@ -0,0 +1,223 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+func TestWrite(t *testing.T) {
+	title := "Psalm van David"
+	psalm := bible.SourceBook{Id: "psalmen", Name: "Psalmen", Chapters: 150, Verses: []bible.SourceVerse{
+		{Chapter: 23, Verse: 2, Paragraph: "n", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "Hij laat mij rusten <&> "},
+			{Tag: "blockquote", Children: []bible.Node{{Text: "in groene weiden"}}},
+		}}},
+		{Chapter: 23, Verse: 1, Title: &title, Paragraph: "y", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "De Heer is mijn herder,"},
+			{Tag: "abbr", Ref: "Ez. 34,11", Title: "vergelijk"},
+			{Tag: "br"},
+			{Text: "\r\nniets kom ik tekort."},
+		}}},
+	}}
+	modified := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, []bible.SourceBook{psalm}, Options{Title: "Psalmen", Modified: modified}))
+	files := validate(t, buf.Bytes())
+
+	opf := files["OEBPS/package.opf"]
+	require.Contains(t, opf, "<dc:language>nl</dc:language>")
+	require.Contains(t, opf, `xml:lang="nl"`)
+	require.Contains(t, opf, `<meta property="dcterms:modified">2026-10-18T12:00:00Z</meta>`)
+	require.Contains(t, opf, "<dc:identifier id=\"pub-id\">urn:uuid:")
+
+	nav := files["OEBPS/nav.xhtml"]
+	require.Contains(t, nav, `<a href="psalmen.xhtml">Psalmen</a>`)
+	require.Contains(t, nav, `<a href="psalmen.xhtml#s1">Psalm van David</a>`)
+
+	doc := files["OEBPS/psalmen.xhtml"]
+	require.Contains(t, doc, `lang="nl"`)
+	require.Contains(t, doc, "<h2>Psalmen 23</h2>")
+	require.Contains(t, doc, `<h3 id="s1">Psalm van David</h3>`)
+	require.Contains(t, doc, `<span class="v" id="v23-1">1</span> De Heer is mijn herder,<a epub:type="noteref" class="noteref" href="#n1">*</a><br/>`)
+	require.Contains(t, doc, `<aside epub:type="footnote" id="n1"><p><a href="#v23-1">23,1</a> <span class="ref">Ez. 34,11</span> vergelijk</p></aside>`)
+	require.Contains(t, doc, `Hij laat mij rusten &lt;&amp;&gt; <q>in groene weiden</q>`)
+	require.Less(t, strings.Index(doc, "v23-1"), strings.Index(doc, "v23-2"), "verses must be in order")
+}
+
+func TestWriteCrossReferences(t *testing.T) {
+	var books []bible.SourceBook
+	for _, id := range []string{"genesis", "psalmen", "johannes", "judas"} {
+		book, err := bible.ReadSourceBook(id)
+		require.NoError(t, err)
+		books = append(books, book)
+	}
+
+	var buf bytes.Buffer
+	opts := Options{Title: "Selectie", CrossReferences: crossref.Current(), MinVotes: 20}
+	require.NoError(t, Write(&buf, books, opts))
+	files := validate(t, buf.Bytes())
+
+	// Genesis 1:1 points at John 1:1 with many votes.
+	genesis := files["OEBPS/genesis.xhtml"]
+	require.Contains(t, genesis, `<a epub:type="noteref" class="xref" href="#x1-1">°</a>`)
+	require.Contains(t, genesis, `<a href="johannes.xhtml#v1-1">Evangelie volgens Johannes 1,1-3</a>`)
+	// Books outside the publication are not linked.
+	require.NotContains(t, genesis, `href="hebreeen.xhtml`)
+
+	// Judas has a single chapter, numbered 0 in the data and 1 in the
+	// cross-references.
+	require.Contains(t, files["OEBPS/judas.xhtml"], `<aside epub:type="footnote" id="x0-`)
+}
+
+func TestWriteNoBooks(t *testing.T) {
+	require.Error(t, Write(io.Discard, nil, Options{}))
+}
+
+// validate checks the structure of an EPUB container: the uncompressed
+// mimetype first, a container pointing at the package document, a manifest
+// listing every content file, a spine of manifest items, a navigation
+// document, well-formed XHTML and internal links that resolve. It returns
+// the files by name.
+func validate(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.NotEmpty(t, zr.File)
+	require.Equal(t, "mimetype", zr.File[0].Name)
+	require.Equal(t, zip.Store, zr.File[0].Method)
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	require.Equal(t, MediaType, files["mimetype"])
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(files["META-INF/container.xml"]), &container))
+	require.Len(t, container.Rootfiles, 1)
+	opfPath := container.Rootfiles[0].FullPath
+	require.Equal(t, "application/oebps-package+xml", container.Rootfiles[0].MediaType)
+
+	var pkg struct {
+		Version          string `xml:"version,attr"`
+		UniqueIdentifier string `xml:"unique-identifier,attr"`
+		Identifiers      []struct {
+			Id    string `xml:"id,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"metadata>identifier"`
+		Titles    []string `xml:"metadata>title"`
+		Languages []string `xml:"metadata>language"`
+		Meta      []struct {
+			Property string `xml:"property,attr"`
+		} `xml:"metadata>meta"`
+		Items []struct {
+			Id         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+		Itemrefs []struct {
+			Idref string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	opf, ok := files[opfPath]
+	require.True(t, ok, "package document %s missing", opfPath)
+	require.NoError(t, xml.Unmarshal([]byte(opf), &pkg))
+	require.Equal(t, "3.0", pkg.Version)
+	require.Len(t, pkg.Identifiers, 1)
+	require.Equal(t, pkg.UniqueIdentifier, pkg.Identifiers[0].Id)
+	require.NotEmpty(t, pkg.Titles)
+	require.NotEmpty(t, pkg.Languages)
+	require.True(t, len(pkg.Meta) > 0 && pkg.Meta[0].Property == "dcterms:modified")
+
+	dir := path.Dir(opfPath)
+	items := map[string]string{}
+	listed := map[string]bool{opfPath: true, "mimetype": true, "META-INF/container.xml": true}
+	nav := ""
+	for _, item := range pkg.Items {
+		name := path.Join(dir, item.Href)
+		_, ok := files[name]
+		require.True(t, ok, "manifest item %s missing", name)
+		items[item.Id] = name
+		listed[name] = true
+		if item.Properties == "nav" {
+			nav = name
+		}
+	}
+	for name := range files {
+		require.True(t, listed[name], "file %s is not in the manifest", name)
+	}
+	require.NotEmpty(t, nav, "no navigation document")
+	require.Contains(t, files[nav], `epub:type="toc"`)
+	for _, ref := range pkg.Itemrefs {
+		require.Contains(t, items, ref.Idref)
+	}
+
+	// Every XHTML file must be well-formed and every internal link must
+	// point at an existing document and id.
+	ids := map[string]map[string]bool{}
+	var links []string
+	for _, item := range pkg.Items {
+		if item.MediaType != "application/xhtml+xml" {
+			continue
+		}
+		name := path.Join(dir, item.Href)
+		ids[name] = map[string]bool{}
+		dec := xml.NewDecoder(strings.NewReader(files[name]))
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err, name)
+			start, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			for _, a := range start.Attr {
+				switch {
+				case a.Name.Local == "id":
+					require.False(t, ids[name][a.Value], "%s: duplicate id %s", name, a.Value)
+					ids[name][a.Value] = true
+				case a.Name.Local == "href" && start.Name.Local == "a":
+					target := a.Value
+					if strings.HasPrefix(target, "#") {
+						target = path.Base(name) + target
+					}
+					links = append(links, path.Join(dir, target))
+				}
+			}
+		}
+	}
+	for _, l := range links {
+		file, id, _ := strings.Cut(l, "#")
+		require.Contains(t, ids, file, "link %s", l)
+		if id != "" {
+			require.True(t, ids[file][id], "link %s points at a missing id", l)
+		}
+	}
+	return files
+}
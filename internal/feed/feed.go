@@ -0,0 +1,169 @@
+// Package feed writes syndication feeds in the Atom (RFC 4287) and RSS 2.0
+// formats, such as the verse of the day.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed is a list of items, newest first.
+type Feed struct {
+	// Id is a URI that identifies the feed, such as a tag URI.
+	Id          string
+	Title       string
+	Description string
+	// Link is the page the feed belongs to, Self the address of the feed.
+	Link    string
+	Self    string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	Id        string
+	Title     string
+	Link      string
+	Content   string
+	Published time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Tagline string      `xml:"subtitle,omitempty"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Id        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Link      *atomLink `xml:"link,omitempty"`
+	Published string    `xml:"published"`
+	Updated   string    `xml:"updated"`
+	Content   atomText  `xml:"content"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteAtom writes f as an Atom feed.
+func WriteAtom(w io.Writer, f Feed) error {
+	af := atomFeed{
+		Id:      f.Id,
+		Title:   f.Title,
+		Tagline: f.Description,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: f.Title},
+	}
+	if f.Link != "" {
+		af.Links = append(af.Links, atomLink{Href: f.Link, Rel: "alternate"})
+	}
+	if f.Self != "" {
+		af.Links = append(af.Links, atomLink{Href: f.Self, Rel: "self"})
+	}
+	for _, item := range f.Items {
+		e := atomEntry{
+			Id:        item.Id,
+			Title:     item.Title,
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Published.UTC().Format(time.RFC3339),
+			Content:   atomText{Type: "text", Body: item.Content},
+		}
+		if item.Link != "" {
+			e.Link = &atomLink{Href: item.Link, Rel: "alternate"}
+		}
+		af.Entries = append(af.Entries, e)
+	}
+	return write(w, af)
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr,omitempty"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          *rssSelf  `xml:"atom:link,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Id          string `xml:",chardata"`
+}
+
+// WriteRSS writes f as an RSS 2.0 feed.
+func WriteRSS(w io.Writer, f Feed) error {
+	ch := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+	}
+	doc := rss{Version: "2.0", Channel: ch}
+	if f.Self != "" {
+		doc.Atom = "http://www.w3.org/2005/Atom"
+		doc.Channel.Self = &rssSelf{Href: f.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, item := range f.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Content,
+			GUID:        rssGUID{Id: item.Id},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return write(w, doc)
+}
+
+func write(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testFeed = Feed{
+	Id:          "tag:bijbel.fido21.nl,2026:verse-of-the-day",
+	Title:       "Tekst van de dag",
+	Description: "Elke dag een vers uit de Bijbel.",
+	Link:        "https://bijbel.fido21.nl/",
+	Self:        "https://api.example/verse-of-the-day/atom.xml",
+	Updated:     time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+	Items: []Item{{
+		Id:        "tag:bijbel.fido21.nl,2026-10-18:verse-of-the-day",
+		Title:     "johannes 3:16",
+		Link:      "https://bijbel.fido21.nl/#johannes/3/16",
+		Content:   "Zozeer immers heeft God de wereld liefgehad & <meer>",
+		Published: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+	}},
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteAtom(&buf, testFeed))
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Id      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			Id      string `xml:"id"`
+			Title   string `xml:"title"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, testFeed.Id, doc.Id)
+	require.Equal(t, "2026-10-18T00:00:00Z", doc.Updated)
+	require.Len(t, doc.Links, 2)
+	require.Equal(t, "self", doc.Links[1].Rel)
+	require.Len(t, doc.Entries, 1)
+	require.Equal(t, "johannes 3:16", doc.Entries[0].Title)
+	require.Equal(t, testFeed.Items[0].Content, doc.Entries[0].Content)
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRSS(&buf, testFeed))
+	require.Contains(t, buf.String(), `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`)
+
+	var doc struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title       string `xml:"title"`
+				Description string `xml:"description"`
+				GUID        string `xml:"guid"`
+				PubDate     string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, "Tekst van de dag", doc.Channel.Title)
+	require.Len(t, doc.Channel.Items, 1)
+	require.Equal(t, testFeed.Items[0].Id, doc.Channel.Items[0].GUID)
+	require.Equal(t, "Sun, 18 Oct 2026 00:00:00 +0000", doc.Channel.Items[0].PubDate)
+	require.Equal(t, testFeed.Items[0].Content, doc.Channel.Items[0].Description)
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// Check is a named readiness condition. Run returns nil when the condition
+// holds. A failing optional check is reported as a warning but does not make
+// the service unready.
+type Check struct {
+	Name     string
+	Run      func() error
+	Optional bool
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Check statuses.
+const (
+	StatusPending = "pending"
+	StatusPass    = "pass"
+	StatusWarn    = "warn"
+	StatusFail    = "fail"
+)
+
+// Checker runs a fixed set of checks and remembers their results, so that
+// expensive checks run once instead of on every probe.
+type Checker struct {
+	checks []Check
+
+	mu      sync.RWMutex
+	results []Result
+	checked bool
+}
+
+// NewChecker returns a checker whose checks are all pending until Run is
+// called.
+func NewChecker(checks ...Check) *Checker {
+	c := &Checker{checks: checks}
+	c.results = make([]Result, len(checks))
+	for i, check := range checks {
+		c.results[i] = Result{Name: check.Name, Status: StatusPending}
+	}
+	return c
+}
+
+// Run executes every check and replaces the stored results.
+func (c *Checker) Run() {
+	results := make([]Result, len(c.checks))
+	for i, check := range c.checks {
+		start := time.Now()
+		err := check.Run()
+
+		results[i] = Result{
+			Name:     check.Name,
+			Status:   StatusPass,
+			Duration: time.Since(start).Round(time.Millisecond).String(),
+		}
+		if err != nil {
+			results[i].Status = StatusFail
+			if check.Optional {
+				results[i].Status = StatusWarn
+			}
+			results[i].Error = err.Error()
+		}
+	}
+
+	c.mu.Lock()
+	c.results = results
+	c.checked = true
+	c.mu.Unlock()
+}
+
+// Ready reports whether the checks have run and all passed, together with
+// the individual results.
+func (c *Checker) Ready() (bool, []Result) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	results := make([]Result, len(c.results))
+	copy(results, c.results)
+
+	ready := c.checked
+	for _, r := range results {
+		if r.Status == StatusPending || r.Status == StatusFail {
+			ready = false
+		}
+	}
+	return ready, results
+}
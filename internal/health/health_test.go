@@ -0,0 +1,69 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckerPendingUntilRun(t *testing.T) {
+	c := NewChecker(Check{Name: "books", Run: func() error { return nil }})
+
+	ready, results := c.Ready()
+	if ready {
+		t.Fatal("expected checker not to be ready before Run")
+	}
+	if results[0].Status != StatusPending {
+		t.Errorf("status = %q, want %q", results[0].Status, StatusPending)
+	}
+
+	c.Run()
+
+	ready, results = c.Ready()
+	if !ready {
+		t.Fatalf("expected checker to be ready, got %+v", results)
+	}
+	if results[0].Status != StatusPass {
+		t.Errorf("status = %q, want %q", results[0].Status, StatusPass)
+	}
+}
+
+func TestCheckerFailure(t *testing.T) {
+	c := NewChecker(
+		Check{Name: "books", Run: func() error { return nil }},
+		Check{Name: "crossrefs", Run: func() error { return errors.New("missing gen.json") }},
+	)
+	c.Run()
+
+	ready, results := c.Ready()
+	if ready {
+		t.Fatal("expected checker not to be ready when a check fails")
+	}
+	if results[1].Status != StatusFail || results[1].Error != "missing gen.json" {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+}
+
+func TestCheckerWithoutChecks(t *testing.T) {
+	c := NewChecker()
+	c.Run()
+
+	if ready, _ := c.Ready(); !ready {
+		t.Error("expected checker without checks to be ready after Run")
+	}
+}
+
+func TestCheckerOptionalFailure(t *testing.T) {
+	c := NewChecker(
+		Check{Name: "books", Run: func() error { return nil }},
+		Check{Name: "crossrefs", Run: func() error { return errors.New("missing ps.json") }, Optional: true},
+	)
+	c.Run()
+
+	ready, results := c.Ready()
+	if !ready {
+		t.Fatalf("expected optional failure to keep the checker ready, got %+v", results)
+	}
+	if results[1].Status != StatusWarn {
+		t.Errorf("status = %q, want %q", results[1].Status, StatusWarn)
+	}
+}
@@ -0,0 +1,101 @@
+// Package ical writes iCalendar feeds (RFC 5545) of all-day events, such as
+// the readings of a plan or of the Sundays of the lectionary.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Calendar is a feed of events.
+type Calendar struct {
+	// Name is shown by calendar apps that subscribe to the feed.
+	Name string
+	// Stamp is the time the feed was generated, written as the DTSTAMP of
+	// every event.
+	Stamp  time.Time
+	Events []Event
+}
+
+// Event is an all-day event.
+type Event struct {
+	// UID identifies the event across updates of the feed.
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// ProdID identifies the product that wrote the feed.
+const ProdID = "-//bijbel-api//NONSGML Bijbel API//NL"
+
+// lineLength is the maximum length of a line in octets, excluding the line
+// break.
+const lineLength = 75
+
+// Write writes cal to w.
+func Write(w io.Writer, cal Calendar) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		fold(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+	stamp := cal.Stamp.UTC().Format("20060102T150405Z")
+	for _, e := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", stamp)
+		line("DTSTART;VALUE=DATE", e.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE", e.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// fold writes a content line, folding it after lineLength octets by
+// starting the next line with a space. Lines are not folded within a UTF-8
+// sequence.
+func fold(w *bufio.Writer, s string) {
+	limit := lineLength
+	for len(s) > limit {
+		n := limit
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		w.WriteString(s[:n])
+		w.WriteString("\r\n ")
+		s = s[n:]
+		// The space of a continuation line counts towards its length.
+		limit = lineLength - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,64 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Calendar{
+		Name:  "Lezingen",
+		Stamp: time.Date(2026, time.October, 18, 9, 30, 0, 0, time.UTC),
+		Events: []Event{{
+			UID:         "lectionary-2026-10-18@bijbel-api",
+			Date:        time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+			Summary:     "29e zondag; matteus 22:15-21",
+			Description: "Toen gingen de Farizeeën heen,\nen beraadslaagden",
+			URL:         "https://bijbel.fido21.nl/#matteus/22/15",
+		}},
+	}))
+
+	require.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + ProdID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Lezingen",
+		"BEGIN:VEVENT",
+		"UID:lectionary-2026-10-18@bijbel-api",
+		"DTSTAMP:20261018T093000Z",
+		"DTSTART;VALUE=DATE:20261018",
+		"DTEND;VALUE=DATE:20261019",
+		`SUMMARY:29e zondag\; matteus 22:15-21`,
+		`DESCRIPTION:Toen gingen de Farizeeën heen\,\nen beraadslaagden`,
+		"URL:https://bijbel.fido21.nl/#matteus/22/15",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), buf.String())
+}
+
+func TestFold(t *testing.T) {
+	var buf bytes.Buffer
+	text := strings.Repeat("ë", 100)
+	require.NoError(t, Write(&buf, Calendar{Events: []Event{{Summary: text}}}))
+
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), lineLength, "line %d", i)
+		require.True(t, strings.ToValidUTF8(line, "?") == line, "line %d splits a character", i)
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	require.Contains(t, unfolded.String(), "\nSUMMARY:"+text+"\n")
+}
@@ -0,0 +1,74 @@
+package jsonstream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// bufferSize is how much encoded output is collected before it is written to
+// the underlying writer.
+const bufferSize = 32 << 10
+
+// Array writes items as a JSON array, encoding one element at a time so the
+// full document never has to be held in memory.
+func Array[T any](w io.Writer, items []T) error {
+	bw := bufio.NewWriterSize(w, bufferSize)
+	if err := writeArray(bw, items); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Object writes head as a JSON object with items streamed in as an extra
+// array-valued field. The field must not also be present in head, so head is
+// usually a struct with that field tagged `json:"-"`.
+func Object[T any](w io.Writer, head any, field string, items []T) error {
+	prefix, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+	prefix = bytes.TrimSpace(prefix)
+	if len(prefix) < 2 || prefix[0] != '{' || prefix[len(prefix)-1] != '}' {
+		return fmt.Errorf("jsonstream: head must encode to a JSON object, got %s", prefix)
+	}
+
+	key, err := json.Marshal(field)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriterSize(w, bufferSize)
+	bw.Write(prefix[:len(prefix)-1])
+	if len(prefix) > 2 {
+		bw.WriteByte(',')
+	}
+	bw.Write(key)
+	bw.WriteByte(':')
+	if err := writeArray(bw, items); err != nil {
+		return err
+	}
+	bw.WriteByte('}')
+	return bw.Flush()
+}
+
+func writeArray[T any](bw *bufio.Writer, items []T) error {
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+	}
+	return bw.WriteByte(']')
+}
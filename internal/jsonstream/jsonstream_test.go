@@ -0,0 +1,79 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type verse struct {
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+	Text    string `json:"text"`
+}
+
+func TestArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []verse
+		want  string
+	}{
+		{"empty", []verse{}, `[]`},
+		{"nil", nil, `[]`},
+		{"two", []verse{{1, 1, "a"}, {1, 2, "b"}}, `[{"chapter":1,"verse":1,"text":"a"},{"chapter":1,"verse":2,"text":"b"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Array(&buf, tt.items); err != nil {
+				t.Fatalf("Array() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Array() = %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestObject(t *testing.T) {
+	head := struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}{"genesis", "Genesis"}
+	items := []verse{{1, 1, "In het begin"}}
+
+	var buf bytes.Buffer
+	if err := Object(&buf, head, "verses", items); err != nil {
+		t.Fatalf("Object() error = %v", err)
+	}
+
+	var got struct {
+		Id     string  `json:"id"`
+		Name   string  `json:"name"`
+		Verses []verse `json:"verses"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v: %s", err, buf.String())
+	}
+	if got.Id != "genesis" || got.Name != "Genesis" || len(got.Verses) != 1 || got.Verses[0].Text != "In het begin" {
+		t.Errorf("Object() = %s", buf.String())
+	}
+}
+
+func TestObjectEmptyHead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Object(&buf, struct{}{}, "items", []int{1, 2}); err != nil {
+		t.Fatalf("Object() error = %v", err)
+	}
+	if want := `{"items":[1,2]}`; buf.String() != want {
+		t.Errorf("Object() = %s, want %s", buf.String(), want)
+	}
+}
+
+func TestObjectRejectsNonObjectHead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Object(&buf, []int{1}, "items", []int{1}); err == nil {
+		t.Error("expected error for non-object head")
+	}
+}
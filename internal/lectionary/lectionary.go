@@ -0,0 +1,102 @@
+// Package lectionary maps the days of the liturgical calendar to the
+// readings of their Mass: the first reading, the responsorial psalm, the
+// second reading on Sundays and solemnities, and the Gospel.
+package lectionary
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+)
+
+// lectionary.json maps celebration keys of the liturgy package to the
+// readings by cycle: "A", "B" and "C" for the Sunday cycle, "I" and "II"
+// for the weekday cycle and "*" for readings that are the same every year.
+// The references are numbered like the Dutch text and read by the passage
+// package.
+//
+//go:embed lectionary.json
+var data []byte
+
+// Readings are the references of the readings of a Mass. Second is empty
+// on weekdays.
+type Readings struct {
+	First  string `json:"first,omitempty"`
+	Psalm  string `json:"psalm,omitempty"`
+	Second string `json:"second,omitempty"`
+	Gospel string `json:"gospel,omitempty"`
+}
+
+// Lectionary is a set of readings by celebration and cycle.
+type Lectionary struct {
+	days map[string]map[string]Readings
+}
+
+// Parse reads a lectionary in the format of lectionary.json.
+func Parse(data []byte) (*Lectionary, error) {
+	var days map[string]map[string]Readings
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("failed to parse lectionary: %w", err)
+	}
+	for key, cycles := range days {
+		for cycle := range cycles {
+			switch cycle {
+			case "*", "A", "B", "C", "I", "II":
+			default:
+				return nil, fmt.Errorf("lectionary %s: unknown cycle %q", key, cycle)
+			}
+		}
+	}
+	return &Lectionary{days: days}, nil
+}
+
+// Default returns the bundled lectionary.
+var Default = sync.OnceValue(func() *Lectionary {
+	l, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return l
+})
+
+// Readings returns the readings of a day. The readings for every year are
+// completed, reading by reading, by those of the day's weekday cycle and
+// then its Sunday cycle, so that a weekday can share its Gospel between
+// both years. It reports false when the lectionary has no readings for the
+// day, as on Holy Saturday, which has no Mass.
+func (l *Lectionary) Readings(day liturgy.Day) (Readings, bool) {
+	cycles, ok := l.days[day.Celebration.Key]
+	if !ok {
+		return Readings{}, false
+	}
+	var r Readings
+	found := false
+	for _, cycle := range []string{"*", day.WeekdayCycle, day.SundayCycle} {
+		c, ok := cycles[cycle]
+		if !ok {
+			continue
+		}
+		found = true
+		r.First = cmp.Or(c.First, r.First)
+		r.Psalm = cmp.Or(c.Psalm, r.Psalm)
+		r.Second = cmp.Or(c.Second, r.Second)
+		r.Gospel = cmp.Or(c.Gospel, r.Gospel)
+	}
+	return r, found
+}
+
+// Keys returns the celebration keys that have readings, sorted.
+func (l *Lectionary) Keys() []string {
+	return slices.Sorted(maps.Keys(l.days))
+}
+
+// Cycles returns the readings of a celebration by cycle, as stored.
+func (l *Lectionary) Cycles(key string) map[string]Readings {
+	return maps.Clone(l.days[key])
+}
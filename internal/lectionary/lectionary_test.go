@@ -0,0 +1,117 @@
+package lectionary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+func bookResolver() reference.BookResolver {
+	var ids, names []string
+	for _, b := range bible.GetBooks() {
+		ids = append(ids, b.Id)
+		names = append(names, b.Name)
+	}
+	return reference.Resolver(ids, names)
+}
+
+// Every reference of the bundled lectionary reads in the Dutch text.
+func TestDefaultReferencesResolve(t *testing.T) {
+	books := bookResolver()
+	l := Default()
+	for _, key := range l.Keys() {
+		for cycle, r := range l.Cycles(key) {
+			for _, s := range []string{r.First, r.Psalm, r.Second, r.Gospel} {
+				if s == "" {
+					continue
+				}
+				p, err := passage.Parse(s, books)
+				if err != nil {
+					t.Errorf("%s %s: %v", key, cycle, err)
+					continue
+				}
+				if p.String() != s {
+					t.Errorf("%s %s: %q is written as %q", key, cycle, s, p.String())
+				}
+				if _, err := passage.Read(bible.Current(), p); err != nil {
+					t.Errorf("%s %s: %v", key, cycle, err)
+				}
+			}
+		}
+	}
+}
+
+func day(t *testing.T, cal *liturgy.Calendar, s string) liturgy.Day {
+	t.Helper()
+	d, err := time.Parse(time.DateOnly, s)
+	require.NoError(t, err)
+	return cal.Day(d)
+}
+
+func TestReadings(t *testing.T) {
+	cal := liturgy.New(liturgy.DutchRules)
+	l := Default()
+
+	r, ok := l.Readings(day(t, cal, "2026-10-18"))
+	require.True(t, ok)
+	require.Equal(t, Readings{
+		First:  "jesaja 45:1, 4-6",
+		Psalm:  "psalmen 96:1, 3-5, 7-10",
+		Second: "1tessalonicenzen 1:1-5b",
+		Gospel: "matteus 22:15-21",
+	}, r)
+
+	// Holy Family shares its readings across the cycles but the Gospel.
+	r, ok = l.Readings(day(t, cal, "2026-12-27"))
+	require.True(t, ok)
+	require.Equal(t, "jezussirach 3:2-6, 12-14", r.First)
+	require.Equal(t, "lucas 2:22-40", r.Gospel, "2026-12-27 is in year B")
+
+	// Advent weekdays read from Isaiah, but Monday of the first week has
+	// its own first reading in year A.
+	r, ok = l.Readings(day(t, cal, "2025-12-01"))
+	require.True(t, ok)
+	require.Equal(t, "jesaja 4:2-6", r.First)
+	require.Equal(t, "matteus 8:5-11", r.Gospel)
+	r, ok = l.Readings(day(t, cal, "2026-11-30"))
+	require.True(t, ok)
+	require.Equal(t, "andrew", day(t, cal, "2026-11-30").Celebration.Key)
+	require.Equal(t, "romeinen 10:9-18", r.First)
+
+	_, ok = l.Readings(day(t, cal, "2026-04-04"))
+	require.False(t, ok, "Holy Saturday has no Mass")
+}
+
+// Every day of a few years has readings, but for Holy Saturday.
+func TestReadingsCoverCalendar(t *testing.T) {
+	l := Default()
+	for _, rules := range []liturgy.Rules{liturgy.DutchRules, liturgy.GeneralRules} {
+		cal := liturgy.New(rules)
+		for d := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC); d.Year() < 2031; d = d.AddDate(0, 0, 1) {
+			day := cal.Day(d)
+			r, ok := l.Readings(day)
+			if day.Celebration.Key == "holy-saturday" {
+				require.False(t, ok)
+				continue
+			}
+			require.True(t, ok, "%s %s", day.Date, day.Celebration.Key)
+			require.NotEmpty(t, r.First, day.Date)
+			require.NotEmpty(t, r.Psalm, day.Date)
+			require.NotEmpty(t, r.Gospel, day.Date)
+			if day.Celebration.Rank == liturgy.RankSunday {
+				require.NotEmpty(t, r.Second, "%s %s", day.Date, day.Celebration.Key)
+			}
+		}
+	}
+}
+
+func TestParseRejectsUnknownCycle(t *testing.T) {
+	_, err := Parse([]byte(`{"advent-1-sunday": {"D": {"first": "jesaja 2:1-5"}}}`))
+	require.ErrorContains(t, err, `unknown cycle "D"`)
+}
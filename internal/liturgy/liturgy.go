@@ -0,0 +1,228 @@
+// Package liturgy computes the Roman Catholic liturgical calendar: Easter
+// and the movable feasts, the seasons with their weeks, the Sunday and
+// weekday cycles, and the solemnities and feasts of the General Roman
+// Calendar with their colors. Memorials are not included.
+package liturgy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+)
+
+// Season is a liturgical season.
+type Season string
+
+const (
+	Advent    Season = "advent"
+	Christmas Season = "christmas"
+	Lent      Season = "lent"
+	Triduum   Season = "triduum"
+	Easter    Season = "easter"
+	Ordinary  Season = "ordinary"
+)
+
+// Name returns the Dutch name of the season.
+func (s Season) Name() string {
+	switch s {
+	case Advent:
+		return "Advent"
+	case Christmas:
+		return "Kersttijd"
+	case Lent:
+		return "Veertigdagentijd"
+	case Triduum:
+		return "Paastriduüm"
+	case Easter:
+		return "Paastijd"
+	}
+	return "Door het jaar"
+}
+
+// Rank is the kind of a celebration.
+type Rank string
+
+const (
+	RankTriduum       Rank = "triduum"
+	RankSolemnity     Rank = "solemnity"
+	RankCommemoration Rank = "commemoration"
+	RankFeast         Rank = "feast"
+	RankSunday        Rank = "sunday"
+	RankWeekday       Rank = "weekday"
+)
+
+// Color is a liturgical color.
+type Color string
+
+const (
+	White  Color = "white"
+	Red    Color = "red"
+	Green  Color = "green"
+	Violet Color = "violet"
+	Rose   Color = "rose"
+)
+
+// Celebration is what a day celebrates: a solemnity or feast, or the
+// Sunday or weekday of its season.
+type Celebration struct {
+	// Key identifies the celebration across years, such as "pentecost",
+	// "ordinary-15-sunday" or "advent-12-17".
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Rank  Rank   `json:"rank"`
+	Color Color  `json:"color"`
+
+	// precedence is the number in the Table of Liturgical Days; the lower
+	// number wins when two celebrations meet.
+	precedence int
+}
+
+// Precedence in the Table of Liturgical Days, from the General Norms for
+// the Liturgical Year and the Calendar.
+const (
+	precTriduum           = 1  // the Triduum and Easter Sunday
+	precPrivileged        = 2  // Christmas, Epiphany, Ascension, Pentecost, Sundays of Advent, Lent and Easter, Ash Wednesday, Holy Week, the Easter octave
+	precSolemnity         = 3  // solemnities of the general calendar, All Souls
+	precLordFeast         = 5  // feasts of the Lord
+	precSunday            = 6  // Sundays of Christmas time and Ordinary Time
+	precFeast             = 7  // feasts of Mary and the saints
+	precPrivilegedWeekday = 9  // Advent from 17 December, the Christmas octave, Lent
+	precWeekday           = 13 // other weekdays
+)
+
+// Day describes one day of the liturgical year.
+type Day struct {
+	Date string `json:"date"`
+	// Year is the liturgical year, named after the calendar year in which
+	// it ends: Advent 2025 starts liturgical year 2026.
+	Year       int    `json:"year"`
+	Season     Season `json:"season"`
+	SeasonName string `json:"seasonName"`
+	// Week is the week within the season: 1-4 in Advent, 0 for the days
+	// after Ash Wednesday and 1-6 in Lent, 1-8 in Easter time and 1-34 in
+	// Ordinary Time. Christmas time and the Triduum have no numbered weeks
+	// and use 0.
+	Week         int         `json:"week"`
+	SundayCycle  string      `json:"sundayCycle"`
+	WeekdayCycle string      `json:"weekdayCycle"`
+	Celebration  Celebration `json:"celebration"`
+}
+
+// Rules are the choices a bishops' conference makes for the movable
+// solemnities that are not holy days of obligation.
+type Rules struct {
+	// EpiphanyOnSunday celebrates Epiphany on the Sunday between 2 and 8
+	// January instead of on 6 January.
+	EpiphanyOnSunday bool
+	// AscensionOnSunday moves Ascension from Thursday to the seventh
+	// Sunday of Easter.
+	AscensionOnSunday bool
+	// CorpusChristiOnSunday moves Corpus Christi from Thursday to the
+	// Sunday after Trinity Sunday.
+	CorpusChristiOnSunday bool
+}
+
+var (
+	// GeneralRules keep the dates of the General Roman Calendar.
+	GeneralRules = Rules{}
+	// DutchRules are the rules of the Netherlands and Belgium: Epiphany and
+	// Corpus Christi on Sunday, Ascension on its Thursday.
+	DutchRules = Rules{EpiphanyOnSunday: true, CorpusChristiOnSunday: true}
+)
+
+// Calendar computes days under a set of rules. It is safe for concurrent
+// use.
+type Calendar struct {
+	rules Rules
+	years *cache.LRU[int, *year]
+}
+
+// New returns a calendar with the given rules.
+func New(rules Rules) *Calendar {
+	return &Calendar{rules: rules, years: cache.NewLRU[int, *year](8)}
+}
+
+// Day returns the liturgical day of the date of t, in t's location.
+func (c *Calendar) Day(t time.Time) Day {
+	d := date(t.Year(), t.Month(), t.Day())
+	y := c.year(d.Year())
+
+	season, week, cel := y.seasonDay(d)
+	// A solemnity or feast takes the place of the day when it ranks at least
+	// as high, as Ascension does on the seventh Sunday of Easter.
+	for _, other := range y.celebrations[d.YearDay()] {
+		if other.precedence <= cel.precedence {
+			cel = other
+		}
+	}
+
+	litYear := d.Year()
+	if !d.Before(y.advent) {
+		litYear++
+	}
+	return Day{
+		Date:         d.Format(time.DateOnly),
+		Year:         litYear,
+		Season:       season,
+		SeasonName:   season.Name(),
+		Week:         week,
+		SundayCycle:  string("ABC"[(litYear-1)%3]),
+		WeekdayCycle: map[bool]string{true: "I", false: "II"}[litYear%2 == 1],
+		Celebration:  cel,
+	}
+}
+
+func (c *Calendar) year(y int) *year {
+	yr, _ := c.years.GetOrLoad(y, func(y int) (*year, error) {
+		return newYear(y, c.rules), nil
+	})
+	return yr
+}
+
+// EasterDate returns Easter Sunday of a year in the Gregorian calendar,
+// with the anonymous Gregorian algorithm (Meeus/Jones/Butcher).
+func EasterDate(y int) time.Time {
+	a := y % 19
+	b, c := y/100, y%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(y, time.Month(month), day)
+}
+
+// date returns midnight UTC of a day, the form all dates take here.
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// days returns the number of days from a to b.
+func days(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// sundayOnOrBefore returns the Sunday on or before d.
+func sundayOnOrBefore(d time.Time) time.Time {
+	return d.AddDate(0, 0, -int(d.Weekday()))
+}
+
+var weekdayNames = [...]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"}
+
+var monthNames = [...]string{"", "januari", "februari", "maart", "april", "mei", "juni",
+	"juli", "augustus", "september", "oktober", "november", "december"}
+
+// capitalize upper-cases the first letter of a Dutch weekday name.
+func capitalize(s string) string {
+	return string(s[0]-'a'+'A') + s[1:]
+}
+
+// dayMonth formats a date as "17 december".
+func dayMonth(d time.Time) string {
+	return fmt.Sprintf("%d %s", d.Day(), monthNames[d.Month()])
+}
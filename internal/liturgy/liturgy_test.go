@@ -0,0 +1,164 @@
+package liturgy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEasterDate(t *testing.T) {
+	for y, want := range map[int]string{
+		1818: "1818-03-22",
+		1943: "1943-04-25",
+		2000: "2000-04-23",
+		2019: "2019-04-21",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+		2038: "2038-04-25",
+	} {
+		require.Equal(t, want, EasterDate(y).Format(time.DateOnly), "Easter %d", y)
+	}
+}
+
+func TestDay(t *testing.T) {
+	dutch := New(DutchRules)
+	general := New(GeneralRules)
+
+	tests := []struct {
+		cal    *Calendar
+		date   string
+		key    string
+		season Season
+		week   int
+		color  Color
+	}{
+		{dutch, "2026-01-04", "epiphany", Christmas, 0, White},
+		{general, "2026-01-04", "christmas-2-sunday", Christmas, 0, White},
+		{general, "2026-01-06", "epiphany", Christmas, 0, White},
+		{dutch, "2026-01-11", "baptism-of-the-lord", Christmas, 0, White},
+		{dutch, "2026-01-12", "ordinary-1-monday", Ordinary, 1, Green},
+		{dutch, "2026-02-15", "ordinary-6-sunday", Ordinary, 6, Green},
+		{dutch, "2026-02-18", "ash-wednesday", Lent, 0, Violet},
+		{dutch, "2026-02-19", "lent-0-thursday", Lent, 0, Violet},
+		{dutch, "2026-03-15", "lent-4-sunday", Lent, 4, Rose},
+		{dutch, "2026-03-19", "joseph", Lent, 4, White},
+		{dutch, "2026-03-29", "palm-sunday", Lent, 6, Red},
+		{dutch, "2026-03-31", "holy-week-tuesday", Lent, 6, Violet},
+		{dutch, "2026-04-03", "good-friday", Triduum, 0, Red},
+		{dutch, "2026-04-05", "easter-sunday", Easter, 1, White},
+		{dutch, "2026-04-06", "easter-1-monday", Easter, 1, White},
+		{dutch, "2026-04-12", "easter-2-sunday", Easter, 2, White},
+		{dutch, "2026-05-14", "ascension", Easter, 6, White},
+		{general, "2026-05-17", "easter-7-sunday", Easter, 7, White},
+		{New(Rules{AscensionOnSunday: true}), "2026-05-17", "ascension", Easter, 7, White},
+		{dutch, "2026-05-24", "pentecost", Easter, 8, Red},
+		{dutch, "2026-05-25", "ordinary-8-monday", Ordinary, 8, Green},
+		{dutch, "2026-05-31", "trinity-sunday", Ordinary, 9, White},
+		{dutch, "2026-06-04", "ordinary-9-thursday", Ordinary, 9, Green},
+		{general, "2026-06-04", "corpus-christi", Ordinary, 9, White},
+		{dutch, "2026-06-07", "corpus-christi", Ordinary, 10, White},
+		{dutch, "2026-06-12", "sacred-heart", Ordinary, 10, White},
+		{dutch, "2026-08-06", "transfiguration", Ordinary, 18, White},
+		{dutch, "2026-10-18", "ordinary-29-sunday", Ordinary, 29, Green},
+		{dutch, "2026-10-19", "ordinary-29-monday", Ordinary, 29, Green},
+		{dutch, "2026-11-02", "all-souls", Ordinary, 31, Violet},
+		{dutch, "2026-11-22", "christ-the-king", Ordinary, 34, White},
+		{dutch, "2026-11-29", "advent-1-sunday", Advent, 1, Violet},
+		{dutch, "2026-11-30", "andrew", Advent, 1, Red},
+		{dutch, "2026-12-08", "immaculate-conception", Advent, 2, White},
+		{dutch, "2026-12-13", "advent-3-sunday", Advent, 3, Rose},
+		{dutch, "2026-12-17", "advent-12-17", Advent, 3, Violet},
+		{dutch, "2026-12-25", "christmas", Christmas, 0, White},
+		{dutch, "2026-12-26", "stephen", Christmas, 0, Red},
+		{dutch, "2026-12-27", "holy-family", Christmas, 0, White},
+		{dutch, "2026-12-29", "christmas-12-29", Christmas, 0, White},
+		{dutch, "2027-01-01", "mary-mother-of-god", Christmas, 0, White},
+
+		// Epiphany on 7 or 8 January moves the Baptism to Monday.
+		{dutch, "2023-01-08", "epiphany", Christmas, 0, White},
+		{dutch, "2023-01-09", "baptism-of-the-lord", Christmas, 0, White},
+		{dutch, "2023-01-10", "ordinary-1-tuesday", Ordinary, 1, Green},
+		{dutch, "2023-01-15", "ordinary-2-sunday", Ordinary, 2, Green},
+
+		// Christmas on a Sunday moves the Holy Family to 30 December.
+		{dutch, "2022-12-30", "holy-family", Christmas, 0, White},
+
+		// Transfers.
+		{dutch, "2024-03-25", "holy-week-monday", Lent, 6, Violet},
+		{dutch, "2024-04-08", "annunciation", Easter, 2, White},
+		{dutch, "2024-12-08", "advent-2-sunday", Advent, 2, Violet},
+		{dutch, "2024-12-09", "immaculate-conception", Advent, 2, White},
+		{dutch, "2022-06-23", "birth-of-john-the-baptist", Ordinary, 12, White},
+		{dutch, "2022-06-24", "sacred-heart", Ordinary, 12, White},
+		{dutch, "2035-03-17", "joseph", Lent, 5, White},
+		{dutch, "2035-03-19", "holy-week-monday", Lent, 6, Violet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date+" "+tt.key, func(t *testing.T) {
+			d, err := time.Parse(time.DateOnly, tt.date)
+			require.NoError(t, err)
+			day := tt.cal.Day(d)
+			require.Equal(t, tt.date, day.Date)
+			require.Equal(t, tt.key, day.Celebration.Key)
+			require.Equal(t, tt.season, day.Season)
+			require.Equal(t, tt.week, day.Week)
+			require.Equal(t, tt.color, day.Celebration.Color)
+			require.NotEmpty(t, day.Celebration.Name)
+		})
+	}
+}
+
+func TestCycles(t *testing.T) {
+	cal := New(DutchRules)
+	tests := []struct {
+		date    string
+		year    int
+		sunday  string
+		weekday string
+	}{
+		{"2025-11-29", 2025, "C", "I"},
+		{"2025-11-30", 2026, "A", "II"},
+		{"2026-10-18", 2026, "A", "II"},
+		{"2026-11-29", 2027, "B", "I"},
+		{"2027-12-25", 2028, "C", "II"},
+	}
+	for _, tt := range tests {
+		d, err := time.Parse(time.DateOnly, tt.date)
+		require.NoError(t, err)
+		day := cal.Day(d)
+		require.Equal(t, tt.year, day.Year, tt.date)
+		require.Equal(t, tt.sunday, day.SundayCycle, tt.date)
+		require.Equal(t, tt.weekday, day.WeekdayCycle, tt.date)
+	}
+}
+
+func TestDayUsesLocalDate(t *testing.T) {
+	// 23:30 UTC on Saturday is already Sunday in Amsterdam.
+	d := time.Date(2026, time.October, 17, 23, 30, 0, 0, time.UTC).In(time.FixedZone("CEST", 2*60*60))
+	require.Equal(t, "2026-10-18", New(DutchRules).Day(d).Date)
+}
+
+// Every day of a range of years gets a season, a week in range and a named
+// celebration.
+func TestDayCoversEveryDay(t *testing.T) {
+	cal := New(DutchRules)
+	for d := date(2000, time.January, 1); d.Year() < 2050; d = d.AddDate(0, 0, 1) {
+		day := cal.Day(d)
+		require.NotEmpty(t, day.Celebration.Key, day.Date)
+		require.NotEmpty(t, day.Celebration.Name, day.Date)
+		require.NotEmpty(t, day.Celebration.Color, day.Date)
+		switch day.Season {
+		case Ordinary:
+			require.True(t, day.Week >= 1 && day.Week <= 34, "%s week %d", day.Date, day.Week)
+		case Advent:
+			require.True(t, day.Week >= 1 && day.Week <= 4, "%s week %d", day.Date, day.Week)
+		case Lent:
+			require.True(t, day.Week >= 0 && day.Week <= 6, "%s week %d", day.Date, day.Week)
+		case Easter:
+			require.True(t, day.Week >= 1 && day.Week <= 8, "%s week %d", day.Date, day.Week)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package liturgy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// year holds the dates that a calendar year's days are computed from, and
+// the solemnities and feasts placed on their day after transfers.
+type year struct {
+	y         int
+	baptism   time.Time
+	ashWed    time.Time
+	easter    time.Time
+	pentecost time.Time
+	// advent is the first Sunday of Advent in this calendar year, which
+	// starts the next liturgical year.
+	advent time.Time
+
+	celebrations map[int][]Celebration
+}
+
+// fixed is a solemnity or feast on a fixed date.
+type fixed struct {
+	month time.Month
+	day   int
+	Celebration
+}
+
+func solemnity(key, name string, color Color) Celebration {
+	return Celebration{Key: key, Name: name, Rank: RankSolemnity, Color: color, precedence: precSolemnity}
+}
+
+func lordFeast(key, name string, color Color) Celebration {
+	return Celebration{Key: key, Name: name, Rank: RankFeast, Color: color, precedence: precLordFeast}
+}
+
+func feast(key, name string, color Color) Celebration {
+	return Celebration{Key: key, Name: name, Rank: RankFeast, Color: color, precedence: precFeast}
+}
+
+// fixedCelebrations are the solemnities and feasts of the General Roman
+// Calendar on a fixed date. Christmas and Mary, Mother of God are part of
+// the Christmas season.
+var fixedCelebrations = []fixed{
+	{time.January, 25, feast("conversion-of-paul", "Bekering van de heilige apostel Paulus", White)},
+	{time.February, 2, lordFeast("presentation-of-the-lord", "Opdracht van de Heer in de tempel", White)},
+	{time.February, 22, feast("chair-of-peter", "Sint-Pieters Stoel", White)},
+	{time.March, 19, solemnity("joseph", "Heilige Jozef, bruidegom van de heilige Maagd Maria", White)},
+	{time.March, 25, solemnity("annunciation", "Aankondiging van de Heer", White)},
+	{time.April, 25, feast("mark", "Heilige Marcus, evangelist", Red)},
+	{time.May, 3, feast("philip-and-james", "Heilige Filippus en Jakobus, apostelen", Red)},
+	{time.May, 14, feast("matthias", "Heilige Mattias, apostel", Red)},
+	{time.May, 31, feast("visitation", "Bezoek van de heilige Maagd Maria", White)},
+	{time.June, 24, solemnity("birth-of-john-the-baptist", "Geboorte van de heilige Johannes de Doper", White)},
+	{time.June, 29, solemnity("peter-and-paul", "Heilige Petrus en Paulus, apostelen", Red)},
+	{time.July, 3, feast("thomas", "Heilige Tomas, apostel", Red)},
+	{time.July, 22, feast("mary-magdalene", "Heilige Maria Magdalena", White)},
+	{time.July, 25, feast("james", "Heilige Jakobus, apostel", Red)},
+	{time.August, 6, lordFeast("transfiguration", "Gedaanteverandering van de Heer", White)},
+	{time.August, 10, feast("lawrence", "Heilige Laurentius, diaken en martelaar", Red)},
+	{time.August, 15, solemnity("assumption", "Maria Tenhemelopneming", White)},
+	{time.August, 24, feast("bartholomew", "Heilige Bartolomeüs, apostel", Red)},
+	{time.September, 8, feast("birth-of-mary", "Geboorte van de heilige Maagd Maria", White)},
+	{time.September, 14, lordFeast("exaltation-of-the-cross", "Kruisverheffing", Red)},
+	{time.September, 21, feast("matthew", "Heilige Matteüs, apostel en evangelist", Red)},
+	{time.September, 29, feast("archangels", "Heilige Michaël, Gabriël en Rafaël, aartsengelen", White)},
+	{time.October, 18, feast("luke", "Heilige Lucas, evangelist", Red)},
+	{time.October, 28, feast("simon-and-jude", "Heilige Simon en Judas, apostelen", Red)},
+	{time.November, 1, solemnity("all-saints", "Allerheiligen", White)},
+	{time.November, 2, Celebration{Key: "all-souls", Name: "Allerzielen", Rank: RankCommemoration, Color: Violet, precedence: precSolemnity}},
+	// The dedication of the Lateran basilica ranks with the feasts of the
+	// Lord and so takes the place of a Sunday.
+	{time.November, 9, lordFeast("dedication-of-the-lateran", "Kerkwijding van de basiliek van Sint-Jan van Lateranen", White)},
+	{time.November, 30, feast("andrew", "Heilige Andreas, apostel", Red)},
+	{time.December, 8, solemnity("immaculate-conception", "Onbevlekte Ontvangenis van de heilige Maagd Maria", White)},
+	{time.December, 26, feast("stephen", "Heilige Stefanus, eerste martelaar", Red)},
+	{time.December, 27, feast("john", "Heilige Johannes, apostel en evangelist", White)},
+	{time.December, 28, feast("holy-innocents", "Heilige Onnozele Kinderen, martelaren", Red)},
+}
+
+func newYear(y int, rules Rules) *year {
+	easter := EasterDate(y)
+	yr := &year{
+		y:            y,
+		ashWed:       easter.AddDate(0, 0, -46),
+		easter:       easter,
+		pentecost:    easter.AddDate(0, 0, 49),
+		advent:       sundayOnOrBefore(date(y, time.December, 3)),
+		celebrations: make(map[int][]Celebration),
+	}
+
+	epiphany := date(y, time.January, 6)
+	if rules.EpiphanyOnSunday {
+		epiphany = sundayOnOrBefore(date(y, time.January, 8))
+	}
+	// The Baptism of the Lord is the Sunday after Epiphany, or the Monday
+	// when Epiphany falls on 7 or 8 January and takes that Sunday.
+	yr.baptism = sundayOnOrBefore(epiphany).AddDate(0, 0, 7)
+	if rules.EpiphanyOnSunday && epiphany.Day() >= 7 {
+		yr.baptism = epiphany.AddDate(0, 0, 1)
+	}
+	yr.add(epiphany, Celebration{Key: "epiphany", Name: "Openbaring van de Heer", Rank: RankSolemnity, Color: White, precedence: precPrivileged})
+	yr.add(yr.baptism, lordFeast("baptism-of-the-lord", "Doop van de Heer", White))
+
+	ascension := easter.AddDate(0, 0, 39)
+	if rules.AscensionOnSunday {
+		ascension = easter.AddDate(0, 0, 42)
+	}
+	yr.add(ascension, Celebration{Key: "ascension", Name: "Hemelvaart van de Heer", Rank: RankSolemnity, Color: White, precedence: precPrivileged})
+
+	corpusChristi := yr.pentecost.AddDate(0, 0, 11)
+	if rules.CorpusChristiOnSunday {
+		corpusChristi = yr.pentecost.AddDate(0, 0, 14)
+	}
+	sacredHeart := yr.pentecost.AddDate(0, 0, 19)
+	yr.add(yr.pentecost.AddDate(0, 0, 7), solemnity("trinity-sunday", "Heilige Drie-eenheid", White))
+	yr.add(corpusChristi, solemnity("corpus-christi", "Heilig Sacrament van het Lichaam en Bloed van Christus", White))
+	yr.add(sacredHeart, solemnity("sacred-heart", "Heilig Hart van Jezus", White))
+	yr.add(yr.advent.AddDate(0, 0, -7), solemnity("christ-the-king", "Christus, Koning van het heelal", White))
+
+	// The Holy Family is the Sunday within the Christmas octave, or 30
+	// December when Christmas is a Sunday.
+	holyFamily := sundayOnOrBefore(date(y, time.December, 31))
+	if holyFamily.Day() == 25 {
+		holyFamily = date(y, time.December, 30)
+	}
+	yr.add(holyFamily, lordFeast("holy-family", "Heilige Familie", White))
+
+	palmSunday := easter.AddDate(0, 0, -7)
+	for _, f := range fixedCelebrations {
+		d := date(y, f.month, f.day)
+		switch {
+		case f.Key == "joseph" && !d.Before(palmSunday):
+			// Moved before Holy Week rather than after Easter.
+			d = palmSunday.AddDate(0, 0, -1)
+		case f.Key == "annunciation" && !d.Before(palmSunday) && !d.After(easter.AddDate(0, 0, 7)):
+			// Moved to the Monday after the Easter octave.
+			d = easter.AddDate(0, 0, 8)
+		case f.Key == "birth-of-john-the-baptist" && d.Equal(sacredHeart):
+			// Anticipated by a day rather than postponed.
+			d = d.AddDate(0, 0, -1)
+		}
+		if f.precedence <= precSolemnity {
+			for yr.impeded(d) {
+				d = d.AddDate(0, 0, 1)
+			}
+		}
+		yr.add(d, f.Celebration)
+	}
+	return yr
+}
+
+func (yr *year) add(d time.Time, c Celebration) {
+	yr.celebrations[d.YearDay()] = append(yr.celebrations[d.YearDay()], c)
+}
+
+// impeded reports whether a solemnity can't be celebrated on d because the
+// day has a higher precedence or already has a solemnity, in which case it
+// is transferred to the next day that is free.
+func (yr *year) impeded(d time.Time) bool {
+	if _, _, c := yr.seasonDay(d); c.precedence < precSolemnity {
+		return true
+	}
+	for _, c := range yr.celebrations[d.YearDay()] {
+		if c.precedence <= precSolemnity {
+			return true
+		}
+	}
+	return false
+}
+
+// seasonDay returns the season and week of d and the Sunday or weekday it
+// is within that season, before solemnities and feasts are considered.
+func (yr *year) seasonDay(d time.Time) (Season, int, Celebration) {
+	weekday := weekdayNames[d.Weekday()]
+	key := strings.ToLower(d.Weekday().String())
+	christmas := date(yr.y, time.December, 25)
+	holyThursday := yr.easter.AddDate(0, 0, -3)
+
+	switch {
+	case d.Equal(christmas):
+		return Christmas, 0, Celebration{Key: "christmas", Name: "Geboorte van de Heer", Rank: RankSolemnity, Color: White, precedence: precPrivileged}
+
+	case d.After(christmas):
+		return Christmas, 0, Celebration{
+			Key:        fmt.Sprintf("christmas-12-%02d", d.Day()),
+			Name:       dayMonth(d) + ", onder het octaaf van Kerstmis",
+			Rank:       RankWeekday,
+			Color:      White,
+			precedence: precPrivilegedWeekday,
+		}
+
+	case !d.Before(yr.advent):
+		week := days(yr.advent, d)/7 + 1
+		if d.Weekday() == time.Sunday {
+			color := Violet
+			if week == 3 {
+				color = Rose
+			}
+			return Advent, week, Celebration{
+				Key:        fmt.Sprintf("advent-%d-sunday", week),
+				Name:       fmt.Sprintf("%de zondag van de Advent", week),
+				Rank:       RankSunday,
+				Color:      color,
+				precedence: precPrivileged,
+			}
+		}
+		if d.Day() >= 17 && d.Month() == time.December {
+			return Advent, week, Celebration{
+				Key:        fmt.Sprintf("advent-12-%02d", d.Day()),
+				Name:       fmt.Sprintf("%s %s in de Advent", capitalize(weekday), dayMonth(d)),
+				Rank:       RankWeekday,
+				Color:      Violet,
+				precedence: precPrivilegedWeekday,
+			}
+		}
+		return Advent, week, Celebration{
+			Key:        fmt.Sprintf("advent-%d-%s", week, key),
+			Name:       fmt.Sprintf("%s in week %d van de Advent", capitalize(weekday), week),
+			Rank:       RankWeekday,
+			Color:      Violet,
+			precedence: precWeekday,
+		}
+
+	case d.Month() == time.January && d.Day() == 1:
+		return Christmas, 0, Celebration{Key: "mary-mother-of-god", Name: "Heilige Maria, Moeder van God", Rank: RankSolemnity, Color: White, precedence: precSolemnity}
+
+	case !d.After(yr.baptism):
+		if d.Weekday() == time.Sunday {
+			return Christmas, 0, Celebration{Key: "christmas-2-sunday", Name: "2e zondag na Kerstmis", Rank: RankSunday, Color: White, precedence: precSunday}
+		}
+		return Christmas, 0, Celebration{
+			Key:        fmt.Sprintf("christmas-01-%02d", d.Day()),
+			Name:       fmt.Sprintf("%s %s in de kersttijd", capitalize(weekday), dayMonth(d)),
+			Rank:       RankWeekday,
+			Color:      White,
+			precedence: precWeekday,
+		}
+
+	case d.Before(yr.ashWed):
+		week := days(sundayOnOrBefore(yr.baptism), sundayOnOrBefore(d))/7 + 1
+		return Ordinary, week, ordinaryDay(d, week)
+
+	case d.Before(holyThursday):
+		return yr.lentDay(d)
+
+	case d.Before(yr.easter):
+		c := Celebration{Rank: RankTriduum, precedence: precTriduum}
+		switch days(d, yr.easter) {
+		case 3:
+			c.Key, c.Name, c.Color = "holy-thursday", "Witte Donderdag", White
+		case 2:
+			c.Key, c.Name, c.Color = "good-friday", "Goede Vrijdag", Red
+		default:
+			c.Key, c.Name, c.Color = "holy-saturday", "Stille Zaterdag", Violet
+		}
+		return Triduum, 0, c
+
+	case !d.After(yr.pentecost):
+		return yr.easterDay(d)
+	}
+
+	week := 35 - days(sundayOnOrBefore(d), yr.advent)/7
+	return Ordinary, week, ordinaryDay(d, week)
+}
+
+func ordinaryDay(d time.Time, week int) Celebration {
+	if d.Weekday() == time.Sunday {
+		return Celebration{
+			Key:        fmt.Sprintf("ordinary-%d-sunday", week),
+			Name:       fmt.Sprintf("%de zondag door het jaar", week),
+			Rank:       RankSunday,
+			Color:      Green,
+			precedence: precSunday,
+		}
+	}
+	return Celebration{
+		Key:        fmt.Sprintf("ordinary-%d-%s", week, strings.ToLower(d.Weekday().String())),
+		Name:       fmt.Sprintf("%s in week %d door het jaar", capitalize(weekdayNames[d.Weekday()]), week),
+		Rank:       RankWeekday,
+		Color:      Green,
+		precedence: precWeekday,
+	}
+}
+
+// lentDay returns a day from Ash Wednesday to the Wednesday of Holy Week.
+// The days after Ash Wednesday are week 0 and Holy Week is week 6.
+func (yr *year) lentDay(d time.Time) (Season, int, Celebration) {
+	firstSunday := yr.easter.AddDate(0, 0, -42)
+	week := 0
+	if !d.Before(firstSunday) {
+		week = days(firstSunday, d)/7 + 1
+	}
+	weekday := weekdayNames[d.Weekday()]
+	key := strings.ToLower(d.Weekday().String())
+
+	c := Celebration{Rank: RankWeekday, Color: Violet, precedence: precPrivilegedWeekday}
+	switch {
+	case d.Equal(yr.ashWed):
+		c.Key, c.Name, c.precedence = "ash-wednesday", "Aswoensdag", precPrivileged
+	case week == 6 && d.Weekday() == time.Sunday:
+		c.Key, c.Name, c.Rank, c.Color, c.precedence = "palm-sunday", "Palmzondag van het lijden van de Heer", RankSunday, Red, precPrivileged
+	case week == 6:
+		c.Key, c.Name, c.precedence = "holy-week-"+key, capitalize(weekday)+" in de Goede Week", precPrivileged
+	case d.Weekday() == time.Sunday:
+		c.Key = fmt.Sprintf("lent-%d-sunday", week)
+		c.Name = fmt.Sprintf("%de zondag van de Veertigdagentijd", week)
+		c.Rank, c.precedence = RankSunday, precPrivileged
+		if week == 4 {
+			c.Color = Rose
+		}
+	case week == 0:
+		c.Key, c.Name = "lent-0-"+key, capitalize(weekday)+" na Aswoensdag"
+	default:
+		c.Key = fmt.Sprintf("lent-%d-%s", week, key)
+		c.Name = fmt.Sprintf("%s in week %d van de Veertigdagentijd", capitalize(weekday), week)
+	}
+	return Lent, week, c
+}
+
+// easterDay returns a day from Easter Sunday to Pentecost.
+func (yr *year) easterDay(d time.Time) (Season, int, Celebration) {
+	n := days(yr.easter, d)
+	week := n/7 + 1
+	weekday := weekdayNames[d.Weekday()]
+	key := strings.ToLower(d.Weekday().String())
+
+	switch {
+	case n == 0:
+		return Easter, week, Celebration{Key: "easter-sunday", Name: "Paaszondag, Verrijzenis van de Heer", Rank: RankSolemnity, Color: White, precedence: precTriduum}
+	case n == 49:
+		return Easter, week, Celebration{Key: "pentecost", Name: "Pinksteren", Rank: RankSolemnity, Color: Red, precedence: precPrivileged}
+	case n < 7:
+		// The days of the Easter octave rank as solemnities of the Lord.
+		return Easter, week, Celebration{
+			Key:        "easter-1-" + key,
+			Name:       capitalize(weekday) + " onder het octaaf van Pasen",
+			Rank:       RankSolemnity,
+			Color:      White,
+			precedence: precPrivileged,
+		}
+	case d.Weekday() == time.Sunday:
+		return Easter, week, Celebration{
+			Key:        fmt.Sprintf("easter-%d-sunday", week),
+			Name:       fmt.Sprintf("%de zondag van Pasen", week),
+			Rank:       RankSunday,
+			Color:      White,
+			precedence: precPrivileged,
+		}
+	}
+	return Easter, week, Celebration{
+		Key:        fmt.Sprintf("easter-%d-%s", week, key),
+		Name:       fmt.Sprintf("%s in week %d van de Paastijd", capitalize(weekday), week),
+		Rank:       RankWeekday,
+		Color:      White,
+		precedence: precWeekday,
+	}
+}
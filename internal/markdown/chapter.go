@@ -0,0 +1,250 @@
+package markdown
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+type chapter struct {
+	number  int
+	content string
+}
+
+// chapters renders the notes of a book in chapter order.
+func (e *exporter) chapters(book bible.SourceBook) []chapter {
+	verses := slices.Clone(book.Verses)
+	slices.SortStableFunc(verses, func(a, b bible.SourceVerse) int {
+		return cmp.Or(a.Chapter-b.Chapter, a.Verse-b.Verse)
+	})
+	links := e.crossReferences(book.Id, verses)
+
+	var chapters []chapter
+	for start := 0; start < len(verses); {
+		end := start
+		for end < len(verses) && verses[end].Chapter == verses[start].Chapter {
+			end++
+		}
+		number := verses[start].Chapter
+		chapters = append(chapters, chapter{number, e.chapter(book, number, verses[start:end], links)})
+		start = end
+	}
+	return chapters
+}
+
+// chapter renders a chapter: front matter, the heading, section titles,
+// paragraphs with verse anchors, the cross-references and the footnotes.
+func (e *exporter) chapter(book bible.SourceBook, number int, verses []bible.SourceVerse, links map[versification.Verse][]string) string {
+	meta := e.repo.GetBook(book.Id)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "---\nbook: %s\nname: %s\nchapter: %d\norder: %d\n---\n\n", book.Id, strconv.Quote(meta.Name), bible.ChapterNumber(number), meta.Order)
+	if book.Chapters > 1 {
+		fmt.Fprintf(&sb, "# %s %d\n", meta.Name, number)
+	} else {
+		fmt.Fprintf(&sb, "# %s\n", meta.Name)
+	}
+
+	w := &chapterWriter{sb: &sb}
+	var refs []string
+	for _, v := range verses {
+		if v.Title != nil && strings.TrimSpace(*v.Title) != "" {
+			w.endParagraph()
+			sb.WriteString("\n## " + escape(strings.Join(strings.Fields(*v.Title), " ")) + "\n")
+		}
+		if v.Paragraph == "y" {
+			w.endParagraph()
+		}
+		w.verse(v)
+		if l := links[versification.Verse{Book: book.Id, Chapter: v.Chapter, Verse: v.Verse}]; len(l) > 0 {
+			refs = append(refs, fmt.Sprintf("- **%d** %s\n", v.Verse, strings.Join(l, ", ")))
+		}
+	}
+	w.endParagraph()
+
+	if len(refs) > 0 {
+		sb.WriteString("\n## Kruisverwijzingen\n\n")
+		for _, r := range refs {
+			sb.WriteString(r)
+		}
+	}
+	if len(w.notes) > 0 {
+		sb.WriteString("\n")
+		for i, n := range w.notes {
+			fmt.Fprintf(&sb, "[^%d]: %s\n", i+1, n)
+		}
+	}
+	return sb.String()
+}
+
+type chapterWriter struct {
+	sb *strings.Builder
+	// open is set while a paragraph is being written.
+	open bool
+	// notes holds the footnotes of the chapter.
+	notes []string
+}
+
+func (w *chapterWriter) endParagraph() {
+	if w.open {
+		w.sb.WriteString("\n")
+		w.open = false
+	}
+}
+
+// verse writes a verse with an anchor that the wiki-links point at. The
+// verses of a paragraph share a line; poetry lines end in a hard break.
+func (w *chapterWriter) verse(v bible.SourceVerse) {
+	if w.open {
+		w.sb.WriteString(" ")
+	} else {
+		w.sb.WriteString("\n")
+		w.open = true
+	}
+	fmt.Fprintf(w.sb, `<sup id="v%d">%d</sup>`, v.Verse, v.Verse)
+
+	var lines [][]bible.Node
+	if v.TextJson != nil {
+		lines = v.TextJson.Lines()
+	}
+	for i, line := range lines {
+		if i > 0 {
+			w.sb.WriteString("\\\n")
+		} else {
+			w.sb.WriteString(" ")
+		}
+		w.sb.WriteString(strings.TrimSpace(w.inline(line, v)))
+	}
+}
+
+var whitespace = regexp.MustCompile(`[\s\x00-\x1F\x{00A0}]+`)
+
+// inline renders nodes as Markdown. Emphasis and quotations become italics
+// and footnotes become Markdown footnotes.
+func (w *chapterWriter) inline(nodes []bible.Node, v bible.SourceVerse) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.Tag {
+		case "":
+			sb.WriteString(escape(whitespace.ReplaceAllString(n.Text, " ")))
+		case "br":
+			sb.WriteByte(' ')
+		case "abbr":
+			sb.WriteString(w.footnote(n, v))
+		case "em", "i", "blockquote":
+			if text := strings.TrimSpace(w.inline(n.Children, v)); text != "" {
+				sb.WriteString("*" + text + "*")
+			}
+		case "div", "p":
+			// Block elements separate words, see Node.PlainText.
+			sb.WriteString(" " + w.inline(n.Children, v))
+		default:
+			sb.WriteString(w.inline(n.Children, v))
+		}
+	}
+	return sb.String()
+}
+
+// footnote queues an abbr footnote and returns its marker.
+func (w *chapterWriter) footnote(n bible.Node, v bible.SourceVerse) string {
+	note := fmt.Sprintf("**%d,%d**", bible.ChapterNumber(v.Chapter), v.Verse)
+	if ref := strings.Join(strings.Fields(n.Ref), " "); ref != "" {
+		note += " " + escape(ref)
+	}
+	if title := strings.Join(strings.Fields(n.Title), " "); title != "" {
+		note += " " + escape(title)
+	}
+	w.notes = append(w.notes, note)
+	return fmt.Sprintf("[^%d]", len(w.notes))
+}
+
+// escape keeps text from being read as Markdown or, for #, as an Obsidian
+// tag.
+var escape = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, "`", "\\`", "#", `\#`,
+).Replace
+
+type link struct {
+	text  string
+	votes int
+}
+
+// crossReferences returns the wiki-links of the verses of a book, keyed by
+// verse and sorted by votes. Books without cross-reference data have none.
+func (e *exporter) crossReferences(bookId string, verses []bible.SourceVerse) map[versification.Verse][]string {
+	result := map[versification.Verse][]string{}
+	if !e.opts.CrossReferences {
+		return result
+	}
+	refs, err := crossref.GetCrossReferences(bookId)
+	if err != nil {
+		// Not every book has cross-reference data.
+		return result
+	}
+	kjv, _ := versification.Lookup(versification.KJV)
+
+	exists := map[versification.Verse]bool{}
+	for _, v := range verses {
+		exists[versification.Verse{Book: bookId, Chapter: v.Chapter, Verse: v.Verse}] = true
+	}
+	// find returns the verse of the book that v refers to.
+	find := func(v versification.Verse) (versification.Verse, bool) {
+		var ok bool
+		v.Chapter, ok = bible.FindChapter(v.Chapter, func(c int) bool {
+			return exists[versification.Verse{Book: v.Book, Chapter: c, Verse: v.Verse}]
+		})
+		return v, ok
+	}
+	convert := func(ref crossref.VerseRef, chapter, verse int) versification.Verse {
+		v := versification.Verse{Book: ref.Book, Chapter: chapter, Verse: verse}
+		if c := versification.Convert(v, kjv, e.opts.Versification); len(c) > 0 {
+			return c[0]
+		}
+		return v
+	}
+
+	links := map[versification.Verse][]link{}
+	for _, ref := range refs.CrossReferences {
+		if ref.Votes < e.opts.MinVotes {
+			continue
+		}
+		ref, err := crossref.TranslateCrossRefToDutch(ref)
+		if err != nil {
+			continue
+		}
+
+		to := convert(ref.To, ref.To.Chapter, ref.To.Verse)
+		end := to
+		if ref.To.EndVerse > 0 && (ref.To.EndBook == "" || ref.To.EndBook == ref.To.Book) {
+			end = convert(ref.To, cmp.Or(ref.To.EndChapter, ref.To.Chapter), ref.To.EndVerse)
+		}
+		text, ok := e.link(to, end)
+		if !ok {
+			continue
+		}
+		for _, from := range versification.Convert(versification.Verse{Book: bookId, Chapter: ref.From.Chapter, Verse: ref.From.Verse}, kjv, e.opts.Versification) {
+			if from, ok := find(from); ok {
+				links[from] = append(links[from], link{text, ref.Votes})
+			}
+		}
+	}
+
+	for v, l := range links {
+		slices.SortStableFunc(l, func(a, b link) int { return b.votes - a.votes })
+		seen := map[string]bool{}
+		for _, l := range l {
+			if !seen[l.text] {
+				seen[l.text] = true
+				result[v] = append(result[v], l.text)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+// Package markdown exports the Bible text as a vault of Markdown notes, one
+// per chapter, for Obsidian, Logseq and similar tools.
+package markdown
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// DefaultFileName names the notes after the book and chapter, such as
+// "Genesis 1", which is also how the wiki-links read.
+const DefaultFileName = "{name} {chapter}"
+
+// Options configures an export.
+type Options struct {
+	// Books lists the ids of the books to export, all books when empty.
+	Books []string
+	// FileName is the path of a note relative to the vault, without the .md
+	// extension. {id}, {name}, {order} (two digits) and {chapter} are
+	// replaced; a slash puts the notes in folders. DefaultFileName when
+	// empty.
+	FileName string
+	// CrossReferences adds the cross-references with at least MinVotes
+	// votes as wiki-links to the verses they point at.
+	CrossReferences bool
+	MinVotes        int
+	// Versification is the numbering of the books, into which the English
+	// numbering of the cross-references is converted. Nil means the
+	// standard scheme.
+	Versification *versification.Scheme
+}
+
+// Export writes a note for every chapter of the books of repo to dir and
+// returns the number of notes written.
+func Export(dir string, repo bible.Repository, opts Options) (int, error) {
+	if opts.FileName == "" {
+		opts.FileName = DefaultFileName
+	}
+	if !strings.Contains(opts.FileName, "{chapter}") {
+		return 0, fmt.Errorf("file name %q lacks {chapter}", opts.FileName)
+	}
+	if opts.Versification == nil {
+		opts.Versification, _ = versification.Lookup(versification.Standard)
+	}
+
+	ids := opts.Books
+	if len(ids) == 0 {
+		for _, b := range repo.GetBooks() {
+			ids = append(ids, b.Id)
+		}
+	}
+
+	e := &exporter{opts: opts, repo: repo}
+	written := 0
+	for _, id := range ids {
+		book, err := repo.ReadSourceBook(id)
+		if err != nil {
+			return written, fmt.Errorf("book %s: %w", id, err)
+		}
+		for _, ch := range e.chapters(book) {
+			name, ok := e.fileName(id, ch.number)
+			if !ok {
+				return written, fmt.Errorf("book %s is not in books.json", id)
+			}
+			if !filepath.IsLocal(filepath.FromSlash(name)) {
+				return written, fmt.Errorf("file name %q is outside the vault", name)
+			}
+			path := filepath.Join(dir, filepath.FromSlash(name)+".md")
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return written, err
+			}
+			if err := os.WriteFile(path, []byte(ch.content), 0o644); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+type exporter struct {
+	opts Options
+	repo bible.Repository
+}
+
+// fileName returns the note path of a chapter, without extension. Chapter 0
+// of the single-chapter books is written as 1.
+func (e *exporter) fileName(bookId string, chapter int) (string, bool) {
+	meta := e.repo.GetBook(bookId)
+	if meta.Id == "" {
+		return "", false
+	}
+	return strings.NewReplacer(
+		"{id}", meta.Id,
+		"{name}", fileNamePart(meta.Name),
+		"{order}", fmt.Sprintf("%02d", meta.Order),
+		"{chapter}", strconv.Itoa(bible.ChapterNumber(chapter)),
+	).Replace(e.opts.FileName), true
+}
+
+// unsafe matches the characters that file systems or wiki-links don't
+// allow in a note name.
+var unsafe = regexp.MustCompile(`\s*[/\\:*?"<>|#^\[\]]+\s*`)
+
+// fileNamePart makes a book name usable in a file name, so that
+// "Apocalyps // Openbaring" becomes "Apocalyps - Openbaring".
+func fileNamePart(name string) string {
+	return unsafe.ReplaceAllString(name, " - ")
+}
+
+// link returns the wiki-link to a verse, labelled in the Dutch style as
+// "Genesis 1,1" or with a range.
+func (e *exporter) link(to, end versification.Verse) (string, bool) {
+	target, ok := e.fileName(to.Book, to.Chapter)
+	if !ok {
+		return "", false
+	}
+	label := e.repo.GetBook(to.Book).Name + " " + chapterVerse(to)
+	switch {
+	case end.Compare(to) <= 0:
+	case end.Chapter == to.Chapter:
+		label += "-" + strconv.Itoa(end.Verse)
+	default:
+		label += "-" + chapterVerse(end)
+	}
+	return fmt.Sprintf("[[%s#v%d|%s]]", target, to.Verse, label), true
+}
+
+func chapterVerse(v versification.Verse) string {
+	if v.Chapter == 0 {
+		return strconv.Itoa(v.Verse)
+	}
+	return fmt.Sprintf("%d,%d", v.Chapter, v.Verse)
+}
@@ -0,0 +1,109 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+func newTestRepository(t *testing.T) bible.Repository {
+	t.Helper()
+	repo, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[
+			{"id":"psalmen","name":"Psalmen","order":23},
+			{"id":"openbaring","name":"Apocalyps // Openbaring","order":73}]`)},
+		"books/psalmen.json": {Data: []byte(`{"id":"psalmen","name":"Psalmen","chapters":2,"verseCount":3,"verses":[
+			{"chapter":2,"verse":1,"paragraph":"y","textJson":{"tag":"p","children":[{"text":"Waarom woelen de volken?"}]}},
+			{"chapter":1,"verse":2,"paragraph":"n","textJson":{"tag":"p","children":[
+				{"text":"maar zijn vreugde is de wet_van de Heer,"},{"tag":"br"},
+				{"text":"\r\nhij overweegt haar dag en nacht."}]}},
+			{"chapter":1,"verse":1,"paragraph":"y","title":"DE TWEE WEGEN","textJson":{"tag":"p","children":[
+				{"tag":"em","children":[{"text":"Gelukkig"}]},{"text":" de mens #1 die niet meegaat"},
+				{"tag":"abbr","ref":"Jer. 17,7-8","title":"vergelijk [de boom]","class":"ster"}]}}]}`)},
+		"books/openbaring.json": {Data: []byte(`{"id":"openbaring","name":"Apocalyps // Openbaring","chapters":1,"verseCount":1,"verses":[
+			{"chapter":1,"verse":1,"paragraph":"y","textJson":{"tag":"p","children":[{"text":"Openbaring van Jezus Christus."}]}}]}`)},
+	})
+	require.NoError(t, err)
+	return repo
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	n, err := Export(dir, newTestRepository(t), Options{})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	psalm, err := os.ReadFile(filepath.Join(dir, "Psalmen 1.md"))
+	require.NoError(t, err)
+	require.Equal(t, `---
+book: psalmen
+name: "Psalmen"
+chapter: 1
+order: 23
+---
+
+# Psalmen 1
+
+## DE TWEE WEGEN
+
+<sup id="v1">1</sup> *Gelukkig* de mens \#1 die niet meegaat[^1] <sup id="v2">2</sup> maar zijn vreugde is de wet\_van de Heer,\
+hij overweegt haar dag en nacht.
+
+[^1]: **1,1** Jer. 17,7-8 vergelijk \[de boom\]
+`, string(psalm))
+
+	_, err = os.Stat(filepath.Join(dir, "Psalmen 2.md"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "Apocalyps - Openbaring 1.md"))
+	require.NoError(t, err)
+}
+
+func TestExportFileName(t *testing.T) {
+	dir := t.TempDir()
+	n, err := Export(dir, newTestRepository(t), Options{Books: []string{"psalmen"}, FileName: "{order} {id}/{id}-{chapter}"})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	_, err = os.Stat(filepath.Join(dir, "23 psalmen", "psalmen-2.md"))
+	require.NoError(t, err)
+
+	for _, name := range []string{"{name}", "../{name} {chapter}"} {
+		_, err := Export(t.TempDir(), newTestRepository(t), Options{FileName: name})
+		require.Error(t, err, name)
+	}
+	_, err = Export(t.TempDir(), newTestRepository(t), Options{Books: []string{"onbekend"}})
+	require.Error(t, err)
+}
+
+func TestExportCrossReferences(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Books: []string{"genesis", "judas"}, CrossReferences: true, MinVotes: 20}
+	n, err := Export(dir, bible.Current(), opts)
+	require.NoError(t, err)
+	require.Equal(t, 51, n)
+
+	genesis, err := os.ReadFile(filepath.Join(dir, "Genesis 1.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(genesis), "## Kruisverwijzingen\n\n- **1** [[Evangelie volgens Johannes 1#v1|Evangelie volgens Johannes 1,1-3]], ")
+	require.Contains(t, string(genesis), "[[Apocalyps - Openbaring 4#v11|Apocalyps // Openbaring 4,11]]")
+	// Cross-references below the threshold are left out.
+	require.NotContains(t, string(genesis), "- **6** ")
+
+	// Judas numbers its only chapter 0 in the data and 1 in the
+	// cross-references.
+	judas, err := os.ReadFile(filepath.Join(dir, "Judas 1.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(judas), "## Kruisverwijzingen")
+
+	opts.MinVotes = 1000
+	dir = t.TempDir()
+	_, err = Export(dir, bible.Current(), opts)
+	require.NoError(t, err)
+	genesis, err = os.ReadFile(filepath.Join(dir, "Genesis 1.md"))
+	require.NoError(t, err)
+	require.NotContains(t, string(genesis), "Kruisverwijzingen")
+}
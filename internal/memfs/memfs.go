@@ -0,0 +1,161 @@
+// Package memfs copies files from disk into an in-memory fs.FS, so that a
+// data set keeps reading the same bytes while the files on disk change.
+package memfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot reads every file of dir that matches one of patterns (as in
+// fs.Glob) into memory.
+func Snapshot(dir string, patterns ...string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "snapshot", Path: dir, Err: fs.ErrInvalid}
+	}
+
+	src := os.DirFS(dir)
+	snapshot := mapFS{}
+	for _, pattern := range patterns {
+		names, err := fs.Glob(src, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			data, err := fs.ReadFile(src, name)
+			if err != nil {
+				return nil, err
+			}
+			snapshot[path.Clean(name)] = data
+		}
+	}
+	return snapshot, nil
+}
+
+// mapFS is a read-only file system holding the contents of each file by its
+// path. Directories are implied by the paths of the files in them.
+type mapFS map[string][]byte
+
+func (m mapFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if data, ok := m[name]; ok {
+		return &file{entry{path.Base(name), len(data), false}, bytes.NewReader(data)}, nil
+	}
+	entries, err := m.ReadDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &dir{entry{path.Base(name), 0, true}, entries}, nil
+}
+
+func (m mapFS) ReadFile(name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(data), nil
+}
+
+// ReadDir lists the files and directories directly in name, sorted by name.
+func (m mapFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	var entries []fs.DirEntry
+	seen := make(map[string]bool)
+	for p, data := range m {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok {
+			continue
+		}
+		child, _, isDir := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		e := entry{child, 0, isDir}
+		if !isDir {
+			e.size = len(data)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// entry describes a file or directory, as both fs.FileInfo and fs.DirEntry.
+type entry struct {
+	name  string
+	size  int
+	isDir bool
+}
+
+func (e entry) Name() string               { return e.name }
+func (e entry) Size() int64                { return int64(e.size) }
+func (e entry) ModTime() time.Time         { return time.Time{} }
+func (e entry) IsDir() bool                { return e.isDir }
+func (e entry) Sys() any                   { return nil }
+func (e entry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e entry) Info() (fs.FileInfo, error) { return e, nil }
+
+func (e entry) Mode() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type file struct {
+	entry
+	*bytes.Reader
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *file) Close() error               { return nil }
+
+type dir struct {
+	entry
+	entries []fs.DirEntry
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir returns the next n entries, or all remaining ones when n <= 0.
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,43 @@
+package memfs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "books"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books.json"), []byte("[]"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books", "ruth.json"), []byte("{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("skip"), 0o644))
+
+	snapshot, err := Snapshot(dir, "*.json", "books/*.json")
+	require.NoError(t, err)
+
+	// Later changes on disk are not visible in the snapshot.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "books.json"), []byte("[1]"), 0o644))
+
+	data, err := fs.ReadFile(snapshot, "books.json")
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(data))
+
+	names, err := fs.Glob(snapshot, "books/*.json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"books/ruth.json"}, names)
+
+	_, err = fs.Stat(snapshot, "notes.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, fstest.TestFS(snapshot, "books.json", "books/ruth.json"))
+}
+
+func TestSnapshotMissingDir(t *testing.T) {
+	_, err := Snapshot(filepath.Join(t.TempDir(), "missing"), "*.json")
+	require.Error(t, err)
+}
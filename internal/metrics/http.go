@@ -0,0 +1,101 @@
+// Package metrics records request and data store cache metrics for the
+// Prometheus /metrics endpoint.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/middleware"
+)
+
+// SizeBuckets are histogram buckets in bytes suitable for response sizes,
+// from 256 B up to 64 MiB.
+var SizeBuckets = prometheus.ExponentialBuckets(256, 4, 10)
+
+// HTTP holds the request metrics recorded by Middleware.
+type HTTP struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	sizes     *prometheus.HistogramVec
+}
+
+// NewHTTP registers the HTTP request metrics on r.
+func NewHTTP(r prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		sizes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size by method and route pattern.",
+			Buckets: SizeBuckets,
+		}, []string{"method", "route"}),
+	}
+	r.MustRegister(m.requests, m.durations, m.sizes)
+	return m
+}
+
+// Middleware records count, latency and response size of every request,
+// labelled by the chi route pattern rather than the raw path.
+func (m *HTTP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := middleware.NewStatusWriter(w)
+
+		next.ServeHTTP(sw, r)
+
+		route := middleware.RoutePattern(r)
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(sw.Status)).Inc()
+		m.durations.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		m.sizes.WithLabelValues(r.Method, route).Observe(float64(sw.Bytes))
+	})
+}
+
+var (
+	cacheHits = prometheus.NewDesc("bijbel_cache_hits_total",
+		"Lookups served from a data store cache.", []string{"store"}, nil)
+	cacheMisses = prometheus.NewDesc("bijbel_cache_misses_total",
+		"Lookups that had to load from the data store.", []string{"store"}, nil)
+	cacheHitRatio = prometheus.NewDesc("bijbel_cache_hit_ratio",
+		"Fraction of data store lookups served from cache.", []string{"store"}, nil)
+	cacheEntries = prometheus.NewDesc("bijbel_cache_entries",
+		"Number of entries currently held in a data store cache.", []string{"store"}, nil)
+)
+
+// cacheCollector reads the statistics of the data store caches on every
+// scrape.
+type cacheCollector map[string]func() cache.Stats
+
+func (c cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHits
+	ch <- cacheMisses
+	ch <- cacheHitRatio
+	ch <- cacheEntries
+}
+
+func (c cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, stats := range c {
+		s := stats()
+		ch <- prometheus.MustNewConstMetric(cacheHits, prometheus.CounterValue, float64(s.Hits), name)
+		ch <- prometheus.MustNewConstMetric(cacheMisses, prometheus.CounterValue, float64(s.Misses), name)
+		ch <- prometheus.MustNewConstMetric(cacheHitRatio, prometheus.GaugeValue, s.HitRatio(), name)
+		ch <- prometheus.MustNewConstMetric(cacheEntries, prometheus.GaugeValue, float64(s.Entries), name)
+	}
+}
+
+// RegisterCaches exposes hit, miss and hit ratio metrics for the named data
+// store caches.
+func RegisterCaches(r prometheus.Registerer, stores map[string]func() cache.Stats) {
+	r.MustRegister(cacheCollector(stores))
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewHTTP(reg)
+
+	router := chi.NewRouter()
+	router.Use(m.Middleware)
+	router.Get("/books/{bookId}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "Genesis")
+	})
+
+	for _, path := range []string{"/books/genesis", "/books/exodus", "/nope"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/books/{bookId}", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "unmatched", "404")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.durations))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var sum float64
+	for _, f := range families {
+		if f.GetName() != "http_response_size_bytes" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if m.GetLabel()[1].GetValue() == "/books/{bookId}" {
+				sum = m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+	require.Equal(t, 14.0, sum)
+}
+
+func TestRegisterCaches(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	RegisterCaches(reg, map[string]func() cache.Stats{
+		"books": func() cache.Stats { return cache.Stats{Hits: 3, Misses: 1, Entries: 1} },
+	})
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP bijbel_cache_entries Number of entries currently held in a data store cache.
+# TYPE bijbel_cache_entries gauge
+bijbel_cache_entries{store="books"} 1
+# HELP bijbel_cache_hit_ratio Fraction of data store lookups served from cache.
+# TYPE bijbel_cache_hit_ratio gauge
+bijbel_cache_hit_ratio{store="books"} 0.75
+# HELP bijbel_cache_hits_total Lookups served from a data store cache.
+# TYPE bijbel_cache_hits_total counter
+bijbel_cache_hits_total{store="books"} 3
+# HELP bijbel_cache_misses_total Lookups that had to load from the data store.
+# TYPE bijbel_cache_misses_total counter
+bijbel_cache_misses_total{store="books"} 1
+`)))
+}
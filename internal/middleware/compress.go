@@ -0,0 +1,310 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported content codings in order of server preference.
+const (
+	EncodingZstd     = "zstd"
+	EncodingBrotli   = "br"
+	EncodingGzip     = "gzip"
+	EncodingIdentity = "identity"
+)
+
+var preferredEncodings = []string{EncodingZstd, EncodingBrotli, EncodingGzip}
+
+// minCompressSize is the smallest response body worth compressing.
+const minCompressSize = 1024
+
+var gzipPool = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+var brotliPool = sync.Pool{
+	New: func() any {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	},
+}
+
+var zstdPool = sync.Pool{
+	New: func() any {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return w
+	},
+}
+
+// NegotiateEncoding picks the best content coding from an Accept-Encoding
+// header. It returns EncodingIdentity when nothing acceptable is offered.
+func NegotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return EncodingIdentity
+	}
+
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = q
+		} else {
+			weights[name] = q
+		}
+	}
+
+	best, bestQ := EncodingIdentity, 0.0
+	for _, enc := range preferredEncodings {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// Compress negotiates gzip, brotli or zstd compression for responses based on
+// the request's Accept-Encoding header. Responses that already carry a
+// Content-Encoding, are too small, or have an incompressible content type are
+// passed through untouched.
+func Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := NegotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == EncodingIdentity || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers the first bytes of a response to decide whether it
+// is worth compressing, then streams the remainder through the encoder.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	encoder     io.WriteCloser
+	release     func()
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < minCompressSize {
+			return len(p), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide commits the response headers and flushes the buffered prefix.
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	header := cw.ResponseWriter.Header()
+
+	if cw.shouldCompress(header) {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		// The compressed bytes are not those the handler tagged, so its
+		// ETag only still holds as a weak one.
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		cw.encoder, cw.release = newEncoder(cw.encoding, cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) shouldCompress(header http.Header) bool {
+	if len(cw.buf) < minCompressSize {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if cw.status < 200 || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	// A range counts bytes of the uncompressed body.
+	if cw.status == http.StatusPartialContent || header.Get("Content-Range") != "" {
+		return false
+	}
+	return compressible(header.Get("Content-Type"))
+}
+
+// Flush sends any buffered data to the client, compressing it if needed.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		if err := cw.decide(); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the compressed stream and returns the encoder to its pool.
+func (cw *compressWriter) Close() error {
+	if !cw.wroteHeader {
+		return nil
+	}
+	if !cw.decided {
+		if err := cw.decide(); err != nil {
+			return err
+		}
+	}
+	if cw.encoder == nil {
+		return nil
+	}
+	err := cw.encoder.Close()
+	cw.release()
+	cw.encoder = nil
+	return err
+}
+
+// Hijack lets websocket-style handlers take over the connection.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func compressible(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json",
+		mediaType == "application/x-ndjson",
+		mediaType == "application/xml",
+		mediaType == "application/javascript",
+		mediaType == "image/svg+xml":
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// newEncoder returns a pooled encoder for the given coding writing to w, and a
+// function that returns it to the pool once closed.
+func newEncoder(encoding string, w io.Writer) (io.WriteCloser, func()) {
+	switch encoding {
+	case EncodingZstd:
+		enc := zstdPool.Get().(*zstd.Encoder)
+		enc.Reset(w)
+		return enc, func() { zstdPool.Put(enc) }
+	case EncodingBrotli:
+		enc := brotliPool.Get().(*brotli.Writer)
+		enc.Reset(w)
+		return enc, func() { brotliPool.Put(enc) }
+	default:
+		enc := gzipPool.Get().(*gzip.Writer)
+		enc.Reset(w)
+		return enc, func() { gzipPool.Put(enc) }
+	}
+}
+
+// compressBytes encodes data in full with the given content coding.
+func compressBytes(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	enc, release := newEncoder(encoding, &buf)
+	defer release()
+
+	if _, err := enc.Write(data); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
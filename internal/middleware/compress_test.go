@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", EncodingIdentity},
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br", EncodingBrotli},
+		{"gzip, br, zstd", EncodingZstd},
+		{"zstd;q=0.5, gzip;q=0.8", EncodingGzip},
+		{"br;q=0, gzip;q=0", EncodingIdentity},
+		{"*", EncodingZstd},
+		{"*;q=0.1, zstd;q=0", EncodingBrotli},
+		{"deflate", EncodingIdentity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := NegotiateEncoding(tt.header); got != tt.want {
+				t.Errorf("NegotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gr
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		r = bytes.NewReader(body)
+	}
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	payload := strings.Repeat(`{"text":"In het begin schiep God de hemel en de aarde."}`, 200)
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, payload[:100])
+		io.WriteString(w, payload[100:])
+	}))
+
+	for _, encoding := range []string{EncodingGzip, EncodingBrotli, EncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", encoding)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, encoding, rr.Header().Get("Content-Encoding"))
+			require.Contains(t, rr.Header().Values("Vary"), "Accept-Encoding")
+			require.Equal(t, `W/"v1"`, rr.Header().Get("ETag"), "the handler's ETag tags the uncompressed bytes")
+			require.Less(t, rr.Body.Len(), len(payload))
+			require.Equal(t, payload, decode(t, encoding, rr.Body.Bytes()))
+		})
+	}
+}
+
+func TestCompressSkipsSmallAndBinaryResponses(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"small json", "application/json", `{"status":"healthy"}`},
+		{"binary", "application/octet-stream", strings.Repeat("x", 4096)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, tt.body)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusCreated, rr.Code)
+			require.Empty(t, rr.Header().Get("Content-Encoding"))
+			require.Equal(t, tt.body, rr.Body.String())
+		})
+	}
+}
+
+func TestCompressSkipsRanges(t *testing.T) {
+	payload := strings.Repeat("In het begin schiep God de hemel en de aarde. ", 100)
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(payload))
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=0-2047")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusPartialContent, rr.Code)
+	require.Empty(t, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, `"v1"`, rr.Header().Get("ETag"))
+	require.Equal(t, payload[:2048], rr.Body.String())
+}
+
+func TestCompressWithoutAcceptEncoding(t *testing.T) {
+	payload := strings.Repeat("a", 4096)
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, payload)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	require.Empty(t, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, payload, rr.Body.String())
+}
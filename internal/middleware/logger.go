@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type loggerKey struct{}
+
+// AccessLog writes one structured log record per request with its route
+// pattern, status, response size and duration. Handlers log through the
+// logger of GetLogger, which carries the request ID.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := NewStatusWriter(w)
+
+			requestLogger := logger.With(slog.String("request_id", GetRequestID(r.Context())))
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), loggerKey{}, requestLogger)))
+
+			level := slog.LevelInfo
+			switch {
+			case sw.Status >= 500:
+				level = slog.LevelError
+			case sw.Status >= 400:
+				level = slog.LevelWarn
+			}
+
+			logger.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", GetRequestID(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", RoutePattern(r)),
+				slog.Int("status", sw.Status),
+				slog.Int64("bytes", sw.Bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// GetLogger returns the logger stored by AccessLog, or the default logger.
+func GetLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Recover turns panics in handlers into a 500 response and logs the stack
+// trace instead of killing the connection.
+func Recover(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := NewStatusWriter(w)
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logger.ErrorContext(r.Context(), "panic while serving request",
+					slog.String("request_id", GetRequestID(r.Context())),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Any("panic", rec),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if !sw.WroteHeader() {
+					http.Error(sw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// RoutePattern returns the chi route pattern that matched r, or "unmatched"
+// so that unknown paths don't create unbounded label values.
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	require.Len(t, seen, 32)
+	require.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "upstream-1234")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, "upstream-1234", seen)
+	require.Equal(t, "upstream-1234", rr.Header().Get(RequestIDHeader))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotEqual(t, "bad id\nwith newline", seen)
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	router := chi.NewRouter()
+	router.Use(RequestID)
+	router.Use(AccessLog(logger))
+	router.Get("/books/{bookId}", func(w http.ResponseWriter, r *http.Request) {
+		GetLogger(r.Context()).Info("looking up book")
+		http.Error(w, "Book not found", http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest("GET", "/books/pieter", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	dec := json.NewDecoder(&buf)
+	var record map[string]any
+	require.NoError(t, dec.Decode(&record))
+	require.Equal(t, "looking up book", record["msg"])
+	require.Equal(t, "req-1", record["request_id"], "handlers log with the request ID")
+
+	record = nil
+	require.NoError(t, dec.Decode(&record))
+	require.Equal(t, "WARN", record["level"])
+	require.Equal(t, "req-1", record["request_id"])
+	require.Equal(t, "/books/{bookId}", record["route"])
+	require.Equal(t, "/books/pieter", record["path"])
+	require.EqualValues(t, 404, record["status"])
+	require.EqualValues(t, len("Book not found\n"), record["bytes"])
+}
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rr := httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	})
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Contains(t, buf.String(), `"panic":"boom"`)
+	require.Contains(t, buf.String(), "stack")
+}
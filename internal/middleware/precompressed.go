@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etagSuffixes tell the representations of a body apart in its ETag, which
+// must differ between codings so that caches and conditional requests don't
+// mix them up.
+var etagSuffixes = map[string]string{
+	EncodingIdentity: "",
+	EncodingGzip:     "-gz",
+	EncodingBrotli:   "-br",
+	EncodingZstd:     "-zst",
+}
+
+// Precompressed serves a fixed response body whose compressed representations
+// are computed once, typically at startup, instead of on every request. It is
+// served behind Compress, which sets Vary: Accept-Encoding.
+type Precompressed struct {
+	contentType string
+	hash        string
+	variants    map[string][]byte
+}
+
+// NewPrecompressed compresses body with every supported coding. Variants that
+// do not end up smaller than the original are dropped.
+func NewPrecompressed(body []byte, contentType string) (*Precompressed, error) {
+	sum := sha256.Sum256(body)
+	p := &Precompressed{
+		contentType: contentType,
+		hash:        hex.EncodeToString(sum[:8]),
+		variants:    map[string][]byte{EncodingIdentity: body},
+	}
+
+	for _, enc := range preferredEncodings {
+		compressed, err := compressBytes(enc, body)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(body) {
+			p.variants[enc] = compressed
+		}
+	}
+
+	return p, nil
+}
+
+// Size returns the length of the representation for the given coding, or -1
+// when that coding is not available.
+func (p *Precompressed) Size(encoding string) int {
+	if body, ok := p.variants[encoding]; ok {
+		return len(body)
+	}
+	return -1
+}
+
+func (p *Precompressed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoding := NegotiateEncoding(r.Header.Get("Accept-Encoding"))
+	body, ok := p.variants[encoding]
+	if !ok {
+		encoding = EncodingIdentity
+		body = p.variants[EncodingIdentity]
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", p.contentType)
+	etag := `"` + p.hash + etagSuffixes[encoding] + `"`
+	header.Set("ETag", etag)
+	if encoding != EncodingIdentity {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 requires for it.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
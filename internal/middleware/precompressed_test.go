@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrecompressed(t *testing.T) {
+	body := []byte(strings.Repeat(`{"id":"genesis","name":"Genesis","order":1},`, 100))
+	p, err := NewPrecompressed(body, "application/json")
+	require.NoError(t, err)
+
+	etags := map[string]string{}
+	for _, encoding := range []string{EncodingIdentity, EncodingGzip, EncodingBrotli, EncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			require.Positive(t, p.Size(encoding))
+
+			req := httptest.NewRequest("GET", "/books", nil)
+			if encoding != EncodingIdentity {
+				req.Header.Set("Accept-Encoding", encoding)
+			}
+			rr := httptest.NewRecorder()
+
+			Compress(p).ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			require.Equal(t, string(body), decode(t, encoding, rr.Body.Bytes()))
+			if encoding != EncodingIdentity {
+				require.Equal(t, encoding, rr.Header().Get("Content-Encoding"))
+			}
+			require.Equal(t, []string{"Accept-Encoding"}, rr.Header().Values("Vary"))
+			etags[encoding] = rr.Header().Get("ETag")
+		})
+	}
+	require.Len(t, etags, 4)
+	require.Equal(t, `"`+p.hash+`-gz"`, etags[EncodingGzip])
+	for encoding, etag := range etags {
+		for other, otherEtag := range etags {
+			if encoding != other {
+				require.NotEqual(t, etag, otherEtag, "every coding has its own ETag")
+			}
+		}
+	}
+
+	// The ETag of another coding does not validate the representation.
+	req := httptest.NewRequest("GET", "/books", nil)
+	req.Header.Set("Accept-Encoding", EncodingGzip)
+	req.Header.Set("If-None-Match", etags[EncodingIdentity])
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	req.Header.Set("If-None-Match", etags[EncodingBrotli]+", W/"+etags[EncodingGzip])
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+}
+
+func TestPrecompressedNotModified(t *testing.T) {
+	p, err := NewPrecompressed([]byte(`[]`), "application/json")
+	require.NoError(t, err)
+
+	// Tiny bodies do not compress, so only identity should be kept.
+	require.Equal(t, -1, p.Size(EncodingGzip))
+
+	first := httptest.NewRecorder()
+	p.ServeHTTP(first, httptest.NewRequest("GET", "/books", nil))
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest("GET", "/books", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr := httptest.NewRecorder()
+
+	p.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Zero(t, rr.Body.Len())
+}
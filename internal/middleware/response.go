@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// StatusWriter records the status code and number of body bytes written
+// through it, for use by logging and metrics middleware.
+type StatusWriter struct {
+	http.ResponseWriter
+	Status int
+	Bytes  int64
+
+	wroteHeader bool
+}
+
+// NewStatusWriter wraps w. The status defaults to 200 until WriteHeader is
+// called.
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w, Status: http.StatusOK}
+}
+
+// WroteHeader reports whether the response headers have been sent.
+func (sw *StatusWriter) WroteHeader() bool {
+	return sw.wroteHeader
+}
+
+func (sw *StatusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.Status = status
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *StatusWriter) Write(p []byte) (int, error) {
+	sw.wroteHeader = true
+	n, err := sw.ResponseWriter.Write(p)
+	sw.Bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher when the wrapped writer supports it.
+func (sw *StatusWriter) Flush() {
+	sw.wroteHeader = true
+	http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (sw *StatusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,27 @@
+package osis
+
+import "github.com/pschuurmans/bijbel-api/internal/bible"
+
+var (
+	toOSIS   = make(map[string]string)
+	fromOSIS = make(map[string]string)
+)
+
+func init() {
+	for _, b := range bible.BookCodes() {
+		toOSIS[b.Id] = b.OSIS
+		fromOSIS[b.OSIS] = b.Id
+	}
+}
+
+// BookId returns the book id for an OSIS book name, such as genesis for Gen.
+func BookId(osisBook string) (string, bool) {
+	id, ok := fromOSIS[osisBook]
+	return id, ok
+}
+
+// OSISBook returns the OSIS book name for a book id, such as Gen for genesis.
+func OSISBook(id string) (string, bool) {
+	osis, ok := toOSIS[id]
+	return osis, ok
+}
@@ -0,0 +1,281 @@
+// Package osis converts books between the JSON layout of this project and
+// OSIS 2.1.1 XML (https://crosswire.org/osis/), the interchange format of
+// most Bible software.
+package osis
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// Namespace is the OSIS XML namespace.
+const Namespace = "http://www.bibletechnologies.net/2003/OSIS/namespace"
+
+const schemaLocation = Namespace + " http://www.bibletechnologies.net/osisCore.2.1.1.xsd"
+
+// Work describes the translation in the OSIS header.
+type Work struct {
+	// Id is the osisIDWork, such as WV75.
+	Id       string
+	Title    string
+	Language string
+}
+
+// Export writes books as one OSIS document. Section headings come from the
+// verse titles, paragraphs from the paragraph flags, notes from the abbr
+// footnotes and poetry lines from the line breaks in the verse markup.
+// Single-chapter books, which the bundled data numbers as chapter 0, are
+// written as chapter 1.
+func Export(w io.Writer, work Work, books []bible.SourceBook) error {
+	e := &exporter{enc: xml.NewEncoder(w)}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e.start("osis", "xmlns", Namespace,
+		"xmlns:xsi", "http://www.w3.org/2001/XMLSchema-instance",
+		"xsi:schemaLocation", schemaLocation)
+	e.start("osisText", "osisIDWork", work.Id, "osisRefWork", "Bible", "xml:lang", work.Language)
+
+	e.start("header")
+	e.start("work", "osisWork", work.Id)
+	e.element("title", work.Title)
+	e.element("type", "Bible", "type", "OSIS")
+	e.element("identifier", "Bible."+work.Language+"."+work.Id, "type", "OSIS")
+	e.element("refSystem", "Bible")
+	e.end("work")
+	e.end("header")
+
+	for _, book := range books {
+		if err := e.book(book); err != nil {
+			return err
+		}
+	}
+
+	e.end("osisText")
+	e.end("osis")
+	if e.err != nil {
+		return e.err
+	}
+	return e.enc.Flush()
+}
+
+type exporter struct {
+	enc   *xml.Encoder
+	err   error
+	stack []string
+	// inVerse is set while writing verse content, where whitespace is
+	// significant.
+	inVerse bool
+}
+
+func (e *exporter) book(book bible.SourceBook) error {
+	osisBook, ok := OSISBook(book.Id)
+	if !ok {
+		return fmt.Errorf("book %s has no OSIS name", book.Id)
+	}
+
+	verses := slices.Clone(book.Verses)
+	slices.SortStableFunc(verses, func(a, b bible.SourceVerse) int {
+		if a.Chapter != b.Chapter {
+			return a.Chapter - b.Chapter
+		}
+		return a.Verse - b.Verse
+	})
+
+	e.start("div", "type", "book", "osisID", osisBook)
+	e.element("title", book.Name, "type", "main", "short", book.Name)
+
+	chapter := -1
+	for _, v := range verses {
+		if v.Chapter != chapter {
+			e.closeTo("div")
+			chapter = v.Chapter
+			e.start("chapter", "osisID", fmt.Sprintf("%s.%d", osisBook, bible.ChapterNumber(chapter)))
+		}
+
+		if v.Title != nil && *v.Title != "" {
+			e.closeTo("chapter")
+			e.element("title", *v.Title)
+		}
+		if v.Paragraph == "y" {
+			e.closeTo("chapter")
+			e.start("p")
+		}
+
+		id := fmt.Sprintf("%s.%d.%d", osisBook, bible.ChapterNumber(chapter), v.Verse)
+		e.empty("verse", "osisID", id, "sID", id)
+		e.inVerse = true
+		e.verse(v.TextJson)
+		e.inVerse = false
+		e.empty("verse", "eID", id)
+		e.newline()
+	}
+	e.closeTo("div")
+	e.end("div")
+	return e.err
+}
+
+// verse writes the content of a verse, as a line group when it has more
+// than one line.
+func (e *exporter) verse(root *bible.Node) {
+	if root == nil {
+		return
+	}
+
+	verseLines := root.Lines()
+	switch len(verseLines) {
+	case 0:
+		return
+	case 1:
+		e.inline(verseLines[0], true)
+		return
+	}
+
+	e.start("lg")
+	for _, line := range verseLines {
+		e.start("l")
+		e.inline(line, true)
+		e.end("l")
+	}
+	e.end("lg")
+}
+
+var whitespace = regexp.MustCompile(`[\s\x00-\x1F\x{00A0}]+`)
+
+// inline writes nodes as OSIS inline content. Whitespace is collapsed;
+// trim drops the leading whitespace of the first text.
+func (e *exporter) inline(nodes []bible.Node, trim bool) {
+	for _, n := range nodes {
+		switch n.Tag {
+		case "":
+			text := whitespace.ReplaceAllString(n.Text, " ")
+			if trim {
+				text = strings.TrimLeft(text, " ")
+			}
+			e.text(text)
+		case "br":
+			e.empty("lb")
+		case "abbr":
+			e.note(n)
+		case "em", "i":
+			e.start("hi", "type", "italic")
+			e.inline(n.Children, false)
+			e.end("hi")
+		case "blockquote":
+			e.start("q", "type", "block", "marker", "")
+			e.inline(n.Children, false)
+			e.end("q")
+		case "div", "p":
+			// Block elements separate words, see Node.PlainText.
+			if !trim {
+				e.text(" ")
+			}
+			e.inline(n.Children, trim)
+		default:
+			e.inline(n.Children, trim)
+		}
+		trim = false
+	}
+}
+
+// note writes an abbr footnote. Its reference list goes into a reference
+// element, its explanation into the note text.
+func (e *exporter) note(n bible.Node) {
+	attrs := []string{"n", n.IvertalingKey}
+	if n.Title == "" {
+		attrs = append(attrs, "type", "crossReference")
+	}
+	e.start("note", attrs...)
+	if n.Ref != "" {
+		e.element("reference", n.Ref)
+	}
+	if n.Title != "" {
+		e.text(n.Title)
+	}
+	e.end("note")
+}
+
+// closeTo ends elements until name is the innermost open element.
+func (e *exporter) closeTo(name string) {
+	for len(e.stack) > 0 && e.stack[len(e.stack)-1] != name {
+		e.end(e.stack[len(e.stack)-1])
+	}
+}
+
+// Elements in blocks start on a line of their own and elements in lines end
+// one. The encoder's indentation can't be used, as it would add whitespace
+// to the verse text.
+var (
+	blocks = map[string]bool{
+		"osis": true, "osisText": true, "header": true, "work": true,
+		"div": true, "chapter": true, "p": true,
+	}
+	lines = map[string]bool{
+		"title": true, "type": true, "identifier": true, "refSystem": true,
+	}
+)
+
+func (e *exporter) start(name string, attrs ...string) {
+	e.token(xml.StartElement{Name: xml.Name{Local: name}, Attr: attributes(attrs)})
+	e.stack = append(e.stack, name)
+	if blocks[name] {
+		e.newline()
+	}
+}
+
+func (e *exporter) end(name string) {
+	e.token(xml.EndElement{Name: xml.Name{Local: name}})
+	e.stack = e.stack[:len(e.stack)-1]
+	if !e.inVerse && (blocks[name] || lines[name]) {
+		e.newline()
+	}
+}
+
+func (e *exporter) newline() {
+	e.token(xml.CharData("\n"))
+}
+
+func (e *exporter) empty(name string, attrs ...string) {
+	e.start(name, attrs...)
+	e.end(name)
+}
+
+func (e *exporter) element(name, text string, attrs ...string) {
+	e.start(name, attrs...)
+	e.text(text)
+	e.end(name)
+}
+
+func (e *exporter) text(text string) {
+	if text != "" {
+		e.token(xml.CharData(text))
+	}
+}
+
+func (e *exporter) token(t xml.Token) {
+	if e.err == nil {
+		e.err = e.enc.EncodeToken(t)
+	}
+}
+
+// attributes turns name/value pairs into XML attributes, leaving out empty
+// values except for the OSIS marker attribute, whose empty value means
+// "no quotation marks".
+func attributes(pairs []string) []xml.Attr {
+	var attrs []xml.Attr
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" && pairs[i] != "marker" {
+			continue
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: pairs[i]}, Value: pairs[i+1]})
+	}
+	return attrs
+}
@@ -0,0 +1,286 @@
+package osis
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// Import reads an OSIS document into books in the layout of books/*.json.
+// Both milestone verses (sID/eID) and containered verses are supported.
+// Section titles become the title of the verse that follows them, a p or
+// stanza start sets the paragraph flag of the next verse, l and lb
+// boundaries become line breaks and notes become abbr footnotes. Elements
+// without a counterpart in the JSON layout keep only their text.
+func Import(r io.Reader) ([]bible.SourceBook, error) {
+	im := &importer{index: map[string]int{}}
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			err = im.start(t)
+		case xml.EndElement:
+			im.end(t)
+		case xml.CharData:
+			im.charData(string(t))
+		}
+		if err != nil {
+			line, _ := dec.InputPos()
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	im.closeVerse()
+
+	if len(im.books) == 0 {
+		return nil, fmt.Errorf("no verses found")
+	}
+	for i := range im.books {
+		im.books[i].UpdateCounts()
+	}
+	return im.books, nil
+}
+
+type importer struct {
+	books []bible.SourceBook
+	// index maps book ids to their position in books.
+	index map[string]int
+
+	// bookName holds the main title of the book div being read.
+	bookName string
+	// title collects the text of a title element while depth > 0.
+	title      *strings.Builder
+	titleDepth int
+	titleMain  bool
+	// pendingTitle and paragraph apply to the next verse.
+	pendingTitle *string
+	paragraph    bool
+
+	// verse is the open verse, nil between verses.
+	verse *bible.SourceVerse
+	text  bible.TextBuilder
+	// verses records for every open verse element whether it is a
+	// container, so that its end closes the verse.
+	verses []bool
+
+	// note is the footnote being read.
+	note      *bible.Node
+	noteDepth int
+	reference bool
+}
+
+func (im *importer) start(t xml.StartElement) error {
+	if im.title != nil {
+		im.titleDepth++
+		return nil
+	}
+	if im.note != nil {
+		im.noteDepth++
+		if t.Name.Local == "reference" {
+			im.reference = true
+		}
+		return nil
+	}
+
+	switch t.Name.Local {
+	case "div":
+		if attr(t, "type") == "book" {
+			im.closeVerse()
+			im.bookName = attr(t, "osisID")
+		}
+	case "title":
+		im.title = &strings.Builder{}
+		im.titleDepth = 1
+		im.titleMain = attr(t, "type") == "main"
+	case "p":
+		im.paragraph = true
+	case "lg":
+		// A stanza that starts between verses opens a paragraph too.
+		if im.verse == nil {
+			im.paragraph = true
+		}
+	case "verse":
+		return im.startVerse(t)
+	case "note":
+		if im.verse != nil {
+			im.note = &bible.Node{
+				Tag:           "abbr",
+				IvertalingKey: attr(t, "n"),
+				Class:         "ster",
+				Children:      []bible.Node{{Tag: "i", Class: "fa fa-star-o nootjester"}},
+			}
+			im.noteDepth = 1
+		}
+	case "l", "lb":
+		if im.verse != nil {
+			im.text.Break()
+		}
+	case "hi":
+		im.open("em")
+	case "q":
+		if attr(t, "type") == "block" {
+			im.open("blockquote")
+		}
+	}
+	return nil
+}
+
+func (im *importer) startVerse(t xml.StartElement) error {
+	if id := attr(t, "eID"); id != "" {
+		im.verses = append(im.verses, false)
+		im.closeVerse()
+		return nil
+	}
+
+	sID := attr(t, "sID")
+	im.verses = append(im.verses, sID == "")
+	osisID := attr(t, "osisID")
+	if osisID == "" {
+		osisID = sID
+	}
+	// Merged verses list several ids; the first one numbers the verse.
+	osisID, _, _ = strings.Cut(osisID, " ")
+
+	parts := strings.Split(osisID, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("verse %q: osisID must have the form Book.chapter.verse", osisID)
+	}
+	book, ok := BookId(parts[0])
+	if !ok {
+		return fmt.Errorf("verse %q: unknown OSIS book %s", osisID, parts[0])
+	}
+	chapter, err1 := strconv.Atoi(parts[1])
+	verse, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("verse %q: invalid chapter or verse number", osisID)
+	}
+
+	im.closeVerse()
+	v := bible.NewSourceVerse(book, chapter, verse)
+	v.Title = im.pendingTitle
+	if im.paragraph {
+		v.Paragraph = "y"
+	}
+	im.verse = &v
+	im.pendingTitle, im.paragraph = nil, false
+
+	i, ok := im.index[book]
+	if !ok {
+		name := im.bookName
+		if name == "" {
+			name = book
+		}
+		i = len(im.books)
+		im.index[book] = i
+		im.books = append(im.books, bible.SourceBook{Id: book, Name: name})
+	}
+	im.books[i].Verses = append(im.books[i].Verses, *im.verse)
+	return nil
+}
+
+func (im *importer) end(t xml.EndElement) {
+	if im.title != nil {
+		im.titleDepth--
+		if im.titleDepth == 0 {
+			im.endTitle()
+		}
+		return
+	}
+	if im.note != nil {
+		im.noteDepth--
+		switch {
+		case im.noteDepth == 0:
+			im.note.Ref = strings.TrimSpace(im.note.Ref)
+			im.note.Title = strings.Join(strings.Fields(im.note.Title), " ")
+			im.text.Append(*im.note)
+			im.note = nil
+		case t.Name.Local == "reference":
+			im.reference = false
+		}
+		return
+	}
+
+	switch t.Name.Local {
+	case "verse":
+		if n := len(im.verses); n > 0 {
+			container := im.verses[n-1]
+			im.verses = im.verses[:n-1]
+			if container {
+				im.closeVerse()
+			}
+		}
+	case "hi":
+		im.close("em")
+	case "q":
+		im.close("blockquote")
+	}
+}
+
+func (im *importer) endTitle() {
+	text := strings.Join(strings.Fields(im.title.String()), " ")
+	im.title = nil
+	switch {
+	case text == "":
+	case im.titleMain:
+		im.bookName = text
+	default:
+		im.pendingTitle = &text
+	}
+}
+
+func (im *importer) charData(s string) {
+	switch {
+	case im.title != nil:
+		im.title.WriteString(s)
+	case im.note != nil && im.reference:
+		im.note.Ref += s
+	case im.note != nil:
+		im.note.Title += s
+	case im.verse != nil:
+		im.text.Text(s)
+	}
+}
+
+func (im *importer) open(tag string) {
+	if im.verse != nil {
+		im.text.Open(tag)
+	}
+}
+
+func (im *importer) close(tag string) {
+	if im.verse != nil {
+		im.text.Close(tag)
+	}
+}
+
+// closeVerse stores the text of the open verse.
+func (im *importer) closeVerse() {
+	if im.verse == nil {
+		return
+	}
+
+	book := &im.books[im.index[strings.SplitN(im.verse.Id, ".", 2)[0]]]
+	book.Verses[len(book.Verses)-1].SetText(im.text.Finish())
+	im.verse = nil
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
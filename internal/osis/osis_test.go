@@ -0,0 +1,230 @@
+package osis
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+var work = Work{Id: "WV75", Title: "Willibrordvertaling", Language: "nl"}
+
+func TestBookNames(t *testing.T) {
+	for _, b := range bible.GetBooks() {
+		osisBook, ok := OSISBook(b.Id)
+		if !ok {
+			t.Errorf("book %s has no OSIS name", b.Id)
+			continue
+		}
+		if id, _ := BookId(osisBook); id != b.Id {
+			t.Errorf("BookId(%q) = %q, want %q", osisBook, id, b.Id)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	title := "Psalm van David"
+	book := bible.SourceBook{Id: "psalmen", Name: "Psalmen", Verses: []bible.SourceVerse{
+		{Chapter: 23, Verse: 1, Title: &title, Paragraph: "y", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "De Heer is mijn herder,"},
+			{Tag: "abbr", IvertalingKey: "3", Ref: "Ez. 34,11", Title: "vergelijk"},
+			{Tag: "br"},
+			{Text: "\r\nniets kom ik tekort & "},
+			{Tag: "em", Children: []bible.Node{{Text: "nooit"}}},
+			{Tag: "br"},
+			{Text: "\r\n "},
+		}}},
+		{Chapter: 23, Verse: 2, Paragraph: "n", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "Hij laat mij rusten."},
+		}}},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, work, []bible.SourceBook{book}))
+	out := buf.String()
+
+	for _, want := range []string{
+		`<osis xmlns="http://www.bibletechnologies.net/2003/OSIS/namespace"`,
+		`<osisText osisIDWork="WV75" osisRefWork="Bible" xml:lang="nl">`,
+		`<div type="book" osisID="Ps">`,
+		`<title type="main" short="Psalmen">Psalmen</title>`,
+		`<chapter osisID="Ps.23">`,
+		"<title>Psalm van David</title>\n<p>",
+		`<verse osisID="Ps.23.1" sID="Ps.23.1"></verse><lg><l>De Heer is mijn herder,<note n="3"><reference>Ez. 34,11</reference>vergelijk</note></l>`,
+		`<l>niets kom ik tekort &amp; <hi type="italic">nooit</hi></l></lg><verse eID="Ps.23.1"></verse>`,
+		`<verse osisID="Ps.23.2" sID="Ps.23.2"></verse>Hij laat mij rusten.<verse eID="Ps.23.2"></verse>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// The document must be well-formed.
+	dec := xml.NewDecoder(strings.NewReader(out))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestExportUnknownBook(t *testing.T) {
+	err := Export(io.Discard, work, []bible.SourceBook{{Id: "henoch"}})
+	require.ErrorContains(t, err, "henoch")
+}
+
+func TestImport(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<osis xmlns="http://www.bibletechnologies.net/2003/OSIS/namespace">
+<osisText osisIDWork="KJV">
+<div type="book" osisID="Jude">
+<title type="main">Judas</title>
+<chapter osisID="Jude.1">
+<title>Groet</title>
+<p>
+<verse osisID="Jude.1.1">Judas, <hi type="bold">dienaar</hi><note type="crossReference"><reference osisRef="Matt.13.55">Mt. 13,55</reference></note> van Jezus Christus,</verse>
+<verse osisID="Jude.1.2">barmhartigheid<lb/>en vrede.</verse>
+</p>
+<lg><l><verse sID="Jude.1.3" osisID="Jude.1.3"/>Geliefden,</l>
+<l>ik schrijf u<verse eID="Jude.1.3"/></l></lg>
+</chapter>
+</div>
+</osisText>
+</osis>`
+
+	books, err := Import(strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+
+	book := books[0]
+	require.Equal(t, "judas", book.Id)
+	require.Equal(t, "Judas", book.Name)
+	require.Equal(t, 1, book.Chapters)
+	require.Equal(t, 3, book.VerseCount)
+
+	first := book.Verses[0]
+	require.Equal(t, "judas.1.1", first.Id)
+	require.NotNil(t, first.Title)
+	require.Equal(t, "Groet", *first.Title)
+	require.Equal(t, "y", first.Paragraph)
+	require.Equal(t, "Judas, dienaar van Jezus Christus,", first.TextJson.PlainText())
+	require.Equal(t, "Judas, dienaar van Jezus Christus,", first.CleanText())
+	require.Equal(t, []any{}, first.CrossReferences)
+
+	note := first.TextJson.Children[2]
+	require.Equal(t, "abbr", note.Tag)
+	require.Equal(t, "Mt. 13,55", note.Ref)
+
+	second := book.Verses[1]
+	require.Nil(t, second.Title)
+	require.Equal(t, "n", second.Paragraph)
+	require.Equal(t, "barmhartigheid<br />\r\nen vrede.", second.Text)
+
+	third := book.Verses[2]
+	require.Equal(t, "y", third.Paragraph)
+	require.Len(t, third.TextJson.Lines(), 2)
+	require.Equal(t, "Geliefden, ik schrijf u", third.CleanText())
+}
+
+func TestImportErrors(t *testing.T) {
+	for name, doc := range map[string]string{
+		"malformed":    `<osis><verse osisID="Gen.1.1">`,
+		"unknown book": `<osis><verse osisID="Enoch.1.1">tekst</verse></osis>`,
+		"bad osisID":   `<osis><verse osisID="Gen.1">tekst</verse></osis>`,
+		"no verses":    `<osis></osis>`,
+	} {
+		if _, err := Import(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestRoundTrip exports every bundled book and checks that importing the
+// result gives back the same verses, titles, paragraphs, poetry lines and
+// footnotes.
+func TestRoundTrip(t *testing.T) {
+	var books []bible.SourceBook
+	for _, b := range bible.GetBooks() {
+		book, err := bible.ReadSourceBook(b.Id)
+		require.NoError(t, err)
+		books = append(books, book)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, work, books))
+
+	imported, err := Import(&buf)
+	require.NoError(t, err)
+	require.Len(t, imported, len(books))
+
+	for i, want := range books {
+		// The book files aren't stored in verse order; the export is.
+		want.Verses = slices.Clone(want.Verses)
+		slices.SortFunc(want.Verses, func(a, b bible.SourceVerse) int {
+			return cmp.Or(a.Chapter-b.Chapter, a.Verse-b.Verse)
+		})
+		got := imported[i]
+		require.Equal(t, want.Id, got.Id)
+		require.Equal(t, want.Name, got.Name)
+		require.Equal(t, len(want.Verses), len(got.Verses), want.Id)
+
+		for j, w := range want.Verses {
+			g := got.Verses[j]
+			if g.Chapter != max(w.Chapter, 1) || g.Verse != w.Verse {
+				t.Fatalf("%s: expected verse %d:%d, got %d:%d", w.Id, w.Chapter, w.Verse, g.Chapter, g.Verse)
+			}
+			if title(w) != title(g) || w.Paragraph != g.Paragraph {
+				t.Errorf("%s: expected title %q and paragraph %q, got %q and %q", w.Id, title(w), w.Paragraph, title(g), g.Paragraph)
+			}
+			if plain(w) != plain(g) {
+				t.Errorf("%s: expected text %q, got %q", w.Id, plain(w), plain(g))
+			}
+			if g.CleanText() != plain(g) {
+				t.Errorf("%s: text %q doesn't match textJson %q", w.Id, g.CleanText(), plain(g))
+			}
+			if w.TextJson != nil && len(w.TextJson.Lines()) != len(g.TextJson.Lines()) {
+				t.Errorf("%s: expected %d lines, got %d", w.Id, len(w.TextJson.Lines()), len(g.TextJson.Lines()))
+			}
+			if wn, gn := notes(w.TextJson), notes(g.TextJson); strings.Join(wn, "|") != strings.Join(gn, "|") {
+				t.Errorf("%s: expected notes %q, got %q", w.Id, wn, gn)
+			}
+		}
+	}
+}
+
+func title(v bible.SourceVerse) string {
+	if v.Title == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(*v.Title), " ")
+}
+
+func plain(v bible.SourceVerse) string {
+	if v.TextJson == nil {
+		return ""
+	}
+	return v.TextJson.PlainText()
+}
+
+func notes(n *bible.Node) []string {
+	if n == nil {
+		return nil
+	}
+	if n.Tag == "abbr" {
+		return []string{n.IvertalingKey + " " + strings.TrimSpace(n.Ref) + " " + strings.Join(strings.Fields(n.Title), " ")}
+	}
+	var list []string
+	for _, c := range n.Children {
+		list = append(list, notes(&c)...)
+	}
+	return list
+}
@@ -0,0 +1,140 @@
+// Package parallel aligns a passage verse by verse across translations.
+package parallel
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// Column is a translation taking part in the comparison.
+type Column struct {
+	Translation translation.Translation
+	Repository  bible.Repository
+}
+
+// Verse is a verse of one translation, numbered in that translation's
+// versification.
+type Verse struct {
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+	Text    string `json:"text"`
+}
+
+// Row holds, for one verse of the standard versification, the matching
+// verses of every column. A nil cell is a gap: the translation has no
+// counterpart for the verse.
+type Row struct {
+	Standard versification.Verse `json:"standard"`
+	Cells    [][]Verse           `json:"cells"`
+}
+
+// Passage is the result of Align.
+type Passage struct {
+	Reference    reference.Reference       `json:"reference"`
+	Translations []translation.Translation `json:"translations"`
+	Rows         []Row                     `json:"rows"`
+}
+
+// Align reads the passage ref, numbered in the versification of the first
+// column, from every column and lines the verses up. Rows follow the order
+// of the first column's verses.
+func Align(ref reference.Reference, columns []Column) (Passage, error) {
+	if len(columns) == 0 {
+		return Passage{}, fmt.Errorf("no translations to compare")
+	}
+
+	schemes := make([]*versification.Scheme, len(columns))
+	passage := Passage{Reference: ref, Rows: []Row{}}
+	for i, c := range columns {
+		scheme, ok := versification.Lookup(c.Translation.Versification)
+		if !ok {
+			return Passage{}, fmt.Errorf("translation %s: unknown versification %q", c.Translation.Id, c.Translation.Versification)
+		}
+		schemes[i] = scheme
+		passage.Translations = append(passage.Translations, c.Translation)
+	}
+
+	base, err := passageVerses(columns[0].Repository, ref)
+	if err != nil {
+		return Passage{}, err
+	}
+	if len(base) == 0 {
+		return Passage{}, fmt.Errorf("%s not found in %s", ref, columns[0].Translation.Id)
+	}
+
+	var standard []versification.Verse
+	for _, v := range base {
+		for _, std := range schemes[0].ToStandard(versification.Verse{Book: ref.Book, Chapter: v.Chapter, Verse: v.Verse}) {
+			if !slices.Contains(standard, std) {
+				standard = append(standard, std)
+			}
+		}
+	}
+
+	lookups := make([]*chapterCache, len(columns))
+	for i, c := range columns {
+		lookups[i] = &chapterCache{repo: c.Repository, chapters: map[chapterKey]map[int]string{}}
+	}
+
+	for _, std := range standard {
+		row := Row{Standard: std, Cells: make([][]Verse, len(columns))}
+		for i := range columns {
+			for _, v := range schemes[i].FromStandard(std) {
+				if text, ok := lookups[i].verse(v); ok {
+					row.Cells[i] = append(row.Cells[i], Verse{Chapter: v.Chapter, Verse: v.Verse, Text: text})
+				}
+			}
+		}
+		passage.Rows = append(passage.Rows, row)
+	}
+	return passage, nil
+}
+
+// passageVerses returns the verses of ref in repo, in order.
+func passageVerses(repo bible.Repository, ref reference.Reference) ([]Verse, error) {
+	var verses []Verse
+	for c := ref.StartChapter; c <= ref.EndChapter; c++ {
+		chapter, err := bible.ReadChapter(repo, ref.Book, c)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range chapter {
+			if ref.Contains(c, v.Verse) {
+				verses = append(verses, Verse{Chapter: c, Verse: v.Verse, Text: v.Text})
+			}
+		}
+	}
+	return verses, nil
+}
+
+type chapterKey struct {
+	book    string
+	chapter int
+}
+
+// chapterCache reads each chapter of a column once.
+type chapterCache struct {
+	repo     bible.Repository
+	chapters map[chapterKey]map[int]string
+}
+
+func (c *chapterCache) verse(v versification.Verse) (string, bool) {
+	key := chapterKey{v.Book, v.Chapter}
+	verses, ok := c.chapters[key]
+	if !ok {
+		verses = make(map[int]string)
+		if chapter, err := bible.ReadChapter(c.repo, v.Book, v.Chapter); err == nil {
+			for _, vs := range chapter {
+				verses[vs.Verse] = vs.Text
+			}
+		}
+		c.chapters[key] = verses
+	}
+	text, ok := verses[v.Verse]
+	return text, ok
+}
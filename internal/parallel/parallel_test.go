@@ -0,0 +1,136 @@
+package parallel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+)
+
+// englishColumn builds a KJV numbered translation holding Malachi 3-4 and
+// Psalm 3.
+func englishColumn(t *testing.T) Column {
+	t.Helper()
+
+	book := func(id string, chapters map[int]int) string {
+		var verses []string
+		total := 0
+		for c := 1; c <= len(chapters); c++ {
+			for v := 1; v <= chapters[c]; v++ {
+				verses = append(verses, fmt.Sprintf(`{"chapter":%d,"verse":%d,"id":"%s.%d.%d","text":"en %d:%d","paragraph":"n"}`, c, v, id, c, v, c, v))
+				total++
+			}
+		}
+		return fmt.Sprintf(`{"id":"%s","name":"%s","chapters":%d,"verseCount":%d,"verses":[%s]}`, id, id, len(chapters), total, strings.Join(verses, ","))
+	}
+
+	repo, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json":          {Data: []byte(`[{"id":"psalmen","name":"Psalms","order":1},{"id":"maleachi","name":"Malachi","order":2}]`)},
+		"books/maleachi.json": {Data: []byte(book("maleachi", map[int]int{1: 14, 2: 17, 3: 18, 4: 6}))},
+		"books/psalmen.json":  {Data: []byte(book("psalmen", map[int]int{1: 6, 2: 12, 3: 8}))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Column{
+		Translation: translation.Translation{Id: "en", Name: "English", Language: "en", Versification: "kjv"},
+		Repository:  repo,
+	}
+}
+
+func defaultColumn() Column {
+	return Column{Translation: translation.DefaultTranslation, Repository: bible.Current()}
+}
+
+func TestAlignMalachi(t *testing.T) {
+	ref := reference.Reference{Book: "maleachi", StartChapter: 3, StartVerse: 17, EndChapter: 3, EndVerse: 24}
+
+	passage, err := Align(ref, []Column{defaultColumn(), englishColumn(t)})
+	if err != nil {
+		t.Fatalf("Align() error = %v", err)
+	}
+	if len(passage.Rows) != 8 {
+		t.Fatalf("Align() returned %d rows, want 8", len(passage.Rows))
+	}
+
+	last := passage.Rows[7]
+	if last.Standard.Chapter != 3 || last.Standard.Verse != 24 {
+		t.Errorf("last row is %v, want maleachi 3:24", last.Standard)
+	}
+	if len(last.Cells[0]) != 1 || last.Cells[0][0].Verse != 24 || last.Cells[0][0].Text == "" {
+		t.Errorf("default cell = %+v", last.Cells[0])
+	}
+	if len(last.Cells[1]) != 1 || last.Cells[1][0].Text != "en 4:6" {
+		t.Errorf("english cell = %+v, want Malachi 4:6", last.Cells[1])
+	}
+	if got := passage.Rows[0].Cells[1]; len(got) != 1 || got[0].Text != "en 3:17" {
+		t.Errorf("english cell of 3:17 = %+v", got)
+	}
+}
+
+func TestAlignFromSecondVersification(t *testing.T) {
+	ref := reference.Reference{Book: "maleachi", StartChapter: 4, StartVerse: 1, EndChapter: 4, EndVerse: 6}
+
+	passage, err := Align(ref, []Column{englishColumn(t), defaultColumn()})
+	if err != nil {
+		t.Fatalf("Align() error = %v", err)
+	}
+	if len(passage.Rows) != 6 || passage.Rows[0].Standard.Chapter != 3 || passage.Rows[0].Standard.Verse != 19 {
+		t.Fatalf("rows = %+v", passage.Rows)
+	}
+	if got := passage.Rows[0].Cells[1]; len(got) != 1 || got[0].Chapter != 3 || got[0].Verse != 19 {
+		t.Errorf("default cell = %+v, want Maleachi 3:19", got)
+	}
+}
+
+func TestAlignPsalmSuperscription(t *testing.T) {
+	ref := reference.Reference{Book: "psalmen", StartChapter: 3, EndChapter: 3}
+
+	passage, err := Align(ref, []Column{defaultColumn(), englishColumn(t)})
+	if err != nil {
+		t.Fatalf("Align() error = %v", err)
+	}
+	if len(passage.Rows) != 9 {
+		t.Fatalf("Align() returned %d rows, want 9", len(passage.Rows))
+	}
+	if passage.Rows[0].Cells[1] != nil {
+		t.Errorf("superscription has an english verse: %+v", passage.Rows[0].Cells[1])
+	}
+	if got := passage.Rows[1].Cells[1]; len(got) != 1 || got[0].Text != "en 3:1" {
+		t.Errorf("english cell of 3:2 = %+v, want 3:1", got)
+	}
+}
+
+func TestAlignMissingBook(t *testing.T) {
+	ref := reference.Reference{Book: "tobit", StartChapter: 1, StartVerse: 1, EndChapter: 1, EndVerse: 2}
+
+	passage, err := Align(ref, []Column{defaultColumn(), englishColumn(t)})
+	if err != nil {
+		t.Fatalf("Align() error = %v", err)
+	}
+	for _, row := range passage.Rows {
+		if row.Cells[0] == nil || row.Cells[1] != nil {
+			t.Errorf("row %v = %+v, want a gap in the english column", row.Standard, row.Cells)
+		}
+	}
+
+	if _, err := Align(ref, []Column{englishColumn(t)}); err == nil {
+		t.Errorf("Align() error = nil for a passage missing from the first translation")
+	}
+}
+
+func TestAlignSingleChapterBook(t *testing.T) {
+	ref := reference.Reference{Book: "filemon", StartChapter: 1, StartVerse: 1, EndChapter: 1, EndVerse: 3}
+
+	passage, err := Align(ref, []Column{defaultColumn()})
+	if err != nil {
+		t.Fatalf("Align() error = %v", err)
+	}
+	if len(passage.Rows) != 3 {
+		t.Errorf("Align() returned %d rows, want 3", len(passage.Rows))
+	}
+}
@@ -0,0 +1,247 @@
+// Package passage reads liturgical references such as "jesaja 45:1, 4-6"
+// or "1samuel 3:3b-10, 19", which skip verses and may start or end halfway
+// a verse, and resolves them to verse text.
+package passage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+// Point is a verse, or the part of it that a letter selects: "a" is its
+// first sentence, "b" the second and so on.
+type Point struct {
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+	Part    string `json:"part,omitempty"`
+}
+
+// Range is a run of verses within a book. A zero Verse in Start selects
+// whole chapters.
+type Range struct {
+	Start Point `json:"start"`
+	End   Point `json:"end"`
+}
+
+// Passage is one or more ranges of a book, in reading order.
+type Passage struct {
+	Book   string  `json:"book"`
+	Ranges []Range `json:"ranges"`
+}
+
+var (
+	head  = regexp.MustCompile(`^(.+?)\.?\s+(\d.*)$`)
+	point = regexp.MustCompile(`^(?:(\d+):)?(\d+)([a-e]?)$`)
+)
+
+// Parse reads "<book> <chapter>:<verse>[<part>][-[<chapter>:]<verse>[<part>]]"
+// followed by more ranges separated by commas or semicolons, which number
+// their verses in the chapter of the range before them unless they give
+// one. A single "<book> <chapter>[-<chapter>]" selects whole chapters. The
+// book is resolved with books.
+func Parse(s string, books reference.BookResolver) (Passage, error) {
+	m := head.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Passage{}, fmt.Errorf("invalid passage %q", s)
+	}
+	book, ok := books(m[1])
+	if !ok {
+		return Passage{}, fmt.Errorf("unknown book %q", m[1])
+	}
+	p := Passage{Book: book}
+
+	if !strings.Contains(m[2], ":") {
+		ref, err := reference.Parse(s, books)
+		if err != nil {
+			return Passage{}, err
+		}
+		p.Ranges = []Range{{Start: Point{Chapter: ref.StartChapter}, End: Point{Chapter: ref.EndChapter}}}
+		return p, nil
+	}
+
+	chapter := 0
+	for _, part := range strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ';' }) {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, ok := parsePoint(strings.TrimSpace(from), chapter)
+		if !ok {
+			return Passage{}, fmt.Errorf("invalid passage %q", s)
+		}
+		end := start
+		if isRange {
+			if end, ok = parsePoint(strings.TrimSpace(to), start.Chapter); !ok {
+				return Passage{}, fmt.Errorf("invalid passage %q", s)
+			}
+		}
+		if end.Chapter < start.Chapter || (end.Chapter == start.Chapter &&
+			(end.Verse < start.Verse || end.Verse == start.Verse && end.Part != "" && end.Part < start.Part)) {
+			return Passage{}, fmt.Errorf("invalid passage %q: range %q is empty", s, part)
+		}
+		p.Ranges = append(p.Ranges, Range{Start: start, End: end})
+		chapter = end.Chapter
+	}
+	return p, nil
+}
+
+// parsePoint reads "[<chapter>:]<verse>[<part>]" in chapter when the
+// chapter is left out.
+func parsePoint(s string, chapter int) (Point, bool) {
+	m := point.FindStringSubmatch(s)
+	if m == nil {
+		return Point{}, false
+	}
+	if m[1] != "" {
+		chapter, _ = strconv.Atoi(m[1])
+	}
+	verse, _ := strconv.Atoi(m[2])
+	if chapter < 1 || verse < 1 {
+		return Point{}, false
+	}
+	return Point{Chapter: chapter, Verse: verse, Part: m[3]}, true
+}
+
+// String formats the passage the way Parse reads it, using the book id.
+func (p Passage) String() string {
+	var b strings.Builder
+	b.WriteString(p.Book)
+	chapter := 0
+	for i, r := range p.Ranges {
+		switch {
+		case i == 0:
+			b.WriteString(" ")
+		case r.Start.Chapter != chapter:
+			b.WriteString("; ")
+		default:
+			b.WriteString(", ")
+		}
+		if r.Start.Verse == 0 {
+			b.WriteString(strconv.Itoa(r.Start.Chapter))
+			if r.End.Chapter != r.Start.Chapter {
+				fmt.Fprintf(&b, "-%d", r.End.Chapter)
+			}
+			continue
+		}
+		if r.Start.Chapter != chapter {
+			fmt.Fprintf(&b, "%d:", r.Start.Chapter)
+		}
+		fmt.Fprintf(&b, "%d%s", r.Start.Verse, r.Start.Part)
+		switch {
+		case r.End.Chapter != r.Start.Chapter:
+			fmt.Fprintf(&b, "-%d:%d%s", r.End.Chapter, r.End.Verse, r.End.Part)
+		case r.End != r.Start:
+			fmt.Fprintf(&b, "-%d%s", r.End.Verse, r.End.Part)
+		}
+		chapter = r.End.Chapter
+	}
+	return b.String()
+}
+
+// Verse is a verse of a resolved passage. Part is set when only a part of
+// the verse is read, in which case Text holds that part.
+type Verse struct {
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+	Part    string `json:"part,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Read returns the verses of p in repo, in the order of its ranges. It
+// fails when a range selects no verses, so that a passage numbered for
+// another versification doesn't quietly come back short.
+func Read(repo bible.Repository, p Passage) ([]Verse, error) {
+	chapters := make(map[int][]bible.Verse)
+	chapter := func(n int) ([]bible.Verse, error) {
+		if verses, ok := chapters[n]; ok {
+			return verses, nil
+		}
+		verses, err := bible.ReadChapter(repo, p.Book, n)
+		if err != nil {
+			return nil, err
+		}
+		chapters[n] = verses
+		return verses, nil
+	}
+
+	var verses []Verse
+	for _, r := range p.Ranges {
+		n := len(verses)
+		for c := r.Start.Chapter; c <= r.End.Chapter; c++ {
+			chapterVerses, err := chapter(c)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range chapterVerses {
+				if !r.contains(c, v.Verse) {
+					continue
+				}
+				verse := Verse{Chapter: c, Verse: v.Verse, Text: v.Text}
+				from, to := "", ""
+				if r.Start.Verse != 0 && c == r.Start.Chapter && v.Verse == r.Start.Verse {
+					from = r.Start.Part
+				}
+				// A single verse with a part other than "a" reads on to
+				// the end of the verse, as "19b" usually means.
+				if r.Start.Verse != 0 && c == r.End.Chapter && v.Verse == r.End.Verse && (r.Start != r.End || r.End.Part == "a") {
+					to = r.End.Part
+				}
+				if from != "" || to != "" {
+					verse.Part = from + to
+					if from == to {
+						verse.Part = from
+					}
+					verse.Text = partText(v.Text, from, to)
+				}
+				verses = append(verses, verse)
+			}
+		}
+		if len(verses) == n {
+			return nil, fmt.Errorf("%s: no verses in %s", p, Passage{Book: p.Book, Ranges: []Range{r}})
+		}
+	}
+	return verses, nil
+}
+
+func (r Range) contains(chapter, verse int) bool {
+	if r.Start.Verse == 0 {
+		return true
+	}
+	return reference.Reference{
+		StartChapter: r.Start.Chapter, StartVerse: r.Start.Verse,
+		EndChapter: r.End.Chapter, EndVerse: r.End.Verse,
+	}.Contains(chapter, verse)
+}
+
+var sentenceEnd = regexp.MustCompile(`[.;:?!][’”'"»)]*\s+`)
+
+// partText returns the sentences of text from the one that the part letter
+// from selects up to and including the one that to selects; an empty
+// letter leaves that end open. Lectionaries split verses by the sentences
+// of their own translation, so when the text has fewer sentences than the
+// letters ask for, the whole verse is returned.
+func partText(text, from, to string) string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, text[start:loc[1]])
+		start = loc[1]
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+
+	first, last := 0, len(sentences)-1
+	if from != "" {
+		first = int(from[0] - 'a')
+	}
+	if to != "" {
+		last = int(to[0] - 'a')
+	}
+	if first > last || last >= len(sentences) {
+		return text
+	}
+	return strings.TrimSpace(strings.Join(sentences[first:last+1], ""))
+}
@@ -0,0 +1,129 @@
+package passage
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+var books = reference.Resolver(
+	[]string{"jesaja", "1samuel", "psalmen", "hebreeen", "ruth"},
+	[]string{"Jesaja", "1 Samuel", "Psalmen", "Hebreeën", "Ruth"},
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Passage
+	}{
+		{"jesaja 45:1, 4-6", Passage{"jesaja", []Range{
+			{Point{45, 1, ""}, Point{45, 1, ""}},
+			{Point{45, 4, ""}, Point{45, 6, ""}},
+		}}},
+		{"1 Samuel 3:3b-10, 19", Passage{"1samuel", []Range{
+			{Point{3, 3, "b"}, Point{3, 10, ""}},
+			{Point{3, 19, ""}, Point{3, 19, ""}},
+		}}},
+		{"hebreeen 4:14-16; 5:7-9", Passage{"hebreeen", []Range{
+			{Point{4, 14, ""}, Point{4, 16, ""}},
+			{Point{5, 7, ""}, Point{5, 9, ""}},
+		}}},
+		{"jesaja 52:13-53:12", Passage{"jesaja", []Range{
+			{Point{52, 13, ""}, Point{53, 12, ""}},
+		}}},
+		{"psalmen 23", Passage{"psalmen", []Range{
+			{Point{23, 0, ""}, Point{23, 0, ""}},
+		}}},
+		{"Psalmen 1-2", Passage{"psalmen", []Range{
+			{Point{1, 0, ""}, Point{2, 0, ""}},
+		}}},
+		{"jesaja 9:1-3a, 5a", Passage{"jesaja", []Range{
+			{Point{9, 1, ""}, Point{9, 3, "a"}},
+			{Point{9, 5, "a"}, Point{9, 5, "a"}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, books)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+
+			again, err := Parse(got.String(), books)
+			require.NoError(t, err)
+			require.Equal(t, got, again, got.String())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"jesaja",
+		"onbekend 1:1",
+		"jesaja 45:1, x",
+		"jesaja 45:6-4",
+		"jesaja 45:4b-4a",
+		"jesaja 45:0",
+		"jesaja 1, 4-6",
+	} {
+		_, err := Parse(in, books)
+		require.Error(t, err, in)
+	}
+}
+
+func TestRead(t *testing.T) {
+	repo, err := bible.NewFSRepository(fstest.MapFS{
+		"books.json": {Data: []byte(`[{"id":"jesaja","name":"Jesaja","order":1},{"id":"ruth","name":"Ruth","order":2}]`)},
+		"books/jesaja.json": {Data: []byte(`{"id":"jesaja","name":"Jesaja","chapters":2,"verseCount":5,"verses":[
+			{"chapter":1,"verse":2,"id":"jesaja.1.2","text":"Twee.","paragraph":"n"},
+			{"chapter":1,"verse":1,"id":"jesaja.1.1","text":"Een. Nog een; en nog.","paragraph":"y"},
+			{"chapter":1,"verse":3,"id":"jesaja.1.3","text":"Drie? Ja!","paragraph":"n"},
+			{"chapter":2,"verse":1,"id":"jesaja.2.1","text":"Vier.","paragraph":"y"},
+			{"chapter":2,"verse":2,"id":"jesaja.2.2","text":"Vijf.","paragraph":"n"}]}`)},
+		"books/ruth.json": {Data: []byte(`{"id":"ruth","name":"Ruth","chapters":1,"verseCount":1,"verses":[
+			{"chapter":0,"verse":1,"id":"ruth.0.1","text":"Ruth.","paragraph":"y"}]}`)},
+	})
+	require.NoError(t, err)
+
+	read := func(s string) []Verse {
+		t.Helper()
+		p, err := Parse(s, books)
+		require.NoError(t, err)
+		verses, err := Read(repo, p)
+		require.NoError(t, err)
+		return verses
+	}
+
+	require.Equal(t, []Verse{
+		{1, 1, "b", "Nog een; en nog."},
+		{1, 3, "a", "Drie?"},
+		{2, 2, "", "Vijf."},
+	}, read("jesaja 1:1b, 3a; 2:2"))
+
+	require.Equal(t, []Verse{
+		{1, 1, "a", "Een."},
+		{1, 1, "bc", "Nog een; en nog."},
+	}, read("jesaja 1:1a, 1b-1c"))
+
+	require.Equal(t, []Verse{
+		{1, 3, "", "Drie? Ja!"},
+		{2, 1, "a", "Vier."},
+	}, read("jesaja 1:3-2:1a"))
+
+	// A part beyond the sentences of the verse reads the whole verse.
+	require.Equal(t, []Verse{{1, 2, "c", "Twee."}}, read("jesaja 1:2c"))
+
+	// Whole chapters, and chapter 1 of a book stored as chapter 0.
+	require.Len(t, read("jesaja 1-2"), 5)
+	require.Equal(t, []Verse{{1, 1, "", "Ruth."}}, read("ruth 1:1"))
+
+	p, err := Parse("jesaja 1:2, 7-9", books)
+	require.NoError(t, err)
+	_, err = Read(repo, p)
+	require.ErrorContains(t, err, "no verses in jesaja 1:7-9")
+}
@@ -0,0 +1,226 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// Spec describes a generated plan. Every stream is a list of books read in
+// order from the first day to the last; a day holds a part of each stream.
+type Spec struct {
+	Id          string
+	Name        string
+	Description string
+	Days        int
+	Streams     [][]string
+}
+
+// Standard holds the generated plans offered by default.
+var Standard = []Spec{
+	{
+		Id:          "bijbel-in-een-jaar",
+		Name:        "De Bijbel in een jaar",
+		Description: "Alle boeken in de volgorde van de Bijbel.",
+		Days:        365,
+		Streams:     [][]string{canonical},
+	},
+	{
+		Id:          "chronologisch",
+		Name:        "Chronologisch in een jaar",
+		Description: "Alle boeken in de volgorde van de geschiedenis die zij vertellen.",
+		Days:        365,
+		Streams:     [][]string{Chronological(canonical)},
+	},
+	{
+		Id:          "nieuwe-testament",
+		Name:        "Het Nieuwe Testament in 90 dagen",
+		Description: "De boeken van het Nieuwe Testament in de volgorde van de Bijbel.",
+		Days:        90,
+		Streams:     [][]string{newTestament()},
+	},
+	{
+		Id:          "oud-nieuw-psalmen",
+		Name:        "Oude en Nieuwe Testament met de Psalmen",
+		Description: "Elke dag een deel van het Oude Testament, van het Nieuwe Testament en van de Psalmen.",
+		Days:        365,
+		Streams: [][]string{
+			slices.DeleteFunc(oldTestament(), func(id string) bool { return id == "psalmen" }),
+			newTestament(),
+			{"psalmen"},
+		},
+	},
+}
+
+// canonical holds the books in the order of the bundled text.
+var canonical = []string{
+	"genesis", "exodus", "leviticus", "numeri", "deuteronomium", "jozua", "rechters", "ruth",
+	"1samuel", "2samuel", "1koningen", "2koningen", "1kronieken", "2kronieken", "ezra", "nehemia",
+	"tobit", "judit", "ester", "1makkabeeen", "2makkabeeen", "job", "psalmen", "spreuken",
+	"prediker", "hooglied", "wijsheid", "jezussirach", "jesaja", "jeremia", "klaagliederen", "baruch",
+	"ezechiel", "daniel", "hosea", "joel", "amos", "obadja", "jonas", "micha",
+	"nahum", "habakuk", "sefanja", "haggai", "zacharias", "maleachi",
+	"matteus", "marcus", "lucas", "johannes", "handelingen", "romeinen", "1korintiers", "2korintiers",
+	"galaten", "efesiers", "filippenzen", "kolossenzen", "1tessalonicenzen", "2tessalonicenzen", "1timoteus", "2timoteus",
+	"titus", "filemon", "hebreeen", "jacobus", "1petrus", "2petrus", "1johannes", "2johannes",
+	"3johannes", "judas", "apokalyps",
+}
+
+func oldTestament() []string {
+	return slices.Clone(canonical[:slices.Index(canonical, "matteus")])
+}
+
+func newTestament() []string {
+	return slices.Clone(canonical[slices.Index(canonical, "matteus"):])
+}
+
+// chronological holds the books in the order of the history they tell, or
+// of the time they were written in. The prophets are placed with the kings
+// they worked under and the letters with the journeys of Acts.
+var chronological = []string{
+	"genesis", "job", "exodus", "leviticus", "numeri", "deuteronomium", "jozua", "rechters", "ruth",
+	"1samuel", "2samuel", "1kronieken", "psalmen", "1koningen", "spreuken", "prediker", "hooglied",
+	"2koningen", "2kronieken", "jonas", "amos", "hosea", "jesaja", "micha", "nahum", "sefanja",
+	"habakuk", "joel", "jeremia", "klaagliederen", "baruch", "obadja", "ezechiel", "daniel",
+	"tobit", "judit", "ezra", "haggai", "zacharias", "ester", "nehemia", "maleachi",
+	"wijsheid", "jezussirach", "1makkabeeen", "2makkabeeen",
+	"marcus", "matteus", "lucas", "johannes", "handelingen", "jacobus", "galaten",
+	"1tessalonicenzen", "2tessalonicenzen", "1korintiers", "2korintiers", "romeinen",
+	"efesiers", "filippenzen", "kolossenzen", "filemon", "1timoteus", "titus", "1petrus",
+	"2timoteus", "2petrus", "hebreeen", "judas", "1johannes", "2johannes", "3johannes", "apokalyps",
+}
+
+// Chronological returns books sorted in the order of chronological. Books
+// it does not know keep their order at the end.
+func Chronological(books []string) []string {
+	sorted := slices.Clone(books)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		return chronologicalIndex(a) - chronologicalIndex(b)
+	})
+	return sorted
+}
+
+func chronologicalIndex(id string) int {
+	if i := slices.Index(chronological, id); i >= 0 {
+		return i
+	}
+	return len(chronological)
+}
+
+// chapter is a chapter of a book with its verse count, the weight by which
+// chapters are spread over the days.
+type chapter struct {
+	book   string
+	number int
+	verses int
+}
+
+// Generate spreads the streams of spec over its days, balancing the number
+// of verses read each day by the verse counts in repo. Chapters are not
+// split. A stream with fewer chapters than days leaves days without a
+// reading from it.
+func Generate(repo bible.Repository, spec Spec) (*Plan, error) {
+	if spec.Days < 1 {
+		return nil, errors.New("a plan needs at least one day")
+	}
+	if len(spec.Streams) == 0 {
+		return nil, errors.New("a plan needs at least one book")
+	}
+
+	p := &Plan{Id: spec.Id, Name: spec.Name, Description: spec.Description, Days: make([][]string, spec.Days)}
+	for _, books := range spec.Streams {
+		chapters, err := chaptersOf(repo, books)
+		if err != nil {
+			return nil, err
+		}
+		if len(chapters) == 0 {
+			return nil, errors.New("a plan needs at least one book")
+		}
+		for i, day := range split(chapters, spec.Days) {
+			p.Days[i] = append(p.Days[i], references(day)...)
+		}
+	}
+	return p, nil
+}
+
+func chaptersOf(repo bible.Repository, books []string) ([]chapter, error) {
+	var chapters []chapter
+	for _, id := range books {
+		book, err := repo.GetChapters(id)
+		if err != nil {
+			return nil, fmt.Errorf("unknown book %q", id)
+		}
+		counts := make(map[int]int)
+		for _, v := range book.Verses {
+			counts[bible.ChapterNumber(v.Chapter)]++
+		}
+		for _, number := range slices.Sorted(maps.Keys(counts)) {
+			chapters = append(chapters, chapter{book: id, number: number, verses: counts[number]})
+		}
+	}
+	return chapters, nil
+}
+
+// split divides chapters over days in order. Each day takes chapters until
+// it reaches its share of the verses left, ending before a chapter when
+// less than half of that chapter would fit.
+func split(chapters []chapter, days int) [][]chapter {
+	parts := make([][]chapter, days)
+	if len(chapters) <= days {
+		for i, c := range chapters {
+			j := i * days / len(chapters)
+			parts[j] = append(parts[j], c)
+		}
+		return parts
+	}
+
+	remaining := 0
+	for _, c := range chapters {
+		remaining += c.verses
+	}
+	next := 0
+	for day := range days {
+		left := days - day
+		if left == 1 {
+			parts[day] = chapters[next:]
+			break
+		}
+		target := float64(remaining) / float64(left)
+		taken, end := 0, next
+		for end < len(chapters)-(left-1) {
+			c := chapters[end]
+			if end > next && float64(taken)+float64(c.verses)/2 > target {
+				break
+			}
+			taken += c.verses
+			end++
+		}
+		parts[day] = chapters[next:end]
+		remaining -= taken
+		next = end
+	}
+	return parts
+}
+
+// references writes the chapters of a day as one reference per book, such
+// as "genesis 1-3".
+func references(chapters []chapter) []string {
+	var refs []string
+	for i := 0; i < len(chapters); {
+		j := i
+		for j+1 < len(chapters) && chapters[j+1].book == chapters[i].book {
+			j++
+		}
+		ref := chapters[i].book + " " + strconv.Itoa(chapters[i].number)
+		if j > i {
+			ref += "-" + strconv.Itoa(chapters[j].number)
+		}
+		refs = append(refs, ref)
+		i = j + 1
+	}
+	return refs
+}
@@ -0,0 +1,196 @@
+// Package plan provides Bible reading plans: plans generated from rules,
+// which spread books over a number of days by their verse counts, and
+// curated plans loaded from files.
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/cache"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+// Plan is a reading plan. Every day lists references that the passage
+// package reads, such as "genesis 1-3" or "psalmen 119:1-88".
+type Plan struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Days        [][]string `json:"days"`
+}
+
+// Day returns the references of day n, counting from 1.
+func (p *Plan) Day(n int) ([]string, bool) {
+	if n < 1 || n > len(p.Days) {
+		return nil, false
+	}
+	return p.Days[n-1], true
+}
+
+var validId = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Validate checks the fields of a curated plan and that every reference
+// parses with books.
+func (p *Plan) Validate(books reference.BookResolver) error {
+	var errs []error
+	if !validId.MatchString(p.Id) {
+		errs = append(errs, fmt.Errorf("id %q: must consist of lower case letters, digits and dashes", p.Id))
+	}
+	if p.Name == "" {
+		errs = append(errs, fmt.Errorf("%s: name is required", p.Id))
+	}
+	if len(p.Days) == 0 {
+		errs = append(errs, fmt.Errorf("%s: at least one day is required", p.Id))
+	}
+	for i, refs := range p.Days {
+		if len(refs) == 0 {
+			errs = append(errs, fmt.Errorf("%s: day %d has no readings", p.Id, i+1))
+		}
+		for _, ref := range refs {
+			if _, err := passage.Parse(ref, books); err != nil {
+				errs = append(errs, fmt.Errorf("%s: day %d: %w", p.Id, i+1, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Load reads the curated plans in the *.json files at the root of fsys and
+// validates them.
+func Load(fsys fs.FS, books reference.BookResolver) ([]*Plan, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	var plans []*Plan
+	var errs []error
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var p Plan
+		if err := json.Unmarshal(data, &p); err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse plan %s: %w", path.Base(name), err))
+			continue
+		}
+		if err := p.Validate(books); err != nil {
+			errs = append(errs, fmt.Errorf("plan %s: %w", path.Base(name), err))
+			continue
+		}
+		plans = append(plans, &p)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// Summary describes a plan in the list of plans.
+type Summary struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Days        int    `json:"days"`
+	// Generated plans take another number of days.
+	Generated bool `json:"generated"`
+}
+
+// ErrNotFound is returned for an unknown plan id.
+var ErrNotFound = errors.New("plan not found")
+
+type generatedKey struct {
+	id   string
+	days int
+}
+
+// Registry holds the generated plans and the curated plans. It is safe for
+// concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	specs     []Spec
+	curated   []*Plan
+	generated *cache.LRU[generatedKey, *Plan]
+}
+
+// NewRegistry returns a registry with the Standard plans.
+func NewRegistry() *Registry {
+	return &Registry{
+		specs:     Standard,
+		generated: cache.NewLRU[generatedKey, *Plan](16),
+	}
+}
+
+// SetCurated replaces the curated plans, whose ids must differ from each
+// other and from those of the generated plans. It also drops the generated
+// plans, so that they are spread again by the verse counts of reloaded
+// data.
+func (r *Registry) SetCurated(plans []*Plan) error {
+	seen := make(map[string]bool)
+	for _, s := range r.specs {
+		seen[s.Id] = true
+	}
+	for _, p := range plans {
+		if seen[p.Id] {
+			return fmt.Errorf("plan %s: id is used twice", p.Id)
+		}
+		seen[p.Id] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.curated = slices.Clone(plans)
+	r.generated.Purge()
+	return nil
+}
+
+// List returns the generated plans followed by the curated plans.
+func (r *Registry) List() []Summary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Summary, 0, len(r.specs)+len(r.curated))
+	for _, s := range r.specs {
+		list = append(list, Summary{Id: s.Id, Name: s.Name, Description: s.Description, Days: s.Days, Generated: true})
+	}
+	for _, p := range r.curated {
+		list = append(list, Summary{Id: p.Id, Name: p.Name, Description: p.Description, Days: len(p.Days)})
+	}
+	return list
+}
+
+// Get returns a plan. A generated plan is spread over days, or its own
+// number of days when days is 0, using the verse counts of repo; days is
+// ignored for curated plans.
+func (r *Registry) Get(repo bible.Repository, id string, days int) (*Plan, error) {
+	r.mu.RLock()
+	i := slices.IndexFunc(r.specs, func(s Spec) bool { return s.Id == id })
+	j := slices.IndexFunc(r.curated, func(p *Plan) bool { return p.Id == id })
+	var curated *Plan
+	if j >= 0 {
+		curated = r.curated[j]
+	}
+	r.mu.RUnlock()
+
+	switch {
+	case i >= 0:
+		spec := r.specs[i]
+		if days != 0 {
+			spec.Days = days
+		}
+		return r.generated.GetOrLoad(generatedKey{id, spec.Days}, func(generatedKey) (*Plan, error) {
+			return Generate(repo, spec)
+		})
+	case curated != nil:
+		return curated, nil
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,157 @@
+package plan
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+func bookResolver() reference.BookResolver {
+	var ids, names []string
+	for _, b := range bible.GetBooks() {
+		ids = append(ids, b.Id)
+		names = append(names, b.Name)
+	}
+	return reference.Resolver(ids, names)
+}
+
+func TestCanonicalMatchesBooks(t *testing.T) {
+	var ids []string
+	for _, b := range bible.GetBooks() {
+		ids = append(ids, b.Id)
+	}
+	require.Equal(t, ids, canonical)
+	require.ElementsMatch(t, canonical, chronological)
+}
+
+// Every standard plan reads every chapter of its books once, in order, and
+// spreads the verses evenly.
+func TestStandardPlans(t *testing.T) {
+	repo, books := bible.Current(), bookResolver()
+	for _, spec := range Standard {
+		p, err := Generate(repo, spec)
+		require.NoError(t, err, spec.Id)
+		require.Len(t, p.Days, spec.Days, spec.Id)
+
+		var want, got []string
+		verses := make(map[string]int)
+		for _, stream := range spec.Streams {
+			chapters, err := chaptersOf(repo, stream)
+			require.NoError(t, err)
+			for _, c := range chapters {
+				key := fmt.Sprintf("%s %d", c.book, c.number)
+				want = append(want, key)
+				verses[key] = c.verses
+			}
+		}
+		counts := make([]int, len(p.Days))
+		for i, day := range p.Days {
+			require.NotEmpty(t, day, "%s day %d", spec.Id, i+1)
+			for _, ref := range day {
+				ps, err := passage.Parse(ref, books)
+				require.NoError(t, err, ref)
+				r := ps.Ranges[0]
+				for n := r.Start.Chapter; n <= r.End.Chapter; n++ {
+					key := fmt.Sprintf("%s %d", ps.Book, n)
+					got = append(got, key)
+					counts[i] += verses[key]
+				}
+			}
+		}
+		if len(spec.Streams) > 1 {
+			require.ElementsMatch(t, want, got, spec.Id)
+		} else {
+			require.Equal(t, want, got, spec.Id)
+			// A day is at most twice the average, unless it is a single
+			// long chapter.
+			total := 0
+			for _, n := range counts {
+				total += n
+			}
+			for i, n := range counts {
+				if len(p.Days[i]) == 1 && n > 2*total/len(counts) {
+					ps, _ := passage.Parse(p.Days[i][0], books)
+					require.Equal(t, ps.Ranges[0].Start.Chapter, ps.Ranges[0].End.Chapter, "%s day %d: %v", spec.Id, i+1, p.Days[i])
+				}
+			}
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	repo := bible.Current()
+
+	p, err := Generate(repo, Spec{Id: "marcus", Days: 4, Streams: [][]string{{"marcus"}}})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"marcus 1-4"}, {"marcus 5-8"}, {"marcus 9-12"}, {"marcus 13-16"}}, p.Days)
+
+	// Books of a single chapter are read as chapter 1, and a stream shorter
+	// than the plan leaves days empty.
+	p, err = Generate(repo, Spec{Id: "brieven", Days: 4, Streams: [][]string{{"filemon", "judas"}}})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"filemon 1"}, nil, {"judas 1"}, nil}, p.Days)
+
+	p, err = Generate(repo, Spec{Id: "mix", Days: 2, Streams: [][]string{{"ruth"}, {"psalmen"}}})
+	require.NoError(t, err)
+	require.Equal(t, "ruth 1-2", p.Days[0][0])
+	require.Equal(t, "ruth 3-4", p.Days[1][0])
+	require.Len(t, p.Days[1], 2)
+
+	_, err = Generate(repo, Spec{Id: "leeg", Days: 0, Streams: [][]string{{"ruth"}}})
+	require.Error(t, err)
+	_, err = Generate(repo, Spec{Id: "onbekend", Days: 3, Streams: [][]string{{"henoch"}}})
+	require.ErrorContains(t, err, `unknown book "henoch"`)
+}
+
+func TestChronological(t *testing.T) {
+	require.Equal(t, []string{"job", "exodus", "marcus", "romeinen", "henoch"},
+		Chronological([]string{"henoch", "romeinen", "exodus", "marcus", "job"}))
+}
+
+func TestLoad(t *testing.T) {
+	books := bookResolver()
+	plans, err := Load(fstest.MapFS{
+		"advent.json": {Data: []byte(`{"id": "advent", "name": "Advent", "days": [["jesaja 2:1-5", "matteus 24:37-44"], ["jesaja 11"]]}`)},
+		"notes.txt":   {Data: []byte(`not a plan`)},
+	}, books)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	day, ok := plans[0].Day(2)
+	require.True(t, ok)
+	require.Equal(t, []string{"jesaja 11"}, day)
+	_, ok = plans[0].Day(3)
+	require.False(t, ok)
+
+	_, err = Load(fstest.MapFS{
+		"bad.json": {Data: []byte(`{"id": "Bad Id", "days": [[], ["henoch 1"]]}`)},
+	}, books)
+	require.ErrorContains(t, err, "must consist of")
+	require.ErrorContains(t, err, "name is required")
+	require.ErrorContains(t, err, "day 1 has no readings")
+	require.ErrorContains(t, err, `unknown book "henoch"`)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	require.Error(t, r.SetCurated([]*Plan{{Id: "chronologisch", Name: "Dubbel"}}))
+	require.NoError(t, r.SetCurated([]*Plan{{Id: "advent", Name: "Advent", Days: [][]string{{"jesaja 11"}}}}))
+
+	list := r.List()
+	require.Len(t, list, len(Standard)+1)
+	require.Equal(t, Summary{Id: "advent", Name: "Advent", Days: 1}, list[len(list)-1])
+
+	p, err := r.Get(bible.Current(), "nieuwe-testament", 30)
+	require.NoError(t, err)
+	require.Len(t, p.Days, 30)
+	p, err = r.Get(bible.Current(), "advent", 30)
+	require.NoError(t, err)
+	require.Len(t, p.Days, 1)
+	_, err = r.Get(bible.Current(), "onbekend", 0)
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,137 @@
+package psalter
+
+import "time"
+
+// lauds holds the first psalm, the canticle of the Old Testament and the
+// psalm of praise of Lauds.
+type lauds [3]string
+
+func (l lauds) psalmody() []Psalmody {
+	return []Psalmody{{Psalm, l[0]}, {Canticle, l[1]}, {Psalm, l[2]}}
+}
+
+// vespers holds the two psalms and the canticle of the New Testament of
+// Vespers.
+type vespers [3]string
+
+func (v vespers) psalmody() []Psalmody {
+	return []Psalmody{{Psalm, v[0]}, {Psalm, v[1]}, {Canticle, v[2]}}
+}
+
+// The Gospel canticles, the same every day.
+const (
+	benedictus   = "lucas 1:68-79"
+	magnificat   = "lucas 1:46-55"
+	nuncDimittis = "lucas 2:29-32"
+)
+
+// The canticles of the New Testament at Vespers follow the weekday, in
+// every week of the psalter.
+const (
+	sundayCanticle       = "apokalyps 19:1-2, 5-7"
+	lentenCanticle       = "1petrus 2:21-24"
+	mondayCanticle       = "efesiers 1:3-10"
+	tuesdayCanticle      = "apokalyps 4:11; 5:9-10, 12"
+	wednesdayCanticle    = "kolossenzen 1:12-20"
+	thursdayCanticle     = "apokalyps 11:17-18; 12:10b-12a"
+	fridayCanticle       = "apokalyps 15:3-4"
+	firstVespersCanticle = "filippenzen 2:6-11"
+)
+
+// morning holds Lauds by week of the psalter and weekday.
+var morning = [4][7]lauds{
+	{
+		time.Sunday:    {"psalmen 63:1-9", "daniel 3:57-88, 56", "psalmen 149"},
+		time.Monday:    {"psalmen 5:2-10, 12-13", "1kronieken 29:10-13", "psalmen 29"},
+		time.Tuesday:   {"psalmen 24", "tobit 13:2-8", "psalmen 33"},
+		time.Wednesday: {"psalmen 36:2-13", "judit 16:1-2, 13-15", "psalmen 47:2-10"},
+		time.Thursday:  {"psalmen 57:2-12", "jeremia 31:10-14", "psalmen 48:2-15"},
+		time.Friday:    {"psalmen 51:3-21", "jesaja 45:15-25", "psalmen 100"},
+		time.Saturday:  {"psalmen 119:145-152", "exodus 15:1-4a, 8-13, 17-18", "psalmen 117"},
+	},
+	{
+		time.Sunday:    {"psalmen 118", "daniel 3:52-57", "psalmen 150"},
+		time.Monday:    {"psalmen 42:2-12", "jezussirach 36:1-7, 13-16", "psalmen 19:2-7"},
+		time.Tuesday:   {"psalmen 43", "jesaja 38:10-14, 17-20", "psalmen 65:2-14"},
+		time.Wednesday: {"psalmen 77:2-21", "1samuel 2:1-10", "psalmen 97"},
+		time.Thursday:  {"psalmen 80:2-20", "jesaja 12:1-6", "psalmen 81:2-17"},
+		time.Friday:    {"psalmen 51:3-21", "habakuk 3:2-4, 13a, 15-19", "psalmen 147:12-20"},
+		time.Saturday:  {"psalmen 92:2-16", "deuteronomium 32:1-12", "psalmen 8:2-10"},
+	},
+	{
+		time.Sunday:    {"psalmen 93", "daniel 3:57-88, 56", "psalmen 148"},
+		time.Monday:    {"psalmen 84:2-13", "jesaja 2:2-5", "psalmen 96"},
+		time.Tuesday:   {"psalmen 85:2-14", "jesaja 26:1-4, 7-9, 12", "psalmen 67:2-8"},
+		time.Wednesday: {"psalmen 86", "jesaja 33:13-16", "psalmen 98"},
+		time.Thursday:  {"psalmen 87", "jesaja 40:10-17", "psalmen 99"},
+		time.Friday:    {"psalmen 51:3-21", "jeremia 14:17-21", "psalmen 100"},
+		time.Saturday:  {"psalmen 119:145-152", "wijsheid 9:1-6, 9-11", "psalmen 117"},
+	},
+	{
+		time.Sunday:    {"psalmen 118", "daniel 3:52-57", "psalmen 150"},
+		time.Monday:    {"psalmen 90", "jesaja 42:10-16", "psalmen 135:1-12"},
+		time.Tuesday:   {"psalmen 101", "daniel 3:26-27, 29, 34-41", "psalmen 144:1-10"},
+		time.Wednesday: {"psalmen 108:2-14", "jesaja 61:10-62:5", "psalmen 146"},
+		time.Thursday:  {"psalmen 143:1-11", "jesaja 66:10-14a", "psalmen 147:1-11"},
+		time.Friday:    {"psalmen 51:3-21", "tobit 13:10-13, 15-16", "psalmen 147:12-20"},
+		time.Saturday:  {"psalmen 92:2-16", "ezechiel 36:24-28", "psalmen 8:2-10"},
+	},
+}
+
+// firstVespers holds Vespers on Saturday evening, the first of Sunday, by
+// week of the psalter.
+var firstVespers = [4]vespers{
+	{"psalmen 141:1-9", "psalmen 142:2-8", firstVespersCanticle},
+	{"psalmen 119:105-112", "psalmen 16", firstVespersCanticle},
+	{"psalmen 113", "psalmen 116:10-19", firstVespersCanticle},
+	{"psalmen 122", "psalmen 130", firstVespersCanticle},
+}
+
+// evening holds Vespers by week of the psalter and weekday; on Sunday it is
+// the second Vespers. Saturday is left out for firstVespers.
+var evening = [4][7]vespers{
+	{
+		time.Sunday:    {"psalmen 110:1-5, 7", "psalmen 114", sundayCanticle},
+		time.Monday:    {"psalmen 11", "psalmen 15", mondayCanticle},
+		time.Tuesday:   {"psalmen 20:2-8, 10", "psalmen 21:2-8, 14", tuesdayCanticle},
+		time.Wednesday: {"psalmen 27:1-6", "psalmen 27:7-14", wednesdayCanticle},
+		time.Thursday:  {"psalmen 30:2-13", "psalmen 32", thursdayCanticle},
+		time.Friday:    {"psalmen 41:2-14", "psalmen 46:2-12", fridayCanticle},
+	},
+	{
+		time.Sunday:    {"psalmen 110:1-5, 7", "psalmen 115", sundayCanticle},
+		time.Monday:    {"psalmen 45:2-10", "psalmen 45:11-18", mondayCanticle},
+		time.Tuesday:   {"psalmen 49:2-13", "psalmen 49:14-21", tuesdayCanticle},
+		time.Wednesday: {"psalmen 62:2-13", "psalmen 67:2-8", wednesdayCanticle},
+		time.Thursday:  {"psalmen 72:1-11", "psalmen 72:12-19", thursdayCanticle},
+		time.Friday:    {"psalmen 116:1-9", "psalmen 121", fridayCanticle},
+	},
+	{
+		time.Sunday:    {"psalmen 110:1-5, 7", "psalmen 111", sundayCanticle},
+		time.Monday:    {"psalmen 123", "psalmen 124", mondayCanticle},
+		time.Tuesday:   {"psalmen 125", "psalmen 131", tuesdayCanticle},
+		time.Wednesday: {"psalmen 126", "psalmen 127", wednesdayCanticle},
+		time.Thursday:  {"psalmen 132:1-10", "psalmen 132:11-18", thursdayCanticle},
+		time.Friday:    {"psalmen 135:1-12", "psalmen 135:13-21", fridayCanticle},
+	},
+	{
+		time.Sunday:    {"psalmen 110:1-5, 7", "psalmen 112", sundayCanticle},
+		time.Monday:    {"psalmen 136:1-9", "psalmen 136:10-26", mondayCanticle},
+		time.Tuesday:   {"psalmen 137:1-6", "psalmen 138", tuesdayCanticle},
+		time.Wednesday: {"psalmen 139:1-12", "psalmen 139:13-18, 23-24", wednesdayCanticle},
+		time.Thursday:  {"psalmen 144:1-8", "psalmen 144:9-15", thursdayCanticle},
+		time.Friday:    {"psalmen 145:1-13", "psalmen 145:14-21", fridayCanticle},
+	},
+}
+
+// night holds the psalms of Compline by weekday. Saturday's follow the
+// first Vespers of Sunday and Sunday's the second.
+var night = [7][]string{
+	time.Sunday:    {"psalmen 91"},
+	time.Monday:    {"psalmen 86"},
+	time.Tuesday:   {"psalmen 143:1-11"},
+	time.Wednesday: {"psalmen 31:2-6", "psalmen 130"},
+	time.Thursday:  {"psalmen 16"},
+	time.Friday:    {"psalmen 88:2-19"},
+	time.Saturday:  {"psalmen 4:2-9", "psalmen 134"},
+}
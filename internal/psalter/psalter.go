@@ -0,0 +1,164 @@
+// Package psalter schedules the four-week psalter of the Liturgy of the
+// Hours: the psalms and canticles of Lauds, Vespers and Compline for a day
+// of the liturgical calendar.
+package psalter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+)
+
+// Hour is an hour of the Liturgy of the Hours.
+type Hour string
+
+const (
+	Lauds    Hour = "lauds"
+	Vespers  Hour = "vespers"
+	Compline Hour = "compline"
+)
+
+// Name returns the Dutch name of the hour.
+func (h Hour) Name() string {
+	switch h {
+	case Lauds:
+		return "Lauden"
+	case Vespers:
+		return "Vespers"
+	}
+	return "Completen"
+}
+
+// Kind tells a psalm from the canticles of the Old and New Testament and the
+// Gospel canticle that closes Lauds, Vespers and Compline.
+type Kind string
+
+const (
+	Psalm          Kind = "psalm"
+	Canticle       Kind = "canticle"
+	GospelCanticle Kind = "gospel-canticle"
+)
+
+// Psalmody is a psalm or canticle of an hour. Reference is read by the
+// passage package and numbered like the Dutch text, which counts the
+// superscription of a psalm as verse 1 when it stands on its own.
+type Psalmody struct {
+	Kind      Kind   `json:"kind"`
+	Reference string `json:"reference"`
+}
+
+// Office is the psalmody of an hour on a day.
+type Office struct {
+	Hour Hour   `json:"hour"`
+	Name string `json:"name"`
+	// Proper reports that the celebration has psalms of its own for the
+	// hour, which are not included; Psalmody then holds the psalter's.
+	Proper   bool       `json:"proper,omitempty"`
+	Psalmody []Psalmody `json:"psalmody"`
+}
+
+// Schedule is the psalmody of a day.
+type Schedule struct {
+	Day liturgy.Day `json:"day"`
+	// Week is the week of the psalter, 1-4.
+	Week    int      `json:"week"`
+	Offices []Office `json:"offices"`
+}
+
+// For returns the schedule of the date of t in cal. Vespers and Compline
+// on Saturday, and on the eve of a solemnity, belong to the day after.
+func For(cal *liturgy.Calendar, t time.Time) Schedule {
+	day := cal.Day(t)
+	next := cal.Day(t.AddDate(0, 0, 1))
+	week := Week(day)
+	weekday := t.Weekday()
+	triduum := day.Celebration.Key == "good-friday" || day.Celebration.Key == "holy-saturday"
+
+	lauds := Office{Hour: Lauds, Name: Lauds.Name(), Proper: triduum || day.Celebration.Rank == liturgy.RankCommemoration}
+	switch {
+	case festive(day):
+		// Solemnities and feasts take the psalms of Sunday of the first
+		// week.
+		lauds.Psalmody = morning[0][time.Sunday].psalmody()
+	default:
+		lauds.Psalmody = morning[week-1][weekday].psalmody()
+	}
+	lauds.Psalmody = append(lauds.Psalmody, Psalmody{GospelCanticle, benedictus})
+
+	vespers := Office{Hour: Vespers, Name: Vespers.Name()}
+	switch {
+	case weekday == time.Saturday:
+		// First Vespers of Sunday, unless a solemnity keeps its own.
+		vespers.Psalmody = firstVespers[Week(next)-1].psalmody()
+		vespers.Proper = festive(next) || day.Celebration.Rank == liturgy.RankSolemnity
+	default:
+		vespers.Psalmody = evening[week-1][weekday].psalmody()
+		if weekday == time.Sunday && day.Season == liturgy.Lent {
+			vespers.Psalmody[2].Reference = lentenCanticle
+		}
+		vespers.Proper = festive(day) || day.Season == liturgy.Triduum ||
+			day.Celebration.Rank == liturgy.RankCommemoration || next.Celebration.Rank == liturgy.RankSolemnity
+	}
+	vespers.Psalmody = append(vespers.Psalmody, Psalmody{GospelCanticle, magnificat})
+
+	// Compline follows the week rather than the psalter: after the second
+	// Vespers of a Sunday or solemnity it is that of Sunday, after the
+	// first Vespers that of Saturday.
+	compline := Office{Hour: Compline, Name: Compline.Name()}
+	nightWeekday := weekday
+	switch {
+	case day.Celebration.Rank == liturgy.RankSolemnity || day.Season == liturgy.Triduum:
+		nightWeekday = time.Sunday
+	case next.Celebration.Rank == liturgy.RankSolemnity:
+		nightWeekday = time.Saturday
+	}
+	for _, ref := range night[nightWeekday] {
+		compline.Psalmody = append(compline.Psalmody, Psalmody{Psalm, ref})
+	}
+	compline.Psalmody = append(compline.Psalmody, Psalmody{GospelCanticle, nuncDimittis})
+
+	return Schedule{Day: day, Week: week, Offices: []Office{lauds, vespers, compline}}
+}
+
+// festive reports whether a day is a solemnity or feast, which have psalms
+// of their own at Vespers. The days of the Christmas octave are kept like
+// Christmas itself.
+func festive(day liturgy.Day) bool {
+	switch day.Celebration.Rank {
+	case liturgy.RankSolemnity, liturgy.RankFeast:
+		return true
+	}
+	return strings.HasPrefix(day.Celebration.Key, "christmas-12-")
+}
+
+// Week returns the week of the psalter of a day. It follows the weeks of
+// the season, starting again after the fourth: the first weeks of Advent,
+// Lent, Easter time and Ordinary Time are week 1, and the days after Ash
+// Wednesday week 4. Christmas time carries on from the fourth week of
+// Advent, so that the Sunday after Christmas starts week 1 again.
+func Week(day liturgy.Day) int {
+	switch day.Season {
+	case liturgy.Christmas:
+		d, _ := time.Parse(time.DateOnly, day.Date)
+		y := d.Year()
+		if d.Month() == time.January {
+			y--
+		}
+		advent4 := sundayOnOrBefore(time.Date(y, time.December, 24, 0, 0, 0, 0, time.UTC))
+		weeks := int(sundayOnOrBefore(d).Sub(advent4).Hours()/24) / 7
+		return (weeks+3)%4 + 1
+	case liturgy.Lent:
+		if day.Week == 0 {
+			return 4
+		}
+	case liturgy.Triduum:
+		// The Triduum is kept within Holy Week, the sixth week of Lent.
+		return 2
+	}
+	return (day.Week-1)%4 + 1
+}
+
+func sundayOnOrBefore(d time.Time) time.Time {
+	return d.AddDate(0, 0, -int(d.Weekday()))
+}
@@ -0,0 +1,128 @@
+package psalter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/liturgy"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+func bookResolver() reference.BookResolver {
+	var ids, names []string
+	for _, b := range bible.GetBooks() {
+		ids = append(ids, b.Id)
+		names = append(names, b.Name)
+	}
+	return reference.Resolver(ids, names)
+}
+
+// Every psalm and canticle of the psalter reads in the Dutch text.
+func TestReferencesResolve(t *testing.T) {
+	refs := []string{benedictus, magnificat, nuncDimittis, lentenCanticle}
+	for _, week := range morning {
+		for _, l := range week {
+			refs = append(refs, l[:]...)
+		}
+	}
+	for _, week := range evening {
+		for _, v := range week[:time.Saturday] {
+			refs = append(refs, v[:]...)
+		}
+	}
+	for _, v := range firstVespers {
+		refs = append(refs, v[:]...)
+	}
+	for _, psalms := range night {
+		refs = append(refs, psalms...)
+	}
+
+	books := bookResolver()
+	for _, s := range refs {
+		p, err := passage.Parse(s, books)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, err := passage.Read(bible.Current(), p); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(time.DateOnly, s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestWeek(t *testing.T) {
+	cal := liturgy.New(liturgy.DutchRules)
+	tests := []struct {
+		date string
+		want int
+	}{
+		{"2025-11-30", 1}, // first Sunday of Advent
+		{"2025-12-24", 4},
+		{"2025-12-26", 4}, // the week of the fourth Sunday of Advent
+		{"2025-12-28", 1}, // Holy Family
+		{"2026-01-05", 2},
+		{"2026-01-12", 1}, // first week of Ordinary Time
+		{"2026-02-19", 4}, // after Ash Wednesday
+		{"2026-02-22", 1}, // first Sunday of Lent
+		{"2026-03-22", 1}, // fifth Sunday of Lent
+		{"2026-04-02", 2}, // Holy Thursday
+		{"2026-04-12", 2}, // second Sunday of Easter
+		{"2026-05-17", 3}, // seventh Sunday of Easter
+		{"2026-10-18", 1}, // 29th Sunday in Ordinary Time
+		{"2026-11-22", 2}, // Christ the King, the 34th
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Week(cal.Day(date(t, tt.date))), tt.date)
+	}
+}
+
+func TestFor(t *testing.T) {
+	cal := liturgy.New(liturgy.DutchRules)
+
+	s := For(cal, date(t, "2026-10-20")) // Tuesday, week 1
+	require.Equal(t, 1, s.Week)
+	require.Len(t, s.Offices, 3)
+	require.Equal(t, Office{Hour: Lauds, Name: "Lauden", Psalmody: []Psalmody{
+		{Psalm, "psalmen 24"},
+		{Canticle, "tobit 13:2-8"},
+		{Psalm, "psalmen 33"},
+		{GospelCanticle, benedictus},
+	}}, s.Offices[0])
+	require.Equal(t, "psalmen 20:2-8, 10", s.Offices[1].Psalmody[0].Reference)
+	require.False(t, s.Offices[1].Proper)
+	require.Equal(t, []Psalmody{{Psalm, "psalmen 143:1-11"}, {GospelCanticle, nuncDimittis}}, s.Offices[2].Psalmody)
+
+	// Saturday evening is the first Vespers of Sunday, of the next week.
+	s = For(cal, date(t, "2026-10-24"))
+	require.Equal(t, "psalmen 119:105-112", s.Offices[1].Psalmody[0].Reference)
+	require.Equal(t, "psalmen 4:2-9", s.Offices[2].Psalmody[0].Reference)
+
+	// Lent replaces the canticle of the second Vespers of Sunday.
+	s = For(cal, date(t, "2026-03-01"))
+	require.Equal(t, lentenCanticle, s.Offices[1].Psalmody[2].Reference)
+
+	// A solemnity takes Sunday of the first week at Lauds and has psalms of
+	// its own at Vespers; its eve has first Vespers and Compline.
+	s = For(cal, date(t, "2026-08-15")) // Assumption, on a Saturday
+	require.Equal(t, "psalmen 63:1-9", s.Offices[0].Psalmody[0].Reference)
+	require.True(t, s.Offices[1].Proper)
+	require.Equal(t, "psalmen 91", s.Offices[2].Psalmody[0].Reference)
+	s = For(cal, date(t, "2026-08-14"))
+	require.True(t, s.Offices[1].Proper)
+	require.Equal(t, "psalmen 4:2-9", s.Offices[2].Psalmody[0].Reference)
+
+	s = For(cal, date(t, "2026-04-03")) // Good Friday
+	require.True(t, s.Offices[0].Proper)
+	require.True(t, s.Offices[1].Proper)
+}
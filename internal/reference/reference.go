@@ -0,0 +1,139 @@
+// Package reference parses human-written Bible references such as
+// "Maleachi 3:19-24", "psalmen 23" or "Gen. 1,1-2,3".
+package reference
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reference is a passage within one book. A zero StartVerse selects whole
+// chapters; a zero EndVerse runs to the end of EndChapter.
+type Reference struct {
+	Book         string `json:"book"`
+	StartChapter int    `json:"startChapter"`
+	StartVerse   int    `json:"startVerse,omitempty"`
+	EndChapter   int    `json:"endChapter"`
+	EndVerse     int    `json:"endVerse,omitempty"`
+}
+
+// String formats the reference the way Parse reads it, using the book id.
+func (r Reference) String() string {
+	s := fmt.Sprintf("%s %d", r.Book, r.StartChapter)
+	if r.StartVerse > 0 {
+		s += ":" + strconv.Itoa(r.StartVerse)
+	}
+
+	switch {
+	case r.EndChapter != r.StartChapter && r.EndVerse > 0:
+		s += fmt.Sprintf("-%d:%d", r.EndChapter, r.EndVerse)
+	case r.EndChapter != r.StartChapter:
+		s += fmt.Sprintf("-%d", r.EndChapter)
+	case r.EndVerse > 0 && r.EndVerse != r.StartVerse:
+		s += fmt.Sprintf("-%d", r.EndVerse)
+	}
+	return s
+}
+
+// Contains reports whether the verse chapter:verse lies within r.
+func (r Reference) Contains(chapter, verse int) bool {
+	if chapter < r.StartChapter || chapter > r.EndChapter {
+		return false
+	}
+	if chapter == r.StartChapter && verse < r.StartVerse {
+		return false
+	}
+	if chapter == r.EndChapter && r.EndVerse > 0 && verse > r.EndVerse {
+		return false
+	}
+	return true
+}
+
+// BookResolver returns the book id for a book as written in a reference.
+type BookResolver func(name string) (string, bool)
+
+var pattern = regexp.MustCompile(`^(.+?)\.?\s+(\d+)(?:[:,](\d+))?(?:\s*-\s*(\d+)(?:[:,](\d+))?)?$`)
+
+// Parse reads a reference of the form "<book> <chapter>[:<verse>][-[<chapter>:]<verse>]"
+// or "<book> <chapter>-<chapter>". A comma may be used instead of the
+// colon, as is usual in Dutch. The book is resolved with books.
+func Parse(s string, books BookResolver) (Reference, error) {
+	m := pattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Reference{}, fmt.Errorf("invalid reference %q", s)
+	}
+
+	book, ok := books(m[1])
+	if !ok {
+		return Reference{}, fmt.Errorf("unknown book %q", m[1])
+	}
+
+	ref := Reference{Book: book, StartChapter: atoi(m[2]), StartVerse: atoi(m[3])}
+	ref.EndChapter, ref.EndVerse = ref.StartChapter, ref.StartVerse
+
+	switch {
+	case m[4] == "":
+	case m[5] != "":
+		ref.EndChapter, ref.EndVerse = atoi(m[4]), atoi(m[5])
+	case ref.StartVerse > 0:
+		ref.EndVerse = atoi(m[4])
+	default:
+		ref.EndChapter = atoi(m[4])
+	}
+
+	if m[5] != "" && ref.StartVerse == 0 {
+		return Reference{}, fmt.Errorf("invalid reference %q: range ends in a verse but starts at a chapter", s)
+	}
+	if ref.StartChapter < 1 || ref.EndChapter < ref.StartChapter ||
+		(ref.EndChapter == ref.StartChapter && ref.EndVerse < ref.StartVerse) {
+		return Reference{}, fmt.Errorf("invalid reference %q: range is empty", s)
+	}
+	return ref, nil
+}
+
+// Resolver returns a BookResolver matching book ids and names without
+// regard to case, spaces or diacritics, and unambiguous abbreviations of
+// at least three letters.
+func Resolver(ids, names []string) BookResolver {
+	keys := make(map[string]string, len(ids))
+	for i, id := range ids {
+		keys[normalize(id)] = id
+		if i < len(names) {
+			keys[normalize(names[i])] = id
+		}
+	}
+
+	return func(name string) (string, bool) {
+		key := normalize(name)
+		if id, ok := keys[key]; ok {
+			return id, true
+		}
+		if len(key) < 3 {
+			return "", false
+		}
+
+		match := ""
+		for k, id := range keys {
+			if strings.HasPrefix(k, key) {
+				if match != "" && match != id {
+					return "", false
+				}
+				match = id
+			}
+		}
+		return match, match != ""
+	}
+}
+
+var replacer = strings.NewReplacer(" ", "", ".", "", "ë", "e", "é", "e", "ï", "i", "ü", "u", "ö", "o")
+
+func normalize(s string) string {
+	return replacer.Replace(strings.ToLower(s))
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,80 @@
+package reference
+
+import (
+	"testing"
+)
+
+var books = Resolver(
+	[]string{"1samuel", "psalmen", "maleachi", "ezechiel", "johannes", "1johannes", "genesis"},
+	[]string{"1 Samuel", "Psalmen", "Maleachi", "Ezechiël", "Johannes", "1 Johannes", "Genesis"},
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Reference
+	}{
+		{"maleachi 3:19-24", Reference{"maleachi", 3, 19, 3, 24}},
+		{"Maleachi 3", Reference{"maleachi", 3, 0, 3, 0}},
+		{"psalmen 1-2", Reference{"psalmen", 1, 0, 2, 0}},
+		{"1 Samuel 20:42-21:2", Reference{"1samuel", 20, 42, 21, 2}},
+		{"Gen. 1,1-2,3", Reference{"genesis", 1, 1, 2, 3}},
+		{"ezechiel 1:1", Reference{"ezechiel", 1, 1, 1, 1}},
+		{"Ezechiël 1:1", Reference{"ezechiel", 1, 1, 1, 1}},
+		{"1joh 4:8", Reference{"1johannes", 4, 8, 4, 8}},
+		{" johannes 3:16 - 17 ", Reference{"johannes", 3, 16, 3, 17}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, books)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"maleachi",
+		"onbekend 1:1",
+		"jo 1:1",
+		"maleachi 3:24-19",
+		"maleachi 3-2",
+		"maleachi 0",
+		"psalmen 1-2:3",
+	} {
+		if got, err := Parse(in, books); err == nil {
+			t.Errorf("Parse(%q) = %+v, want error", in, got)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, in := range []string{"maleachi 3:19-24", "maleachi 3", "psalmen 1-2", "1samuel 20:42-21:2", "genesis 1:1"} {
+		ref, err := Parse(in, books)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", in, err)
+		}
+		if got := ref.String(); got != in {
+			t.Errorf("String() = %q, want %q", got, in)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	ref := Reference{"genesis", 1, 30, 2, 3}
+	for _, tt := range []struct {
+		chapter, verse int
+		want           bool
+	}{{1, 29, false}, {1, 30, true}, {1, 31, true}, {2, 1, true}, {2, 3, true}, {2, 4, false}, {3, 1, false}} {
+		if got := ref.Contains(tt.chapter, tt.verse); got != tt.want {
+			t.Errorf("Contains(%d, %d) = %v, want %v", tt.chapter, tt.verse, got, tt.want)
+		}
+	}
+}
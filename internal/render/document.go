@@ -0,0 +1,227 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// renderer builds a document in one format. The functions below drive it,
+// so that the formats share the handling of titles, paragraphs and verses.
+type renderer interface {
+	// heading writes a book, chapter or section heading.
+	heading(level int, text string)
+	// verse writes a verse, opening a paragraph if none is open.
+	verse(chapter, verse int, text string)
+	endParagraph()
+	// startSection and endSection enclose a chapter within a book.
+	startSection(chapter int)
+	endSection()
+	// startDocument and endDocument enclose the whole document; class names
+	// what it holds, such as "chapter".
+	startDocument(class string)
+	endDocument()
+	String() string
+}
+
+func newRenderer(f Format) (renderer, error) {
+	switch f {
+	case Text:
+		return &textRenderer{}, nil
+	case Markdown:
+		return &markdownRenderer{}, nil
+	case HTML:
+		return &htmlRenderer{}, nil
+	}
+	return nil, fmt.Errorf("format %s is not rendered", f)
+}
+
+// BookInfo writes the name of a book.
+func BookInfo(w io.Writer, f Format, book bible.BookMetadata) error {
+	r, err := newRenderer(f)
+	if err != nil {
+		return err
+	}
+	r.startDocument("book")
+	r.heading(1, book.Name)
+	r.endDocument()
+	_, err = io.WriteString(w, r.String())
+	return err
+}
+
+// Book writes a whole book, chapter by chapter.
+func Book(w io.Writer, f Format, book bible.Book) error {
+	r, err := newRenderer(f)
+	if err != nil {
+		return err
+	}
+	r.startDocument("book")
+	r.heading(1, book.Name)
+	verses := sortVerses(book.Verses)
+	for start := 0; start < len(verses); {
+		end := start
+		for end < len(verses) && verses[end].Chapter == verses[start].Chapter {
+			end++
+		}
+		chapter := verses[start].Chapter
+		r.startSection(chapter)
+		if book.Chapters > 1 {
+			r.heading(2, chapterName(book.Name, chapter))
+		}
+		writeVerses(r, 3, verses[start:end])
+		r.endSection()
+		start = end
+	}
+	r.endDocument()
+	_, err = io.WriteString(w, r.String())
+	return err
+}
+
+// Chapter writes a chapter with its section titles and paragraphs.
+func Chapter(w io.Writer, f Format, chapter bible.Chapter) error {
+	r, err := newRenderer(f)
+	if err != nil {
+		return err
+	}
+	r.startDocument("chapter")
+	r.heading(1, chapterName(chapter.Name, chapter.Chapter))
+	writeVerses(r, 2, sortVerses(chapter.Verses))
+	r.endDocument()
+	_, err = io.WriteString(w, r.String())
+	return err
+}
+
+// writeVerses writes verses in order, with their section titles at level.
+func writeVerses(r renderer, level int, verses []bible.Verse) {
+	for _, v := range verses {
+		if title := strings.Join(strings.Fields(v.Title), " "); title != "" {
+			r.endParagraph()
+			r.heading(level, title)
+		}
+		if v.Paragraph == "y" {
+			r.endParagraph()
+		}
+		r.verse(v.Chapter, v.Verse, strings.Join(strings.Fields(v.Text), " "))
+	}
+	r.endParagraph()
+}
+
+// textRenderer writes one numbered verse per line, for scripts; headings
+// stand on their own line between blank lines.
+type textRenderer struct {
+	sb strings.Builder
+	// inVerses is set after a verse line, which a heading is separated from.
+	inVerses bool
+}
+
+func (r *textRenderer) heading(level int, text string) {
+	if r.inVerses {
+		r.sb.WriteString("\n")
+		r.inVerses = false
+	}
+	r.sb.WriteString(text + "\n\n")
+}
+
+func (r *textRenderer) verse(chapter, verse int, text string) {
+	r.sb.WriteString(strconv.Itoa(verse) + " " + text + "\n")
+	r.inVerses = true
+}
+
+func (r *textRenderer) endParagraph()              {}
+func (r *textRenderer) startSection(chapter int)   {}
+func (r *textRenderer) endSection()                {}
+func (r *textRenderer) startDocument(class string) {}
+func (r *textRenderer) endDocument()               {}
+func (r *textRenderer) String() string             { return r.sb.String() }
+
+// markdownRenderer writes headings and paragraphs with bold verse numbers.
+type markdownRenderer struct {
+	sb   strings.Builder
+	open bool
+}
+
+func (r *markdownRenderer) heading(level int, text string) {
+	if r.sb.Len() > 0 {
+		r.sb.WriteString("\n")
+	}
+	r.sb.WriteString(strings.Repeat("#", level) + " " + escapeMarkdown(text) + "\n")
+}
+
+func (r *markdownRenderer) verse(chapter, verse int, text string) {
+	if r.open {
+		r.sb.WriteString(" ")
+	} else {
+		r.sb.WriteString("\n")
+		r.open = true
+	}
+	r.sb.WriteString("**" + strconv.Itoa(verse) + "** " + escapeMarkdown(text))
+}
+
+func (r *markdownRenderer) endParagraph() {
+	if r.open {
+		r.sb.WriteString("\n")
+		r.open = false
+	}
+}
+
+func (r *markdownRenderer) startSection(chapter int)   {}
+func (r *markdownRenderer) endSection()                {}
+func (r *markdownRenderer) startDocument(class string) {}
+func (r *markdownRenderer) endDocument()               {}
+func (r *markdownRenderer) String() string             { return r.sb.String() }
+
+// escapeMarkdown keeps text from being read as Markdown markup.
+var escapeMarkdown = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, "`", "\\`", "#", `\#`, "|", `\|`,
+).Replace
+
+// htmlRenderer writes an HTML fragment for embedding: an article with
+// headings, paragraphs and verse numbers that carry an id such as v1-1.
+type htmlRenderer struct {
+	sb   strings.Builder
+	open bool
+}
+
+func (r *htmlRenderer) heading(level int, text string) {
+	fmt.Fprintf(&r.sb, "<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+}
+
+func (r *htmlRenderer) verse(chapter, verse int, text string) {
+	if r.open {
+		r.sb.WriteString(" ")
+	} else {
+		r.sb.WriteString("<p>")
+		r.open = true
+	}
+	fmt.Fprintf(&r.sb, `<sup class="verse" id="v%d-%d">%d</sup> %s`, chapter, verse, verse, html.EscapeString(text))
+}
+
+func (r *htmlRenderer) endParagraph() {
+	if r.open {
+		r.sb.WriteString("</p>\n")
+		r.open = false
+	}
+}
+
+func (r *htmlRenderer) startSection(chapter int) {
+	fmt.Fprintf(&r.sb, "<section id=\"c%d\">\n", chapter)
+}
+
+func (r *htmlRenderer) endSection() {
+	r.sb.WriteString("</section>\n")
+}
+
+func (r *htmlRenderer) startDocument(class string) {
+	r.sb.WriteString(`<article class="` + class + `">` + "\n")
+}
+
+func (r *htmlRenderer) endDocument() {
+	r.sb.WriteString("</article>\n")
+}
+
+func (r *htmlRenderer) String() string { return r.sb.String() }
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/parallel"
+)
+
+// Passage writes a passage aligned across translations: in text a block
+// per verse with a line per translation, in Markdown and HTML a table with
+// a column per translation. Gaps, where a translation has no counterpart,
+// show as a dash.
+func Passage(w io.Writer, f Format, p parallel.Passage) error {
+	labels := make([]string, len(p.Translations))
+	for i, t := range p.Translations {
+		labels[i] = t.Abbreviation
+		if labels[i] == "" {
+			labels[i] = t.Id
+		}
+	}
+
+	var sb strings.Builder
+	switch f {
+	case Text:
+		sb.WriteString(p.Reference.String() + "\n")
+		for _, row := range p.Rows {
+			fmt.Fprintf(&sb, "\n%d,%d\n", row.Standard.Chapter, row.Standard.Verse)
+			for i, cell := range row.Cells {
+				sb.WriteString(labels[i] + " " + cellText(cell) + "\n")
+			}
+		}
+	case Markdown:
+		sb.WriteString("# " + escapeMarkdown(p.Reference.String()) + "\n\n|  |")
+		for _, l := range labels {
+			sb.WriteString(" " + escapeMarkdown(l) + " |")
+		}
+		sb.WriteString("\n|---|" + strings.Repeat("---|", len(labels)) + "\n")
+		for _, row := range p.Rows {
+			fmt.Fprintf(&sb, "| %d,%d |", row.Standard.Chapter, row.Standard.Verse)
+			for _, cell := range row.Cells {
+				sb.WriteString(" " + escapeMarkdown(cellText(cell)) + " |")
+			}
+			sb.WriteString("\n")
+		}
+	case HTML:
+		sb.WriteString("<article class=\"passage\">\n<h1>" + html.EscapeString(p.Reference.String()) + "</h1>\n")
+		sb.WriteString("<table>\n<thead><tr><th></th>")
+		for _, l := range labels {
+			sb.WriteString("<th>" + html.EscapeString(l) + "</th>")
+		}
+		sb.WriteString("</tr></thead>\n<tbody>\n")
+		for _, row := range p.Rows {
+			fmt.Fprintf(&sb, "<tr><th>%d,%d</th>", row.Standard.Chapter, row.Standard.Verse)
+			for _, cell := range row.Cells {
+				sb.WriteString("<td>" + html.EscapeString(cellText(cell)) + "</td>")
+			}
+			sb.WriteString("</tr>\n")
+		}
+		sb.WriteString("</tbody>\n</table>\n</article>\n")
+	default:
+		return fmt.Errorf("format %s is not rendered", f)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// cellText joins the verses of a cell, numbering each in its own
+// translation's versification.
+func cellText(cell []parallel.Verse) string {
+	if cell == nil {
+		return "-"
+	}
+	parts := make([]string, len(cell))
+	for i, v := range cell {
+		parts[i] = fmt.Sprintf("%d,%d %s", v.Chapter, v.Verse, strings.Join(strings.Fields(v.Text), " "))
+	}
+	return strings.Join(parts, " ")
+}
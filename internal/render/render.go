@@ -0,0 +1,134 @@
+// Package render writes books, chapters and passages as plain text,
+// Markdown or HTML fragments, for the formats the API negotiates besides
+// JSON.
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// Format is a response format.
+type Format string
+
+// Supported formats, JSON first as the default.
+const (
+	JSON     Format = "json"
+	Text     Format = "text"
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+var formats = []Format{JSON, Text, Markdown, HTML}
+
+// ContentType returns the Content-Type header of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case Text:
+		return "text/plain; charset=utf-8"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case HTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/json"
+}
+
+func (f Format) mediaType() string {
+	mediaType, _, _ := strings.Cut(f.ContentType(), ";")
+	return mediaType
+}
+
+// ParseFormat reads the value of a format query parameter: json, text or
+// txt, markdown or md, and html.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json":
+		return JSON, nil
+	case "text", "txt":
+		return Text, nil
+	case "markdown", "md":
+		return Markdown, nil
+	case "html":
+		return HTML, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected json, text, markdown or html", s)
+}
+
+// Negotiate picks the format of a response: the format query parameter if
+// given, otherwise the best match for the Accept header. On a tie the
+// earlier format in JSON, text, Markdown, HTML order wins; text/* counts
+// for all text formats. When nothing acceptable is offered the response is
+// JSON, as it was before negotiation existed.
+func Negotiate(format, accept string) (Format, error) {
+	if format != "" {
+		return ParseFormat(format)
+	}
+	if accept == "" {
+		return JSON, nil
+	}
+
+	weights := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		weights[mediaType] = max(weights[mediaType], q)
+	}
+
+	best, bestQ := JSON, 0.0
+	for _, f := range formats {
+		mediaType := f.mediaType()
+		typ, _, _ := strings.Cut(mediaType, "/")
+		// The most specific range that matches decides.
+		q, ok := weights[mediaType]
+		if !ok {
+			q, ok = weights[typ+"/*"]
+		}
+		if !ok {
+			q = weights["*/*"]
+		}
+		if q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	return best, nil
+}
+
+// sortVerses returns the verses in chapter and verse order; the book files
+// aren't stored in order.
+func sortVerses(verses []bible.Verse) []bible.Verse {
+	verses = slices.Clone(verses)
+	slices.SortStableFunc(verses, func(a, b bible.Verse) int {
+		if a.Chapter != b.Chapter {
+			return a.Chapter - b.Chapter
+		}
+		return a.Verse - b.Verse
+	})
+	return verses
+}
+
+// chapterName names a chapter as "Genesis 1"; the single chapter of the
+// books numbered 0 in the data is named after the book alone.
+func chapterName(book string, chapter int) string {
+	if chapter == 0 {
+		return book
+	}
+	return book + " " + strconv.Itoa(chapter)
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/parallel"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+	"github.com/pschuurmans/bijbel-api/internal/translation"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		format, accept string
+		want           Format
+	}{
+		{"", "", JSON},
+		{"", "*/*", JSON},
+		{"", "application/json", JSON},
+		{"", "text/plain", Text},
+		{"", "text/markdown", Markdown},
+		{"", "text/html", HTML},
+		{"", "text/*", Text},
+		{"", "text/html;q=0.5, text/markdown", Markdown},
+		{"", "application/json;q=0, */*", Text},
+		// A browser gets HTML.
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", HTML},
+		// Nothing acceptable falls back to JSON.
+		{"", "application/xml", JSON},
+		{"md", "application/json", Markdown},
+		{"TXT", "", Text},
+		{"html", "", HTML},
+		{"json", "text/html", JSON},
+	}
+	for _, tt := range tests {
+		got, err := Negotiate(tt.format, tt.accept)
+		if err != nil {
+			t.Errorf("Negotiate(%q, %q) failed: %v", tt.format, tt.accept, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Negotiate(%q, %q) = %s, want %s", tt.format, tt.accept, got, tt.want)
+		}
+	}
+
+	_, err := Negotiate("xml", "")
+	require.Error(t, err)
+}
+
+var psalm = bible.Chapter{Id: "psalmen", Name: "Psalmen", Chapter: 1, Verses: []bible.Verse{
+	{Chapter: 1, Verse: 2, Paragraph: "n", Text: "maar zijn vreugde\n is de wet_van de Heer."},
+	{Chapter: 1, Verse: 1, Paragraph: "y", Title: "DE TWEE WEGEN", Text: "Gelukkig de mens <die> niet meegaat *"},
+	{Chapter: 1, Verse: 3, Paragraph: "y", Text: "Hij is als een boom."},
+}}
+
+func TestChapter(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{Text, `Psalmen 1
+
+DE TWEE WEGEN
+
+1 Gelukkig de mens <die> niet meegaat *
+2 maar zijn vreugde is de wet_van de Heer.
+3 Hij is als een boom.
+`},
+		{Markdown, `# Psalmen 1
+
+## DE TWEE WEGEN
+
+**1** Gelukkig de mens \<die> niet meegaat \* **2** maar zijn vreugde is de wet\_van de Heer.
+
+**3** Hij is als een boom.
+`},
+		{HTML, `<article class="chapter">
+<h1>Psalmen 1</h1>
+<h2>DE TWEE WEGEN</h2>
+<p><sup class="verse" id="v1-1">1</sup> Gelukkig de mens &lt;die&gt; niet meegaat * <sup class="verse" id="v1-2">2</sup> maar zijn vreugde is de wet_van de Heer.</p>
+<p><sup class="verse" id="v1-3">3</sup> Hij is als een boom.</p>
+</article>
+`},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		require.NoError(t, Chapter(&sb, tt.format, psalm))
+		require.Equal(t, tt.want, sb.String(), tt.format)
+	}
+
+	require.Error(t, Chapter(&strings.Builder{}, JSON, psalm))
+}
+
+func TestBook(t *testing.T) {
+	book := bible.Book{Id: "psalmen", Name: "Psalmen", Chapters: 2, Verses: append([]bible.Verse{
+		{Chapter: 2, Verse: 1, Paragraph: "y", Text: "Waarom woelen de volken?"},
+	}, psalm.Verses...)}
+
+	var sb strings.Builder
+	require.NoError(t, Book(&sb, HTML, book))
+	html := sb.String()
+	require.True(t, strings.HasPrefix(html, "<article class=\"book\">\n<h1>Psalmen</h1>\n<section id=\"c1\">\n<h2>Psalmen 1</h2>\n<h3>DE TWEE WEGEN</h3>\n"), html)
+	require.Less(t, strings.Index(html, `id="c1"`), strings.Index(html, `id="c2"`))
+
+	// A single-chapter book has no chapter headings.
+	judas := bible.Book{Id: "judas", Name: "Judas", Chapters: 1, Verses: []bible.Verse{{Chapter: 0, Verse: 1, Text: "Van Judas."}}}
+	sb.Reset()
+	require.NoError(t, Book(&sb, Text, judas))
+	require.Equal(t, "Judas\n\n1 Van Judas.\n", sb.String())
+}
+
+func TestPassage(t *testing.T) {
+	p := parallel.Passage{
+		Reference: reference.Reference{Book: "maleachi", StartChapter: 3, StartVerse: 24, EndChapter: 3, EndVerse: 24},
+		Translations: []translation.Translation{
+			translation.DefaultTranslation,
+			{Id: "sv", Versification: versification.KJV},
+		},
+		Rows: []parallel.Row{{
+			Standard: versification.Verse{Book: "maleachi", Chapter: 3, Verse: 24},
+			Cells:    [][]parallel.Verse{{{Chapter: 3, Verse: 24, Text: "Hij zal | het hart"}}, nil},
+		}},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, Passage(&sb, Text, p))
+	require.Equal(t, "maleachi 3:24\n\n3,24\nWV 3,24 Hij zal | het hart\nsv -\n", sb.String())
+
+	sb.Reset()
+	require.NoError(t, Passage(&sb, Markdown, p))
+	require.Equal(t, "# maleachi 3:24\n\n|  | WV | sv |\n|---|---|---|\n| 3,24 | 3,24 Hij zal \\| het hart | - |\n", sb.String())
+
+	sb.Reset()
+	require.NoError(t, Passage(&sb, HTML, p))
+	require.Contains(t, sb.String(), "<tr><th>3,24</th><td>3,24 Hij zal | het hart</td><td>-</td></tr>")
+}
@@ -0,0 +1,72 @@
+// Package storage opens the embedded SQLite database that holds the data of
+// users, such as accounts and annotations, so that the API runs without
+// outside services. Every subsystem keeps its own tables and migrates them
+// with Migrate.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the database at path, creating it if needed, with foreign keys
+// enforced and write-ahead logging.
+func Open(path string) (*sql.DB, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer. One connection serializes the writes in
+	// the process instead of failing them with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+		name    TEXT PRIMARY KEY,
+		version INTEGER NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate brings the tables of the subsystem name up to date by running the
+// steps it has not run yet, each in a transaction. Steps are only ever
+// appended.
+func Migrate(db *sql.DB, name string, steps []string) error {
+	var version int
+	err := db.QueryRow("SELECT version FROM migrations WHERE name = ?", name).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if version > len(steps) {
+		return fmt.Errorf("%s: database version %d is newer than this server (%d)", name, version, len(steps))
+	}
+	for i := version; i < len(steps); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(steps[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: migration %d: %w", name, i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO migrations (name, version) VALUES (?, ?)
+			ON CONFLICT (name) DO UPDATE SET version = excluded.version`, name, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+
+	steps := []string{
+		"CREATE TABLE notes (id INTEGER PRIMARY KEY, text TEXT NOT NULL)",
+		"ALTER TABLE notes ADD COLUMN color TEXT",
+	}
+	require.NoError(t, Migrate(db, "notes", steps[:1]))
+	_, err = db.Exec("INSERT INTO notes (text) VALUES ('eerste')")
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db, "notes", steps))
+	require.NoError(t, Migrate(db, "notes", steps), "migrating twice runs nothing")
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	var text string
+	require.NoError(t, db.QueryRow("SELECT text FROM notes WHERE color IS NULL").Scan(&text))
+	require.Equal(t, "eerste", text)
+
+	require.Error(t, Migrate(db, "notes", steps[:1]), "the database is newer")
+	require.Error(t, Migrate(db, "broken", []string{"CREATE TABLE"}))
+
+	var version int
+	err = db.QueryRow("SELECT version FROM migrations WHERE name = 'broken'").Scan(&version)
+	require.Error(t, err, "a failed step is not recorded")
+
+	var fk int
+	require.NoError(t, db.QueryRow("PRAGMA foreign_keys").Scan(&fk))
+	require.Equal(t, 1, fk)
+}
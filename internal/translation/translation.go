@@ -0,0 +1,134 @@
+// Package translation keeps track of the Bible translations the API serves.
+// Every translation has its own book repository; book ids are shared between
+// translations so that the same reference works in each of them.
+package translation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// Translation describes a Bible text.
+type Translation struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	Abbreviation  string `json:"abbreviation"`
+	Language      string `json:"language"`
+	Year          int    `json:"year,omitempty"`
+	Versification string `json:"versification"`
+	Default       bool   `json:"default"`
+}
+
+// DefaultTranslation is the embedded text that the routes without a
+// translation id serve.
+var DefaultTranslation = Translation{
+	Id:            "wv75",
+	Name:          "Willibrordvertaling",
+	Abbreviation:  "WV",
+	Language:      "nl",
+	Year:          1975,
+	Versification: versification.Standard,
+	Default:       true,
+}
+
+var validId = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Validate checks that t has the fields every translation needs.
+func (t Translation) Validate() error {
+	var errs []error
+	if !validId.MatchString(t.Id) {
+		errs = append(errs, fmt.Errorf("id %q: must consist of lower case letters, digits and dashes", t.Id))
+	}
+	if t.Name == "" {
+		errs = append(errs, fmt.Errorf("%s: name is required", t.Id))
+	}
+	if t.Language == "" {
+		errs = append(errs, fmt.Errorf("%s: language is required", t.Id))
+	}
+	if _, ok := versification.Lookup(t.Versification); !ok {
+		errs = append(errs, fmt.Errorf("%s: versification must be one of %s, got %q",
+			t.Id, strings.Join(versification.Names(), ", "), t.Versification))
+	}
+	return errors.Join(errs...)
+}
+
+// Entry pairs a translation with the repository holding its text.
+type Entry struct {
+	Translation Translation
+	Repository  bible.Repository
+}
+
+// Registry maps translation ids to their repositories. The default
+// translation always reads from bible.Current, so that reloading the
+// default data needs no registry update.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+// NewRegistry returns a registry holding only the default translation.
+func NewRegistry() *Registry {
+	return &Registry{entries: map[string]Entry{}}
+}
+
+// Replace swaps the additional translations for entries in one step.
+func (r *Registry) Replace(entries []Entry) error {
+	replacement := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		if err := e.Translation.Validate(); err != nil {
+			return err
+		}
+		if _, dup := replacement[e.Translation.Id]; dup || e.Translation.Id == DefaultTranslation.Id {
+			return fmt.Errorf("translation %s is registered twice", e.Translation.Id)
+		}
+		e.Translation.Default = false
+		replacement[e.Translation.Id] = e
+	}
+
+	r.mu.Lock()
+	r.entries = replacement
+	r.mu.Unlock()
+	return nil
+}
+
+// Get returns the translation with the given id and its repository.
+func (r *Registry) Get(id string) (Translation, bible.Repository, bool) {
+	if id == DefaultTranslation.Id {
+		return DefaultTranslation, bible.Current(), true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[id]
+	return e.Translation, e.Repository, ok
+}
+
+// List returns the default translation followed by the others sorted by id.
+func (r *Registry) List() []Translation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Translation, 0, len(r.entries)+1)
+	for _, e := range r.entries {
+		list = append(list, e.Translation)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return append([]Translation{DefaultTranslation}, list...)
+}
+
+// Canon returns the ids of the books in repo, in canonical order.
+func Canon(repo bible.Repository) []string {
+	books := repo.GetBooks()
+	ids := make([]string, len(books))
+	for i, b := range books {
+		ids[i] = b.Id
+	}
+	return ids
+}
@@ -0,0 +1,62 @@
+package translation
+
+import (
+	"testing"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+func TestRegistryDefault(t *testing.T) {
+	r := NewRegistry()
+
+	tr, repo, ok := r.Get(DefaultTranslation.Id)
+	if !ok || !tr.Default {
+		t.Fatalf("Get(%q) = %+v, %v", DefaultTranslation.Id, tr, ok)
+	}
+	if repo != bible.Current() {
+		t.Errorf("default translation does not use bible.Current()")
+	}
+	if got := len(Canon(repo)); got != 73 {
+		t.Errorf("len(Canon()) = %d, want 73", got)
+	}
+	if _, _, ok := r.Get("unknown"); ok {
+		t.Errorf("Get(unknown) found a translation")
+	}
+}
+
+func TestRegistryReplace(t *testing.T) {
+	sv := Translation{Id: "sv", Name: "Statenvertaling", Language: "nl", Versification: "kjv", Default: true}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		wantErr bool
+	}{
+		{"valid", []Entry{{Translation: sv}}, false},
+		{"duplicate", []Entry{{Translation: sv}, {Translation: sv}}, true},
+		{"shadows default", []Entry{{Translation: Translation{Id: "wv75", Name: "x", Language: "nl", Versification: "kjv"}}}, true},
+		{"bad id", []Entry{{Translation: Translation{Id: "Staten Vertaling", Name: "x", Language: "nl", Versification: "kjv"}}}, true},
+		{"missing name", []Entry{{Translation: Translation{Id: "x", Language: "nl", Versification: "kjv"}}}, true},
+		{"unknown versification", []Entry{{Translation: Translation{Id: "x", Name: "x", Language: "nl", Versification: "lxx"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			err := r.Replace(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Replace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && len(r.List()) != 1 {
+				t.Errorf("failed Replace() changed the registry")
+			}
+		})
+	}
+
+	r := NewRegistry()
+	r.Replace([]Entry{{Translation: sv}})
+	list := r.List()
+	if len(list) != 2 || list[1].Id != "sv" || list[1].Default {
+		t.Errorf("List() = %+v", list)
+	}
+}
@@ -0,0 +1,27 @@
+package usfm
+
+import "github.com/pschuurmans/bijbel-api/internal/bible"
+
+var (
+	toCode   = make(map[string]string)
+	fromCode = make(map[string]string)
+)
+
+func init() {
+	for _, b := range bible.BookCodes() {
+		toCode[b.Id] = b.USFM
+		fromCode[b.USFM] = b.Id
+	}
+}
+
+// BookId returns the book id for a USFM book code, such as genesis for GEN.
+func BookId(code string) (string, bool) {
+	id, ok := fromCode[code]
+	return id, ok
+}
+
+// Code returns the USFM book code for a book id, such as GEN for genesis.
+func Code(id string) (string, bool) {
+	code, ok := toCode[id]
+	return code, ok
+}
@@ -0,0 +1,166 @@
+// Package usfm converts books between the JSON layout of this project and
+// USFM 3 (https://ubsicap.github.io/usfm/), the format of Paratext and most
+// translation and publishing tools. A USFM file holds one book.
+package usfm
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// Export writes book as a USFM file. Section titles become \s1, paragraphs
+// \p, poetry lines \q1 for the first line of a verse and \q2 for the
+// others, and abbr footnotes \f notes with an \fr origin reference. The
+// references of a footnote are written as \xt and its explanation as \ft.
+// Single-chapter books, which the bundled data numbers as chapter 0, are
+// written as chapter 1.
+func Export(w io.Writer, book bible.SourceBook) error {
+	code, ok := Code(book.Id)
+	if !ok {
+		return fmt.Errorf("book %s has no USFM code", book.Id)
+	}
+
+	verses := slices.Clone(book.Verses)
+	slices.SortStableFunc(verses, func(a, b bible.SourceVerse) int {
+		if a.Chapter != b.Chapter {
+			return a.Chapter - b.Chapter
+		}
+		return a.Verse - b.Verse
+	})
+
+	e := &exporter{}
+	e.line(`\id ` + code + " " + book.Name)
+	e.line(`\usfm 3.0`)
+	e.line(`\h ` + book.Name)
+	e.line(`\toc1 ` + book.Name)
+	e.line(`\mt1 ` + book.Name)
+
+	chapter := -1
+	// open is false where a paragraph marker is required before a verse,
+	// poetry is set after a verse written as poetry.
+	open, poetry := false, false
+	for _, v := range verses {
+		if v.Chapter != chapter {
+			chapter = v.Chapter
+			e.line(fmt.Sprintf(`\c %d`, bible.ChapterNumber(chapter)))
+			open = false
+		}
+		if v.Title != nil && *v.Title != "" {
+			e.line(`\s1 ` + whitespace.ReplaceAllString(strings.TrimSpace(*v.Title), " "))
+			open = false
+		}
+
+		e.origin = fmt.Sprintf("%d:%d", bible.ChapterNumber(chapter), v.Verse)
+		marker := fmt.Sprintf(`\v %d `, v.Verse)
+
+		var lines [][]bible.Node
+		if v.TextJson != nil {
+			lines = v.TextJson.Lines()
+		}
+		if len(lines) > 1 {
+			if v.Paragraph == "y" {
+				e.line(`\b`)
+			}
+			for i, line := range lines {
+				q := `\q2 `
+				if i == 0 {
+					q = `\q1 ` + marker
+				}
+				e.line(q + e.inline(line, true, 0))
+			}
+			open, poetry = true, true
+			continue
+		}
+
+		switch {
+		case v.Paragraph == "y":
+			e.line(`\p`)
+		case !open || poetry:
+			e.line(`\m`)
+		}
+		text := ""
+		if len(lines) == 1 {
+			text = e.inline(lines[0], true, 0)
+		}
+		e.line(strings.TrimRight(marker+text, " "))
+		open, poetry = true, false
+	}
+
+	_, err := io.WriteString(w, e.sb.String())
+	return err
+}
+
+type exporter struct {
+	sb strings.Builder
+	// origin is the chapter:verse of the verse being written, for \fr.
+	origin string
+}
+
+func (e *exporter) line(s string) {
+	e.sb.WriteString(s)
+	e.sb.WriteByte('\n')
+}
+
+var (
+	whitespace = regexp.MustCompile(`[\s\x00-\x1F\x{00A0}]+`)
+	// backslashes would start a marker.
+	escaper = strings.NewReplacer(`\`, "/")
+)
+
+// inline renders nodes as USFM character content. Whitespace is collapsed;
+// trim drops the leading whitespace of the first text. depth counts the
+// enclosing character styles, within which USFM 3 nests styles with a +.
+func (e *exporter) inline(nodes []bible.Node, trim bool, depth int) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.Tag {
+		case "":
+			text := whitespace.ReplaceAllString(escaper.Replace(n.Text), " ")
+			if trim {
+				text = strings.TrimLeft(text, " ")
+			}
+			sb.WriteString(text)
+		case "br":
+			sb.WriteByte(' ')
+		case "abbr":
+			sb.WriteString(e.note(n))
+		case "em", "i":
+			sb.WriteString(style("it", e.inline(n.Children, false, depth+1), depth))
+		case "blockquote":
+			sb.WriteString(style("qt", e.inline(n.Children, false, depth+1), depth))
+		case "div", "p":
+			// Block elements separate words, see Node.PlainText.
+			if !trim {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(e.inline(n.Children, trim, depth))
+		default:
+			sb.WriteString(e.inline(n.Children, trim, depth))
+		}
+		trim = false
+	}
+	return sb.String()
+}
+
+func style(marker, content string, depth int) string {
+	if depth > 0 {
+		marker = "+" + marker
+	}
+	return `\` + marker + " " + content + `\` + marker + "*"
+}
+
+func (e *exporter) note(n bible.Node) string {
+	note := `\f + \fr ` + e.origin + " "
+	if ref := strings.TrimSpace(n.Ref); ref != "" {
+		note += `\xt ` + whitespace.ReplaceAllString(escaper.Replace(ref), " ") + " "
+	}
+	if title := strings.TrimSpace(n.Title); title != "" {
+		note += `\ft ` + whitespace.ReplaceAllString(escaper.Replace(title), " ")
+	}
+	return strings.TrimRight(note, " ") + `\f*`
+}
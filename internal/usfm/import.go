@@ -0,0 +1,319 @@
+package usfm
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// Import reads a USFM file into a book in the layout of books/*.json.
+// Section headings (\s, \ms, \d) become the title of the verse that follows
+// them, \p and \b set its paragraph flag, poetry and list lines within a
+// verse become line breaks, and \f and \x notes become abbr footnotes with
+// the \xt references as ref and the remaining note text as title. Markers
+// without a counterpart in the JSON layout keep only their text.
+func Import(r io.Reader) (bible.SourceBook, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return bible.SourceBook{}, err
+	}
+
+	im := &importer{src: strings.ReplaceAll(string(data), "\r\n", "\n")}
+	if err := im.parse(); err != nil {
+		line := strings.Count(im.src[:im.pos], "\n") + 1
+		return bible.SourceBook{}, fmt.Errorf("line %d: %w", line, err)
+	}
+	im.closeVerse()
+
+	if im.book.Id == "" {
+		return bible.SourceBook{}, fmt.Errorf(`no \id marker found`)
+	}
+	if len(im.book.Verses) == 0 {
+		return bible.SourceBook{}, fmt.Errorf("%s: no verses found", im.book.Id)
+	}
+	im.book.Name = im.name()
+	im.book.UpdateCounts()
+	return im.book, nil
+}
+
+type importer struct {
+	src string
+	pos int
+
+	book    bible.SourceBook
+	names   map[string]string
+	chapter int
+
+	// pendingTitle and paragraph apply to the next verse; lineBreak to the
+	// next text of the open verse.
+	pendingTitle *string
+	paragraph    bool
+	lineBreak    bool
+
+	// verse is the open verse, nil before the first \v of a chapter.
+	verse *bible.SourceVerse
+	text  bible.TextBuilder
+
+	// note is the footnote being read and noteField its current field.
+	note      *bible.Node
+	noteField string
+	// inWord is set within \w, whose text may end in |attributes.
+	inWord bool
+}
+
+var marker = regexp.MustCompile(`^\\(\+?[A-Za-z0-9-]+)(\*?)`)
+
+func (im *importer) parse() error {
+	for im.pos < len(im.src) {
+		m := marker.FindStringSubmatch(im.src[im.pos:])
+		if m == nil {
+			end := strings.IndexByte(im.src[im.pos+1:], '\\')
+			if end < 0 {
+				end = len(im.src) - im.pos - 1
+			}
+			im.charData(im.src[im.pos : im.pos+1+end])
+			im.pos += 1 + end
+			continue
+		}
+
+		im.pos += len(m[0])
+		name := strings.TrimPrefix(m[1], "+")
+		if m[2] == "*" {
+			im.closeMarker(name)
+			continue
+		}
+		// A single space or newline separates a marker from its content.
+		if im.pos < len(im.src) && (im.src[im.pos] == ' ' || im.src[im.pos] == '\n') {
+			im.pos++
+		}
+		if err := im.openMarker(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restOfLine consumes the rest of the current line.
+func (im *importer) restOfLine() string {
+	end := strings.IndexByte(im.src[im.pos:], '\n')
+	if end < 0 {
+		end = len(im.src) - im.pos
+	}
+	line := im.src[im.pos : im.pos+end]
+	im.pos += end
+	return line
+}
+
+// word consumes the next word, such as a chapter or verse number.
+func (im *importer) word() string {
+	rest := im.src[im.pos:]
+	trimmed := strings.TrimLeft(rest, " \t")
+	end := strings.IndexAny(trimmed, " \t\n\\")
+	if end < 0 {
+		end = len(trimmed)
+	}
+	im.pos += len(rest) - len(trimmed) + end
+	if im.pos < len(im.src) && im.src[im.pos] == ' ' {
+		im.pos++
+	}
+	return trimmed[:end]
+}
+
+var notes = regexp.MustCompile(`\\(f|fe|x) .*?\\(f|fe|x)\*`)
+var markers = regexp.MustCompile(`\\\+?[A-Za-z0-9-]+\*?`)
+
+// plainLine consumes the rest of the line and returns its text without
+// notes and markers.
+func (im *importer) plainLine() string {
+	line := notes.ReplaceAllString(im.restOfLine(), "")
+	return strings.Join(strings.Fields(markers.ReplaceAllString(line, " ")), " ")
+}
+
+func (im *importer) openMarker(name string) error {
+	if im.note != nil {
+		switch name {
+		case "fr", "xo":
+			im.noteField = "skip"
+		case "xt":
+			im.noteField = "ref"
+		default:
+			im.noteField = "title"
+		}
+		return nil
+	}
+
+	switch {
+	case name == "id":
+		code := im.word()
+		id, ok := BookId(strings.ToUpper(code))
+		if !ok {
+			return fmt.Errorf("unknown USFM book code %q", code)
+		}
+		im.book.Id = id
+		im.names = map[string]string{}
+		im.restOfLine()
+	case name == "h" || name == "toc1" || name == "mt" || name == "mt1":
+		if text := im.plainLine(); im.names != nil && im.names[name] == "" {
+			im.names[name] = text
+		}
+	case name == "c":
+		n, err := strconv.Atoi(im.word())
+		if err != nil {
+			return fmt.Errorf(`\c: invalid chapter number`)
+		}
+		im.closeVerse()
+		im.chapter = n
+	case name == "v":
+		return im.startVerse(im.word())
+	case name == "s" || name == "d" || isNumbered(name, "s") || isNumbered(name, "ms"):
+		if title := im.plainLine(); title != "" {
+			im.pendingTitle = &title
+		}
+	case name == "p" || name == "b":
+		im.paragraph, im.lineBreak = true, true
+	case name == "m" || name == "nb" || name == "pi" || isNumbered(name, "pi") ||
+		isNumbered(name, "q") || name == "q" || isNumbered(name, "li") || name == "li":
+		im.lineBreak = true
+	case name == "f" || name == "fe" || name == "x":
+		im.word() // the caller
+		if im.verse != nil {
+			im.note = &bible.Node{
+				Tag:      "abbr",
+				Class:    "ster",
+				Children: []bible.Node{{Tag: "i", Class: "fa fa-star-o nootjester"}},
+			}
+			im.noteField = "title"
+			if name == "x" {
+				im.noteField = "ref"
+			}
+		}
+	case name == "it" || name == "em":
+		im.open("em")
+	case name == "qt":
+		im.open("blockquote")
+	case name == "w":
+		im.inWord = true
+	case strings.HasPrefix(name, "toc") || strings.HasPrefix(name, "mt") ||
+		name == "ide" || name == "usfm" || name == "rem" || name == "sts" ||
+		name == "cl" || name == "cp" || name == "r" || name == "mr" || name == "sr":
+		im.restOfLine()
+	}
+	return nil
+}
+
+func (im *importer) closeMarker(name string) {
+	switch name {
+	case "f", "fe", "x":
+		if im.note != nil {
+			im.note.Ref = strings.Join(strings.Fields(im.note.Ref), " ")
+			im.note.Title = strings.Join(strings.Fields(im.note.Title), " ")
+			im.text.Append(*im.note)
+			im.note = nil
+		}
+	case "xt":
+		if im.note != nil {
+			im.noteField = "title"
+		}
+	case "it", "em":
+		im.close("em")
+	case "qt":
+		im.close("blockquote")
+	case "w":
+		im.inWord = false
+	}
+}
+
+func (im *importer) charData(s string) {
+	if im.inWord {
+		if i := strings.IndexByte(s, '|'); i >= 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.ReplaceAll(s, "\n", " ")
+
+	switch {
+	case im.note != nil && im.noteField == "ref":
+		im.note.Ref += s
+	case im.note != nil && im.noteField == "title":
+		im.note.Title += s
+	case im.note != nil:
+	case im.verse != nil && strings.TrimSpace(s) != "":
+		if im.lineBreak {
+			im.text.Break()
+			im.lineBreak = false
+		}
+		im.text.Text(s)
+	case im.verse != nil:
+		im.text.Text(s)
+	}
+}
+
+func (im *importer) startVerse(number string) error {
+	if im.book.Id == "" {
+		return fmt.Errorf(`\v before \id`)
+	}
+	if im.chapter == 0 {
+		return fmt.Errorf(`\v %s before the first \c`, number)
+	}
+	// Bridged and split verses, such as 1-2 or 3a, take their first number.
+	digits := strings.IndexFunc(number, func(r rune) bool { return r < '0' || r > '9' })
+	if digits >= 0 {
+		number = number[:digits]
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return fmt.Errorf(`\v: invalid verse number`)
+	}
+
+	im.closeVerse()
+	v := bible.NewSourceVerse(im.book.Id, im.chapter, n)
+	v.Title = im.pendingTitle
+	if im.paragraph {
+		v.Paragraph = "y"
+	}
+	im.verse = &v
+	im.pendingTitle, im.paragraph, im.lineBreak = nil, false, false
+	return nil
+}
+
+func (im *importer) open(tag string) {
+	if im.verse != nil {
+		im.text.Open(tag)
+	}
+}
+
+func (im *importer) close(tag string) {
+	if im.verse != nil {
+		im.text.Close(tag)
+	}
+}
+
+// closeVerse stores the open verse.
+func (im *importer) closeVerse() {
+	if im.verse == nil {
+		return
+	}
+	im.verse.SetText(im.text.Finish())
+	im.book.Verses = append(im.book.Verses, *im.verse)
+	im.verse = nil
+}
+
+// name picks the book name from the header markers.
+func (im *importer) name() string {
+	for _, marker := range []string{"h", "toc1", "mt1", "mt"} {
+		if name := im.names[marker]; name != "" {
+			return name
+		}
+	}
+	return im.book.Id
+}
+
+// isNumbered reports whether name is marker followed by a level, as s1.
+func isNumbered(name, marker string) bool {
+	level, ok := strings.CutPrefix(name, marker)
+	return ok && len(level) == 1 && level[0] >= '1' && level[0] <= '9'
+}
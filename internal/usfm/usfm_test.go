@@ -0,0 +1,227 @@
+package usfm
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+func TestBookCodes(t *testing.T) {
+	for _, b := range bible.GetBooks() {
+		code, ok := Code(b.Id)
+		if !ok {
+			t.Errorf("book %s has no USFM code", b.Id)
+			continue
+		}
+		if id, _ := BookId(code); id != b.Id {
+			t.Errorf("BookId(%q) = %q, want %q", code, id, b.Id)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	title := "Psalm van David"
+	book := bible.SourceBook{Id: "psalmen", Name: "Psalmen", Verses: []bible.SourceVerse{
+		{Chapter: 23, Verse: 2, Paragraph: "n", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "Hij laat mij rusten."},
+		}}},
+		{Chapter: 23, Verse: 1, Title: &title, Paragraph: "y", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "De Heer is mijn herder,"},
+			{Tag: "abbr", IvertalingKey: "3", Ref: "Ez. 34,11", Title: "vergelijk"},
+			{Tag: "br"},
+			{Text: "\r\nniets kom ik tekort, "},
+			{Tag: "em", Children: []bible.Node{{Text: "nooit"}}},
+			{Tag: "br"},
+			{Text: "\r\n "},
+		}}},
+		{Chapter: 23, Verse: 3, Paragraph: "y", TextJson: &bible.Node{Tag: "p", Children: []bible.Node{
+			{Text: "Hij geeft mij nieuwe kracht"},
+			{Tag: "abbr", Ref: "Jes. 40,31"},
+			{Text: "."},
+		}}},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, book))
+
+	want := `\id PSA Psalmen
+\usfm 3.0
+\h Psalmen
+\toc1 Psalmen
+\mt1 Psalmen
+\c 23
+\s1 Psalm van David
+\b
+\q1 \v 1 De Heer is mijn herder,\f + \fr 23:1 \xt Ez. 34,11 \ft vergelijk\f*
+\q2 niets kom ik tekort, \it nooit\it*
+\m
+\v 2 Hij laat mij rusten.
+\p
+\v 3 Hij geeft mij nieuwe kracht\f + \fr 23:3 \xt Jes. 40,31\f*.
+`
+	require.Equal(t, want, buf.String())
+}
+
+func TestExportUnknownBook(t *testing.T) {
+	err := Export(io.Discard, bible.SourceBook{Id: "henoch"})
+	require.ErrorContains(t, err, "henoch")
+}
+
+func TestImport(t *testing.T) {
+	doc := `\id JUD - Nederlandse testtekst
+\ide UTF-8
+\h Judas
+\toc1 De brief van Judas
+\mt1 Judas
+\c 1
+\s1 Groet \f + \ft Opschrift.\f*
+\p
+\v 1 Judas, \w dienaar|strong="G1401"\w* van Jezus Christus\x - \xo 1:1 \xt Mt. 13,55\x*,
+\v 2 barmhartigheid \em en\em* vrede\f + \fr 1:2 \fk vrede: \ft of: heil.\f*.
+\q1
+\v 3 Geliefden,
+\q2 ik schrijf u
+\p over \+it onze\+it* redding.
+\v 4-5 Er zijn mensen binnengeslopen.
+`
+
+	book, err := Import(strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Equal(t, "judas", book.Id)
+	require.Equal(t, "Judas", book.Name)
+	require.Equal(t, 1, book.Chapters)
+	require.Equal(t, 4, book.VerseCount)
+
+	first := book.Verses[0]
+	require.Equal(t, "judas.1.1", first.Id)
+	require.NotNil(t, first.Title)
+	require.Equal(t, "Groet", *first.Title)
+	require.Equal(t, "y", first.Paragraph)
+	require.Equal(t, "Judas, dienaar van Jezus Christus,", first.CleanText())
+	require.Equal(t, []any{}, first.CrossReferences)
+
+	notes := abbrs(first.TextJson)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Mt. 13,55", notes[0].Ref)
+	require.Empty(t, notes[0].Title)
+
+	second := book.Verses[1]
+	require.Nil(t, second.Title)
+	require.Equal(t, "n", second.Paragraph)
+	require.Equal(t, "barmhartigheid en vrede.", second.TextJson.PlainText())
+	require.Equal(t, "barmhartigheid en vrede.", second.CleanText())
+	notes = abbrs(second.TextJson)
+	require.Len(t, notes, 1)
+	require.Equal(t, "vrede: of: heil.", notes[0].Title)
+
+	third := book.Verses[2]
+	require.Len(t, third.TextJson.Lines(), 3)
+	require.Equal(t, "Geliefden, ik schrijf u over onze redding.", third.CleanText())
+
+	require.Equal(t, 4, book.Verses[3].Verse)
+}
+
+func TestImportErrors(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no id":        "\\c 1\n\\p\n\\v 1 tekst\n",
+		"unknown book": "\\id ENO\n\\c 1\n\\p\n\\v 1 tekst\n",
+		"no chapter":   "\\id GEN\n\\p\n\\v 1 tekst\n",
+		"bad chapter":  "\\id GEN\n\\c een\n",
+		"no verses":    "\\id GEN\n\\c 1\n",
+	} {
+		if _, err := Import(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestRoundTrip exports every bundled book and checks that importing the
+// result gives back the same verses, titles, paragraphs, poetry lines and
+// footnotes.
+func TestRoundTrip(t *testing.T) {
+	for _, b := range bible.GetBooks() {
+		want, err := bible.ReadSourceBook(b.Id)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, Export(&buf, want))
+		got, err := Import(&buf)
+		require.NoError(t, err, b.Id)
+
+		// The book files aren't stored in verse order; the export is.
+		slices.SortFunc(want.Verses, func(a, b bible.SourceVerse) int {
+			return cmp.Or(a.Chapter-b.Chapter, a.Verse-b.Verse)
+		})
+		require.Equal(t, want.Id, got.Id)
+		require.Equal(t, want.Name, got.Name)
+		require.Equal(t, want.Chapters, got.Chapters, want.Id)
+		require.Equal(t, len(want.Verses), len(got.Verses), want.Id)
+
+		for j, w := range want.Verses {
+			g := got.Verses[j]
+			if g.Chapter != max(w.Chapter, 1) || g.Verse != w.Verse {
+				t.Fatalf("%s: expected verse %d:%d, got %d:%d", w.Id, w.Chapter, w.Verse, g.Chapter, g.Verse)
+			}
+			if title(w) != title(g) || w.Paragraph != g.Paragraph {
+				t.Errorf("%s: expected title %q and paragraph %q, got %q and %q", w.Id, title(w), w.Paragraph, title(g), g.Paragraph)
+			}
+			if plain(w) != plain(g) {
+				t.Errorf("%s: expected text %q, got %q", w.Id, plain(w), plain(g))
+			}
+			if g.CleanText() != plain(g) {
+				t.Errorf("%s: text %q doesn't match textJson %q", w.Id, g.CleanText(), plain(g))
+			}
+			if w.TextJson != nil && len(w.TextJson.Lines()) != len(g.TextJson.Lines()) {
+				t.Errorf("%s: expected %d lines, got %d", w.Id, len(w.TextJson.Lines()), len(g.TextJson.Lines()))
+			}
+			if wn, gn := noteTexts(w.TextJson), noteTexts(g.TextJson); strings.Join(wn, "|") != strings.Join(gn, "|") {
+				t.Errorf("%s: expected notes %q, got %q", w.Id, wn, gn)
+			}
+		}
+	}
+}
+
+func title(v bible.SourceVerse) string {
+	if v.Title == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(*v.Title), " ")
+}
+
+func plain(v bible.SourceVerse) string {
+	if v.TextJson == nil {
+		return ""
+	}
+	return v.TextJson.PlainText()
+}
+
+func abbrs(n *bible.Node) []bible.Node {
+	if n == nil {
+		return nil
+	}
+	if n.Tag == "abbr" {
+		return []bible.Node{*n}
+	}
+	var list []bible.Node
+	for _, c := range n.Children {
+		list = append(list, abbrs(&c)...)
+	}
+	return list
+}
+
+// noteTexts returns the reference and text of every footnote. The
+// ivertalingkey has no place in USFM and is left out.
+func noteTexts(n *bible.Node) []string {
+	var list []string
+	for _, a := range abbrs(n) {
+		list = append(list, strings.Join(strings.Fields(a.Ref), " ")+" "+strings.Join(strings.Fields(a.Title), " "))
+	}
+	return list
+}
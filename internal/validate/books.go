@@ -0,0 +1,246 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+)
+
+// verseIndex records, per book Id and chapter, the highest verse number.
+type verseIndex map[string]map[int]int
+
+func (vi verseIndex) has(book string, chapter, verse int) bool {
+	chapters, ok := vi[book]
+	if !ok {
+		return false
+	}
+	last, ok := chapters[chapter]
+	return ok && verse >= 1 && verse <= last
+}
+
+var requiredBookFields = []string{"id", "name", "chapters", "verseCount", "verses"}
+var requiredVerseFields = []string{"chapter", "verse", "id", "text", "textJson", "paragraph", "title", "cross_references"}
+
+var (
+	leftoverTag    = regexp.MustCompile(`<[^>]*>|[<>]`)
+	leftoverEntity = regexp.MustCompile(`&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+	controlChar    = regexp.MustCompile(`[\x00-\x1F\x7F]`)
+)
+
+func validateBooks(report *Report, booksFS fs.FS) verseIndex {
+	index := make(verseIndex)
+
+	const metaFile = "books.json"
+	data, err := fs.ReadFile(booksFS, metaFile)
+	if err != nil {
+		report.errorf("missing-file", metaFile, "", "%v", err)
+		return index
+	}
+
+	var books []bible.BookMetadata
+	if err := strictUnmarshal(data, &books); err != nil {
+		report.errorf("schema", metaFile, "", "%v", err)
+		return index
+	}
+
+	seen := make(map[string]bool)
+	for i, meta := range books {
+		ref := fmt.Sprintf("[%d]", i)
+		if meta.Id == "" || meta.Name == "" {
+			report.errorf("schema", metaFile, ref, "book entry needs both id and name")
+		}
+		if seen[meta.Id] {
+			report.errorf("duplicate-book", metaFile, meta.Id, "book %s is listed more than once", meta.Id)
+		}
+		seen[meta.Id] = true
+		if meta.Order != i+1 {
+			report.errorf("book-order", metaFile, meta.Id, "order is %d, expected %d", meta.Order, i+1)
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, meta := range books {
+		listed["books/"+meta.Id+".json"] = true
+		validateBook(report, booksFS, meta, index)
+	}
+
+	files, _ := fs.Glob(booksFS, "books/*.json")
+	for _, file := range files {
+		if !listed[file] {
+			report.warnf("unlisted-file", file, "", "book file is not listed in %s", metaFile)
+		}
+	}
+
+	report.Summary.Books = len(books)
+	return index
+}
+
+func validateBook(report *Report, booksFS fs.FS, meta bible.BookMetadata, index verseIndex) {
+	file := "books/" + meta.Id + ".json"
+	data, err := fs.ReadFile(booksFS, file)
+	if err != nil {
+		report.errorf("missing-file", file, meta.Id, "%v", err)
+		return
+	}
+
+	if !checkRequiredFields(report, file, data) {
+		return
+	}
+
+	book, err := bible.ParseSourceBook(data)
+	if err != nil {
+		report.errorf("schema", file, "", "%v", err)
+		return
+	}
+
+	if book.Id != meta.Id {
+		report.errorf("book-id", file, "", "id is %q, books.json lists %q", book.Id, meta.Id)
+	}
+	if book.Name != meta.Name {
+		report.errorf("book-name", file, "", "name is %q, books.json lists %q", book.Name, meta.Name)
+	}
+	if len(book.Verses) != book.VerseCount {
+		report.errorf("verse-count", file, "", "has %d verses, verseCount is %d", len(book.Verses), book.VerseCount)
+	}
+
+	chapters := make(map[int]map[int]bool)
+	for _, v := range book.Verses {
+		ref := fmt.Sprintf("%s.%d.%d", meta.Id, v.Chapter, v.Verse)
+		if chapters[v.Chapter] == nil {
+			chapters[v.Chapter] = make(map[int]bool)
+		}
+		if chapters[v.Chapter][v.Verse] {
+			report.errorf("duplicate-verse", file, ref, "verse occurs more than once")
+		}
+		chapters[v.Chapter][v.Verse] = true
+
+		validateVerse(report, file, ref, v)
+	}
+
+	if len(chapters) != book.Chapters {
+		report.errorf("chapter-count", file, "", "has %d chapters, chapters is %d", len(chapters), book.Chapters)
+	}
+
+	numbers := sortedKeys(chapters)
+	if len(numbers) == 1 && numbers[0] == 0 {
+		// Single-chapter books are numbered as chapter 0 in the source data.
+		report.warnf("chapter-zero", file, "", "single chapter is numbered 0 instead of 1")
+	} else {
+		for i, n := range numbers {
+			if n != i+1 {
+				report.errorf("chapter-numbering", file, fmt.Sprintf("%s.%d", meta.Id, n), "chapter %d found where chapter %d was expected", n, i+1)
+				break
+			}
+		}
+	}
+
+	index[meta.Id] = make(map[int]int)
+	for _, n := range numbers {
+		verses := sortedKeys(chapters[n])
+		for i, v := range verses {
+			if v != i+1 {
+				report.errorf("verse-numbering", file, fmt.Sprintf("%s.%d.%d", meta.Id, n, v), "verse %d found where verse %d was expected", v, i+1)
+				break
+			}
+		}
+		chapter := n
+		if chapter == 0 {
+			chapter = 1
+		}
+		index[meta.Id][chapter] = verses[len(verses)-1]
+	}
+
+	report.Summary.Verses += len(book.Verses)
+}
+
+func validateVerse(report *Report, file, ref string, v bible.SourceVerse) {
+	if v.Id != ref {
+		report.errorf("verse-id", file, ref, "id is %q but chapter and verse fields give %q", v.Id, ref)
+	}
+	if v.Paragraph != "y" && v.Paragraph != "n" {
+		report.errorf("schema", file, ref, "paragraph must be \"y\" or \"n\", got %q", v.Paragraph)
+	}
+	if v.TextJson == nil {
+		report.errorf("schema", file, ref, "textJson is missing")
+		return
+	}
+
+	cleaned := v.CleanText()
+	if cleaned == "" {
+		report.warnf("empty-verse", file, ref, "verse has no text")
+	}
+
+	if controlChar.MatchString(cleaned) {
+		report.errorf("control-character", file, ref, "cleaned text contains control characters")
+	}
+	if m := leftoverEntity.FindString(cleaned); m != "" {
+		report.errorf("leftover-entity", file, ref, "cleaned text contains HTML entity %s", m)
+	}
+	if m := leftoverTag.FindString(cleaned); m != "" {
+		report.warnf("leftover-markup", file, ref, "cleaned text contains markup %q", m)
+	}
+
+	fromJson := strings.Join(strings.Fields(v.TextJson.PlainText()), " ")
+	fromText := strings.Join(strings.Fields(cleaned), " ")
+	if fromJson != fromText {
+		report.errorf("text-mismatch", file, ref, "textJson reads %q but cleaned text reads %q", abbreviate(fromJson), abbreviate(fromText))
+	}
+}
+
+// checkRequiredFields reports missing fields that a plain unmarshal would
+// silently leave at their zero value.
+func checkRequiredFields(report *Report, file string, data []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		report.errorf("schema", file, "", "invalid JSON: %v", err)
+		return false
+	}
+
+	ok := true
+	for _, field := range requiredBookFields {
+		if _, found := fields[field]; !found {
+			report.errorf("schema", file, "", "missing field %q", field)
+			ok = false
+		}
+	}
+
+	var verses []map[string]json.RawMessage
+	json.Unmarshal(fields["verses"], &verses)
+	for i, verse := range verses {
+		for _, field := range requiredVerseFields {
+			if _, found := verse[field]; !found {
+				report.errorf("schema", file, fmt.Sprintf("verses[%d]", i), "missing field %q", field)
+			}
+		}
+	}
+	return ok
+}
+
+func strictUnmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func abbreviate(s string) string {
+	const max = 80
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "…"
+	}
+	return s
+}
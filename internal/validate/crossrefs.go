@@ -0,0 +1,155 @@
+package validate
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// crossRefScheme is the numbering of the cross-reference data, which comes
+// from an English source.
+var crossRefScheme, _ = versification.Lookup(versification.KJV)
+
+func validateCrossRefs(report *Report, crossRefFS fs.FS, verses verseIndex) {
+	const mappingFile = "book-mapping.json"
+	const indexFile = "index.json"
+
+	var mapping crossref.BookMapping
+	if !readStrict(report, crossRefFS, mappingFile, &mapping) {
+		return
+	}
+
+	var index crossref.CrossRefIndex
+	if !readStrict(report, crossRefFS, indexFile, &index) {
+		return
+	}
+
+	for eng, dutch := range mapping.Mappings {
+		if _, ok := verses[dutch]; !ok {
+			report.errorf("unknown-book", mappingFile, eng, "maps to unknown book %s", dutch)
+		}
+		if slices.Contains(mapping.UnmappedBooks.Books, dutch) {
+			report.errorf("mapping-conflict", mappingFile, eng, "book %s is both mapped and listed as unmapped", dutch)
+		}
+	}
+	mapped := make(map[string]bool)
+	for _, dutch := range mapping.Mappings {
+		mapped[dutch] = true
+	}
+	for dutch := range verses {
+		if !mapped[dutch] && !slices.Contains(mapping.UnmappedBooks.Books, dutch) {
+			report.warnf("unmapped-book", mappingFile, dutch, "book has no cross-reference mapping and is not listed as unmapped")
+		}
+	}
+
+	if len(index.Books) != index.TotalBooks {
+		report.errorf("index-count", indexFile, "", "lists %d books, totalBooks is %d", len(index.Books), index.TotalBooks)
+	}
+
+	indexed := map[string]bool{mappingFile: true, indexFile: true}
+	for _, entry := range index.Books {
+		indexed[entry.File] = true
+		validateCrossRefFile(report, crossRefFS, entry, mapping, verses)
+	}
+
+	files, _ := fs.Glob(crossRefFS, "*.json")
+	for _, file := range files {
+		if !indexed[file] {
+			report.warnf("unlisted-file", file, "", "cross-reference file is not listed in %s", indexFile)
+		}
+	}
+}
+
+func validateCrossRefFile(report *Report, crossRefFS fs.FS, entry crossref.BookEntry, mapping crossref.BookMapping, verses verseIndex) {
+	file := entry.File
+
+	source, ok := mapping.Mappings[entry.Book]
+	if !ok {
+		report.errorf("unmapped-book", "index.json", entry.Book, "indexed book has no Dutch mapping")
+	}
+
+	var refs crossref.BookCrossReferences
+	if !readStrict(report, crossRefFS, file, &refs) {
+		return
+	}
+	report.Summary.CrossRefFiles++
+	report.Summary.CrossReferences += len(refs.CrossReferences)
+
+	if refs.Book != entry.Book {
+		report.errorf("crossref-book", file, "", "book is %q, index lists %q", refs.Book, entry.Book)
+	}
+	if refs.TotalReferences != len(refs.CrossReferences) {
+		report.errorf("crossref-count", file, "", "has %d references, totalReferences is %d", len(refs.CrossReferences), refs.TotalReferences)
+	}
+	if entry.ReferenceCount != len(refs.CrossReferences) {
+		report.errorf("crossref-count", file, "", "has %d references, index lists %d", len(refs.CrossReferences), entry.ReferenceCount)
+	}
+
+	for i, ref := range refs.CrossReferences {
+		label := fmt.Sprintf("crossReferences[%d] %s", i, crossref.FormatVerseRef(ref.To, false))
+
+		if ok && !verses.hasEnglish(source, ref.From.Chapter, ref.From.Verse) {
+			report.errorf("dangling-source", file, label, "source verse %s %d:%d does not exist", source, ref.From.Chapter, ref.From.Verse)
+		}
+
+		target, err := toDutch(mapping, ref.To.Book)
+		if err != nil {
+			report.errorf("unmapped-target", file, label, "%v", err)
+			continue
+		}
+		if !verses.hasEnglish(target, ref.To.Chapter, ref.To.Verse) {
+			report.errorf("dangling-target", file, label, "target verse %s %d:%d does not exist", target, ref.To.Chapter, ref.To.Verse)
+		}
+
+		if ref.To.EndVerse == 0 {
+			continue
+		}
+		endBook, endChapter := target, ref.To.Chapter
+		if ref.To.EndBook != "" {
+			if endBook, err = toDutch(mapping, ref.To.EndBook); err != nil {
+				report.errorf("unmapped-target", file, label, "%v", err)
+				continue
+			}
+		}
+		if ref.To.EndChapter != 0 {
+			endChapter = ref.To.EndChapter
+		}
+		if !verses.hasEnglish(endBook, endChapter, ref.To.EndVerse) {
+			report.errorf("dangling-target", file, label, "range end %s %d:%d does not exist", endBook, endChapter, ref.To.EndVerse)
+		}
+	}
+}
+
+func readStrict(report *Report, fsys fs.FS, file string, v any) bool {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		report.errorf("missing-file", file, "", "%v", err)
+		return false
+	}
+	if err := strictUnmarshal(data, v); err != nil {
+		report.errorf("schema", file, "", "%v", err)
+		return false
+	}
+	return true
+}
+
+func toDutch(mapping crossref.BookMapping, english string) (string, error) {
+	if dutch, ok := mapping.Mappings[english]; ok {
+		return dutch, nil
+	}
+	return "", fmt.Errorf("no mapping found for book: %s", english)
+}
+
+// hasEnglish reports whether the verse, numbered like the cross-reference
+// data, exists in the books.
+func (vi verseIndex) hasEnglish(book string, chapter, verse int) bool {
+	for _, v := range crossRefScheme.ToStandard(versification.Verse{Book: book, Chapter: chapter, Verse: verse}) {
+		if !vi.has(v.Book, v.Chapter, v.Verse) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package validate
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+)
+
+// Severity levels of an Issue.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a single problem found in the data files.
+type Issue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	File     string `json:"file"`
+	Ref      string `json:"ref,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Summary counts what was checked and found.
+type Summary struct {
+	Books           int `json:"books"`
+	Verses          int `json:"verses"`
+	CrossRefFiles   int `json:"crossRefFiles"`
+	CrossReferences int `json:"crossReferences"`
+	Errors          int `json:"errors"`
+	Warnings        int `json:"warnings"`
+}
+
+// Report is the machine-readable result of a validation run.
+type Report struct {
+	Summary Summary `json:"summary"`
+	Issues  []Issue `json:"issues"`
+}
+
+// OK reports whether the run found no errors, and no warnings either when
+// strict is set.
+func (r *Report) OK(strict bool) bool {
+	if strict {
+		return r.Summary.Errors == 0 && r.Summary.Warnings == 0
+	}
+	return r.Summary.Errors == 0
+}
+
+func (r *Report) add(severity, code, file, ref, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{
+		Severity: severity,
+		Code:     code,
+		File:     file,
+		Ref:      ref,
+		Message:  fmt.Sprintf(format, args...),
+	})
+	if severity == SeverityError {
+		r.Summary.Errors++
+	} else {
+		r.Summary.Warnings++
+	}
+}
+
+// Allow reports the errors with code in files as warnings instead, for
+// known gaps in the data, and returns how many it found.
+func (r *Report) Allow(code string, files ...string) int {
+	n := 0
+	for i, issue := range r.Issues {
+		if issue.Severity == SeverityError && issue.Code == code && slices.Contains(files, issue.File) {
+			r.Issues[i].Severity = SeverityWarning
+			r.Summary.Errors--
+			r.Summary.Warnings++
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) errorf(code, file, ref, format string, args ...any) {
+	r.add(SeverityError, code, file, ref, format, args...)
+}
+
+func (r *Report) warnf(code, file, ref, format string, args ...any) {
+	r.add(SeverityWarning, code, file, ref, format, args...)
+}
+
+// Run validates the book data in booksFS (holding books.json and books/) and,
+// when crossRefFS is not nil, the cross-reference data in crossRefFS
+// (holding index.json, book-mapping.json and the per-book files).
+func Run(booksFS, crossRefFS fs.FS) *Report {
+	report := &Report{Issues: []Issue{}}
+
+	verses := validateBooks(report, booksFS)
+	if crossRefFS != nil {
+		validateCrossRefs(report, crossRefFS, verses)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Severity < b.Severity
+	})
+	return report
+}
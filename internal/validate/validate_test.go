@@ -0,0 +1,147 @@
+package validate
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const booksJSON = `[{"id": "ruth", "name": "Ruth", "order": 1}, {"id": "judas", "name": "Judas", "order": 2}]`
+
+func verseJSON(book string, chapter, verse int, text string) string {
+	return `{"chapter": ` + strconv.Itoa(chapter) + `, "verse": ` + strconv.Itoa(verse) + `, "id": "` + book + `.` + strconv.Itoa(chapter) + `.` + strconv.Itoa(verse) +
+		`", "text": "` + text + `", "textJson": {"tag": "p", "children": [{"text": "` + text + `"}]}, "paragraph": "n", "title": null, "cross_references": []}`
+}
+
+func bookJSON(id, name string, chapters int, verses ...string) string {
+	return `{"id": "` + id + `", "name": "` + name + `", "chapters": ` + strconv.Itoa(chapters) + `, "verseCount": ` + strconv.Itoa(len(verses)) +
+		`, "verses": [` + strings.Join(verses, ",") + `]}`
+}
+
+func validBooks() fstest.MapFS {
+	return fstest.MapFS{
+		"books.json": {Data: []byte(booksJSON)},
+		"books/ruth.json": {Data: []byte(bookJSON("ruth", "Ruth", 2,
+			verseJSON("ruth", 1, 1, "In de tijd van de Rechters"),
+			verseJSON("ruth", 1, 2, "De man heette Elimelek"),
+			verseJSON("ruth", 2, 1, "Noomi had een bloedverwant"),
+		))},
+		"books/judas.json": {Data: []byte(bookJSON("judas", "Judas", 1,
+			verseJSON("judas", 1, 1, "Judas, dienaar van Jezus Christus"),
+		))},
+	}
+}
+
+func codes(report *Report) map[string]int {
+	found := make(map[string]int)
+	for _, issue := range report.Issues {
+		found[issue.Code]++
+	}
+	return found
+}
+
+func TestRunValidBooks(t *testing.T) {
+	report := Run(validBooks(), nil)
+
+	if !report.OK(true) {
+		t.Fatalf("expected no issues, got %+v", report.Issues)
+	}
+	if report.Summary.Books != 2 || report.Summary.Verses != 4 {
+		t.Errorf("unexpected summary %+v", report.Summary)
+	}
+}
+
+func TestRunBookProblems(t *testing.T) {
+	fsys := validBooks()
+	fsys["books/ruth.json"] = &fstest.MapFile{Data: []byte(strings.Replace(bookJSON("ruth", "Ruth", 3,
+		verseJSON("ruth", 1, 1, "In de tijd van de Rechters"),
+		verseJSON("ruth", 1, 3, "Elimelek stierf"),
+		strings.Replace(verseJSON("ruth", 2, 1, "Noomi"), `"id": "ruth.2.1"`, `"id": "ruth.2.2"`, 1),
+		strings.Replace(verseJSON("ruth", 2, 2, "Boaz&amp;"), `"text": "Boaz&amp;"`, `"text": "Boaz &amp;amp;<br />"`, 1),
+		strings.Replace(verseJSON("ruth", 2, 3, "Zo"), `"paragraph": "n"`, `"paragraph": "x"`, 1),
+	), `"verseCount": 5`, `"verseCount": 6`, 1))}
+	fsys["books/extra.json"] = &fstest.MapFile{Data: []byte(`{}`)}
+
+	report := Run(fsys, nil)
+	found := codes(report)
+
+	for _, code := range []string{"verse-count", "chapter-count", "verse-numbering", "verse-id", "leftover-entity", "text-mismatch", "schema", "unlisted-file"} {
+		if found[code] == 0 {
+			t.Errorf("expected a %s issue, got %v", code, found)
+		}
+	}
+	if report.OK(false) {
+		t.Error("expected report with errors not to be OK")
+	}
+}
+
+func TestRunSchemaProblems(t *testing.T) {
+	fsys := validBooks()
+	fsys["books/judas.json"] = &fstest.MapFile{Data: []byte(`{"id": "judas", "name": "Judas", "chapters": 1, "verses": [{"chapter": 1, "verse": 1}]}`)}
+	fsys["books/ruth.json"] = &fstest.MapFile{Data: []byte(`{"id": "ruth", "naam": "Ruth", "name": "Ruth", "chapters": 0, "verseCount": 0, "verses": []}`)}
+
+	report := Run(fsys, nil)
+
+	var messages []string
+	for _, issue := range report.Issues {
+		messages = append(messages, issue.File+": "+issue.Message)
+	}
+	joined := strings.Join(messages, "\n")
+
+	for _, want := range []string{`books/judas.json: missing field "verseCount"`, `missing field "textJson"`, `books/ruth.json: json: unknown field "naam"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in\n%s", want, joined)
+		}
+	}
+}
+
+func TestRunCrossRefs(t *testing.T) {
+	crossRefs := fstest.MapFS{
+		"book-mapping.json": {Data: []byte(`{"description": "test", "mappings": {"Ruth": "ruth", "Jude": "judas"}, "unmappedBooks": {"note": "", "books": []}}`)},
+		"index.json": {Data: []byte(`{"source": "test", "generatedDate": "", "totalBooks": 2, "books": [
+			{"book": "Ruth", "file": "ruth.json", "referenceCount": 3},
+			{"book": "Jude", "file": "jude.json", "referenceCount": 1}]}`)},
+		"ruth.json": {Data: []byte(`{"book": "Ruth", "totalReferences": 3, "crossReferences": [
+			{"from": {"chapter": 1, "verse": 1}, "to": {"book": "Jude", "chapter": 1, "verse": 1}, "votes": 3},
+			{"from": {"chapter": 1, "verse": 9}, "to": {"book": "Jude", "chapter": 1, "verse": 5}, "votes": 2},
+			{"from": {"chapter": 2, "verse": 1}, "to": {"book": "Gen", "chapter": 1, "verse": 1}, "votes": 1}]}`)},
+	}
+
+	report := Run(validBooks(), crossRefs)
+	found := codes(report)
+
+	if found["dangling-source"] != 1 || found["dangling-target"] != 1 {
+		t.Errorf("expected one dangling source and target, got %v", found)
+	}
+	if found["unmapped-target"] != 1 {
+		t.Errorf("expected one unmapped target, got %v", found)
+	}
+	if found["missing-file"] != 1 {
+		t.Errorf("expected jude.json to be reported missing, got %v", found)
+	}
+	if report.Summary.CrossReferences != 3 {
+		t.Errorf("expected 3 cross-references, got %d", report.Summary.CrossReferences)
+	}
+
+	errors := report.Summary.Errors
+	if n := report.Allow("missing-file", "jude.json"); n != 1 || report.Summary.Errors != errors-1 {
+		t.Errorf("expected the missing jude.json to become a warning, got %d and %d errors", n, report.Summary.Errors)
+	}
+}
+
+func TestRunBundledData(t *testing.T) {
+	report := Run(os.DirFS("../bible"), os.DirFS("../crossref"))
+
+	if report.Summary.Books != 73 {
+		t.Errorf("expected 73 books, got %d", report.Summary.Books)
+	}
+	// The index lists Isaiah and Psalms, whose files are not bundled.
+	if n := report.Allow("missing-file", "isa.json", "ps.json"); n != 2 {
+		t.Errorf("expected isa.json and ps.json to be missing, got %d", n)
+	}
+	if !report.OK(false) {
+		t.Errorf("bundled data should have no other errors, got %v", codes(report))
+	}
+}
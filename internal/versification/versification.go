@@ -0,0 +1,187 @@
+// Package versification maps verse numbers between the numbering schemes
+// used by different translations. Every scheme is described relative to
+// the standard scheme, the numbering of the default translation: Hebrew
+// chapter and verse divisions, psalm superscriptions counted as verses and
+// the Vulgate numbering of Daniel.
+package versification
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the known schemes.
+const (
+	Standard = "catholic"
+	KJV      = "kjv"
+)
+
+// Verse identifies a verse by book id, chapter and verse number.
+type Verse struct {
+	Book    string `json:"book"`
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+}
+
+func (v Verse) String() string {
+	return fmt.Sprintf("%s %d:%d", v.Book, v.Chapter, v.Verse)
+}
+
+// Compare orders verses by chapter and verse. Verses of different books
+// are ordered by book id, which only serves to make sorting deterministic.
+func (v Verse) Compare(o Verse) int {
+	switch {
+	case v.Book != o.Book:
+		return strings.Compare(v.Book, o.Book)
+	case v.Chapter != o.Chapter:
+		return v.Chapter - o.Chapter
+	default:
+		return v.Verse - o.Verse
+	}
+}
+
+// Scheme converts verse numbers between one numbering scheme and the
+// standard scheme.
+type Scheme struct {
+	name string
+	// toStandard holds the verses that have a rule; all others keep their
+	// number.
+	toStandard   map[Verse][]Verse
+	fromStandard map[Verse][]Verse
+	// shared holds the standard verses that rules map onto only in part.
+	shared map[Verse]bool
+}
+
+//go:embed kjv.txt
+var kjvRules []byte
+
+var schemes = map[string]*Scheme{
+	Standard: {name: Standard},
+	KJV:      mustParse(KJV, kjvRules),
+}
+
+// Lookup returns the scheme with the given name.
+func Lookup(name string) (*Scheme, bool) {
+	s, ok := schemes[name]
+	return s, ok
+}
+
+// Names returns the names of all known schemes.
+func Names() []string {
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Name returns the name of the scheme.
+func (s *Scheme) Name() string {
+	return s.name
+}
+
+// ToStandard returns the standard verses that v corresponds to.
+func (s *Scheme) ToStandard(v Verse) []Verse {
+	if mapped, ok := s.toStandard[v]; ok {
+		return mapped
+	}
+	return []Verse{v}
+}
+
+// FromStandard returns the verses of this scheme that correspond to the
+// standard verse v. The result is empty when the scheme has no counterpart,
+// as for a psalm superscription in a scheme that doesn't number it. Verses
+// are not checked against any text, so the caller must still skip verses a
+// translation doesn't have.
+func (s *Scheme) FromStandard(v Verse) []Verse {
+	verses := slices.Clone(s.fromStandard[v])
+	_, moved := s.toStandard[v]
+	if !moved && (len(verses) == 0 || s.shared[v]) {
+		verses = append(verses, v)
+	}
+	slices.SortFunc(verses, Verse.Compare)
+	return slices.Compact(verses)
+}
+
+// Convert maps v from scheme from to scheme to via the standard scheme.
+func Convert(v Verse, from, to *Scheme) []Verse {
+	var verses []Verse
+	for _, std := range from.ToStandard(v) {
+		verses = append(verses, to.FromStandard(std)...)
+	}
+	slices.SortFunc(verses, Verse.Compare)
+	return slices.Compact(verses)
+}
+
+var rulePattern = regexp.MustCompile(`^(\S+) (\d+):(\d+)(?:-(\d+))? = (?:(\S+) )?(?:(\d+):)?(\d+)(?:-(\d+)|([ab]))?$`)
+
+// parse reads the rules of a scheme, see kjv.txt for the format.
+func parse(name string, data []byte) (*Scheme, error) {
+	s := &Scheme{
+		name:         name,
+		toStandard:   make(map[Verse][]Verse),
+		fromStandard: make(map[Verse][]Verse),
+		shared:       make(map[Verse]bool),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		m := rulePattern.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("%s.txt:%d: invalid rule %q", name, line, text)
+		}
+		book, chapter, first, last := m[1], atoi(m[2]), atoi(m[3]), atoi(m[4])
+		toBook, toChapter, toFirst, toLast := m[5], atoi(m[6]), atoi(m[7]), atoi(m[8])
+		if last == 0 {
+			last = first
+		}
+		if toBook == "" {
+			toBook = book
+		}
+		if toChapter == 0 {
+			toChapter = chapter
+		}
+		if toLast == 0 {
+			toLast = toFirst
+		}
+		if last-first != toLast-toFirst || last < first {
+			return nil, fmt.Errorf("%s.txt:%d: ranges of %q differ in length", name, line, text)
+		}
+
+		for i := 0; i <= last-first; i++ {
+			from := Verse{book, chapter, first + i}
+			to := Verse{toBook, toChapter, toFirst + i}
+			s.toStandard[from] = append(s.toStandard[from], to)
+			s.fromStandard[to] = append(s.fromStandard[to], from)
+			if m[9] != "" {
+				s.shared[to] = true
+			}
+		}
+	}
+	return s, scanner.Err()
+}
+
+func mustParse(name string, data []byte) *Scheme {
+	s, err := parse(name, data)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
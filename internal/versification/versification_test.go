@@ -0,0 +1,64 @@
+package versification
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	kjv, _ := Lookup(KJV)
+	std, _ := Lookup(Standard)
+
+	tests := []struct {
+		name string
+		from *Scheme
+		to   *Scheme
+		in   Verse
+		want []Verse
+	}{
+		{"Malachi 4 to 3", kjv, std, Verse{"maleachi", 4, 1}, []Verse{{"maleachi", 3, 19}}},
+		{"Malachi 3 to 4", std, kjv, Verse{"maleachi", 3, 24}, []Verse{{"maleachi", 4, 6}}},
+		{"unchanged verse", std, kjv, Verse{"maleachi", 3, 5}, []Verse{{"maleachi", 3, 5}}},
+		{"psalm superscription", std, kjv, Verse{"psalmen", 3, 1}, nil},
+		{"psalm verse", std, kjv, Verse{"psalmen", 3, 2}, []Verse{{"psalmen", 3, 1}}},
+		{"two verse superscription", kjv, std, Verse{"psalmen", 51, 1}, []Verse{{"psalmen", 51, 3}}},
+		{"merged verses", std, kjv, Verse{"psalmen", 13, 6}, []Verse{{"psalmen", 13, 5}, {"psalmen", 13, 6}}},
+		{"renumbered verse", std, kjv, Verse{"psalmen", 13, 5}, []Verse{{"psalmen", 13, 4}}},
+		{"split verse", std, kjv, Verse{"jesaja", 63, 19}, []Verse{{"jesaja", 63, 19}, {"jesaja", 64, 1}}},
+		{"Joel", kjv, std, Verse{"joel", 3, 21}, []Verse{{"joel", 4, 21}}},
+		{"joined verses", std, kjv, Verse{"ezechiel", 37, 27}, []Verse{{"ezechiel", 37, 27}, {"ezechiel", 37, 28}}},
+		{"omitted verse", kjv, std, Verse{"lucas", 17, 37}, []Verse{{"lucas", 17, 36}}},
+		{"Daniel additions", std, kjv, Verse{"daniel", 3, 50}, []Verse{{"daniel", 3, 50}}},
+		{"Daniel after additions", std, kjv, Verse{"daniel", 3, 91}, []Verse{{"daniel", 3, 24}}},
+		{"same scheme", std, std, Verse{"psalmen", 3, 1}, []Verse{{"psalmen", 3, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.in, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Convert(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []string{
+		"psalmen 3:1-8 = 3:2-10",
+		"psalmen 3 = 4",
+		"psalmen 3:8-1 = 3:9-2",
+	} {
+		if _, err := parse("test", []byte(rule)); err == nil {
+			t.Errorf("parse(%q) error = nil", rule)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, ok := Lookup("lxx"); ok {
+		t.Errorf("Lookup(lxx) found a scheme")
+	}
+	if got := Names(); !reflect.DeepEqual(got, []string{Standard, KJV}) {
+		t.Errorf("Names() = %v", got)
+	}
+}
@@ -0,0 +1,196 @@
+package votd
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+	"github.com/pschuurmans/bijbel-api/internal/versification"
+)
+
+// maxCandidates is the number of verses the fallback picks from: enough
+// for a year without repeats, few enough that every one is well known.
+const maxCandidates = 1000
+
+//go:embed excluded.txt
+var excludedList []byte
+
+// candidate is a verse with the sum of the votes of its cross-references.
+type candidate struct {
+	verse versification.Verse
+	votes int
+}
+
+// candidates returns the most cross-referenced verses that read well on
+// their own, most votes first. The cross-references are numbered like
+// English Bibles and are converted to the numbering of repo.
+func candidates(repo bible.Repository, refs crossref.Repository) ([]candidate, error) {
+	books := resolver(repo)
+	excluded, err := parseExcluded(excludedList, books)
+	if err != nil {
+		return nil, err
+	}
+
+	kjv, _ := versification.Lookup(versification.KJV)
+	standard, _ := versification.Lookup(versification.Standard)
+	votes := make(map[versification.Verse]int)
+	for _, b := range repo.GetBooks() {
+		book, err := refs.GetCrossReferences(b.Id)
+		if err != nil {
+			// Not every book has cross-reference data.
+			continue
+		}
+		for _, ref := range book.CrossReferences {
+			if ref.Votes <= 0 {
+				continue
+			}
+			v := versification.Verse{Book: b.Id, Chapter: ref.From.Chapter, Verse: ref.From.Verse}
+			if c := versification.Convert(v, kjv, standard); len(c) > 0 {
+				v = c[0]
+			}
+			votes[v] += ref.Votes
+		}
+	}
+
+	all := make([]candidate, 0, len(votes))
+	for v, n := range votes {
+		all = append(all, candidate{v, n})
+	}
+	slices.SortFunc(all, func(a, b candidate) int {
+		return cmp.Or(b.votes-a.votes,
+			repo.GetBookOrder(a.verse.Book)-repo.GetBookOrder(b.verse.Book),
+			a.verse.Compare(b.verse))
+	})
+
+	texts := make(map[string]map[[2]int]string)
+	text := func(v versification.Verse) string {
+		if texts[v.Book] == nil {
+			texts[v.Book] = make(map[[2]int]string)
+			if book, err := repo.GetChapters(v.Book); err == nil {
+				for _, bv := range book.Verses {
+					texts[v.Book][[2]int{bible.ChapterNumber(bv.Chapter), bv.Verse}] = bv.Text
+				}
+			}
+		}
+		return texts[v.Book][[2]int{v.Chapter, v.Verse}]
+	}
+
+	var list []candidate
+	for _, c := range all {
+		if len(list) == maxCandidates {
+			break
+		}
+		if !excluded.contains(c.verse) && standsAlone(text(c.verse)) {
+			list = append(list, c)
+		}
+	}
+	if len(list) == 0 {
+		return nil, errors.New("no cross-referenced verses to pick from")
+	}
+	return list, nil
+}
+
+// pick returns the verse of date. The candidates are shuffled once a year,
+// with the most voted ones more likely to come first, and read in that
+// order by day of the year, so that no verse returns within a year.
+func pick(candidates []candidate, date time.Time) versification.Verse {
+	rng := rand.New(rand.NewPCG(uint64(date.Year()), 0x766f7464))
+	keys := make([]float64, len(candidates))
+	order := make([]int, len(candidates))
+	for i, c := range candidates {
+		// Weighted sampling without replacement: sorting by u^(1/w)
+		// draws the candidates in proportion to their weights.
+		keys[i] = math.Pow(rng.Float64(), 1/float64(c.votes))
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(keys[b], keys[a])
+	})
+	return candidates[order[(date.YearDay()-1)%len(order)]].verse
+}
+
+// connectives start verses that continue a sentence or argument of the
+// verses before them.
+var connectives = []string{
+	"en", "want", "maar", "doch", "dus", "daarom", "daarop", "daarna", "toen",
+	"omdat", "opdat", "zodat", "hierom", "ook", "dan", "hij", "zij", "dit", "dat", "deze",
+}
+
+// standsAlone reports whether a verse reads as a sentence of its own: it
+// starts with a capital, not with a word that ties it to the verses before,
+// and ends a sentence.
+func standsAlone(text string) bool {
+	text = strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("'`\"“”‘’*", r)
+	})
+	words := strings.Fields(text)
+	if len(words) < 6 {
+		return false
+	}
+	first := []rune(words[0])
+	if !unicode.IsUpper(first[0]) {
+		return false
+	}
+	if slices.Contains(connectives, strings.ToLower(strings.TrimRight(words[0], ",:;"))) {
+		return false
+	}
+	return strings.ContainsRune(".!?", rune(text[len(text)-1]))
+}
+
+// exclusions holds the passages that are never picked from the
+// cross-references, because they say something else out of context.
+type exclusions []passage.Passage
+
+func parseExcluded(data []byte, books reference.BookResolver) (exclusions, error) {
+	var list exclusions
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := passage.Parse(line, books)
+		if err != nil {
+			return nil, fmt.Errorf("excluded.txt line %d: %w", n, err)
+		}
+		list = append(list, p)
+	}
+	return list, sc.Err()
+}
+
+func (e exclusions) contains(v versification.Verse) bool {
+	for _, p := range e {
+		if p.Book != v.Book {
+			continue
+		}
+		for _, r := range p.Ranges {
+			if r.Start.Verse == 0 {
+				if v.Chapter >= r.Start.Chapter && v.Chapter <= r.End.Chapter {
+					return true
+				}
+				continue
+			}
+			ref := reference.Reference{
+				StartChapter: r.Start.Chapter, StartVerse: r.Start.Verse,
+				EndChapter: r.End.Chapter, EndVerse: r.End.Verse,
+			}
+			if ref.Contains(v.Chapter, v.Verse) {
+				return true
+			}
+		}
+	}
+	return false
+}
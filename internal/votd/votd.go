@@ -0,0 +1,141 @@
+// Package votd picks the verse of the day. A date reads the curated list
+// from its start date on; once the list is exhausted, it falls back to a
+// choice among the most cross-referenced verses, weighted by their votes.
+// Every picker with the same list and data picks the same verse on a date.
+package votd
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+	"github.com/pschuurmans/bijbel-api/internal/passage"
+	"github.com/pschuurmans/bijbel-api/internal/reference"
+)
+
+//go:embed curated.txt
+var curatedList []byte
+
+// List is a curated list of verses of the day.
+type List struct {
+	// Start is the date of the first reference.
+	Start      time.Time
+	References []string
+}
+
+// ParseList reads a list: a "start YYYY-MM-DD" line followed by one
+// reference per line. Empty lines and lines starting with # are skipped.
+func ParseList(data []byte) (*List, error) {
+	var l List
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if date, ok := strings.CutPrefix(line, "start "); ok {
+			start, err := time.Parse(time.DateOnly, strings.TrimSpace(date))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid start date %q", n, date)
+			}
+			l.Start = start
+			continue
+		}
+		if l.Start.IsZero() {
+			return nil, fmt.Errorf("line %d: the list must begin with a start date", n)
+		}
+		l.References = append(l.References, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// DefaultList returns the bundled list.
+func DefaultList() *List {
+	l, err := ParseList(curatedList)
+	if err != nil {
+		panic(fmt.Sprintf("votd: bundled list: %v", err))
+	}
+	return l
+}
+
+// Source tells where a verse of the day was picked from.
+type Source string
+
+const (
+	Curated         Source = "curated"
+	CrossReferences Source = "cross-references"
+)
+
+// Choice is the verse of the day of a date. Reference is read by the
+// passage package.
+type Choice struct {
+	Date      string `json:"date"`
+	Reference string `json:"reference"`
+	Source    Source `json:"source"`
+}
+
+// Picker picks the verses of the day from a list and the text and
+// cross-references of a translation. It is safe for concurrent use.
+type Picker struct {
+	list *List
+	repo bible.Repository
+	refs crossref.Repository
+
+	once       sync.Once
+	candidates []candidate
+	err        error
+}
+
+// New returns a picker for list, reporting references of the list that do
+// not read in repo.
+func New(list *List, repo bible.Repository, refs crossref.Repository) (*Picker, error) {
+	books := resolver(repo)
+	for i, ref := range list.References {
+		p, err := passage.Parse(ref, books)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", list.Start.AddDate(0, 0, i).Format(time.DateOnly), err)
+		}
+		if _, err := passage.Read(repo, p); err != nil {
+			return nil, fmt.Errorf("%s: %w", list.Start.AddDate(0, 0, i).Format(time.DateOnly), err)
+		}
+	}
+	return &Picker{list: list, repo: repo, refs: refs}, nil
+}
+
+// Pick returns the verse of the day of the date of t.
+func (p *Picker) Pick(t time.Time) (Choice, error) {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	c := Choice{Date: date.Format(time.DateOnly)}
+	if i := int(date.Sub(p.list.Start).Hours() / 24); i >= 0 && i < len(p.list.References) {
+		c.Reference, c.Source = p.list.References[i], Curated
+		return c, nil
+	}
+
+	p.once.Do(func() {
+		p.candidates, p.err = candidates(p.repo, p.refs)
+	})
+	if p.err != nil {
+		return Choice{}, p.err
+	}
+	v := pick(p.candidates, date)
+	c.Reference, c.Source = fmt.Sprintf("%s %d:%d", v.Book, v.Chapter, v.Verse), CrossReferences
+	return c, nil
+}
+
+func resolver(repo bible.Repository) reference.BookResolver {
+	var ids, names []string
+	for _, b := range repo.GetBooks() {
+		ids = append(ids, b.Id)
+		names = append(names, b.Name)
+	}
+	return reference.Resolver(ids, names)
+}
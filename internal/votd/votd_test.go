@@ -0,0 +1,103 @@
+package votd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pschuurmans/bijbel-api/internal/bible"
+	"github.com/pschuurmans/bijbel-api/internal/crossref"
+)
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(time.DateOnly, s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestParseList(t *testing.T) {
+	l, err := ParseList([]byte("# comment\nstart 2027-01-01\n\njohannes 3:16\npsalmen 23:1-4\n"))
+	require.NoError(t, err)
+	require.Equal(t, date(t, "2027-01-01"), l.Start)
+	require.Equal(t, []string{"johannes 3:16", "psalmen 23:1-4"}, l.References)
+
+	_, err = ParseList([]byte("johannes 3:16\n"))
+	require.ErrorContains(t, err, "line 1: the list must begin with a start date")
+	_, err = ParseList([]byte("start 1 januari\n"))
+	require.ErrorContains(t, err, "invalid start date")
+}
+
+func TestNewRejectsUnreadableReferences(t *testing.T) {
+	_, err := New(&List{Start: date(t, "2027-01-01"), References: []string{"johannes 3:16", "johannes 30:1"}}, bible.Current(), crossref.Current())
+	require.ErrorContains(t, err, "2027-01-02")
+}
+
+func TestPick(t *testing.T) {
+	p, err := New(DefaultList(), bible.Current(), crossref.Current())
+	require.NoError(t, err)
+
+	start := DefaultList().Start
+	c, err := p.Pick(start)
+	require.NoError(t, err)
+	require.Equal(t, Choice{Date: start.Format(time.DateOnly), Reference: "genesis 1:1", Source: Curated}, c)
+
+	// The time of day does not matter.
+	c, err = p.Pick(start.Add(23 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "genesis 1:1", c.Reference)
+
+	// After the list the cross-references take over, the same for every
+	// picker, and no verse returns within a year.
+	seen := make(map[string]string)
+	other, err := New(DefaultList(), bible.Current(), crossref.Current())
+	require.NoError(t, err)
+	for d := date(t, "2028-01-01"); d.Year() == 2028; d = d.AddDate(0, 0, 1) {
+		c, err := p.Pick(d)
+		require.NoError(t, err)
+		require.Equal(t, CrossReferences, c.Source)
+		require.NotContains(t, seen, c.Reference, "%s and %s", seen[c.Reference], c.Date)
+		seen[c.Reference] = c.Date
+
+		if d.Day() == 1 {
+			o, err := other.Pick(d)
+			require.NoError(t, err)
+			require.Equal(t, c, o)
+		}
+	}
+}
+
+func TestCandidatesStandAlone(t *testing.T) {
+	list, err := candidates(bible.Current(), crossref.Current())
+	require.NoError(t, err)
+	require.Len(t, list, maxCandidates)
+
+	books := resolver(bible.Current())
+	excluded, err := parseExcluded(excludedList, books)
+	require.NoError(t, err)
+	for i, c := range list {
+		require.False(t, excluded.contains(c.verse), c.verse.String())
+		if i > 0 {
+			require.LessOrEqual(t, c.votes, list[i-1].votes)
+		}
+	}
+}
+
+func TestStandsAlone(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Vertrouw op Jahwe met heel uw hart en verlaat u niet op uw eigen inzicht.", true},
+		{"“Laat uw hart niet verontrust worden. Gij gelooft in God, gelooft ook in Mij.", true},
+		{"want voor God is niets onmogelijk.'", false},
+		{"Want God heeft ons niet een geest geschonken van vreesachtigheid.", false},
+		{"Hij wijst mij te liggen in grazige weiden, Hij voert mij naar wateren der rust.", false},
+		{"Bij God alleen verstilt mijn ziel, van Hem komt mijn bevrijding:", false},
+		{"Weest altijd blij.", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, standsAlone(tt.text), tt.text)
+	}
+}